@@ -0,0 +1,155 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+const (
+	// The path of the admin API's readiness endpoint, which answers
+	// {"status": "ready"} once redpanda is ready to serve requests.
+	readyPath	= "/v1/status/ready"
+	// Lists the brokers in the cluster, as the node answering knows them.
+	brokersPath	= "/v1/brokers"
+	// Summarizes the cluster's health, including its controller.
+	healthOverviewPath	= "/v1/cluster/health_overview"
+)
+
+// A client for redpanda's admin API.
+type Client struct {
+	client	*http.Client
+	url	string
+}
+
+type readyResponse struct {
+	Status string `json:"status"`
+}
+
+// A broker, as listed by the admin API.
+type Broker struct {
+	NodeID			int	`json:"node_id"`
+	NumCores		int	`json:"num_cores"`
+	MembershipStatus	string	`json:"membership_status,omitempty"`
+	// Unset by the versions which don't report the brokers' liveness.
+	IsAlive	*bool	`json:"is_alive,omitempty"`
+	Version	string	`json:"version,omitempty"`
+}
+
+type HealthOverview struct {
+	IsHealthy	bool	`json:"is_healthy"`
+	// The node which leads the controller, i.e. the cluster's leader. -1
+	// if there's none.
+	ControllerID		int		`json:"controller_id"`
+	AllNodes		[]int		`json:"all_nodes"`
+	NodesDown		[]int		`json:"nodes_down"`
+	LeaderlessPartitions	[]string	`json:"leaderless_partitions"`
+}
+
+// Returns a client for the admin API at the given URL, e.g.
+// http://127.0.0.1:9644.
+func NewClient(url string, timeout time.Duration) *Client {
+	return &Client{&http.Client{Timeout: timeout}, strings.TrimSuffix(url, "/")}
+}
+
+// Returns the URL to reach the given node's admin API at from the same
+// machine, replacing wildcard addresses (e.g. 0.0.0.0) with the loopback one.
+func LocalURL(addr config.SocketAddress) string {
+	host := addr.Address
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(addr.Port))
+}
+
+// Returns whether redpanda reports it's ready, along with the response it
+// sent, e.g. to show why it isn't.
+func (c *Client) Ready() (ready bool, response string, err error) {
+	res, err := c.client.Get(c.url + readyPath)
+	if err != nil {
+		return false, "", err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return false, "", err
+	}
+	response = fmt.Sprintf("%d %s", res.StatusCode, strings.TrimSpace(string(body)))
+	if res.StatusCode != http.StatusOK {
+		return false, response, nil
+	}
+	r := readyResponse{}
+	err = json.Unmarshal(body, &r)
+	if err != nil {
+		return false, response, fmt.Errorf(
+			"couldn't parse the readiness response '%s': %v",
+			body,
+			err,
+		)
+	}
+	return r.Status == "ready", response, nil
+}
+
+// Returns the brokers in the cluster.
+func (c *Client) Brokers() ([]Broker, error) {
+	brokers := []Broker{}
+	err := c.getJSON(brokersPath, &brokers)
+	return brokers, err
+}
+
+// Returns the cluster's health overview.
+func (c *Client) HealthOverview() (*HealthOverview, error) {
+	overview := &HealthOverview{}
+	err := c.getJSON(healthOverviewPath, overview)
+	if err != nil {
+		return nil, err
+	}
+	return overview, nil
+}
+
+// GETs path and decodes its JSON response into v, failing on non-2xx
+// statuses.
+func (c *Client) getJSON(path string, v interface{}) error {
+	res, err := c.client.Get(c.url + path)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf(
+			"%s answered %d %s",
+			path,
+			res.StatusCode,
+			strings.TrimSpace(string(body)),
+		)
+	}
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return fmt.Errorf(
+			"couldn't parse the response to %s: %v",
+			path,
+			err,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		name			string
+		status			int
+		body			string
+		expectedReady		bool
+		expectedResponse	string
+		expectedErrMsg		string
+	}{
+		{
+			name:			"it should be ready if redpanda says so",
+			status:			http.StatusOK,
+			body:			`{"status": "ready"}`,
+			expectedReady:		true,
+			expectedResponse:	`200 {"status": "ready"}`,
+		},
+		{
+			name:			"it shouldn't be ready while redpanda is booting",
+			status:			http.StatusServiceUnavailable,
+			body:			`{"status": "booting"}`,
+			expectedResponse:	`503 {"status": "booting"}`,
+		},
+		{
+			name:			"it should fail if the response isn't valid",
+			status:			http.StatusOK,
+			body:			`ready`,
+			expectedResponse:	`200 ready`,
+			expectedErrMsg:		"couldn't parse the readiness response 'ready': invalid character 'r' looking for beginning of value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(st, readyPath, r.URL.Path)
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+				}))
+			defer ts.Close()
+
+			ready, res, err := NewClient(ts.URL, time.Second).Ready()
+			require.Equal(st, tt.expectedResponse, res)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expectedReady, ready)
+		})
+	}
+}
+
+func TestLocalURL(t *testing.T) {
+	require.Equal(
+		t,
+		"http://127.0.0.1:9644",
+		LocalURL(config.SocketAddress{Address: "0.0.0.0", Port: 9644}),
+	)
+	require.Equal(
+		t,
+		"http://127.0.0.1:9644",
+		LocalURL(config.SocketAddress{Address: "::", Port: 9644}),
+	)
+	require.Equal(
+		t,
+		"http://10.0.0.1:9645",
+		LocalURL(config.SocketAddress{Address: "10.0.0.1", Port: 9645}),
+	)
+}
+
+func TestBrokersAndHealthOverview(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case brokersPath:
+				w.Write([]byte(`[
+  {"node_id": 0, "num_cores": 2, "is_alive": true, "version": "v21.4.2"},
+  {"node_id": 1, "num_cores": 4, "is_alive": false}
+]`))
+			case healthOverviewPath:
+				w.Write([]byte(`{"is_healthy": false, "controller_id": 0, "all_nodes": [0, 1], "nodes_down": [1], "leaderless_partitions": []}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("not found"))
+			}
+		}))
+	defer ts.Close()
+	client := NewClient(ts.URL, time.Second)
+
+	alive, dead := true, false
+	brokers, err := client.Brokers()
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]Broker{
+			{NodeID: 0, NumCores: 2, IsAlive: &alive, Version: "v21.4.2"},
+			{NodeID: 1, NumCores: 4, IsAlive: &dead},
+		},
+		brokers,
+	)
+
+	overview, err := client.HealthOverview()
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		&HealthOverview{
+			ControllerID:		0,
+			AllNodes:		[]int{0, 1},
+			NodesDown:		[]int{1},
+			LeaderlessPartitions:	[]string{},
+		},
+		overview,
+	)
+}
+
+func TestGetJSONError(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("booting\n"))
+		}))
+	defer ts.Close()
+
+	_, err := NewClient(ts.URL, time.Second).Brokers()
+	require.EqualError(t, err, "/v1/brokers answered 503 booting")
+}
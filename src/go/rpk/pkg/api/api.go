@@ -46,13 +46,39 @@ type CheckPayload struct {
 	ErrorMsg	string	`json:"errorMsg"`
 	Current		string	`json:"current"`
 	Required	string	`json:"required"`
+	// Additional information about what was checked, e.g. each swap area.
+	Details	map[string]string	`json:"details,omitempty"`
 }
 
 type TunerPayload struct {
-	Name		string	`json:"name"`
-	ErrorMsg	string	`json:"errorMsg"`
-	Enabled		bool	`json:"enabled"`
-	Supported	bool	`json:"supported"`
+	Name		string	`json:"name" yaml:"name"`
+	ErrorMsg	string	`json:"errorMsg" yaml:"errorMsg"`
+	Enabled		bool	`json:"enabled" yaml:"enabled"`
+	Supported	bool	`json:"supported" yaml:"supported"`
+	// How far the tuner got before failing, e.g. "applied 12 of 40 IRQs".
+	Progress	string	`json:"progress,omitempty" yaml:"progress,omitempty"`
+	// Whether the tuner was skipped because it's disruptive and running it
+	// wasn't confirmed.
+	Skipped	bool	`json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	// What the tuner changed, e.g. the CPU mask set for each IRQ.
+	Details	map[string]string	`json:"details,omitempty" yaml:"details,omitempty"`
+	// How long the tuner took to run, in milliseconds.
+	DurationMs	int64	`json:"durationMs,omitempty" yaml:"durationMs,omitempty"`
+}
+
+// Changes the environment payload and the config sent with it, e.g. to add
+// fields or redact values. config is the JSON representation of the config,
+// decoded into a map.
+type EnvTransform func(env *EnvironmentPayload, config map[string]interface{}) error
+
+var envTransforms []EnvTransform
+
+// Registers a transform which SendEnvironment applies before sending the
+// environment, after the ones registered before it. It's meant to be called
+// from an init function, and isn't safe to call concurrently with
+// SendEnvironment.
+func RegisterEnvTransform(fn EnvTransform) {
+	envTransforms = append(envTransforms, fn)
 }
 
 type metricsBody struct {
@@ -100,6 +126,10 @@ func SendEnvironment(
 	if err != nil {
 		return err
 	}
+	err = applyEnvTransforms(&env, confMap)
+	if err != nil {
+		return err
+	}
 	cloudVendor := "N/A"
 	vmType := "N/A"
 	v, err := cloud.AvailableVendor()
@@ -154,6 +184,17 @@ func SendEnvironment(
 	)
 }
 
+func applyEnvTransforms(
+	env *EnvironmentPayload, confMap map[string]interface{},
+) error {
+	for _, transform := range envTransforms {
+		if err := transform(env, confMap); err != nil {
+			return fmt.Errorf("couldn't transform the environment: %v", err)
+		}
+	}
+	return nil
+}
+
 func stripCtlFromUTF8(str string) string {
 	return strings.Map(func(r rune) rune {
 		if r >= 32 && r != 127 {
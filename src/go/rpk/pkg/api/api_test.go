@@ -11,6 +11,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -162,3 +163,46 @@ func TestSkipSendEnvironment(t *testing.T) {
 	err := sendEnvironmentToUrl(environmentBody{}, ts.URL, *conf)
 	require.NoError(t, err)
 }
+
+func TestApplyEnvTransforms(t *testing.T) {
+	defer func() { envTransforms = nil }()
+	env := EnvironmentPayload{ErrorMsg: "failed"}
+	confMap := map[string]interface{}{
+		"organization":	"test.vectorized.io",
+		"nodeUuid":	"abc123-hu234-234kh",
+	}
+
+	// Without transforms, nothing changes.
+	require.NoError(t, applyEnvTransforms(&env, confMap))
+	require.Equal(t, EnvironmentPayload{ErrorMsg: "failed"}, env)
+	require.Len(t, confMap, 2)
+
+	RegisterEnvTransform(func(e *EnvironmentPayload, c map[string]interface{}) error {
+		e.Checks = append(e.Checks, CheckPayload{Name: "distro check"})
+		delete(c, "organization")
+		return nil
+	})
+	RegisterEnvTransform(func(e *EnvironmentPayload, c map[string]interface{}) error {
+		// Sees the previous transform's changes.
+		require.Len(t, e.Checks, 1)
+		c["distro"] = "custom"
+		return nil
+	})
+	require.NoError(t, applyEnvTransforms(&env, confMap))
+	require.Equal(t, []CheckPayload{{Name: "distro check"}}, env.Checks)
+	require.Equal(
+		t,
+		map[string]interface{}{
+			"nodeUuid":	"abc123-hu234-234kh",
+			"distro":	"custom",
+		},
+		confMap,
+	)
+
+	envTransforms = nil
+	RegisterEnvTransform(func(*EnvironmentPayload, map[string]interface{}) error {
+		return errors.New("boom")
+	})
+	err := applyEnvTransforms(&env, confMap)
+	require.EqualError(t, err, "couldn't transform the environment: boom")
+}
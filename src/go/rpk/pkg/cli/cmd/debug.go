@@ -22,6 +22,7 @@ func NewDebugCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
 		Short:	"Debug the local Redpanda process",
 	}
 	command.AddCommand(debug.NewInfoCommand(fs, mgr))
+	command.AddCommand(debug.NewBundleCommand(fs, mgr))
 
 	return command
 }
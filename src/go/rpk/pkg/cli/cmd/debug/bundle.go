@@ -0,0 +1,339 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package debug
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/cmd/version"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/hwloc"
+)
+
+const (
+	bundlePrefix		= "redpanda-debug-bundle-"
+	bundleTimeFormat	= "20060102-150405"
+	redactedValue		= "REDACTED"
+)
+
+// A file in the bundle, named relative to the bundle's root directory.
+type bundleFile struct {
+	name		string
+	contents	[]byte
+}
+
+// The result of a check, as written to the bundle.
+type bundleCheck struct {
+	Name		string	`json:"name"`
+	Desc		string	`json:"desc"`
+	Category	string	`json:"category"`
+	Severity	string	`json:"severity"`
+	Ok		bool	`json:"ok"`
+	Current		string	`json:"current"`
+	Required	string	`json:"required"`
+	ErrorMsg	string	`json:"errorMsg,omitempty"`
+}
+
+func NewBundleCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
+	var (
+		configFile	string
+		output		string
+		timeout		time.Duration
+		logLines	int
+		redact		bool
+	)
+	command := &cobra.Command{
+		Use:	"bundle",
+		Short:	"Collect the config, check and tuner results, logs and system info into a tarball",
+		Long: "Collect the config, the check results, the tuners' persisted" +
+			" state, redpanda's logs and the system info into a" +
+			" timestamped .tar.gz, to attach to issues.",
+		SilenceUsage:	true,
+		RunE: func(ccmd *cobra.Command, args []string) error {
+			return executeBundle(
+				fs,
+				mgr,
+				vos.NewProc(),
+				configFile,
+				output,
+				timeout,
+				logLines,
+				redact,
+				time.Now(),
+			)
+		},
+	}
+	command.Flags().StringVar(
+		&configFile,
+		"config",
+		"",
+		"Redpanda config file, if not set the file will be searched for"+
+			" in the default locations",
+	)
+	command.Flags().StringVarP(
+		&output,
+		"output",
+		"o",
+		"",
+		"The file to write the bundle to, or the directory to write it"+
+			" to with a timestamped name. Defaults to the current"+
+			" directory",
+	)
+	command.Flags().DurationVar(
+		&timeout,
+		"timeout",
+		10*time.Second,
+		"The maximum amount of time to wait for each piece of"+
+			" information (e.g. the checks or the logs) to be gathered",
+	)
+	command.Flags().IntVar(
+		&logLines,
+		"log-lines",
+		10000,
+		"How many of the latest lines of redpanda's logs (from"+
+			" journalctl) to include. 0 to skip them",
+	)
+	command.Flags().BoolVar(
+		&redact,
+		"redact-license-key",
+		true,
+		"Replace the license key in the bundled config with '"+
+			redactedValue+"'",
+	)
+	return command
+}
+
+func executeBundle(
+	fs afero.Fs,
+	mgr config.Manager,
+	proc vos.Proc,
+	configFile, output string,
+	timeout time.Duration,
+	logLines int,
+	redact bool,
+	now time.Time,
+) error {
+	conf, err := mgr.FindOrGenerate(configFile)
+	if err != nil {
+		return err
+	}
+	files := collectBundle(fs, mgr, proc, conf, timeout, logLines, redact)
+	path, err := bundlePath(fs, output, now)
+	if err != nil {
+		return err
+	}
+	err = writeBundle(fs, path, files, now)
+	if err != nil {
+		return err
+	}
+	log.Infof("Wrote the debug bundle to '%s'", path)
+	return nil
+}
+
+// Gathers the bundle's files. Failing to gather one doesn't fail the bundle:
+// the error is recorded in errors.txt instead, since a partial bundle is
+// still useful.
+func collectBundle(
+	fs afero.Fs,
+	mgr config.Manager,
+	proc vos.Proc,
+	conf *config.Config,
+	timeout time.Duration,
+	logLines int,
+	redact bool,
+) []bundleFile {
+	files := []bundleFile{{
+		name:		"version.txt",
+		contents:	[]byte(version.Pretty() + "\n"),
+	}}
+	errs := []string{}
+	add := func(name string, contents []byte, err error) {
+		if err != nil {
+			log.Warnf("Couldn't collect %s: %v", name, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			return
+		}
+		files = append(files, bundleFile{name: name, contents: contents})
+	}
+
+	confJSON, err := mgr.ReadAsJSON(conf.ConfigFile)
+	if err == nil {
+		confJSON, err = formatConfigJSON(confJSON, redact)
+	}
+	add("config.json", []byte(confJSON), err)
+
+	checks, err := checksJSON(fs, conf, timeout)
+	add("checks.json", checks, err)
+
+	states := []struct {
+		name	string
+		path	string
+	}{
+		{name: "tune_state.json", path: tuners.TuneStatePath(conf)},
+		{name: "check_state.json", path: tuners.CheckStatePath(conf)},
+	}
+	for _, s := range states {
+		if exists, _ := afero.Exists(fs, s.path); exists {
+			bs, err := afero.ReadFile(fs, s.path)
+			add(s.name, bs, err)
+		}
+	}
+
+	uname, err := system.UnameAndDistro(timeout)
+	add("uname.txt", []byte(uname+"\n"), err)
+
+	topology, err := topologyJSON(proc, timeout)
+	add("topology.json", topology, err)
+
+	if logLines > 0 {
+		lines, err := proc.RunWithSystemLdPath(
+			timeout,
+			"journalctl",
+			"--unit", "redpanda",
+			"--no-pager",
+			"--lines", strconv.Itoa(logLines),
+		)
+		add("redpanda.log", []byte(strings.Join(lines, "\n")+"\n"), err)
+	}
+
+	if len(errs) > 0 {
+		files = append(files, bundleFile{
+			name:		"errors.txt",
+			contents:	[]byte(strings.Join(errs, "\n") + "\n"),
+		})
+	}
+	return files
+}
+
+// Indents the config's JSON, replacing the license key if redact is true.
+// The keys are sorted, so that the same config always yields the same output.
+func formatConfigJSON(confJSON string, redact bool) (string, error) {
+	confMap := map[string]interface{}{}
+	err := json.Unmarshal([]byte(confJSON), &confMap)
+	if err != nil {
+		return "", err
+	}
+	if key, ok := confMap["license_key"]; ok && redact && key != "" {
+		confMap["license_key"] = redactedValue
+	}
+	bs, err := json.MarshalIndent(confMap, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bs) + "\n", nil
+}
+
+func checksJSON(
+	fs afero.Fs, conf *config.Config, timeout time.Duration,
+) ([]byte, error) {
+	results, err := tuners.Check(fs, conf, timeout)
+	if err != nil {
+		return nil, err
+	}
+	checks := []bundleCheck{}
+	for _, r := range results {
+		c := bundleCheck{
+			Name:		r.CheckerId.String(),
+			Desc:		r.Desc,
+			Category:	r.Category.String(),
+			Severity:	r.Severity.String(),
+			Ok:		r.IsOk,
+			Current:	r.Current,
+			Required:	r.Required,
+		}
+		if r.Err != nil {
+			c.ErrorMsg = r.Err.Error()
+		}
+		checks = append(checks, c)
+	}
+	return json.MarshalIndent(checks, "", "  ")
+}
+
+func topologyJSON(proc vos.Proc, timeout time.Duration) ([]byte, error) {
+	hw := hwloc.NewHwLocCmd(proc, timeout)
+	if !hw.IsSupported() {
+		return nil, fmt.Errorf("'%s' isn't installed", hwloc.CalcBin)
+	}
+	summary, err := hwloc.Summarize(hw, "all")
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(summary, "", "  ")
+}
+
+// Returns the path to write the bundle to: output itself, unless it's empty
+// or a directory, in which case the bundle gets a timestamped name in it.
+func bundlePath(fs afero.Fs, output string, now time.Time) (string, error) {
+	name := bundlePrefix + now.UTC().Format(bundleTimeFormat) + ".tar.gz"
+	if output == "" {
+		return name, nil
+	}
+	isDir, err := afero.IsDir(fs, output)
+	if err == nil && isDir {
+		return filepath.Join(output, name), nil
+	}
+	return output, nil
+}
+
+// Writes the files to a gzipped tarball at path, under a directory named after
+// it. Every entry gets the same mode and modification time, and no owner, so
+// that the same files always yield the same tarball.
+func writeBundle(
+	fs afero.Fs, path string, files []bundleFile, modTime time.Time,
+) (err error) {
+	f, err := fs.Create(path)
+	if err != nil {
+		return fmt.Errorf("couldn't create '%s': %v", path, err)
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	root := strings.TrimSuffix(filepath.Base(path), ".tar.gz")
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		err = tw.WriteHeader(&tar.Header{
+			Name:		filepath.Join(root, file.name),
+			Mode:		0644,
+			Size:		int64(len(file.contents)),
+			ModTime:	modTime.UTC().Truncate(time.Second),
+			Typeflag:	tar.TypeReg,
+			Format:		tar.FormatPAX,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(file.contents)
+		if err != nil {
+			return err
+		}
+	}
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
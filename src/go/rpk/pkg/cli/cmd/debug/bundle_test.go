@@ -0,0 +1,212 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package debug
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+type journalProcMock struct {
+	logs	[]string
+}
+
+func (m *journalProcMock) RunWithSystemLdPath(
+	_ time.Duration, command string, _ ...string,
+) ([]string, error) {
+	if command != "journalctl" {
+		return nil, errors.New("executable file not found in $PATH")
+	}
+	return m.logs, nil
+}
+
+func (*journalProcMock) IsRunning(time.Duration, string) bool {
+	return false
+}
+
+// Reads the bundle's files, keyed by their names relative to its root.
+func readBundle(t *testing.T, fs afero.Fs, path string) map[string]string {
+	f, err := fs.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			break
+		}
+		contents, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		files[h.Name[strings.Index(h.Name, "/")+1:]] = string(contents)
+	}
+	return files
+}
+
+func TestFormatConfigJSON(t *testing.T) {
+	tests := []struct {
+		name		string
+		confJSON	string
+		redact		bool
+		expected	string
+	}{
+		{
+			name:		"it should redact the license key",
+			confJSON:	`{"redpanda":{"node_id":1},"license_key":"secret"}`,
+			redact:		true,
+			expected: `{
+  "license_key": "REDACTED",
+  "redpanda": {
+    "node_id": 1
+  }
+}
+`,
+		},
+		{
+			name:		"it should keep the license key if redact is false",
+			confJSON:	`{"redpanda":{"node_id":1},"license_key":"secret"}`,
+			expected: `{
+  "license_key": "secret",
+  "redpanda": {
+    "node_id": 1
+  }
+}
+`,
+		},
+		{
+			name:		"it should leave an empty license key empty",
+			confJSON:	`{"license_key":""}`,
+			redact:		true,
+			expected: `{
+  "license_key": ""
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			actual, err := formatConfigJSON(tt.confJSON, tt.redact)
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, actual)
+		})
+	}
+}
+
+func TestBundlePath(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/tmp/bundles", 0755))
+	tests := []struct {
+		name		string
+		output		string
+		expected	string
+	}{
+		{
+			name:		"it should default to a timestamped name",
+			expected:	"redpanda-debug-bundle-20210304-050607.tar.gz",
+		},
+		{
+			name:		"it should put the bundle in output if it's a directory",
+			output:		"/tmp/bundles",
+			expected:	"/tmp/bundles/redpanda-debug-bundle-20210304-050607.tar.gz",
+		},
+		{
+			name:		"it should use output as the path otherwise",
+			output:		"/tmp/bundle.tar.gz",
+			expected:	"/tmp/bundle.tar.gz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			path, err := bundlePath(fs, tt.output, now)
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, path)
+		})
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC)
+	files := []bundleFile{
+		{name: "version.txt", contents: []byte("v21.3.4\n")},
+		{name: "config.json", contents: []byte("{}\n")},
+	}
+	require.NoError(t, writeBundle(fs, "/a.tar.gz", files, now))
+	first, err := afero.ReadFile(fs, "/a.tar.gz")
+	require.NoError(t, err)
+	// The same files should yield the same bundle.
+	require.NoError(t, writeBundle(fs, "/a.tar.gz", files, now))
+	second, err := afero.ReadFile(fs, "/a.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	f, err := fs.Open("/a.tar.gz")
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	for _, file := range files {
+		h, err := tr.Next()
+		require.NoError(t, err)
+		require.Equal(t, "a/"+file.name, h.Name)
+		require.Equal(t, now.Truncate(time.Second), h.ModTime.UTC())
+		require.Equal(t, int64(0644), h.Mode)
+		contents, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		require.Equal(t, file.contents, contents)
+	}
+	_, err = tr.Next()
+	require.Error(t, err)
+}
+
+func TestExecuteBundle(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mgr := config.NewManager(fs)
+	conf := config.Default()
+	conf.LicenseKey = "secret"
+	require.NoError(t, mgr.Write(conf))
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	proc := &journalProcMock{logs: []string{"INFO  redpanda started"}}
+
+	err := executeBundle(
+		fs,
+		mgr,
+		proc,
+		conf.ConfigFile,
+		"/bundle.tar.gz",
+		time.Second,
+		100,
+		true,
+		now,
+	)
+	require.NoError(t, err)
+
+	files := readBundle(t, fs, "/bundle.tar.gz")
+	require.Contains(t, files, "version.txt")
+	require.Contains(t, files["config.json"], `"license_key": "REDACTED"`)
+	require.NotContains(t, files["config.json"], "secret")
+	require.Equal(t, "INFO  redpanda started\n", files["redpanda.log"])
+	// hwloc isn't available, which shouldn't fail the bundle.
+	require.NotContains(t, files, "topology.json")
+	require.Contains(t, files["errors.txt"], "topology.json: ")
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package generate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func NewShellCompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:	"shell-completion <bash|zsh>",
+		Short:	"Generate shell completion commands.",
+		Long: `Generate shell completion commands.
+
+For bash, the tuner and checker names taken by 'rpk redpanda tune' and
+'rpk redpanda check --skip-checks/--only-checks' are completed too. To load the
+completion in the current shell:
+
+	source <(rpk generate shell-completion bash)
+`,
+		Args:		cobra.ExactValidArgs(1),
+		ValidArgs:	[]string{"bash", "zsh"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletion(os.Stdout)
+			case "zsh":
+				return root.GenZshCompletion(os.Stdout)
+			}
+			return fmt.Errorf("unsupported shell '%s'", args[0])
+		},
+	}
+}
@@ -16,6 +16,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/cmd/redpanda"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
 )
@@ -82,6 +83,8 @@ func NewIoTuneCmd(fs afero.Fs, mgr config.Manager) *cobra.Command {
 			"fraction and a unit suffix, such as '300ms', '1.5s' or '2h45m'. "+
 			"Valid time units are 'ns', 'us' (or 'µs'), 'ms', 's', 'm', 'h'",
 	)
+	command.AddCommand(redpanda.NewListWellKnownIoCommand())
+	command.AddCommand(redpanda.NewIoTuneRunCommand(fs, mgr))
 	return command
 }
 
@@ -0,0 +1,38 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/cmd/profile"
+)
+
+func NewProfileCommand(fs afero.Fs) *cobra.Command {
+	command := &cobra.Command{
+		Use:	"profile",
+		Short:	"Manage named sets of settings (config file, install dir, brokers)",
+		Long: "Manage named sets of the settings rpk commands would" +
+			" otherwise take as flags: --config, --install-dir and" +
+			" --brokers. The active profile's values are used for the" +
+			" flags which aren't passed, in the commands where they" +
+			" mean those settings (not in 'rpk topic create' nor" +
+			" 'rpk redpanda tune'). --profile overrides the active" +
+			" profile for a single command. The profiles are stored in" +
+			" rpk/profiles.yaml in the user's config dir, or in" +
+			" $RPK_PROFILES_FILE if it's set.",
+	}
+	command.AddCommand(profile.NewCreateCommand(fs))
+	command.AddCommand(profile.NewUseCommand(fs))
+	command.AddCommand(profile.NewListCommand(fs))
+	command.AddCommand(profile.NewDeleteCommand(fs))
+
+	return command
+}
@@ -0,0 +1,288 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+func NewCreateCommand(fs afero.Fs) *cobra.Command {
+	var (
+		profile	config.Profile
+		use	bool
+	)
+	command := &cobra.Command{
+		Use:	"create <name>",
+		Short:	"Create a profile with the given settings",
+		Long: "Create a named set of the settings rpk commands would" +
+			" otherwise take as flags. While the profile is active, its" +
+			" values are used for the flags which aren't passed.",
+		Args:	exactlyOneName,
+		RunE: func(_ *cobra.Command, args []string) error {
+			profile.Name = args[0]
+			return updateProfiles(fs, func(p *config.Profiles) error {
+				err := p.Add(profile)
+				if err != nil {
+					return err
+				}
+				if use {
+					p.Current = profile.Name
+					log.Infof("Created and activated profile '%s'", profile.Name)
+					return nil
+				}
+				log.Infof(
+					"Created profile '%s'. Run 'rpk profile use %s'"+
+						" to activate it",
+					profile.Name,
+					profile.Name,
+				)
+				return nil
+			})
+		},
+	}
+	command.Flags().StringVar(
+		&profile.ConfigFile,
+		"config",
+		"",
+		"The Redpanda config file to use",
+	)
+	command.Flags().StringVar(
+		&profile.InstallDir,
+		"install-dir",
+		"",
+		"The directory where redpanda has been installed",
+	)
+	command.Flags().StringSliceVar(
+		&profile.Brokers,
+		"brokers",
+		[]string{},
+		"Comma-separated list of broker ip:port pairs",
+	)
+	command.Flags().BoolVar(
+		&use,
+		"use",
+		false,
+		"Activate the profile once it's created",
+	)
+	return command
+}
+
+func NewUseCommand(fs afero.Fs) *cobra.Command {
+	return &cobra.Command{
+		Use:	"use <name>",
+		Short:	"Activate a profile",
+		Args:	exactlyOneName,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return updateProfiles(fs, func(p *config.Profiles) error {
+				if p.Get(args[0]) == nil {
+					return fmt.Errorf(
+						"profile '%s' doesn't exist. Run"+
+							" 'rpk profile list' to list them",
+						args[0],
+					)
+				}
+				p.Current = args[0]
+				log.Infof("Activated profile '%s'", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+func NewDeleteCommand(fs afero.Fs) *cobra.Command {
+	return &cobra.Command{
+		Use:	"delete <name>",
+		Short:	"Delete a profile, deactivating it if it's active",
+		Args:	exactlyOneName,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return updateProfiles(fs, func(p *config.Profiles) error {
+				err := p.Delete(args[0])
+				if err != nil {
+					return err
+				}
+				log.Infof("Deleted profile '%s'", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+func NewListCommand(fs afero.Fs) *cobra.Command {
+	return &cobra.Command{
+		Use:	"list",
+		Short:	"List the profiles. The active one is marked with '*'",
+		Args:	cobra.NoArgs,
+		RunE: func(ccmd *cobra.Command, _ []string) error {
+			path, err := config.ProfilesFile()
+			if err != nil {
+				return err
+			}
+			profiles, err := config.ReadProfiles(fs, path)
+			if err != nil {
+				return err
+			}
+			printProfilesTable(ccmd.OutOrStdout(), profiles)
+			return nil
+		},
+	}
+}
+
+// The commands which take the active profile's settings, by their path
+// without the root command. Their flags named like them must mean the same,
+// e.g. rpk topic create's --config takes topic configs instead, so only the
+// commands whose --config is a redpanda config file, --install-dir redpanda's
+// installation directory, and --brokers the brokers to connect to are listed.
+// rpk redpanda tune isn't either, since its --config can't be combined with
+// the tuners' params.
+var profileCommands = map[string]bool{
+	"api":				true,
+	"topic":			true,
+	"wasm deploy":			true,
+	"wasm remove":			true,
+	"debug bundle":			true,
+	"debug info":			true,
+	"generate prometheus-config":	true,
+	"iotune":			true,
+	"iotune run":			true,
+	"config bootstrap":		true,
+	"config diff":			true,
+	"config get":			true,
+	"config init":			true,
+	"config lint":			true,
+	"config set":			true,
+	"check":			true,
+	"mode":				true,
+	"start":			true,
+	"stop":				true,
+	"status":			true,
+	"redpanda start":		true,
+	"redpanda stop":		true,
+	"redpanda status":		true,
+	"redpanda check":		true,
+	"redpanda mode":		true,
+	"redpanda flags":		true,
+	"redpanda info":		true,
+	"redpanda config bootstrap":	true,
+	"redpanda config diff":		true,
+	"redpanda config get":		true,
+	"redpanda config init":		true,
+	"redpanda config lint":		true,
+	"redpanda config set":		true,
+}
+
+// Returns whether cmd takes the active profile's settings, either as its own
+// flags or as the persistent ones of one of its parents.
+func TakesProfile(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if profileCommands[commandPath(c)] {
+			return true
+		}
+	}
+	return false
+}
+
+// Sets the flags named like the profile's settings to its values, in cmd and
+// its parents which take them. The flags which were passed aren't changed,
+// and the ones which weren't still aren't marked as such, so that the
+// commands' own defaults apply if the profile doesn't set them.
+func ApplyProfile(cmd *cobra.Command, profile *config.Profile) error {
+	values := map[string]string{
+		"config":	profile.ConfigFile,
+		"install-dir":	profile.InstallDir,
+		"brokers":	strings.Join(profile.Brokers, ","),
+	}
+	// A persistent flag is in both sets, and setting a slice twice would
+	// append to it.
+	visited := map[*pflag.Flag]bool{}
+	for c := cmd; c != nil; c = c.Parent() {
+		if !profileCommands[commandPath(c)] {
+			continue
+		}
+		for _, fs := range []*pflag.FlagSet{c.Flags(), c.PersistentFlags()} {
+			for name, value := range values {
+				f := fs.Lookup(name)
+				if value == "" || f == nil || f.Changed || visited[f] {
+					continue
+				}
+				visited[f] = true
+				err := f.Value.Set(value)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Returns cmd's path without the root command's name.
+func commandPath(cmd *cobra.Command) string {
+	path := cmd.CommandPath()
+	if !cmd.HasParent() {
+		return ""
+	}
+	return path[strings.Index(path, " ")+1:]
+}
+
+// Reads the profiles, passes them to update and writes them back if it
+// succeeds.
+func updateProfiles(
+	fs afero.Fs, update func(*config.Profiles) error,
+) error {
+	path, err := config.ProfilesFile()
+	if err != nil {
+		return err
+	}
+	profiles, err := config.ReadProfiles(fs, path)
+	if err != nil {
+		return err
+	}
+	err = update(profiles)
+	if err != nil {
+		return err
+	}
+	return config.WriteProfiles(fs, path, profiles)
+}
+
+func printProfilesTable(w io.Writer, profiles *config.Profiles) {
+	t := ui.NewRpkTable(w)
+	t.SetHeader([]string{"", "Name", "Config", "Install dir", "Brokers"})
+	for _, p := range profiles.Profiles {
+		active := ""
+		if p.Name == profiles.Current {
+			active = "*"
+		}
+		t.Append([]string{
+			active,
+			p.Name,
+			p.ConfigFile,
+			p.InstallDir,
+			strings.Join(p.Brokers, ","),
+		})
+	}
+	t.Render()
+}
+
+func exactlyOneName(_ *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("requires the profile's name")
+	}
+	return nil
+}
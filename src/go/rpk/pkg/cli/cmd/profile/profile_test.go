@@ -0,0 +1,128 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package profile
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+const profilesFile = "/home/user/.config/rpk/profiles.yaml"
+
+func run(t *testing.T, c *cobra.Command, args ...string) (string, error) {
+	var out bytes.Buffer
+	c.SetOut(&out)
+	c.SetArgs(args)
+	err := c.Execute()
+	return out.String(), err
+}
+
+func TestProfileCommands(t *testing.T) {
+	os.Setenv(config.ProfilesFileEnv, profilesFile)
+	defer os.Unsetenv(config.ProfilesFileEnv)
+	fs := afero.NewMemMapFs()
+
+	_, err := run(
+		t,
+		NewCreateCommand(fs),
+		"prod",
+		"--install-dir", "/opt/redpanda",
+		"--brokers", "10.0.0.1:9092,10.0.0.2:9092",
+	)
+	require.NoError(t, err)
+	_, err = run(t, NewCreateCommand(fs), "dev", "--config", "/tmp/redpanda.yaml", "--use")
+	require.NoError(t, err)
+	_, err = run(t, NewCreateCommand(fs), "dev")
+	require.EqualError(t, err, "profile 'dev' already exists")
+
+	profiles, err := config.ReadProfiles(fs, profilesFile)
+	require.NoError(t, err)
+	require.Equal(t, "dev", profiles.Current)
+
+	_, err = run(t, NewUseCommand(fs), "prod")
+	require.NoError(t, err)
+	_, err = run(t, NewUseCommand(fs), "staging")
+	require.EqualError(
+		t,
+		err,
+		"profile 'staging' doesn't exist. Run 'rpk profile list' to list them",
+	)
+
+	out, err := run(t, NewListCommand(fs))
+	require.NoError(t, err)
+	require.Regexp(t, `\*\s+prod\s+/opt/redpanda\s+10.0.0.1:9092,10.0.0.2:9092`, out)
+	require.Contains(t, out, "/tmp/redpanda.yaml")
+
+	_, err = run(t, NewDeleteCommand(fs), "prod")
+	require.NoError(t, err)
+	profiles, err = config.ReadProfiles(fs, profilesFile)
+	require.NoError(t, err)
+	require.Equal(t, "", profiles.Current)
+	require.Len(t, profiles.Profiles, 1)
+}
+
+func TestApplyProfile(t *testing.T) {
+	var (
+		configFile	string
+		installDir	string
+		brokers		[]string
+		topicConfigs	[]string
+		tuneConfig	string
+	)
+	root := &cobra.Command{Use: "rpk"}
+	topic := &cobra.Command{Use: "topic"}
+	topic.PersistentFlags().StringSliceVar(&brokers, "brokers", []string{}, "")
+	topic.PersistentFlags().StringVar(&configFile, "config", "", "")
+	create := &cobra.Command{Use: "create"}
+	create.Flags().StringArrayVar(&topicConfigs, "config", []string{}, "")
+	topic.AddCommand(create)
+	redpanda := &cobra.Command{Use: "redpanda"}
+	start := &cobra.Command{Use: "start"}
+	start.Flags().StringVar(&installDir, "install-dir", "", "")
+	tune := &cobra.Command{Use: "tune"}
+	tune.Flags().StringVar(&tuneConfig, "config", "", "")
+	redpanda.AddCommand(start, tune)
+	profile := &cobra.Command{Use: "profile"}
+	root.AddCommand(topic, redpanda, profile)
+
+	require.True(t, TakesProfile(create))
+	require.True(t, TakesProfile(start))
+	require.False(t, TakesProfile(tune))
+	require.False(t, TakesProfile(profile))
+	require.False(t, TakesProfile(root))
+
+	p := &config.Profile{
+		Name:		"prod",
+		ConfigFile:	"/etc/redpanda/redpanda.yaml",
+		InstallDir:	"/opt/redpanda",
+		Brokers:	[]string{"10.0.0.1:9092", "10.0.0.2:9092"},
+	}
+	// A passed flag shouldn't be overridden.
+	require.NoError(t, topic.PersistentFlags().Set("config", "/etc/other.yaml"))
+	require.NoError(t, ApplyProfile(create, p))
+	require.Equal(t, "/etc/other.yaml", configFile)
+	require.Equal(t, []string{"10.0.0.1:9092", "10.0.0.2:9092"}, brokers)
+	// rpk topic create's --config takes topic configs.
+	require.Empty(t, topicConfigs)
+
+	require.NoError(t, ApplyProfile(start, p))
+	require.Equal(t, "/opt/redpanda", installDir)
+	// The flags set from the profile shouldn't count as passed.
+	require.False(t, start.Flags().Changed("install-dir"))
+
+	require.NoError(t, ApplyProfile(tune, p))
+	require.Equal(t, "", tuneConfig)
+}
@@ -31,6 +31,9 @@ func NewRedpandaCommand(
 	command.AddCommand(redpanda.NewTuneCommand(fs, mgr))
 	command.AddCommand(redpanda.NewModeCommand(mgr))
 	command.AddCommand(redpanda.NewConfigCommand(fs, mgr))
+	command.AddCommand(redpanda.NewFlagsCommand(fs, mgr))
+	command.AddCommand(redpanda.NewInfoCommand(fs, mgr))
+	command.AddCommand(redpanda.NewStatusCommand(fs, mgr))
 
 	return command
 }
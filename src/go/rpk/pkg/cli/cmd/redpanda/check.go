@@ -10,30 +10,77 @@
 package redpanda
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
 )
 
+const failOnWarnFlag = "fail-on-warn"
+
 func NewCheckCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
 	var (
 		configFile	string
 		timeout		time.Duration
+		selection	checkSelection
+		watch		bool
+		interval	time.Duration
+		metricsFile	string
+		failOnWarn	bool
 	)
 	command := &cobra.Command{
 		Use:		"check",
 		Short:		"Check if system meets redpanda requirements",
 		SilenceUsage:	true,
 		RunE: func(ccmd *cobra.Command, args []string) error {
-			return executeCheck(fs, mgr, configFile, timeout)
+			if !watch {
+				return executeCheck(
+					fs,
+					mgr,
+					configFile,
+					timeout,
+					selection,
+					metricsFile,
+					failOnWarn,
+				)
+			}
+			if failOnWarn {
+				return fmt.Errorf(
+					"--%s can't be used with --watch",
+					failOnWarnFlag,
+				)
+			}
+			if interval <= 0 {
+				return errors.New("--interval must be positive")
+			}
+			stop := make(chan os.Signal, 1)
+			signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(stop)
+			return executeWatchCheck(
+				fs,
+				mgr,
+				configFile,
+				timeout,
+				selection,
+				metricsFile,
+				interval,
+				stop,
+			)
 		},
 	}
 	command.Flags().StringVar(
@@ -52,45 +99,363 @@ func NewCheckCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
 			"fraction and a unit suffix, such as '300ms', '1.5s' or '2h45m'. "+
 			"Valid time units are 'ns', 'us' (or 'µs'), 'ms', 's', 'm', 'h'",
 	)
+	command.Flags().BoolVar(
+		&selection.rerunFailed,
+		"rerun-failed",
+		false,
+		"Only run the checks which failed in the previous run. If there's"+
+			" no previous run, or none failed, all checks are run",
+	)
+	command.Flags().BoolVar(
+		&watch,
+		"watch",
+		false,
+		"Re-run the checks every --interval until interrupted, highlighting"+
+			" the ones which stop passing. --timeout applies to each run",
+	)
+	command.Flags().DurationVar(
+		&interval,
+		"interval",
+		5*time.Second,
+		"How often to re-run the checks with --watch",
+	)
+	command.Flags().StringVar(
+		&metricsFile,
+		metricsFileFlag,
+		"",
+		"Write the check results to this file in Prometheus' text format,"+
+			" e.g. for node_exporter's textfile collector (*.prom)."+
+			" With --watch, it's rewritten on every run",
+	)
+	command.Flags().BoolVar(
+		&failOnWarn,
+		failOnWarnFlag,
+		false,
+		"Fail if any check fails, not just the ones with a fatal severity",
+	)
+	addCheckSelectionFlags(command.Flags(), &selection)
 	return command
 }
 
+// Adds the flags selecting which checkers to run by name, shared by 'check'
+// and 'start'.
+func addCheckSelectionFlags(flags *pflag.FlagSet, selection *checkSelection) {
+	flags.StringSliceVar(
+		&selection.skip,
+		"skip-checks",
+		[]string{},
+		"Comma-separated list of checks not to run. Available: "+
+			strings.Join(tuners.CheckerNames(), ", "),
+	)
+	flags.StringSliceVar(
+		&selection.only,
+		"only-checks",
+		[]string{},
+		"Comma-separated list of the only checks to run",
+	)
+	cobra.MarkFlagCustom(flags, "skip-checks", CheckerNamesCompletionFunc)
+	cobra.MarkFlagCustom(flags, "only-checks", CheckerNamesCompletionFunc)
+}
+
 func appendToTable(t *tablewriter.Table, r tuners.CheckResult) {
 	t.Append([]string{
 		r.Desc,
+		r.Category.String(),
 		r.Required,
 		r.Current,
-		fmt.Sprint(r.Severity),
+		printSeverity(r.Severity, r.IsOk),
 		fmt.Sprint(printResult(r.Severity, r.IsOk)),
 	})
 }
 
 func executeCheck(
-	fs afero.Fs, mgr config.Manager, configFile string, timeout time.Duration,
+	fs afero.Fs,
+	mgr config.Manager,
+	configFile string,
+	timeout time.Duration,
+	selection checkSelection,
+	metricsFile string,
+	failOnWarn bool,
 ) error {
-	conf, err := mgr.FindOrGenerate(configFile)
+	run, err := checkRunner(
+		fs,
+		mgr,
+		configFile,
+		timeout,
+		selection,
+		metricsFile,
+	)
 	if err != nil {
 		return err
 	}
-	results, err := tuners.Check(fs, conf, timeout)
+	results, err := run()
+	if err != nil {
+		return err
+	}
+	table := newCheckTable(os.Stdout)
+	for _, res := range results {
+		appendToTable(table, res)
+	}
+	fmt.Printf("\nSystem check results\n")
+	table.Render()
+	if failOnWarn {
+		return failedChecksError(results)
+	}
+	return nil
+}
+
+// Returns an error naming the checks which failed, regardless of their
+// severity, or nil if they all passed.
+func failedChecksError(results []tuners.CheckResult) error {
+	failed := []string{}
+	for _, r := range results {
+		if !r.IsOk {
+			failed = append(failed, fmt.Sprintf("'%s'", r.Desc))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf(
+		"%d system check(s) failed: %s",
+		len(failed),
+		strings.Join(failed, ", "),
+	)
+}
+
+func executeWatchCheck(
+	fs afero.Fs,
+	mgr config.Manager,
+	configFile string,
+	timeout time.Duration,
+	selection checkSelection,
+	metricsFile string,
+	interval time.Duration,
+	stop <-chan os.Signal,
+) error {
+	run, err := checkRunner(
+		fs,
+		mgr,
+		configFile,
+		timeout,
+		selection,
+		metricsFile,
+	)
 	if err != nil {
 		return err
 	}
-	table := ui.NewRpkTable(os.Stdout)
+	return watchChecks(os.Stdout, run, interval, stop)
+}
+
+// Returns a function running the selected checks and saving their results,
+// so that --rerun-failed can pick them up, and to metricsFile, if it's set.
+func checkRunner(
+	fs afero.Fs,
+	mgr config.Manager,
+	configFile string,
+	timeout time.Duration,
+	selection checkSelection,
+	metricsFile string,
+) (func() ([]tuners.CheckResult, error), error) {
+	conf, err := mgr.FindOrGenerate(configFile)
+	if err != nil {
+		return nil, err
+	}
+	statePath := tuners.CheckStatePath(conf)
+	filter, err := checkFilter(fs, statePath, selection)
+	if err != nil {
+		return nil, err
+	}
+	return func() ([]tuners.CheckResult, error) {
+		results, err := tuners.CheckFiltered(fs, conf, timeout, filter)
+		if err != nil {
+			return nil, err
+		}
+		if err := tuners.SaveCheckState(fs, statePath, results); err != nil {
+			log.Warnf("Couldn't save the check results to '%s': %v", statePath, err)
+		}
+		if metricsFile != "" {
+			err = writeMetricsFile(fs, metricsFile, checkMetrics(results))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return results, nil
+	}, nil
+}
+
+// Runs the checks every interval, redrawing their results, until stop
+// receives a signal. Each run's timeout is handled by run. The checks which
+// passed in the previous run but failed in the latest one are highlighted.
+func watchChecks(
+	out io.Writer,
+	run func() ([]tuners.CheckResult, error),
+	interval time.Duration,
+	stop <-chan os.Signal,
+) error {
+	passed := map[string]bool{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		results, err := run()
+		if err != nil {
+			return err
+		}
+		// Move the cursor to the top left and clear the screen.
+		fmt.Fprint(out, "\033[H\033[2J")
+		fmt.Fprintf(
+			out,
+			"System check results at %s, every %s. Press Ctrl+C to exit\n",
+			time.Now().Format("15:04:05"),
+			interval,
+		)
+		table := newCheckTable(out)
+		for _, res := range results {
+			payload := checkPayload(res)
+			appendWatchRow(table, res, payload, passed[payload.Name] && !res.IsOk)
+			passed[payload.Name] = res.IsOk
+		}
+		table.Render()
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			// select picks randomly if both are ready, so make sure
+			// it wasn't stopped in the meantime.
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+		}
+	}
+}
+
+func appendWatchRow(
+	t *tablewriter.Table,
+	r tuners.CheckResult,
+	payload api.CheckPayload,
+	regressed bool,
+) {
+	result := printResult(r.Severity, r.IsOk)
+	if regressed {
+		result = color.New(color.FgRed, color.Bold).Sprint("false (was true)")
+	}
+	current := payload.Current
+	if payload.ErrorMsg != "" {
+		current = payload.ErrorMsg
+	}
+	t.Append([]string{
+		payload.Name,
+		r.Category.String(),
+		payload.Required,
+		current,
+		printSeverity(r.Severity, r.IsOk),
+		result,
+	})
+}
+
+func newCheckTable(out io.Writer) *tablewriter.Table {
+	table := ui.NewRpkTable(out)
 	table.SetHeader([]string{
 		"Condition",
+		"Category",
 		"Required",
 		"Current",
 		"Severity",
 		"Passed",
 	})
+	return table
+}
 
-	for _, res := range results {
-		appendToTable(table, res)
+// Highlights the severity of failed checks, so that fatal ones stand out.
+func printSeverity(sev tuners.Severity, isOk bool) string {
+	if isOk {
+		return fmt.Sprint(sev)
 	}
-	fmt.Printf("\nSystem check results\n")
-	table.Render()
-	return nil
+	switch sev {
+	case tuners.Fatal:
+		return color.RedString("%v", sev)
+	case tuners.Warning:
+		return color.YellowString("%v", sev)
+	}
+	return fmt.Sprint(sev)
+}
+
+// Which checks the user asked to run.
+type checkSelection struct {
+	rerunFailed	bool
+	skip		[]string
+	only		[]string
+}
+
+// Returns the filter selecting which checkers to run. With rerunFailed, only
+// those which failed in the previous run are selected, if there was one and
+// any failed.
+func checkFilter(
+	fs afero.Fs, statePath string, selection checkSelection,
+) (tuners.CheckFilter, error) {
+	var filters []tuners.CheckFilter
+	only, err := checkerIDs(selection.only)
+	if err != nil {
+		return nil, err
+	}
+	skip, err := checkerIDs(selection.skip)
+	if err != nil {
+		return nil, err
+	}
+	onlyFilter := tuners.OnlyCheckers(only)
+	for i, id := range skip {
+		if onlyFilter(id) {
+			return nil, fmt.Errorf(
+				"check '%s' can't be passed to both --only-checks"+
+					" and --skip-checks",
+				selection.skip[i],
+			)
+		}
+	}
+	if len(only) > 0 {
+		filters = append(filters, onlyFilter)
+	}
+	if len(skip) > 0 {
+		filters = append(filters, tuners.SkipCheckers(skip))
+	}
+	if selection.rerunFailed {
+		failed, found, err := tuners.LoadFailedCheckers(fs, statePath)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !found:
+			log.Info("No previous check results found, running all checks")
+		case len(failed) == 0:
+			log.Info("No checks failed in the previous run, so there's" +
+				" nothing to rerun. Running all checks")
+		default:
+			log.Infof("Re-running %d check(s) which failed in the previous run", len(failed))
+			filters = append(filters, tuners.OnlyCheckers(failed))
+		}
+	}
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	return tuners.AllCheckFilters(filters...), nil
+}
+
+func checkerIDs(names []string) ([]tuners.CheckerID, error) {
+	ids := make([]tuners.CheckerID, 0, len(names))
+	for _, name := range names {
+		id, err := tuners.CheckerIDFromName(name)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%v. Available: %s",
+				err,
+				strings.Join(tuners.CheckerNames(), ", "),
+			)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
 }
 
 func printResult(sev tuners.Severity, isOk bool) string {
@@ -0,0 +1,199 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+)
+
+func TestCheckFilter(t *testing.T) {
+	tests := []struct {
+		name		string
+		selection	checkSelection
+		expectedRun	[]tuners.CheckerID
+		expectedSkipped	[]tuners.CheckerID
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should run all the checks if none is selected",
+			expectedRun:	[]tuners.CheckerID{tuners.SwapChecker, tuners.ClockSource},
+		},
+		{
+			name:			"it should only run the given checks",
+			selection:		checkSelection{only: []string{"swap"}},
+			expectedRun:		[]tuners.CheckerID{tuners.SwapChecker},
+			expectedSkipped:	[]tuners.CheckerID{tuners.ClockSource},
+		},
+		{
+			name:			"it should skip the given checks",
+			selection:		checkSelection{skip: []string{"clocksource"}},
+			expectedRun:		[]tuners.CheckerID{tuners.SwapChecker},
+			expectedSkipped:	[]tuners.CheckerID{tuners.ClockSource},
+		},
+		{
+			name:	"it should combine --only-checks and --skip-checks",
+			selection: checkSelection{
+				only:	[]string{"swap", "ntp"},
+				skip:	[]string{"clocksource"},
+			},
+			expectedRun:		[]tuners.CheckerID{tuners.SwapChecker, tuners.NtpChecker},
+			expectedSkipped:	[]tuners.CheckerID{tuners.ClockSource},
+		},
+		{
+			name:	"it should fail if a check is both selected and skipped",
+			selection: checkSelection{
+				only:	[]string{"swap", "ntp"},
+				skip:	[]string{"ntp"},
+			},
+			expectedErrMsg:	"check 'ntp' can't be passed to both --only-checks and --skip-checks",
+		},
+		{
+			name:		"it should fail if a check doesn't exist",
+			selection:	checkSelection{skip: []string{"nope"}},
+			expectedErrMsg:	"unknown checker 'nope'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			filter, err := checkFilter(afero.NewMemMapFs(), "", tt.selection)
+			if tt.expectedErrMsg != "" {
+				require.Error(st, err)
+				require.Contains(st, err.Error(), tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			for _, id := range tt.expectedRun {
+				require.True(st, filter == nil || filter(id))
+			}
+			for _, id := range tt.expectedSkipped {
+				require.False(st, filter(id))
+			}
+		})
+	}
+}
+
+func TestCheckFilterRerunFailed(t *testing.T) {
+	const path = "/etc/redpanda/.rpk_check_state.json"
+	tests := []struct {
+		name		string
+		results		[]tuners.CheckResult
+		expectedRun	[]tuners.CheckerID
+		expectedSkipped	[]tuners.CheckerID
+	}{
+		{
+			name:		"it should run all the checks if there's no previous run",
+			expectedRun:	[]tuners.CheckerID{tuners.SwapChecker, tuners.ClockSource},
+		},
+		{
+			name:	"it should only run the checks which failed",
+			results: []tuners.CheckResult{
+				{CheckerId: tuners.SwapChecker, IsOk: false},
+				{CheckerId: tuners.ClockSource, IsOk: true},
+			},
+			expectedRun:		[]tuners.CheckerID{tuners.SwapChecker},
+			expectedSkipped:	[]tuners.CheckerID{tuners.ClockSource},
+		},
+		{
+			name:	"it should run all the checks if none failed",
+			results: []tuners.CheckResult{
+				{CheckerId: tuners.SwapChecker, IsOk: true},
+				{CheckerId: tuners.ClockSource, IsOk: true},
+			},
+			expectedRun:	[]tuners.CheckerID{tuners.SwapChecker, tuners.ClockSource},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.results != nil {
+				err := tuners.SaveCheckState(fs, path, tt.results)
+				require.NoError(st, err)
+			}
+			filter, err := checkFilter(
+				fs,
+				path,
+				checkSelection{rerunFailed: true},
+			)
+			require.NoError(st, err)
+			for _, id := range tt.expectedRun {
+				require.True(st, filter == nil || filter(id))
+			}
+			for _, id := range tt.expectedSkipped {
+				require.False(st, filter(id))
+			}
+		})
+	}
+}
+
+func TestWatchChecks(t *testing.T) {
+	swap := func(ok bool) tuners.CheckResult {
+		return tuners.CheckResult{
+			CheckerId:	tuners.SwapChecker,
+			IsOk:		ok,
+			Desc:		"Swap enabled",
+			Severity:	tuners.Warning,
+			Required:	"true",
+			Current:	"false",
+		}
+	}
+	iterations := [][]tuners.CheckResult{
+		{swap(true)},
+		{swap(false)},
+		{swap(false)},
+	}
+	stop := make(chan os.Signal, 1)
+	runs := 0
+	run := func() ([]tuners.CheckResult, error) {
+		if runs == len(iterations) {
+			return nil, errors.New("ran after being stopped")
+		}
+		results := iterations[runs]
+		runs++
+		if runs == len(iterations) {
+			stop <- os.Interrupt
+		}
+		return results, nil
+	}
+	var out bytes.Buffer
+	err := watchChecks(&out, run, time.Millisecond, stop)
+	require.NoError(t, err)
+	require.Equal(t, len(iterations), runs)
+
+	// Only the run where the check stopped passing highlights it.
+	screens := strings.Split(out.String(), "\033[H\033[2J")[1:]
+	require.Len(t, screens, len(iterations))
+	require.NotContains(t, screens[0], "was true")
+	require.Contains(t, screens[1], "false (was true)")
+	require.NotContains(t, screens[2], "was true")
+}
+
+func TestFailedChecksError(t *testing.T) {
+	results := []tuners.CheckResult{
+		{Desc: "Swap enabled", IsOk: false, Severity: tuners.Warning},
+		{Desc: "Max AIO Events", IsOk: true, Severity: tuners.Fatal},
+		{Desc: "Clock Source", IsOk: false, Severity: tuners.Warning},
+	}
+	err := failedChecksError(results)
+	require.EqualError(
+		t,
+		err,
+		"2 system check(s) failed: 'Swap enabled', 'Clock Source'",
+	)
+	require.NoError(t, failedChecksError(results[1:2]))
+}
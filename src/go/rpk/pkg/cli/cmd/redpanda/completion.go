@@ -0,0 +1,71 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/factory"
+)
+
+// The bash functions completing (comma-separated lists of) checker and tuner
+// names.
+const (
+	CheckerNamesCompletionFunc	= "__rpk_complete_checkers"
+	TunerNamesCompletionFunc	= "__rpk_complete_tuners"
+)
+
+// A format string, so '%%' is a single '%' in the generated script, e.g.
+// prefix="${cur%,*}," keeps every element but the last one being completed.
+const bashCompletionTmpl = `
+__rpk_complete_list()
+{
+    local last="${cur##*,}" prefix=""
+    if [[ "${cur}" == *,* ]]; then
+        prefix="${cur%%,*},"
+    fi
+    COMPREPLY=( $(compgen -P "${prefix}" -W "$1" -- "${last}") )
+}
+
+%[1]s()
+{
+    __rpk_complete_list "%[2]s"
+}
+
+%[3]s()
+{
+    __rpk_complete_list "%[4]s"
+}
+
+__rpk_custom_func()
+{
+    case ${last_command} in
+        rpk_tune | rpk_redpanda_tune)
+            %[3]s
+            ;;
+    esac
+}
+`
+
+// Returns the bash functions which complete the checker and tuner names
+// taken by rpk's commands and flags, built from the names currently
+// registered. They must be set as the root command's BashCompletionFunction.
+func BashCompletionFunctions() string {
+	tunerNames := append([]string{"all"}, factory.AvailableTuners()...)
+	return fmt.Sprintf(
+		bashCompletionTmpl,
+		CheckerNamesCompletionFunc,
+		strings.Join(tuners.CheckerNames(), " "),
+		TunerNamesCompletionFunc,
+		strings.Join(tunerNames, " "),
+	)
+}
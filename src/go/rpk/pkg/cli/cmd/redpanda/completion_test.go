@@ -0,0 +1,74 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBashCompletionFunctions(t *testing.T) {
+	script := BashCompletionFunctions()
+	// Only the last element is removed from the prefix.
+	require.Contains(t, script, `prefix="${cur%,*},"`)
+	require.NotContains(t, script, `prefix="${cur%%,*},"`)
+}
+
+func TestCompleteCheckerNames(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash isn't installed")
+	}
+	tests := []struct {
+		name		string
+		cur		string
+		expected	[]string
+	}{
+		{
+			name:		"it should complete the first element",
+			cur:		"clo",
+			expected:	[]string{"clocksource"},
+		},
+		{
+			name:		"it should keep the previous element",
+			cur:		"swap,clo",
+			expected:	[]string{"swap,clocksource"},
+		},
+		{
+			name:		"it should keep all the previous elements",
+			cur:		"swap,ntp,clo",
+			expected:	[]string{"swap,ntp,clocksource"},
+		},
+		{
+			name:		"it should keep all the previous elements of longer lists",
+			cur:		"swap,ntp,fs_type,clo",
+			expected:	[]string{"swap,ntp,fs_type,clocksource"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			script := BashCompletionFunctions() + `
+cur="$1"
+` + CheckerNamesCompletionFunc + `
+printf '%s\n' "${COMPREPLY[@]}"
+`
+			out, err := exec.Command(bash, "-c", script, "bash", tt.cur).Output()
+			require.NoError(st, err)
+			require.Equal(
+				st,
+				tt.expected,
+				strings.Fields(string(out)),
+			)
+		})
+	}
+}
@@ -10,13 +10,18 @@
 package redpanda
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"gopkg.in/yaml.v2"
 )
 
 const configFileFlag = "config"
@@ -27,8 +32,11 @@ func NewConfigCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
 		Short:	"Edit configuration",
 	}
 	root.AddCommand(set(fs, mgr))
+	root.AddCommand(get(fs, mgr))
 	root.AddCommand(bootstrap(mgr))
 	root.AddCommand(initNode(mgr))
+	root.AddCommand(lint(fs, mgr))
+	root.AddCommand(diff(fs, mgr))
 
 	return root
 }
@@ -37,6 +45,7 @@ func set(fs afero.Fs, mgr config.Manager) *cobra.Command {
 	var (
 		format		string
 		configPath	string
+		appendValue	bool
 	)
 	c := &cobra.Command{
 		Use:	"set <key> <value>",
@@ -52,6 +61,9 @@ func set(fs afero.Fs, mgr config.Manager) *cobra.Command {
 					return err
 				}
 			}
+			if appendValue {
+				return mgr.Append(key, value, format, configPath)
+			}
 			return mgr.Set(key, value, format, configPath)
 		},
 	}
@@ -62,6 +74,13 @@ func set(fs afero.Fs, mgr config.Manager) *cobra.Command {
 			" '/etc/redpanda' or 100; and 'json' and 'yaml' when"+
 			" partially or completely setting config objects",
 	)
+	c.Flags().BoolVar(
+		&appendValue,
+		"append",
+		false,
+		"Append the value to the list at <key> instead of replacing it."+
+			" It isn't appended if the list already contains it",
+	)
 	c.Flags().StringVar(
 		&configPath,
 		configFileFlag,
@@ -72,6 +91,74 @@ func set(fs afero.Fs, mgr config.Manager) *cobra.Command {
 	return c
 }
 
+func get(fs afero.Fs, mgr config.Manager) *cobra.Command {
+	var (
+		format		string
+		configPath	string
+	)
+	c := &cobra.Command{
+		Use:	"get <key>",
+		Short:	"Print a configuration value, such as redpanda.data_directory",
+		Long: "Print the value at the given dotted path, e.g." +
+			" 'redpanda.kafka_api.port' or 'redpanda.seed_servers.0'," +
+			" including the keys rpk doesn't know. Objects and lists" +
+			" are printed as YAML, unless --format json is passed.",
+		Args:		cobra.ExactArgs(1),
+		SilenceUsage:	true,
+		RunE: func(ccmd *cobra.Command, args []string) error {
+			conf, err := mgr.FindOrGenerate(configPath)
+			if err != nil {
+				return err
+			}
+			value, err := config.Get(fs, conf, args[0])
+			if err != nil {
+				return err
+			}
+			return printConfigValue(ccmd.OutOrStdout(), value, format)
+		},
+	}
+	c.Flags().StringVar(
+		&format,
+		"format",
+		"yaml",
+		"The output format. Can be 'yaml' or 'json'. Single values are"+
+			" printed as they are with 'yaml'",
+	)
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		"Redpanda config file, if not set the file will be searched"+
+			" for in the default location",
+	)
+	return c
+}
+
+func printConfigValue(out io.Writer, value interface{}, format string) error {
+	switch format {
+	case "json":
+		bs, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(bs))
+	case "yaml":
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			bs, err := yaml.Marshal(value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(out, string(bs))
+		default:
+			fmt.Fprintln(out, value)
+		}
+	default:
+		return fmt.Errorf("unsupported format '%s'", format)
+	}
+	return nil
+}
+
 func bootstrap(mgr config.Manager) *cobra.Command {
 	var (
 		ips		[]string
@@ -80,10 +167,10 @@ func bootstrap(mgr config.Manager) *cobra.Command {
 		configPath	string
 	)
 	c := &cobra.Command{
-		Use:	"bootstrap --id <id> [--self <ip>] [--ips <ip1,ip2,...>]",
+		Use:	"bootstrap [--id <id>] [--self <ip>] [--ips <ip1,ip2,...>]",
 		Short:	"Initialize the configuration to bootstrap a cluster",
 		Long: "Initialize the configuration to bootstrap a cluster." +
-			" --id is mandatory. bootstrap will expect the machine" +
+			" bootstrap will expect the machine" +
 			" it's running on to have only one private non-" +
 			"loopback IP address associated to it, and use it in the" +
 			" configuration as the node's address. If it has multiple" +
@@ -92,7 +179,10 @@ func bootstrap(mgr config.Manager) *cobra.Command {
 			" machine's addresses or not. The elements in --ips must" +
 			" be separated by a comma, no spaces. If omitted, the" +
 			" node will be configured as a root node, that other" +
-			" ones can join later.",
+			" ones can join later. If --id isn't passed, the node's" +
+			" ID is its IP's position in --ips, starting at 0, so" +
+			" passing the same --ips on every node assigns them" +
+			" unique IDs, which match their seed servers' IDs.",
 		Args:	cobra.OnlyValidArgs,
 		RunE: func(c *cobra.Command, args []string) error {
 			defaultRpcPort := config.Default().Redpanda.RPCServer.Port
@@ -116,10 +206,24 @@ func bootstrap(mgr config.Manager) *cobra.Command {
 					return err
 				}
 			}
+			if !c.Flags().Changed("id") {
+				id, err = nodeID(ownIp, ips)
+				if err != nil {
+					return err
+				}
+			}
 			conf.Redpanda.Id = id
 			conf.Redpanda.RPCServer.Address = ownIp.String()
 			conf.Redpanda.KafkaApi.Address = ownIp.String()
 			conf.Redpanda.AdminApi.Address = ownIp.String()
+			conf.Redpanda.AdvertisedRPCAPI = &config.SocketAddress{
+				Address:	ownIp.String(),
+				Port:		conf.Redpanda.RPCServer.Port,
+			}
+			conf.Redpanda.AdvertisedKafkaApi = &config.SocketAddress{
+				Address:	ownIp.String(),
+				Port:		conf.Redpanda.KafkaApi.Port,
+			}
 			conf.Redpanda.SeedServers = []config.SeedServer{}
 			seeds := []config.SeedServer{}
 			for i, ip := range ips {
@@ -159,12 +263,30 @@ func bootstrap(mgr config.Manager) *cobra.Command {
 		&id,
 		"id",
 		-1,
-		"This node's ID (required).",
+		"This node's ID. If not set, it's the position of this"+
+			" node's IP in --ips, starting at 0",
 	)
-	cobra.MarkFlagRequired(c.Flags(), "id")
 	return c
 }
 
+// Returns the position of ownIp in ips, which is used as the node's ID, or 0
+// if ips is empty, i.e. if it's a root node.
+func nodeID(ownIp net.IP, ips []net.IP) (int, error) {
+	if len(ips) == 0 {
+		return 0, nil
+	}
+	for i, ip := range ips {
+		if ip.Equal(ownIp) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf(
+		"%s isn't in --ips, so the node's ID can't be deduced. Pass"+
+			" --id, or add it to --ips",
+		ownIp,
+	)
+}
+
 func initNode(mgr config.Manager) *cobra.Command {
 	var (
 		configPath string
@@ -195,6 +317,180 @@ func initNode(mgr config.Manager) *cobra.Command {
 	return c
 }
 
+func lint(fs afero.Fs, mgr config.Manager) *cobra.Command {
+	var (
+		configPath	string
+		format		string
+	)
+	c := &cobra.Command{
+		Use:		"lint",
+		Short:		"Report deprecated and risky configuration settings",
+		Args:		cobra.NoArgs,
+		SilenceUsage:	true,
+		RunE: func(ccmd *cobra.Command, _ []string) error {
+			conf, err := mgr.FindOrGenerate(configPath)
+			if err != nil {
+				return err
+			}
+			results := tuners.LintConfig(fs, conf)
+			err = printLintResults(ccmd.OutOrStdout(), results, format)
+			if err != nil {
+				return err
+			}
+			fatal := 0
+			for _, r := range results {
+				if r.Severity == tuners.Fatal {
+					fatal++
+				}
+			}
+			if fatal > 0 {
+				return fmt.Errorf(
+					"config lint found %d fatal issue(s)",
+					fatal,
+				)
+			}
+			return nil
+		},
+	}
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		"Redpanda config file, if not set the file will be searched"+
+			" for in the default location",
+	)
+	c.Flags().StringVar(
+		&format,
+		"format",
+		"text",
+		"The output format. Can be 'text' or 'json'",
+	)
+	return c
+}
+
+func printLintResults(
+	out io.Writer, results []tuners.LintResult, format string,
+) error {
+	type lintJSON struct {
+		Rule		string	`json:"rule"`
+		Severity	string	`json:"severity"`
+		Category	string	`json:"category"`
+		Message		string	`json:"message"`
+	}
+	switch format {
+	case "json":
+		rs := make([]lintJSON, 0, len(results))
+		for _, r := range results {
+			rs = append(rs, lintJSON{
+				Rule:		r.Rule,
+				Severity:	r.Severity.String(),
+				Category:	r.Category.String(),
+				Message:	r.Message,
+			})
+		}
+		bs, err := json.Marshal(rs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(bs))
+	case "text":
+		if len(results) == 0 {
+			fmt.Fprintln(out, "No issues found")
+			return nil
+		}
+		t := ui.NewRpkTable(out)
+		t.SetHeader([]string{"Rule", "Severity", "Category", "Message"})
+		for _, r := range results {
+			t.Append([]string{
+				r.Rule,
+				r.Severity.String(),
+				r.Category.String(),
+				r.Message,
+			})
+		}
+		t.Render()
+	default:
+		return fmt.Errorf("unsupported format '%s'", format)
+	}
+	return nil
+}
+
+func diff(fs afero.Fs, mgr config.Manager) *cobra.Command {
+	var (
+		configPath	string
+		format		string
+	)
+	c := &cobra.Command{
+		Use:	"diff",
+		Short:	"Show the config keys rpk ignores or takes from the defaults",
+		Long: "Compare the config file with the config rpk loads from it." +
+			" 'unknown' keys are in the file, but rpk doesn't know" +
+			" them (e.g. because of a typo), so it ignores them. Keys" +
+			" under 'redpanda' may still be read by redpanda." +
+			" 'defaulted' keys aren't in the file, so their values" +
+			" come from the defaults or an included file.",
+		Args:		cobra.NoArgs,
+		SilenceUsage:	true,
+		RunE: func(ccmd *cobra.Command, _ []string) error {
+			var err error
+			if configPath == "" {
+				configPath, err = config.FindConfigFile(fs)
+				if err != nil {
+					return err
+				}
+			}
+			conf, err := mgr.Read(configPath)
+			if err != nil {
+				return err
+			}
+			diffs, err := config.Diff(fs, configPath, conf)
+			if err != nil {
+				return err
+			}
+			return printDiff(ccmd.OutOrStdout(), diffs, format)
+		},
+	}
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		"Redpanda config file, if not set the file will be searched"+
+			" for in the default location",
+	)
+	c.Flags().StringVar(
+		&format,
+		"format",
+		"text",
+		"The output format. Can be 'text' or 'json'",
+	)
+	return c
+}
+
+func printDiff(out io.Writer, diffs []config.KeyDiff, format string) error {
+	switch format {
+	case "json":
+		bs, err := json.Marshal(diffs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(bs))
+	case "text":
+		if len(diffs) == 0 {
+			fmt.Fprintln(out, "No differences found")
+			return nil
+		}
+		t := ui.NewRpkTable(out)
+		t.SetHeader([]string{"Key", "Kind", "Value"})
+		for _, d := range diffs {
+			t.Append([]string{d.Key, string(d.Kind), d.Value})
+		}
+		t.Render()
+	default:
+		return fmt.Errorf("unsupported format '%s'", format)
+	}
+	return nil
+}
+
 func parseIPs(ips []string) ([]net.IP, error) {
 	parsed := []net.IP{}
 	for _, i := range ips {
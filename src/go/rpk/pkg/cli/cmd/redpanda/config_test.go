@@ -10,6 +10,7 @@
 package redpanda_test
 
 import (
+	"bytes"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -67,10 +68,12 @@ func TestSet(t *testing.T) {
 				"tune_disk_write_cache":	false,
 				"tune_disk_nomerges":		false,
 				"tune_disk_irq":		true,
+				"tune_nvme_irq":		false,
 				"tune_cpu":			false,
 				"tune_aio_events":		false,
 				"tune_clocksource":		false,
 				"tune_swappiness":		false,
+				"tune_overcommit":		false,
 				"tune_transparent_hugepages":	false,
 				"enable_memory_locking":	false,
 				"tune_fstrim":			false,
@@ -173,18 +176,32 @@ func TestBootstrap(t *testing.T) {
 		ips		[]string
 		self		string
 		id		string
+		expectedId	int
 		expectedErr	string
 	}{
 		{
-			name:	"it should set the root node config for a single node",
-			id:	"1",
-			self:	"192.168.34.5",
+			name:		"it should set the root node config for a single node",
+			id:		"1",
+			self:		"192.168.34.5",
+			expectedId:	1,
+		},
+		{
+			name:		"it should fill the seed servers",
+			ips:		[]string{"187.89.76.3", "192.168.34.5", "192.168.45.8"},
+			self:		"192.168.34.5",
+			id:		"1",
+			expectedId:	1,
 		},
 		{
-			name:	"it should fill the seed servers",
-			ips:	[]string{"187.89.76.3", "192.168.34.5", "192.168.45.8"},
-			self:	"192.168.34.5",
-			id:	"1",
+			name:		"it should use the node's position in --ips as its ID if --id isn't passed",
+			ips:		[]string{"187.89.76.3", "192.168.34.5", "192.168.45.8"},
+			self:		"192.168.45.8",
+			expectedId:	2,
+		},
+		{
+			name:		"it should set the ID to 0 for a root node if --id isn't passed",
+			self:		"192.168.34.5",
+			expectedId:	0,
 		},
 		{
 			name:		"it should fail if any of the --ips IPs isn't valid",
@@ -201,8 +218,10 @@ func TestBootstrap(t *testing.T) {
 			expectedErr:	"www.host.com is not a valid IP.",
 		},
 		{
-			name:		"it should fail if --id isn't passed",
-			expectedErr:	"required flag(s) \"id\" not set",
+			name:		"it should fail if --id isn't passed and --self isn't in --ips",
+			ips:		[]string{"187.89.76.3", "192.168.45.8"},
+			self:		"192.168.34.5",
+			expectedErr:	"192.168.34.5 isn't in --ips, so the node's ID can't be deduced. Pass --id, or add it to --ips",
 		},
 	}
 
@@ -239,12 +258,37 @@ func TestBootstrap(t *testing.T) {
 				return
 			}
 			require.NoError(t, err)
-			_, err = fs.Stat(configPath)
+			bs, err := afero.ReadFile(fs, configPath)
+			require.NoError(t, err)
+			// Re-running it with the same args leaves the config as is.
+			c = cmd.NewConfigCommand(fs, mgr)
+			c.SetArgs(args)
+			require.NoError(t, c.Execute())
+			rerun, err := afero.ReadFile(fs, configPath)
+			require.NoError(t, err)
+			require.Equal(t, string(bs), string(rerun))
 			conf, err := mgr.Read(configPath)
 			require.NoError(t, err)
+			require.Equal(t, tt.expectedId, conf.Redpanda.Id)
 			require.Equal(t, conf.Redpanda.RPCServer.Address, tt.self)
 			require.Equal(t, conf.Redpanda.KafkaApi.Address, tt.self)
 			require.Equal(t, conf.Redpanda.AdminApi.Address, tt.self)
+			require.Equal(
+				t,
+				&config.SocketAddress{
+					Address:	tt.self,
+					Port:		conf.Redpanda.RPCServer.Port,
+				},
+				conf.Redpanda.AdvertisedRPCAPI,
+			)
+			require.Equal(
+				t,
+				&config.SocketAddress{
+					Address:	tt.self,
+					Port:		conf.Redpanda.KafkaApi.Port,
+				},
+				conf.Redpanda.AdvertisedKafkaApi,
+			)
 			if len(tt.ips) == 1 {
 				require.Equal(
 					t,
@@ -285,3 +329,73 @@ func TestInitNode(t *testing.T) {
 	val := v.Get("node_uuid")
 	require.NotEmpty(t, val)
 }
+
+func TestDiff(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mgr := config.NewManager(fs)
+	conf := config.Default()
+	err := mgr.Write(conf)
+	require.NoError(t, err)
+	err = mgr.Set("rpk.tune_netwrok", "true", "single", conf.ConfigFile)
+	require.NoError(t, err)
+	c := cmd.NewConfigCommand(fs, config.NewManager(fs))
+	var out bytes.Buffer
+	c.SetOut(&out)
+	c.SetArgs([]string{"diff", "--format", "json"})
+
+	err = c.Execute()
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		`[{"key":"rpk.tune_netwrok","kind":"unknown","value":"true"}]`+"\n",
+		out.String(),
+	)
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name		string
+		args		[]string
+		expected	string
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should print single values as they are",
+			args:		[]string{"get", "redpanda.data_directory"},
+			expected:	"/var/lib/redpanda/data\n",
+		},
+		{
+			name:		"it should print objects as YAML",
+			args:		[]string{"get", "redpanda.admin"},
+			expected:	"address: 0.0.0.0\nport: 9644\n",
+		},
+		{
+			name:		"it should print JSON with --format json",
+			args:		[]string{"get", "redpanda.admin", "--format", "json"},
+			expected:	`{"address":"0.0.0.0","port":9644}` + "\n",
+		},
+		{
+			name:		"it should fail if the key doesn't exist",
+			args:		[]string{"get", "redpanda.nope"},
+			expectedErrMsg:	"'redpanda.nope' doesn't exist in the config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			mgr := config.NewManager(fs)
+			require.NoError(st, mgr.Write(config.Default()))
+			c := cmd.NewConfigCommand(fs, config.NewManager(fs))
+			var out bytes.Buffer
+			c.SetOut(&out)
+			c.SetArgs(tt.args)
+			err := c.Execute()
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, out.String())
+		})
+	}
+}
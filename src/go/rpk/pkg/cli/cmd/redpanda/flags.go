@@ -0,0 +1,299 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	rp "github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
+)
+
+const (
+	defaultSource	flagSource	= "default"
+	envSource	flagSource	= "env"
+)
+
+// The rpk start flags which fall back to an env var when they aren't passed.
+var envFlags = []struct {
+	flag	string
+	env	string
+}{
+	{"seeds", "REDPANDA_SEEDS"},
+	{"kafka-addr", "REDPANDA_KAFKA_ADDRESS"},
+	{"rpc-addr", "REDPANDA_RPC_ADDRESS"},
+	{"advertise-kafka-addr", "REDPANDA_ADVERTISE_KAFKA_ADDRESS"},
+	{"advertise-rpc-addr", "REDPANDA_ADVERTISE_RPC_ADDRESS"},
+}
+
+// The values a flag may take from each source, and the one which wins.
+type flagResolution struct {
+	name		string
+	defaultValue	string
+	configValue	string
+	envValue	string
+	cliValue	string
+	resolved	string
+	source		flagSource
+}
+
+func NewFlagsCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
+	var configFile string
+	sFlags := seastarFlags{}
+	command := &cobra.Command{
+		Use:	"flags",
+		Short:	"Show where the value of each of redpanda's start flags comes from",
+		Long: "Resolves the start flags like 'rpk redpanda start' would for" +
+			" the current environment, and shows each flag's default, config," +
+			" env and command line values, and which one is used. It" +
+			" accepts the same flags as 'rpk redpanda start'.",
+		Args:	cobra.NoArgs,
+		RunE: func(ccmd *cobra.Command, _ []string) error {
+			conf, err := mgr.FindOrGenerate(configFile)
+			if err != nil {
+				return err
+			}
+			resolutions, err := resolveFlags(
+				fs,
+				conf,
+				sFlags,
+				ccmd.Flags(),
+				os.Getenv,
+			)
+			if err != nil {
+				return err
+			}
+			printFlagResolutions(resolutions)
+			return nil
+		},
+	}
+	command.Flags().StringVar(
+		&configFile,
+		"config",
+		"",
+		"Redpanda config file, if not set the file will be searched for"+
+			" in the default locations",
+	)
+	addSeastarFlags(command.Flags(), &sFlags)
+	command.Flags().String(
+		wellKnownIOFlag,
+		"",
+		"The cloud vendor and VM type, in the format <vendor>:<vm type>:<storage type>",
+	)
+	command.Flags().StringArray(
+		presetFlag,
+		[]string{},
+		"The name of a preset of flags, defined in the presets file next"+
+			" to the config file. Can be passed more than once",
+	)
+	for _, f := range envFlags {
+		if f.flag == "seeds" {
+			command.Flags().StringSlice(
+				f.flag,
+				[]string{},
+				"A list of seed nodes to connect to, in the format "+
+					seedFormat,
+			)
+			continue
+		}
+		command.Flags().String(
+			f.flag,
+			"",
+			"The address, in the format <host>:<port>",
+		)
+	}
+	return command
+}
+
+// Resolves the flags redpanda would be started with, keeping track of where
+// each value came from.
+func resolveFlags(
+	fs afero.Fs,
+	conf *config.Config,
+	sFlags seastarFlags,
+	flags *pflag.FlagSet,
+	getenv func(string) string,
+) ([]flagResolution, error) {
+	configValues := seastarFlagsConfigValues(conf)
+	configValues[wellKnownIOFlag] = conf.Rpk.WellKnownIo
+
+	// 'start' binds these flags to the config, so that they override it.
+	if flags.Changed(lockMemoryFlag) {
+		conf.Rpk.EnableMemoryLocking = sFlags.lockMemory
+	}
+	if flags.Changed(overprovisionedFlag) {
+		conf.Rpk.Overprovisioned = sFlags.overprovisioned
+	}
+	args, sources, err := buildRedpandaFlagsWithSources(
+		fs,
+		conf,
+		sFlags,
+		flags,
+		getenv,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	seastarFlagNames := flagsMap(sFlags)
+	names := map[string]bool{wellKnownIOFlag: true}
+	for name := range seastarFlagNames {
+		names[name] = true
+	}
+	for name := range args.SeastarFlags {
+		names[name] = true
+	}
+	var resolutions []flagResolution
+	for name := range names {
+		r := flagResolution{
+			name:		name,
+			configValue:	configValues[name],
+			source:		defaultSource,
+		}
+		if _, ok := seastarFlagNames[name]; ok {
+			r.envValue = getenv(envVarName(name))
+		}
+		if f := flags.Lookup(cliFlagName(name)); f != nil {
+			r.defaultValue = f.DefValue
+			if f.Changed {
+				r.cliValue = f.Value.String()
+				if slice, ok := f.Value.(pflag.SliceValue); ok {
+					r.cliValue = strings.Join(slice.GetSlice(), ":")
+				}
+			}
+		}
+		if v, ok := args.SeastarFlags[name]; ok {
+			r.resolved = v
+			r.source = sources[name]
+		}
+		if name == wellKnownIOFlag {
+			r.resolved = conf.Rpk.WellKnownIo
+			if r.cliValue != "" {
+				r.source = cliSource
+			} else if r.configValue != "" {
+				r.source = configSource
+			}
+		}
+		resolutions = append(resolutions, r)
+	}
+	sort.Slice(resolutions, func(i, j int) bool {
+		return resolutions[i].name < resolutions[j].name
+	})
+
+	envConfigValues := envFlagsConfigValues(conf)
+	for _, f := range envFlags {
+		r := flagResolution{
+			name:		f.flag,
+			configValue:	envConfigValues[f.flag],
+			envValue:	getenv(f.env),
+			source:		defaultSource,
+		}
+		if fl := flags.Lookup(f.flag); fl != nil && fl.Changed {
+			r.cliValue = fl.Value.String()
+			if slice, ok := fl.Value.(pflag.SliceValue); ok {
+				r.cliValue = strings.Join(slice.GetSlice(), ",")
+			}
+		}
+		switch {
+		case r.cliValue != "":
+			r.resolved, r.source = r.cliValue, cliSource
+		case r.envValue != "":
+			r.resolved, r.source = r.envValue, envSource
+		case r.configValue != "":
+			r.resolved, r.source = r.configValue, configSource
+		}
+		resolutions = append(resolutions, r)
+	}
+	return resolutions, nil
+}
+
+// Returns the values the config holds for the flags passed to redpanda.
+func seastarFlagsConfigValues(conf *config.Config) map[string]string {
+	values := rp.ParseFlags(conf.Rpk.AdditionalStartFlags)
+	values[overprovisionedFlag] = fmt.Sprint(conf.Rpk.Overprovisioned)
+	values[lockMemoryFlag] = fmt.Sprint(conf.Rpk.EnableMemoryLocking)
+	if conf.Rpk.SMP != nil && *conf.Rpk.SMP != 0 {
+		values[smpFlag] = fmt.Sprint(*conf.Rpk.SMP)
+	}
+	if conf.Rpk.MemoryPercent != "" {
+		values[memoryFlag] = "rpk.memory_percent: " + conf.Rpk.MemoryPercent
+	}
+	if conf.Rpk.RedpandaLogLevel != "" {
+		values[defaultLogLevelFlag] = conf.Rpk.RedpandaLogLevel
+	}
+	if len(conf.Rpk.LoggerLogLevels) > 0 {
+		values[loggerLogLevelFlag] = strings.Join(conf.Rpk.LoggerLogLevels, ":")
+	}
+	return values
+}
+
+func envFlagsConfigValues(conf *config.Config) map[string]string {
+	var seeds []string
+	for _, s := range conf.Redpanda.SeedServers {
+		seeds = append(seeds, fmt.Sprintf(
+			"%s:%d+%d",
+			s.Host.Address,
+			s.Host.Port,
+			s.Id,
+		))
+	}
+	return map[string]string{
+		"seeds":		strings.Join(seeds, ","),
+		"kafka-addr":		addressString(&conf.Redpanda.KafkaApi),
+		"rpc-addr":		addressString(&conf.Redpanda.RPCServer),
+		"advertise-kafka-addr":	addressString(conf.Redpanda.AdvertisedKafkaApi),
+		"advertise-rpc-addr":	addressString(conf.Redpanda.AdvertisedRPCAPI),
+	}
+}
+
+func addressString(addr *config.SocketAddress) string {
+	if addr == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", addr.Address, addr.Port)
+}
+
+func printFlagResolutions(resolutions []flagResolution) {
+	t := ui.NewRpkTable(os.Stdout)
+	t.SetHeader([]string{
+		"Flag",
+		"Default",
+		"Config",
+		"Env",
+		"CLI",
+		"Resolved",
+		"Source",
+	})
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	for _, r := range resolutions {
+		t.Append([]string{
+			r.name,
+			orDash(r.defaultValue),
+			orDash(r.configValue),
+			orDash(r.envValue),
+			orDash(r.cliValue),
+			orDash(r.resolved),
+			string(r.source),
+		})
+	}
+	t.Render()
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+func TestResolveFlags(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	conf := config.Default()
+	conf.Rpk.AdditionalStartFlags = []string{"--default-log-level=trace"}
+	smp := 2
+	conf.Rpk.SMP = &smp
+
+	sFlags := seastarFlags{}
+	flags := pflag.NewFlagSet("flags", pflag.ContinueOnError)
+	addSeastarFlags(flags, &sFlags)
+	flags.String(wellKnownIOFlag, "", "")
+	flags.StringSlice("seeds", []string{}, "")
+	for _, f := range envFlags[1:] {
+		flags.String(f.flag, "", "")
+	}
+	err := flags.Parse([]string{
+		"--memory", "4G",
+		"--overprovisioned=false",
+		"--kafka-addr", "10.0.0.1:9093",
+	})
+	require.NoError(t, err)
+	env := map[string]string{
+		"REDPANDA_KAFKA_ADDRESS":	"10.0.0.2:9093",
+		"REDPANDA_RPC_ADDRESS":		"10.0.0.2:33145",
+		"RPK_MEMORY":			"2G",
+		"RPK_MAX_IO_REQUESTS":		"128",
+	}
+
+	resolutions, err := resolveFlags(
+		fs,
+		conf,
+		sFlags,
+		flags,
+		func(k string) string { return env[k] },
+	)
+	require.NoError(t, err)
+	byName := map[string]flagResolution{}
+	for _, r := range resolutions {
+		byName[r.name] = r
+	}
+
+	require.Equal(t, flagResolution{
+		name:		memoryFlag,
+		envValue:	"2G",
+		cliValue:	"4G",
+		resolved:	"4G",
+		source:		cliSource,
+	}, byName[memoryFlag])
+	require.Equal(t, flagResolution{
+		name:		overprovisionedFlag,
+		defaultValue:	"true",
+		configValue:	"false",
+		cliValue:	"false",
+		resolved:	"false",
+		source:		cliSource,
+	}, byName[overprovisionedFlag])
+	require.Equal(t, flagResolution{
+		name:		smpFlag,
+		defaultValue:	"0",
+		configValue:	"2",
+		resolved:	"2",
+		source:		configSource,
+	}, byName[smpFlag])
+	require.Equal(t, flagResolution{
+		name:		"default-log-level",
+		configValue:	"trace",
+		resolved:	"trace",
+		source:		additionalFlagsSource,
+	}, byName["default-log-level"])
+	require.Equal(t, flagResolution{
+		name:		maxIoRequestsFlag,
+		defaultValue:	"0",
+		envValue:	"128",
+		resolved:	"128",
+		source:		envSource,
+	}, byName[maxIoRequestsFlag])
+	require.Equal(t, flagResolution{
+		name:		mbindFlag,
+		defaultValue:	"true",
+		source:		defaultSource,
+	}, byName[mbindFlag])
+	require.Equal(t, flagResolution{
+		name:		"kafka-addr",
+		configValue:	"0.0.0.0:9092",
+		envValue:	"10.0.0.2:9093",
+		cliValue:	"10.0.0.1:9093",
+		resolved:	"10.0.0.1:9093",
+		source:		cliSource,
+	}, byName["kafka-addr"])
+	require.Equal(t, flagResolution{
+		name:		"rpc-addr",
+		configValue:	"0.0.0.0:33145",
+		envValue:	"10.0.0.2:33145",
+		resolved:	"10.0.0.2:33145",
+		source:		envSource,
+	}, byName["rpc-addr"])
+}
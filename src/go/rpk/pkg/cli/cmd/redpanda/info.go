@@ -0,0 +1,101 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/cmd/version"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+	rp "github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
+)
+
+func NewInfoCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
+	var (
+		installDirFlag	string
+		timeout		time.Duration
+	)
+	command := &cobra.Command{
+		Use:		"info",
+		Short:		"Print where redpanda is installed and its version",
+		Args:		cobra.NoArgs,
+		SilenceUsage:	true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			// The config is only needed for rpk.install_dir, so
+			// it's fine if there's none.
+			confInstallDir := ""
+			if conf, err := mgr.ReadOrFind(""); err == nil {
+				confInstallDir = conf.Rpk.InstallDir
+			}
+			installDir, err := cli.GetOrFindInstallDir(
+				fs,
+				installDirFlag,
+				confInstallDir,
+			)
+			if err != nil {
+				log.Warnf("Couldn't find the install directory: %v", err)
+				installDir = ""
+			}
+			binaries := rp.FindBinaries(
+				fs,
+				vos.NewProc(),
+				timeout,
+				installDir,
+				os.Getenv("PATH"),
+			)
+			printInfo(cmd.OutOrStdout(), installDir, binaries)
+			return nil
+		},
+	}
+	command.Flags().StringVar(
+		&installDirFlag,
+		"install-dir",
+		"",
+		"Directory where redpanda has been installed",
+	)
+	command.Flags().DurationVar(
+		&timeout,
+		"timeout",
+		5*time.Second,
+		"The maximum time to wait for each redpanda binary to report"+
+			" its version",
+	)
+	return command
+}
+
+func printInfo(out io.Writer, installDir string, binaries []rp.Binary) {
+	fmt.Fprintf(out, "rpk version:\t%s\n", version.Pretty())
+	if installDir == "" {
+		installDir = "unknown"
+	}
+	fmt.Fprintf(out, "Install dir:\t%s\n", installDir)
+	if len(binaries) == 0 {
+		fmt.Fprintln(out, "No redpanda binary was found.")
+		return
+	}
+	for _, b := range binaries {
+		used := ""
+		if b.Used {
+			used = " (used by 'rpk redpanda start')"
+		}
+		fmt.Fprintf(out, "Binary:\t\t%s %s%s\n", b.Path, b.Version, used)
+	}
+	if warning := rp.MultipleBinariesWarning(binaries); warning != "" {
+		log.Warn(warning)
+	}
+}
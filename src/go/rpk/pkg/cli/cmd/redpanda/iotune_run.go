@@ -0,0 +1,163 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+	rp "github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/iotune"
+)
+
+func NewIoTuneRunCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
+	var (
+		configFile	string
+		duration	time.Duration
+		timeout		time.Duration
+		overwrite	bool
+	)
+	command := &cobra.Command{
+		Use:	"run",
+		Short:	"Benchmark the data directory's disk and store its IO properties",
+		Long: "Runs iotune against redpanda's data directory for" +
+			" --duration, and writes the measured IO properties to" +
+			" io-config.yaml, next to the config file, where" +
+			" 'rpk start' picks them up. The data directory must be" +
+			" empty.",
+		Args:	cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			conf, err := mgr.FindOrGenerate(configFile)
+			if err != nil {
+				return err
+			}
+			out := rp.GetIOConfigPath(filepath.Dir(conf.ConfigFile))
+			log.Info("Starting iotune...")
+			err = runIoTune(
+				fs,
+				iotune.NewIoTune(os.NewProc(), duration+timeout),
+				conf.Redpanda.Directory,
+				out,
+				duration,
+				overwrite,
+			)
+			if err != nil {
+				return err
+			}
+			log.Infof("IO properties stored in '%s'", out)
+			return nil
+		},
+	}
+	command.Flags().StringVar(
+		&configFile,
+		"config",
+		"",
+		"Redpanda config file, if not set the file will be searched for"+
+			" in the default locations",
+	)
+	command.Flags().DurationVar(
+		&duration,
+		"duration",
+		10*time.Minute,
+		"How long to benchmark the disk for",
+	)
+	command.Flags().DurationVar(
+		&timeout,
+		"timeout",
+		1*time.Hour,
+		"The maximum time after --duration to wait for iotune to complete",
+	)
+	command.Flags().BoolVar(
+		&overwrite,
+		"overwrite",
+		false,
+		"Replace the IO properties file if it exists already",
+	)
+	return command
+}
+
+// Benchmarks directory's disk with ioTune and writes its IO properties to
+// out. iotune writes them to a temporary file first, so that out is left
+// untouched if it fails.
+func runIoTune(
+	fs afero.Fs,
+	ioTune iotune.IoTune,
+	directory, out string,
+	duration time.Duration,
+	overwrite bool,
+) error {
+	exists, err := afero.Exists(fs, out)
+	if err != nil {
+		return err
+	}
+	if exists && !overwrite {
+		return fmt.Errorf(
+			"'%s' exists already. Pass --overwrite to replace it",
+			out,
+		)
+	}
+	exists, err = afero.Exists(fs, directory)
+	if err != nil {
+		return err
+	}
+	if exists {
+		empty, err := afero.IsEmpty(fs, directory)
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return fmt.Errorf(
+				"the data directory '%s' isn't empty. Benchmark the"+
+					" disk before redpanda stores data in it",
+				directory,
+			)
+		}
+	} else {
+		err = fs.MkdirAll(directory, 0755)
+		if err != nil {
+			return err
+		}
+	}
+	tmp := filepath.Join(filepath.Dir(out), "."+filepath.Base(out)+".tmp")
+	defer fs.Remove(tmp)
+	output, err := ioTune.Run(iotune.IoTuneArgs{
+		Dirs:		[]string{directory},
+		Format:		iotune.Seastar,
+		PropertiesFile:	tmp,
+		Duration:	duration,
+	})
+	for _, line := range output {
+		log.Debug(line)
+	}
+	if err != nil {
+		return fmt.Errorf("iotune failed: %v", err)
+	}
+	disks, err := iotune.ReadFile(fs, tmp)
+	if err != nil {
+		return err
+	}
+	if len(disks) != 1 {
+		return fmt.Errorf(
+			"expected iotune to measure 1 disk, but it measured %d",
+			len(disks),
+		)
+	}
+	yaml, err := iotune.ToYaml(disks[0])
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, out, []byte(yaml), 0644)
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/iotune"
+)
+
+type ioTuneMock struct {
+	fs	afero.Fs
+	output	string
+	err	error
+	args	*iotune.IoTuneArgs
+}
+
+func (m *ioTuneMock) Run(args iotune.IoTuneArgs) ([]string, error) {
+	m.args = &args
+	if m.err != nil {
+		return nil, m.err
+	}
+	return nil, afero.WriteFile(m.fs, args.PropertiesFile, []byte(m.output), 0644)
+}
+
+func TestRunIoTune(t *testing.T) {
+	const (
+		dir	= "/var/lib/redpanda/data"
+		out	= "/etc/redpanda/io-config.yaml"
+	)
+	measured := `disks:
+- mountpoint: /var/lib/redpanda/data
+  read_iops: 10000
+  read_bandwidth: 20000
+  write_iops: 30000
+  write_bandwidth: 40000
+`
+	tests := []struct {
+		name		string
+		before		func(afero.Fs) error
+		overwrite	bool
+		output		string
+		runErr		error
+		expectedErrMsg	string
+		expected	string
+	}{{
+		name:		"it should write the measured properties",
+		output:		measured,
+		expected:	measured,
+	}, {
+		name:	"it should fail if the properties file exists",
+		before: func(fs afero.Fs) error {
+			return afero.WriteFile(fs, out, []byte("old"), 0644)
+		},
+		output:		measured,
+		expectedErrMsg:	"'/etc/redpanda/io-config.yaml' exists already. Pass --overwrite to replace it",
+		expected:	"old",
+	}, {
+		name:	"it should replace the properties file with --overwrite",
+		before: func(fs afero.Fs) error {
+			return afero.WriteFile(fs, out, []byte("old"), 0644)
+		},
+		overwrite:	true,
+		output:		measured,
+		expected:	measured,
+	}, {
+		name:	"it should fail if the data directory isn't empty",
+		before: func(fs afero.Fs) error {
+			return afero.WriteFile(fs, dir+"/pid.lock", []byte("1"), 0644)
+		},
+		output:		measured,
+		expectedErrMsg:	"the data directory '/var/lib/redpanda/data' isn't empty. Benchmark the disk before redpanda stores data in it",
+	}, {
+		name:	"it should keep the properties file if iotune fails",
+		before: func(fs afero.Fs) error {
+			return afero.WriteFile(fs, out, []byte("old"), 0644)
+		},
+		overwrite:	true,
+		runErr:		errors.New("iotune-redpanda not found"),
+		expectedErrMsg:	"iotune failed: iotune-redpanda not found",
+		expected:	"old",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(st, fs.MkdirAll("/etc/redpanda", 0755))
+			if tt.before != nil {
+				require.NoError(st, tt.before(fs))
+			}
+			mock := &ioTuneMock{fs: fs, output: tt.output, err: tt.runErr}
+			err := runIoTune(fs, mock, dir, out, 5*time.Minute, tt.overwrite)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+			} else {
+				require.NoError(st, err)
+				require.Equal(st, []string{dir}, mock.args.Dirs)
+				require.Equal(st, 5*time.Minute, mock.args.Duration)
+			}
+			if tt.expected != "" {
+				bs, err := afero.ReadFile(fs, out)
+				require.NoError(st, err)
+				require.Equal(st, tt.expected, string(bs))
+			}
+			files, err := afero.ReadDir(fs, "/etc/redpanda")
+			require.NoError(st, err)
+			for _, f := range files {
+				require.NotContains(st, f.Name(), ".tmp")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+)
+
+const metricsFileFlag = "metrics-file"
+
+// A gauge in Prometheus' text format, with one sample per label set.
+type gauge struct {
+	name	string
+	help	string
+	samples	[]string
+}
+
+func (g *gauge) add(labels string, value float64) {
+	g.samples = append(
+		g.samples,
+		fmt.Sprintf("%s{%s} %s", g.name, labels, strconv.FormatFloat(value, 'g', -1, 64)),
+	)
+}
+
+func (g *gauge) String() string {
+	if len(g.samples) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(
+		"# HELP %s %s\n# TYPE %s gauge\n%s\n",
+		g.name,
+		g.help,
+		g.name,
+		strings.Join(g.samples, "\n"),
+	)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func nameLabel(name string) string {
+	return fmt.Sprintf(`name="%s"`, labelEscaper.Replace(name))
+}
+
+// Renders the check results in Prometheus' text format, labeled with the
+// checkers' names (see 'rpk check --only-checks'). Their current and required
+// values are only exported if they're numeric.
+func checkMetrics(results []tuners.CheckResult) string {
+	sorted := append([]tuners.CheckResult{}, results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CheckerId.String() < sorted[j].CheckerId.String()
+	})
+	ok := &gauge{
+		name:	"rpk_check_ok",
+		help:	"Whether the check passed (1) or not (0).",
+	}
+	current := &gauge{
+		name:	"rpk_check_current",
+		help:	"The check's current value, if it's numeric.",
+	}
+	required := &gauge{
+		name:	"rpk_check_required",
+		help:	"The check's required value, if it's numeric.",
+	}
+	for _, r := range sorted {
+		labels := fmt.Sprintf(
+			`%s,severity="%s"`,
+			nameLabel(r.CheckerId.String()),
+			strings.ToLower(r.Severity.String()),
+		)
+		ok.add(labels, boolValue(r.IsOk))
+		if v, err := parseMetricValue(r.Current); err == nil {
+			current.add(labels, v)
+		}
+		if v, err := parseMetricValue(r.Required); err == nil {
+			required.add(labels, v)
+		}
+	}
+	return ok.String() + current.String() + required.String()
+}
+
+// Renders the tuners' results in Prometheus' text format, labeled with the
+// tuners' names.
+func tunerMetrics(payloads []api.TunerPayload) string {
+	sorted := append([]api.TunerPayload{}, payloads...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	applied := &gauge{
+		name:	"rpk_tuner_applied",
+		help:	"Whether the tuner was applied (1) or not (0).",
+	}
+	enabled := &gauge{
+		name:	"rpk_tuner_enabled",
+		help:	"Whether the tuner is enabled in the config (1) or not (0).",
+	}
+	supported := &gauge{
+		name:	"rpk_tuner_supported",
+		help:	"Whether the tuner is supported on this system (1) or not (0).",
+	}
+	for _, p := range sorted {
+		labels := nameLabel(p.Name)
+		ok := p.Enabled && p.Supported && !p.Skipped && p.ErrorMsg == ""
+		applied.add(labels, boolValue(ok))
+		enabled.add(labels, boolValue(p.Enabled))
+		supported.add(labels, boolValue(p.Supported))
+	}
+	return applied.String() + enabled.String() + supported.String()
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func parseMetricValue(value string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(value), 64)
+}
+
+// Writes the metrics to path, through a temporary file which is renamed over
+// it, so that node_exporter's textfile collector never reads a partial file.
+func writeMetricsFile(fs afero.Fs, path, metrics string) error {
+	tmp := filepath.Join(
+		filepath.Dir(path),
+		"."+filepath.Base(path)+".tmp",
+	)
+	err := afero.WriteFile(fs, tmp, []byte(metrics), 0644)
+	if err != nil {
+		return fmt.Errorf("couldn't write the metrics to '%s': %v", tmp, err)
+	}
+	err = fs.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("couldn't write the metrics to '%s': %v", path, err)
+	}
+	log.Debugf("Wrote the metrics to '%s'", path)
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+)
+
+func TestCheckMetrics(t *testing.T) {
+	results := []tuners.CheckResult{{
+		CheckerId:	tuners.SwapChecker,
+		IsOk:		false,
+		Current:	"false",
+		Required:	"true",
+		Severity:	tuners.Warning,
+	}, {
+		CheckerId:	tuners.FreeMemChecker,
+		IsOk:		true,
+		Current:	"2048",
+		Required:	"1024",
+		Severity:	tuners.Fatal,
+	}}
+	expected := `# HELP rpk_check_ok Whether the check passed (1) or not (0).
+# TYPE rpk_check_ok gauge
+rpk_check_ok{name="free_memory",severity="fatal"} 1
+rpk_check_ok{name="swap",severity="warning"} 0
+# HELP rpk_check_current The check's current value, if it's numeric.
+# TYPE rpk_check_current gauge
+rpk_check_current{name="free_memory",severity="fatal"} 2048
+# HELP rpk_check_required The check's required value, if it's numeric.
+# TYPE rpk_check_required gauge
+rpk_check_required{name="free_memory",severity="fatal"} 1024
+`
+	require.Equal(t, expected, checkMetrics(results))
+}
+
+func TestTunerMetrics(t *testing.T) {
+	payloads := []api.TunerPayload{{
+		Name:		"swappiness",
+		ErrorMsg:	"boom",
+		Enabled:	true,
+		Supported:	true,
+	}, {
+		Name:		"aio_events",
+		Enabled:	true,
+		Supported:	true,
+	}, {
+		Name:		"cpu",
+		Supported:	true,
+	}}
+	expected := `# HELP rpk_tuner_applied Whether the tuner was applied (1) or not (0).
+# TYPE rpk_tuner_applied gauge
+rpk_tuner_applied{name="aio_events"} 1
+rpk_tuner_applied{name="cpu"} 0
+rpk_tuner_applied{name="swappiness"} 0
+# HELP rpk_tuner_enabled Whether the tuner is enabled in the config (1) or not (0).
+# TYPE rpk_tuner_enabled gauge
+rpk_tuner_enabled{name="aio_events"} 1
+rpk_tuner_enabled{name="cpu"} 0
+rpk_tuner_enabled{name="swappiness"} 1
+# HELP rpk_tuner_supported Whether the tuner is supported on this system (1) or not (0).
+# TYPE rpk_tuner_supported gauge
+rpk_tuner_supported{name="aio_events"} 1
+rpk_tuner_supported{name="cpu"} 1
+rpk_tuner_supported{name="swappiness"} 1
+`
+	require.Equal(t, expected, tunerMetrics(payloads))
+}
+
+func TestWriteMetricsFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/var/lib/node_exporter/rpk.prom"
+	require.NoError(t, fs.MkdirAll("/var/lib/node_exporter", 0755))
+	require.NoError(t, afero.WriteFile(fs, path, []byte("old"), 0644))
+
+	err := writeMetricsFile(fs, path, "new\n")
+	require.NoError(t, err)
+	bs, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Equal(t, "new\n", string(bs))
+	files, err := afero.ReadDir(fs, "/var/lib/node_exporter")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
@@ -34,11 +34,13 @@ func fillRpkConfig(path, mode string) *config.Config {
 		TuneDiskWriteCache:	val,
 		TuneNomerges:		val,
 		TuneDiskIrq:		val,
+		TuneNvmeIrq:		val,
 		TuneFstrim:		val,
 		TuneCpu:		val,
 		TuneAioEvents:		val,
 		TuneClocksource:	val,
 		TuneSwappiness:		val,
+		TuneOvercommit:		val,
 		CoredumpDir:		path,
 		Overprovisioned:	!val,
 	}
@@ -141,7 +143,7 @@ func TestModeCommand(t *testing.T) {
 				return wdConfigPath, afero.WriteFile(fs, wdConfigPath, bs, 0644)
 			},
 			expectedOutput:	"",
-			expectedErrMsg:	"'invalidmode' is not a supported mode. Available modes: dev, development, prod, production",
+			expectedErrMsg:	"'invalidmode' is not a supported mode. Available modes: dev, development, prod, production, benchmark",
 		},
 	}
 
@@ -0,0 +1,126 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+	rp "github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+)
+
+const (
+	resolveOnlyFlag		= "resolve-only"
+	fromResolvedFlag	= "from-resolved"
+)
+
+// The arguments redpanda was resolved to start with, as written by
+// 'rpk redpanda start --resolve-only', along with what's needed to tell whether
+// they're still valid.
+type resolvedArgs struct {
+	Hostname	string			`json:"hostname"`
+	ConfigFile	string			`json:"config_file"`
+	ConfigModTime	time.Time		`json:"config_mod_time"`
+	Args		*rp.RedpandaArgs	`json:"args"`
+}
+
+// Writes the resolved args to path. It must be called after the config has
+// been written, so that its modification time matches.
+func saveResolvedArgs(
+	fs afero.Fs, path string, args *rp.RedpandaArgs, hostname func() (string, error),
+) error {
+	host, err := hostname()
+	if err != nil {
+		return err
+	}
+	fi, err := fs.Stat(args.ConfigFilePath)
+	if err != nil {
+		return err
+	}
+	bs, err := json.Marshal(resolvedArgs{
+		Hostname:	host,
+		ConfigFile:	args.ConfigFilePath,
+		ConfigModTime:	fi.ModTime(),
+		Args:		args,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = utils.WriteBytes(fs, bs, path)
+	return err
+}
+
+// Reads the resolved args from path, failing if they were resolved on a
+// different host, for a different config file, or if the config file changed
+// since. configFile may be empty, in which case the cached one is used.
+func loadResolvedArgs(
+	fs afero.Fs, path, configFile string, hostname func() (string, error),
+) (*rp.RedpandaArgs, error) {
+	bs, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	resolved := resolvedArgs{}
+	if err := json.Unmarshal(bs, &resolved); err != nil {
+		return nil, fmt.Errorf("couldn't parse the resolved args in '%s': %v", path, err)
+	}
+	if resolved.Args == nil {
+		return nil, fmt.Errorf("'%s' doesn't contain any resolved args", path)
+	}
+	stale := func(reason string, a ...interface{}) error {
+		return fmt.Errorf(
+			"the resolved args in '%s' are stale: %s. Run"+
+				" 'rpk redpanda start --%s %s' again",
+			path,
+			fmt.Sprintf(reason, a...),
+			resolveOnlyFlag,
+			path,
+		)
+	}
+	host, err := hostname()
+	if err != nil {
+		return nil, err
+	}
+	if configFile != "" {
+		configFile, err = filepath.Abs(configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if host != resolved.Hostname {
+		return nil, stale(
+			"they were resolved on host '%s', but this is '%s'",
+			resolved.Hostname,
+			host,
+		)
+	}
+	if configFile != "" && configFile != resolved.ConfigFile {
+		return nil, stale(
+			"they were resolved for config file '%s', not '%s'",
+			resolved.ConfigFile,
+			configFile,
+		)
+	}
+	fi, err := fs.Stat(resolved.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.ModTime().Equal(resolved.ConfigModTime) {
+		return nil, stale(
+			"config file '%s' changed since",
+			resolved.ConfigFile,
+		)
+	}
+	return resolved.Args, nil
+}
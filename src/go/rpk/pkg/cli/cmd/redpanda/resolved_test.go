@@ -0,0 +1,155 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	rp "github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
+)
+
+func TestLoadResolvedArgs(t *testing.T) {
+	const path = "/etc/redpanda/resolved.json"
+	hostname := func(h string) func() (string, error) {
+		return func() (string, error) { return h, nil }
+	}
+	tests := []struct {
+		name		string
+		hostname	string
+		configFile	string
+		before		func(afero.Fs) error
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should load the resolved args",
+			hostname:	"host-1",
+		},
+		{
+			name:		"it should load the resolved args if the config file matches",
+			hostname:	"host-1",
+			configFile:	config.Default().ConfigFile,
+		},
+		{
+			name:		"it should fail if the host is different",
+			hostname:	"host-2",
+			expectedErrMsg:	"the resolved args in '/etc/redpanda/resolved.json' are stale: they were resolved on host 'host-1', but this is 'host-2'. Run 'rpk redpanda start --resolve-only /etc/redpanda/resolved.json' again",
+		},
+		{
+			name:		"it should fail if the config file is different",
+			hostname:	"host-1",
+			configFile:	"/etc/other/redpanda.yaml",
+			expectedErrMsg:	"the resolved args in '/etc/redpanda/resolved.json' are stale: they were resolved for config file '/etc/redpanda/redpanda.yaml', not '/etc/other/redpanda.yaml'. Run 'rpk redpanda start --resolve-only /etc/redpanda/resolved.json' again",
+		},
+		{
+			name:		"it should fail if the config file changed",
+			hostname:	"host-1",
+			before: func(fs afero.Fs) error {
+				return fs.Chtimes(
+					config.Default().ConfigFile,
+					time.Now(),
+					time.Now().Add(time.Hour),
+				)
+			},
+			expectedErrMsg:	"the resolved args in '/etc/redpanda/resolved.json' are stale: config file '/etc/redpanda/redpanda.yaml' changed since. Run 'rpk redpanda start --resolve-only /etc/redpanda/resolved.json' again",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(st, config.NewManager(fs).Write(config.Default()))
+			args := &rp.RedpandaArgs{
+				ConfigFilePath:	config.Default().ConfigFile,
+				SeastarFlags:	map[string]string{"smp": "2"},
+				ExtraArgs:	[]string{"--abort-on-seastar-bad-alloc"},
+			}
+			err := saveResolvedArgs(fs, path, args, hostname("host-1"))
+			require.NoError(st, err)
+			if tt.before != nil {
+				require.NoError(st, tt.before(fs))
+			}
+			loaded, err := loadResolvedArgs(
+				fs,
+				path,
+				tt.configFile,
+				hostname(tt.hostname),
+			)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, args, loaded)
+		})
+	}
+}
+
+func TestStartResolveOnly(t *testing.T) {
+	const path = "/etc/redpanda/resolved.json"
+	fs := afero.NewMemMapFs()
+	mgr := config.NewManager(fs)
+	launcher := &noopLauncher{}
+	c := NewStartCommand(fs, mgr, launcher)
+	c.SetArgs([]string{
+		"--install-dir", "/var/lib/redpanda",
+		"--smp", "2",
+		"--resolve-only", path,
+	})
+	require.NoError(t, c.Execute())
+	require.Nil(t, launcher.rpArgs, "redpanda shouldn't have been started")
+
+	c = NewStartCommand(fs, config.NewManager(fs), launcher)
+	c.SetArgs([]string{
+		"--install-dir", "/var/lib/redpanda",
+		"--from-resolved", path,
+	})
+	require.NoError(t, c.Execute())
+	require.NotNil(t, launcher.rpArgs)
+	require.Equal(t, "2", launcher.rpArgs.SeastarFlags["smp"])
+	require.Equal(t, config.Default().ConfigFile, launcher.rpArgs.ConfigFilePath)
+}
+
+func TestStartFromResolvedUnreadableConfig(t *testing.T) {
+	const path = "/etc/redpanda/resolved.json"
+	fs := afero.NewMemMapFs()
+	launcher := &noopLauncher{}
+	c := NewStartCommand(fs, config.NewManager(fs), launcher)
+	c.SetArgs([]string{
+		"--install-dir", "/var/lib/redpanda",
+		"--resolve-only", path,
+	})
+	require.NoError(t, c.Execute())
+	// Break the config without changing its mod time, so the resolved
+	// args aren't stale.
+	confFile := config.Default().ConfigFile
+	fi, err := fs.Stat(confFile)
+	require.NoError(t, err)
+	modTime := fi.ModTime()
+	err = afero.WriteFile(fs, confFile, []byte("redpanda: [\n"), 0644)
+	require.NoError(t, err)
+	require.NoError(t, fs.Chtimes(confFile, modTime, modTime))
+
+	c = NewStartCommand(fs, config.NewManager(fs), launcher)
+	c.SetArgs([]string{
+		"--install-dir", "/var/lib/redpanda",
+		"--from-resolved", path,
+	})
+	err = c.Execute()
+	require.Error(t, err)
+	require.Contains(
+		t,
+		err.Error(),
+		"couldn't read the config file '/etc/redpanda/redpanda.yaml' the args in '/etc/redpanda/resolved.json' were resolved with",
+	)
+	require.Nil(t, launcher.rpArgs, "redpanda shouldn't have been started")
+}
@@ -10,36 +10,53 @@
 package redpanda
 
 import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/docker/go-units"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api/admin"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/cmd/common"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
 	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
 	rp "github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/factory"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/hwloc"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/iotune"
+	"golang.org/x/crypto/ssh/terminal"
+	"gopkg.in/yaml.v2"
 )
 
 type prestartConfig struct {
 	tuneEnabled	bool
 	checkEnabled	bool
+	assumeYes	bool
+	tuneProfile	string
+	versionCheck	bool
+	checks		checkSelection
+	failOnWarn	bool
 }
 
 type seastarFlags struct {
@@ -49,6 +66,9 @@ type seastarFlags struct {
 	hugepages		string
 	cpuSet			string
 	ioPropertiesFile	string
+	// Set when --io-properties-file is passed more than once, in which
+	// case they're merged into ioPropertiesFile.
+	ioPropertiesFiles	[]string
 	ioProperties		string
 	smp			int
 	threadAffinity		bool
@@ -56,6 +76,8 @@ type seastarFlags struct {
 	maxIoRequests		int
 	mbind			bool
 	overprovisioned		bool
+	defaultLogLevel		string
+	loggerLogLevels		[]string
 }
 
 const (
@@ -67,16 +89,93 @@ const (
 	ioPropertiesFileFlag	= "io-properties-file"
 	ioPropertiesFlag	= "io-properties"
 	wellKnownIOFlag		= "well-known-io"
+	strictIOPropsFlag	= "strict-io-properties"
 	smpFlag			= "smp"
 	threadAffinityFlag	= "thread-affinity"
 	numIoQueuesFlag		= "num-io-queues"
 	maxIoRequestsFlag	= "max-io-requests"
 	mbindFlag		= "mbind"
 	overprovisionedFlag	= "overprovisioned"
+	defaultLogLevelFlag	= "default-log-level"
+	loggerLogLevelFlag	= "logger-log-level"
+
+	redpandaLogLevelFlag	= "redpanda-log-level"
+
+	autoRenameFlagsFlag	= "auto-rename-flags"
+	numaNodeFlag		= "numa-node"
+	printOperatorConfigFlag	= "print-operator-config"
+	dryRunFlag		= "dry-run"
+	validateFlag		= "validate"
+	oomScoreAdjFlag		= "oom-score-adj"
+	listCandidatesFlag	= "list-candidates"
+	chooseIoProfileFlag	= "choose-io-profile"
+	setFlag			= "set"
+	saveFlag		= "save"
+	waitForReadyFlag	= "wait-for-ready"
+	readyTimeoutFlag	= "ready-timeout"
+	startTimeoutFlag	= "start-timeout"
+	pidFileFlag		= "pidfile"
+	memoryPercentFlag	= "memory-percent"
+	overrideFlag		= "override"
+	sandboxFlag		= "sandbox"
+	seastarFlagFlag		= "seastar-flag"
+	printInstallDirFlag	= "print-install-dir"
+	refreshIoFlag		= "refresh-io"
+	presetFlag		= "preset"
+
+	// How often the admin API is polled with --wait-for-ready.
+	readyPollInterval	= 500 * time.Millisecond
+	// How long to wait for redpanda to exit once it's killed.
+	killWaitTimeout		= 5 * time.Second
+	// How long to wait for an IO properties file passed as a URL to be
+	// downloaded.
+	ioPropertiesFetchTimeout	= 10 * time.Second
+	// Where the IO properties files passed as URLs are downloaded, within
+	// the data directory.
+	ioPropertiesCacheDir	= ".rpk_io_properties"
+	// The file several --io-properties-file are merged into, within
+	// ioPropertiesCacheDir. It's overwritten on every start.
+	mergedIoPropertiesFile	= "merged.yaml"
+	// Where the IO properties deduced from rpk.well_known_io or the
+	// detected cloud VM are cached, within the data directory, and for how
+	// long.
+	wellKnownIoCacheFile	= ".rpk_well_known_io.yaml"
+	wellKnownIoCacheTTL	= 24 * time.Hour
+	// Where cloud-init keeps the ID of the instance it ran on, which tells
+	// the VMs apart without querying the metadata API.
+	cloudInitInstanceIDFile	= "/var/lib/cloud/data/instance-id"
 
 	seedFormat	= "<host>[:<port>]+<id>"
+
+	// The prefix of the env vars which set the flags passed to redpanda.
+	envFlagsPrefix	= "RPK_"
 )
 
+// The seastar flags which rpk start takes under another name, mapped to it.
+var flagAliases = map[string]string{
+	defaultLogLevelFlag: redpandaLogLevelFlag,
+}
+
+// The flags --sandbox passes to redpanda unless they're set explicitly, so
+// that it runs with few resources, e.g. on a development machine.
+var sandboxFlags = map[string]interface{}{
+	smpFlag:		1,
+	memoryFlag:		"1G",
+	reserveMemoryFlag:	"0M",
+	overprovisionedFlag:	true,
+}
+
+// The log levels redpanda accepts.
+var logLevels = []string{"trace", "debug", "info", "warn", "error"}
+
+// The seastar flags which have been renamed, mapped to their new names. Only
+// true 1:1 renames belong here, i.e. ones where the new flag takes the same
+// value with the same meaning. Flags which were replaced by others with
+// different semantics (e.g. --num-io-queues by --num-io-groups, or
+// --reserve-additional-memory, a total, by the per-shard
+// --reserve-additional-memory-per-shard) must not be listed.
+var renamedFlags = map[string]string{}
+
 func NewStartCommand(
 	fs afero.Fs, mgr config.Manager, launcher rp.Launcher,
 ) *cobra.Command {
@@ -92,6 +191,24 @@ func NewStartCommand(
 		installDirFlag	string
 		timeout		time.Duration
 		wellKnownIo	string
+		topologyFile	string
+		rpcTLS		config.ServerTLS
+		mode		string
+		resolveOnly	string
+		fromResolved	string
+		numaNode	int
+		printOperator	bool
+		dryRun		bool
+		validate	bool
+		oomScoreAdj	int
+		listCandidates	bool
+		overrides	[]string
+		saveOverrides	bool
+		waitReady	bool
+		readyTimeout	time.Duration
+		startTimeout	time.Duration
+		pidFile		string
+		printInstallDir	bool
 	)
 	sFlags := seastarFlags{}
 
@@ -99,10 +216,94 @@ func NewStartCommand(
 		Use:	"start",
 		Short:	"Start redpanda",
 		RunE: func(ccmd *cobra.Command, args []string) error {
+			if printOperator && (resolveOnly != "" || fromResolved != "") {
+				return fmt.Errorf(
+					"--%s can't be passed with --%s or --%s",
+					printOperatorConfigFlag,
+					resolveOnlyFlag,
+					fromResolvedFlag,
+				)
+			}
+			if validate && !dryRun {
+				return fmt.Errorf(
+					"--%s can only be passed with --%s",
+					validateFlag,
+					dryRunFlag,
+				)
+			}
+			if fromResolved != "" {
+				if resolveOnly != "" {
+					return fmt.Errorf(
+						"--%s and --%s can't be passed at"+
+							" the same time",
+						resolveOnlyFlag,
+						fromResolvedFlag,
+					)
+				}
+				return startFromResolved(
+					fs,
+					mgr,
+					launcher,
+					fromResolved,
+					configFile,
+					installDirFlag,
+					pidFile,
+					args,
+				)
+			}
+			if saveOverrides && len(overrides) == 0 {
+				return fmt.Errorf(
+					"--%s can only be passed with --%s",
+					saveFlag,
+					setFlag,
+				)
+			}
 			conf, err := mgr.FindOrGenerate(configFile)
 			if err != nil {
 				return err
 			}
+			if mode != "" {
+				conf, err = applyMode(mode, conf, sFlags, ccmd.Flags())
+				if err != nil {
+					return err
+				}
+			}
+			sandbox, _ := ccmd.Flags().GetBool(sandboxFlag)
+			if sandbox && !ccmd.Flags().Changed("check") {
+				// Dev machines rarely pass the production checks.
+				prestartCfg.checkEnabled = false
+			}
+			origConf := conf
+			if len(overrides) > 0 {
+				conf, err = config.ApplyOverrides(conf, overrides)
+				if err != nil {
+					return err
+				}
+			}
+			if ccmd.Flags().Changed(oomScoreAdjFlag) {
+				conf.Rpk.OomScoreAdj = &oomScoreAdj
+			}
+			if conf.Rpk.OomScoreAdj != nil {
+				err = system.ValidateOomScoreAdj(*conf.Rpk.OomScoreAdj)
+				if err != nil {
+					return err
+				}
+			}
+			if listCandidates {
+				return printIoCandidates(conf)
+			}
+			if printInstallDir {
+				dir, err := cli.GetOrFindInstallDir(
+					fs,
+					installDirFlag,
+					conf.Rpk.InstallDir,
+				)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(ccmd.OutOrStdout(), dir)
+				return nil
+			}
 			env := api.EnvironmentPayload{}
 			if len(seeds) == 0 {
 				// If --seeds wasn't passed, fall back to the
@@ -186,11 +387,44 @@ func NewStartCommand(
 			if advRPCApi != nil {
 				conf.Redpanda.AdvertisedRPCAPI = advRPCApi
 			}
-			installDirectory, err := cli.GetOrFindInstallDir(fs, installDirFlag)
+			if rpcTLS != (config.ServerTLS{}) {
+				confTLS := &conf.Redpanda.RPCServerTLS
+				confTLS.CertFile = stringOr(rpcTLS.CertFile, confTLS.CertFile)
+				confTLS.KeyFile = stringOr(rpcTLS.KeyFile, confTLS.KeyFile)
+				confTLS.TruststoreFile = stringOr(
+					rpcTLS.TruststoreFile,
+					confTLS.TruststoreFile,
+				)
+				confTLS.Enabled = true
+			}
+			installDirectory, err := cli.GetOrFindInstallDir(
+				fs,
+				installDirFlag,
+				conf.Rpk.InstallDir,
+			)
 			if err != nil {
 				sendEnv(fs, mgr, env, conf, err)
 				return err
 			}
+			logRedpandaVersion(launcher, installDirectory, timeout)
+			binaries := rp.FindBinaries(
+				fs,
+				vos.NewProc(),
+				timeout,
+				installDirectory,
+				os.Getenv("PATH"),
+			)
+			if warning := rp.MultipleBinariesWarning(binaries); warning != "" {
+				log.Warn(warning)
+			}
+			warnUnknownEnvFlags(os.Environ())
+			if topologyFile != "" {
+				err = hwloc.UseTopologyFile(fs, topologyFile)
+				if err != nil {
+					sendEnv(fs, mgr, env, conf, err)
+					return err
+				}
+			}
 			rpArgs, err := buildRedpandaFlags(
 				fs,
 				conf,
@@ -201,11 +435,47 @@ func NewStartCommand(
 				sendEnv(fs, mgr, env, conf, err)
 				return err
 			}
+			if ccmd.Flags().Changed(numaNodeFlag) {
+				err = pinToNUMANode(fs, rpArgs, numaNode)
+				if err != nil {
+					sendEnv(fs, mgr, env, conf, err)
+					return err
+				}
+			}
+			if printOperator {
+				rpArgs.ExtraArgs = args
+				out, err := operatorConfig(rpArgs)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(ccmd.OutOrStdout(), out)
+				return nil
+			}
+			if dryRun {
+				rpArgs.ExtraArgs = args
+				fmt.Fprintln(
+					ccmd.OutOrStdout(),
+					rp.CommandLine(installDirectory, rpArgs),
+				)
+				if !validate {
+					return nil
+				}
+				return validateWithBinary(
+					fs,
+					mgr,
+					conf,
+					rpArgs,
+					installDirectory,
+					binaries,
+					timeout,
+				)
+			}
 			checkPayloads, tunerPayloads, err := prestart(
 				fs,
 				rpArgs,
 				conf,
 				prestartCfg,
+				installDirectory,
 				timeout,
 			)
 			env.Checks = checkPayloads
@@ -215,7 +485,18 @@ func NewStartCommand(
 				return err
 			}
 
-			err = mgr.Write(conf)
+			if len(overrides) > 0 && !saveOverrides {
+				err = writeWithOverrides(
+					fs,
+					mgr,
+					conf,
+					origConf,
+					overrides,
+					rpArgs,
+				)
+			} else {
+				err = mgr.Write(conf)
+			}
 			if err != nil {
 				sendEnv(fs, mgr, env, conf, err)
 				return err
@@ -223,8 +504,48 @@ func NewStartCommand(
 
 			sendEnv(fs, mgr, env, conf, nil)
 			rpArgs.ExtraArgs = args
-			log.Info(common.FeedbackMsg)
+			if resolveOnly != "" {
+				err = saveResolvedArgs(fs, resolveOnly, rpArgs, os.Hostname)
+				if err != nil {
+					return err
+				}
+				log.Infof(
+					"Wrote the resolved args to '%s'. Start"+
+						" redpanda with them with 'rpk"+
+						" redpanda start --%s %s'",
+					resolveOnly,
+					fromResolvedFlag,
+					resolveOnly,
+				)
+				return nil
+			}
+			if conf.Rpk.OomScoreAdj != nil {
+				err = system.SetOomScoreAdj(fs, *conf.Rpk.OomScoreAdj)
+				if err != nil {
+					log.Warn(err)
+				}
+			}
+			if ui.Interactive() {
+				log.Info(common.FeedbackMsg)
+			}
+			rpArgs.PIDFile = launcherPIDFile(conf, pidFile)
 			log.Info("Starting redpanda...")
+			if waitReady {
+				rpArgs.LogFile = rp.GetLogPath(
+					conf.Redpanda.Directory,
+				)
+				return startAndWaitForReady(
+					launcher,
+					installDirectory,
+					rpArgs,
+					admin.NewClient(
+						admin.LocalURL(conf.Redpanda.AdminApi),
+						time.Second,
+					),
+					readyTimeout,
+					startTimeout,
+				)
+			}
 			return launcher.Start(installDirectory, rpArgs)
 		},
 	}
@@ -276,53 +597,267 @@ func NewStartCommand(
 		"",
 		"The advertised RPC address (<host>:<port>)",
 	)
-	command.Flags().StringVar(&sFlags.memory,
-		memoryFlag, "", "Amount of memory for redpanda to use, "+
-			"if not specified redpanda will use all available memory")
-	command.Flags().BoolVar(&sFlags.lockMemory,
-		lockMemoryFlag, false, "If set, will prevent redpanda from swapping")
+	command.Flags().StringVar(
+		&rpcTLS.CertFile,
+		"rpc-tls-cert-file",
+		"",
+		"The PEM-encoded certificate for the RPC server's TLS."+
+			" Setting any of the --rpc-tls-* flags enables RPC TLS",
+	)
+	command.Flags().StringVar(
+		&rpcTLS.KeyFile,
+		"rpc-tls-key-file",
+		"",
+		"The key for the RPC server's TLS certificate",
+	)
+	command.Flags().StringVar(
+		&rpcTLS.TruststoreFile,
+		"rpc-tls-truststore-file",
+		"",
+		"The truststore for the RPC server's TLS",
+	)
+	addSeastarFlags(command.Flags(), &sFlags)
 	mgr.BindFlag("rpk.enable_memory_locking", command.Flags().Lookup(lockMemoryFlag))
-	command.Flags().StringVar(&sFlags.cpuSet, cpuSetFlag, "",
-		"Set of CPUs for redpanda to use in cpuset(7) format, "+
-			"if not specified redpanda will use all available CPUs")
+	command.Flags().StringVar(
+		&mode,
+		"mode",
+		"",
+		fmt.Sprintf(
+			"Apply a mode's defaults before starting, which flags"+
+				" passed explicitly override [%s]",
+			strings.Join(config.AvailableModes(), ", "),
+		),
+	)
+	command.Flags().Bool(
+		autoRenameFlagsFlag,
+		false,
+		"Replace the deprecated names of the flags passed to redpanda"+
+			" (e.g. in rpk.additional_start_flags) with their new ones",
+	)
+	command.Flags().StringVar(
+		&resolveOnly,
+		resolveOnlyFlag,
+		"",
+		"Run the checks (and tuners, if --tune is passed) and resolve"+
+			" the flags redpanda would be started with, writing them"+
+			" to the given file instead of starting redpanda",
+	)
+	command.Flags().StringVar(
+		&fromResolved,
+		fromResolvedFlag,
+		"",
+		"Start redpanda right away with the flags resolved in the given"+
+			" file by --"+resolveOnlyFlag+". It fails if the file was"+
+			" written on another host or the config changed since",
+	)
+	command.Flags().BoolVar(
+		&printOperator,
+		printOperatorConfigFlag,
+		false,
+		"Resolve the flags redpanda would be started with and print them"+
+			" as the k8s operator's additionalConfiguration YAML,"+
+			" without running the checks or tuners or starting redpanda",
+	)
+	command.Flags().BoolVar(
+		&dryRun,
+		dryRunFlag,
+		false,
+		"Print the command redpanda would be started with, without"+
+			" running the checks or tuners or starting redpanda",
+	)
+	command.Flags().BoolVar(
+		&validate,
+		validateFlag,
+		false,
+		"With --"+dryRunFlag+", also have the redpanda binary validate"+
+			" the resolved config and flags, if it supports "+
+			rp.CheckConfigFlag,
+	)
+	command.Flags().BoolVar(
+		&listCandidates,
+		listCandidatesFlag,
+		false,
+		"List the well-known IO profiles which match the detected cloud"+
+			" VM, and which one rpk would use, without starting redpanda",
+	)
+	command.Flags().StringArrayVar(
+		&overrides,
+		setFlag,
+		[]string{},
+		"Override a config value for this start, in the format"+
+			" <key>=<value>, where key is a dotted path like the ones"+
+			" 'rpk config set' takes (e.g. 'rpk.tune_network=false')."+
+			" Can be passed more than once",
+	)
+	command.Flags().StringArray(
+		overrideFlag,
+		[]string{},
+		"A flag to pass to redpanda, overriding its value from any other"+
+			" source (e.g. rpk.additional_start_flags or --memory), in"+
+			" the format '--<name>=<value>', '--<name> <value>' or"+
+			" '--<name>' for boolean flags. Can be passed more than once",
+	)
+	command.Flags().StringArray(
+		seastarFlagFlag,
+		[]string{},
+		"A flag to pass to redpanda as is, in the format '<name>=<value>',"+
+			" or '<name>' for boolean flags, e.g. for the seastar flags"+
+			" rpk doesn't have a flag for. Only rpk start's own flags"+
+			" and --"+overrideFlag+" take precedence over it. Can be"+
+			" passed more than once",
+	)
+	command.Flags().StringArray(
+		presetFlag,
+		[]string{},
+		"The name of a preset of flags to pass to redpanda, defined in"+
+			" the presets file next to the config file. They take"+
+			" precedence over the config and the env, but not over"+
+			" rpk start's own flags. Can be passed more than once, in"+
+			" which case the later presets override the earlier ones",
+	)
+	command.Flags().BoolVar(
+		&printInstallDir,
+		printInstallDirFlag,
+		false,
+		"Print the directory redpanda would be started from, i.e. the"+
+			" one --install-dir, $"+cli.InstallDirEnv+" or"+
+			" rpk.install_dir set or the one found, and exit",
+	)
+	command.Flags().BoolVar(
+		&saveOverrides,
+		saveFlag,
+		false,
+		"Write the --"+setFlag+" values to the config file, instead of"+
+			" only using them for this start",
+	)
+	command.Flags().Bool(
+		chooseIoProfileFlag,
+		false,
+		"Ask which IO profile to use when more than one matches the"+
+			" detected cloud VM, instead of picking one",
+	)
+	command.Flags().Bool(
+		refreshIoFlag,
+		false,
+		"Deduce the IO properties from rpk.well_known_io or the detected"+
+			" cloud VM again, instead of using the ones cached in the"+
+			" data directory",
+	)
+	command.Flags().IntVar(
+		&oomScoreAdj,
+		oomScoreAdjFlag,
+		0,
+		"The OOM score adjustment to start redpanda with, between -1000"+
+			" (never OOM-kill it) and 1000 (OOM-kill it first)."+
+			" Lowering it requires root",
+	)
+	command.Flags().BoolVar(
+		&waitReady,
+		waitForReadyFlag,
+		false,
+		"Start redpanda in the background and wait until its admin API"+
+			" reports it's ready, failing if it isn't before"+
+			" --"+readyTimeoutFlag,
+	)
+	command.Flags().DurationVar(
+		&readyTimeout,
+		readyTimeoutFlag,
+		time.Minute,
+		"How long to wait for redpanda to be ready with --"+
+			waitForReadyFlag+". redpanda is left running if it"+
+			" elapses. Use --"+startTimeoutFlag+" to kill it instead",
+	)
+	command.Flags().DurationVar(
+		&startTimeout,
+		startTimeoutFlag,
+		0,
+		"With --"+waitForReadyFlag+", how long redpanda has to start and"+
+			" be ready before rpk kills it and fails. Unlike --"+
+			readyTimeoutFlag+", it bounds the launch too. Disabled if 0",
+	)
+	command.Flags().StringVar(
+		&pidFile,
+		pidFileFlag,
+		"",
+		"The file to write redpanda's PID to once it's started, removed"+
+			" if rpk sees it exit. Defaults to rpk.pid_file, or"+
+			" redpanda.pid in the data directory",
+	)
+	command.Flags().IntVar(
+		&numaNode,
+		numaNodeFlag,
+		0,
+		"Run redpanda on the given NUMA node's CPUs. --memory must fit in"+
+			" the node's memory, so that it's allocated locally",
+	)
 	command.Flags().StringVar(&installDirFlag,
 		"install-dir", "",
 		"Directory where redpanda has been installed")
+	command.Flags().StringVar(&topologyFile,
+		"topology-file", "",
+		"An hwloc XML topology file (e.g. exported with 'lstopo topo.xml')"+
+			" to compute the CPU masks from, for when /sys is masked")
 	command.Flags().BoolVar(&prestartCfg.tuneEnabled, "tune", false,
 		"When present will enable tuning before starting redpanda")
+	command.Flags().StringVar(
+		&prestartCfg.tuneProfile,
+		"tune-profile",
+		string(tuners.BalancedProfile),
+		fmt.Sprintf(
+			"How far the tuners push the values they set when --tune"+
+				" is passed [%s]. See 'rpk tune --help'",
+			strings.Join(tuners.TuneProfiles(), ", "),
+		),
+	)
 	command.Flags().BoolVar(&prestartCfg.checkEnabled, "check", true,
 		"When set to false will disable system checking before starting redpanda")
-	command.Flags().IntVar(&sFlags.smp, smpFlag, 0, "Restrict redpanda to"+
-		" the given number of CPUs. This option does not mandate a"+
-		" specific placement of CPUs. See --cpuset if you need to do so.")
-	command.Flags().StringVar(&sFlags.reserveMemory, reserveMemoryFlag, "",
-		"Memory reserved for the OS (if --memory isn't specified)")
-	command.Flags().StringVar(&sFlags.hugepages, hugepagesFlag, "",
-		"Path to accessible hugetlbfs mount (typically /dev/hugepages/something)")
-	command.Flags().BoolVar(&sFlags.threadAffinity, threadAffinityFlag, true,
-		"Pin threads to their cpus (disable for overprovisioning)")
-	command.Flags().IntVar(&sFlags.numIoQueues, numIoQueuesFlag, 0,
-		"Number of IO queues. Each IO unit will be responsible for a fraction "+
-			"of the IO requests. Defaults to the number of threads")
-	command.Flags().IntVar(&sFlags.maxIoRequests, maxIoRequestsFlag, 0,
-		"Maximum amount of concurrent requests to be sent to the disk. "+
-			"Defaults to 128 times the number of IO queues")
-	command.Flags().StringVar(&sFlags.ioPropertiesFile, ioPropertiesFileFlag, "",
-		"Path to a YAML file describing the characteristics of the I/O Subsystem")
-	command.Flags().StringVar(&sFlags.ioProperties, ioPropertiesFlag, "",
-		"A YAML string describing the characteristics of the I/O Subsystem")
+	command.Flags().Bool(
+		sandboxFlag,
+		false,
+		"Run redpanda with few resources for local development"+
+			" (--smp 1, --memory 1G, --reserve-memory 0M and"+
+			" --overprovisioned), skipping the system checks unless"+
+			" --check is passed. Flags passed explicitly override them",
+	)
+	addCheckSelectionFlags(command.Flags(), &prestartCfg.checks)
+	command.Flags().BoolVar(
+		&prestartCfg.failOnWarn,
+		failOnWarnFlag,
+		false,
+		"Don't start redpanda if any check fails, not just the ones with"+
+			" a fatal severity",
+	)
+	command.Flags().BoolVar(
+		&prestartCfg.versionCheck,
+		"version-check",
+		false,
+		"Fail if the redpanda binary is older than"+
+			" rpk.min_redpanda_version",
+	)
+	command.Flags().BoolVar(&prestartCfg.assumeYes, "assume-yes", false,
+		"When tuning, run disruptive tuners (e.g. those which restart"+
+			" irqbalance) without asking for confirmation. Otherwise,"+
+			" they're skipped if stdin isn't a terminal")
 	command.Flags().StringVar(
 		&wellKnownIo,
 		wellKnownIOFlag,
 		"",
-		"The cloud vendor and VM type, in the format <vendor>:<vm type>:<storage type>")
+		"The cloud vendor and VM type, in the format <vendor>:<vm type>:<storage type>."+
+			" If the storage type is omitted, the VM type's default"+
+			" or most common one is used")
 	mgr.BindFlag("rpk.well_known_io", command.Flags().Lookup(wellKnownIOFlag))
-	command.Flags().BoolVar(&sFlags.mbind, mbindFlag, true, "enable mbind")
-	command.Flags().BoolVar(
-		&sFlags.overprovisioned,
-		overprovisionedFlag,
-		true,
-		"Enable overprovisioning",
+	command.Flags().String(
+		memoryPercentFlag,
+		"",
+		"The percentage of the system's memory for redpanda to use (e.g."+
+			" 80%), resolved to --"+memoryFlag+". Overrides"+
+			" rpk.memory_percent",
+	)
+	command.Flags().Bool(
+		strictIOPropsFlag,
+		false,
+		"Fail if no IO properties are given and they can't be deduced,"+
+			" instead of starting redpanda without them",
 	)
 	mgr.BindFlag("rpk.overprovisioned", command.Flags().Lookup(overprovisionedFlag))
 	command.Flags().DurationVar(
@@ -335,11 +870,79 @@ func NewStartCommand(
 			"Valid time units are 'ns', 'us' (or 'µs'), 'ms', 's', 'm', 'h'",
 	)
 	for flag := range flagsMap(sFlags) {
-		command.Flag(flag).Hidden = true
+		command.Flag(cliFlagName(flag)).Hidden = true
 	}
 	return command
 }
 
+// Registers the flags which are passed through to redpanda (seastar).
+func addSeastarFlags(flags *pflag.FlagSet, sFlags *seastarFlags) {
+	flags.StringVar(&sFlags.memory,
+		memoryFlag, "", "Amount of memory for redpanda to use, "+
+			"if not specified redpanda will use all available memory")
+	flags.BoolVar(&sFlags.lockMemory,
+		lockMemoryFlag, false, "If set, will prevent redpanda from swapping")
+	flags.StringVar(&sFlags.cpuSet, cpuSetFlag, "",
+		"Set of CPUs for redpanda to use in cpuset(7) format, "+
+			"if not specified redpanda will use all available CPUs")
+	flags.IntVar(&sFlags.smp, smpFlag, 0, "Restrict redpanda to"+
+		" the given number of CPUs. This option does not mandate a"+
+		" specific placement of CPUs. See --cpuset if you need to do so.")
+	flags.StringVar(&sFlags.reserveMemory, reserveMemoryFlag, "",
+		"Memory reserved for the OS (if --memory isn't specified)")
+	flags.StringVar(&sFlags.hugepages, hugepagesFlag, "",
+		"Path to accessible hugetlbfs mount (typically /dev/hugepages/something)")
+	flags.BoolVar(&sFlags.threadAffinity, threadAffinityFlag, true,
+		"Pin threads to their cpus (disable for overprovisioning)")
+	flags.IntVar(&sFlags.numIoQueues, numIoQueuesFlag, 0,
+		"Number of IO queues. Each IO unit will be responsible for a fraction "+
+			"of the IO requests. Defaults to the number of threads")
+	flags.IntVar(&sFlags.maxIoRequests, maxIoRequestsFlag, 0,
+		"Maximum amount of concurrent requests to be sent to the disk. "+
+			"Defaults to 128 times the number of IO queues")
+	flags.StringArrayVar(&sFlags.ioPropertiesFiles, ioPropertiesFileFlag, []string{},
+		"Path to a YAML file describing the characteristics of the I/O Subsystem."+
+			" It may be passed more than once, e.g. for disks measured"+
+			" separately, to merge the files. It may also be an http(s)://"+
+			" or s3://<bucket>/<key> URL, which is downloaded into the"+
+			" data directory once")
+	flags.StringVar(&sFlags.ioProperties, ioPropertiesFlag, "",
+		"A YAML string describing the characteristics of the I/O Subsystem")
+	flags.BoolVar(&sFlags.mbind, mbindFlag, true, "enable mbind")
+	flags.BoolVar(
+		&sFlags.overprovisioned,
+		overprovisionedFlag,
+		true,
+		"Enable overprovisioning",
+	)
+	flags.StringVar(
+		&sFlags.defaultLogLevel,
+		redpandaLogLevelFlag,
+		"",
+		fmt.Sprintf(
+			"The level redpanda logs at (--%s) [%s]",
+			defaultLogLevelFlag,
+			strings.Join(logLevels, ", "),
+		),
+	)
+	flags.StringArrayVar(
+		&sFlags.loggerLogLevels,
+		loggerLogLevelFlag,
+		[]string{},
+		"A redpanda logger's level, overriding --"+redpandaLogLevelFlag+
+			", in the format <logger>=<level> (e.g. raft=debug). Can be"+
+			" passed more than once",
+	)
+}
+
+// Returns the name rpk start takes the given seastar flag with.
+func cliFlagName(flag string) string {
+	if alias, ok := flagAliases[flag]; ok {
+		return alias
+	}
+	return flag
+}
+
 func flagsMap(sFlags seastarFlags) map[string]interface{} {
 	return map[string]interface{}{
 		memoryFlag:		sFlags.memory,
@@ -355,177 +958,1794 @@ func flagsMap(sFlags seastarFlags) map[string]interface{} {
 		maxIoRequestsFlag:	sFlags.maxIoRequests,
 		mbindFlag:		sFlags.mbind,
 		overprovisionedFlag:	sFlags.overprovisioned,
+		defaultLogLevelFlag:	sFlags.defaultLogLevel,
+		loggerLogLevelFlag:	strings.Join(sFlags.loggerLogLevels, ":"),
 	}
 }
 
-func prestart(
+// Returns the resolved flags as the YAML the k8s operator's cluster spec takes
+// under additionalConfiguration, where they're passed to redpanda through
+// rpk.additional_start_flags.
+func operatorConfig(args *rp.RedpandaArgs) (string, error) {
+	flags := rp.SeastarArgs(args.SeastarFlags)
+	sort.Strings(flags)
+	flags = append(flags, args.ExtraArgs...)
+	conf := map[string]interface{}{
+		"additionalConfiguration": map[string]string{
+			"rpk.additional_start_flags": strings.Join(flags, " "),
+		},
+	}
+	out, err := yaml.Marshal(conf)
+	if err != nil {
+		return "", fmt.Errorf(
+			"couldn't render the operator config: %v",
+			err,
+		)
+	}
+	return string(out), nil
+}
+
+// Has the redpanda binary validate the resolved config and flags, if it
+// supports it. The config is written to a temporary directory, so that the one
+// in use isn't changed.
+func validateWithBinary(
 	fs afero.Fs,
-	args *rp.RedpandaArgs,
+	mgr config.Manager,
 	conf *config.Config,
-	prestartCfg prestartConfig,
+	args *rp.RedpandaArgs,
+	installDir string,
+	binaries []rp.Binary,
 	timeout time.Duration,
-) ([]api.CheckPayload, []api.TunerPayload, error) {
-	var err error
-	checkPayloads := []api.CheckPayload{}
-	tunerPayloads := []api.TunerPayload{}
-	if prestartCfg.checkEnabled {
-		checkPayloads, err = check(fs, conf, timeout, checkFailedActions(args))
-		if err != nil {
-			return checkPayloads, tunerPayloads, err
+) error {
+	proc := vos.NewProc()
+	binary := rp.BinaryPath(installDir)
+	if !rp.SupportsCheckConfig(proc, timeout, binary) {
+		version := "unknown version"
+		for _, b := range binaries {
+			if b.Used {
+				version = b.Version
+			}
 		}
-		log.Info("System check - PASSED")
+		log.Warnf(
+			"'%s' (%s) doesn't support %s, so the config and flags"+
+				" weren't validated by redpanda",
+			binary,
+			version,
+			rp.CheckConfigFlag,
+		)
+		return nil
 	}
-	if prestartCfg.tuneEnabled {
-		cpuset := fmt.Sprint(args.SeastarFlags[cpuSetFlag])
-		tunerPayloads, err = tuneAll(fs, cpuset, conf, timeout)
+	dir, err := afero.TempDir(fs, "", "rpk-validate")
+	if err != nil {
+		return err
+	}
+	defer fs.RemoveAll(dir)
+	validated := *conf
+	validated.ConfigFile = filepath.Join(dir, "redpanda.yaml")
+	err = mgr.Write(&validated)
+	if err != nil {
+		return err
+	}
+	validatedArgs := *args
+	validatedArgs.ConfigFilePath = validated.RedpandaConfigFile()
+	err = rp.ValidateArgs(proc, timeout, binary, &validatedArgs)
+	if err != nil {
+		return err
+	}
+	log.Info("redpanda validated the config and flags")
+	return nil
+}
+
+// Writes the config without the --set overrides, and a copy of it with them
+// in the data directory, which redpanda is started with instead.
+func writeWithOverrides(
+	fs afero.Fs,
+	mgr config.Manager,
+	conf, origConf *config.Config,
+	overrides []string,
+	args *rp.RedpandaArgs,
+) error {
+	persisted, err := config.RevertOverrides(conf, origConf, overrides)
+	if err != nil {
+		return err
+	}
+	err = mgr.Write(persisted)
+	if err != nil {
+		return err
+	}
+	overridden := *conf
+	// The persisted config's path, so that 'rpk stop' finds it even if the
+	// data directory is overridden.
+	overridden.ConfigFile = persisted.OverridesConfigFile()
+	err = fs.MkdirAll(filepath.Dir(overridden.ConfigFile), 0700)
+	if err != nil {
+		return err
+	}
+	err = mgr.Write(&overridden)
+	if err != nil {
+		return err
+	}
+	// Set them again, since Write leaves out the empty values, which would
+	// otherwise be taken from the config file. A new manager is used, so
+	// that the settings read from the config file (e.g. its includes) don't
+	// end up in the file redpanda is started with.
+	path := overridden.RedpandaConfigFile()
+	for _, o := range overrides {
+		key, value, err := config.ParseOverride(o)
 		if err != nil {
-			return checkPayloads, tunerPayloads, err
+			return err
+		}
+		err = config.NewManager(fs).Set(key, value, "single", path)
+		if err != nil {
+			return err
 		}
-		log.Info("System tune - PASSED")
 	}
-	return checkPayloads, tunerPayloads, nil
+	args.ConfigFilePath = path
+	log.Infof(
+		"Starting redpanda with the config and the --%s overrides in '%s'",
+		setFlag,
+		path,
+	)
+	return nil
 }
 
-func buildRedpandaFlags(
-	fs afero.Fs, conf *config.Config, sFlags seastarFlags, flags *pflag.FlagSet,
-) (*rp.RedpandaArgs, error) {
-	if flags.Changed(wellKnownIOFlag) {
-		conf.Rpk.WellKnownIo, _ = flags.GetString(wellKnownIOFlag)
-	}
-	wellKnownIOSet := conf.Rpk.WellKnownIo != ""
-	ioPropsSet := flags.Changed(ioPropertiesFileFlag) || flags.Changed(ioPropertiesFlag)
-	if wellKnownIOSet && ioPropsSet {
-		return nil, errors.New(
-			"--well-known-io or (rpk.well_known_io) and" +
-				" --io-properties (or --io-properties-file)" +
-				" can't be set at the same time",
+// Reports whether redpanda is ready, e.g. admin.Client.
+type readinessChecker interface {
+	Ready() (ready bool, response string, err error)
+}
+
+// Starts redpanda in the background and waits for it to be ready. If
+// startTimeout isn't 0 and redpanda isn't ready before it elapses, it's
+// killed.
+func startAndWaitForReady(
+	launcher rp.Launcher,
+	installDir string,
+	args *rp.RedpandaArgs,
+	client readinessChecker,
+	timeout, startTimeout time.Duration,
+) error {
+	bl, ok := launcher.(rp.BackgroundLauncher)
+	if !ok {
+		return fmt.Errorf(
+			"--%s isn't supported, since redpanda can't be started"+
+				" in the background",
+			waitForReadyFlag,
 		)
 	}
+	ctx := context.Background()
+	if startTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, startTimeout)
+		defer cancel()
+	}
+	pid, exited, err := bl.StartInBackground(ctx, installDir, args)
+	if err != nil {
+		return err
+	}
+	log.Infof(
+		"Started redpanda (PID %d). Waiting up to %s for it to be ready",
+		pid,
+		timeout,
+	)
+	err = waitForReady(ctx, client, exited, timeout, readyPollInterval)
+	if err != nil && ctx.Err() != nil {
+		return killUnready(bl, pid, exited, err)
+	}
+	return err
+}
 
-	if !ioPropsSet {
-		// If --io-properties-file and --io-properties weren't set, try
-		// finding an IO props file in the default location.
-		sFlags.ioPropertiesFile = rp.GetIOConfigPath(
-			filepath.Dir(conf.ConfigFile),
+// Kills redpanda once --start-timeout elapses, and waits for the launcher to
+// reap it so that its PID file is removed.
+func killUnready(
+	bl rp.BackgroundLauncher, pid int, exited <-chan error, cause error,
+) error {
+	err := bl.Kill(pid)
+	if err != nil {
+		return fmt.Errorf(
+			"%v. Couldn't kill redpanda (PID %d): %v",
+			cause,
+			pid,
+			err,
 		)
-		if exists, _ := afero.Exists(fs, sFlags.ioPropertiesFile); !exists {
-			sFlags.ioPropertiesFile = ""
+	}
+	select {
+	case <-exited:
+	case <-time.After(killWaitTimeout):
+		log.Warnf("redpanda (PID %d) didn't exit after it was killed", pid)
+	}
+	return fmt.Errorf("%v. redpanda (PID %d) was killed", cause, pid)
+}
+
+// Polls redpanda's readiness until it's ready, it exits, the timeout elapses
+// or ctx is done, in which case the error includes the last response.
+func waitForReady(
+	ctx context.Context,
+	client readinessChecker,
+	exited <-chan error,
+	timeout, interval time.Duration,
+) error {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := "none"
+	for {
+		ready, res, err := client.Ready()
+		if ready {
+			log.Info("redpanda is ready")
+			return nil
+		}
+		last = res
+		if err != nil {
+			last = err.Error()
+		}
+		select {
+		case err := <-exited:
+			if err == nil {
+				return errors.New("redpanda exited before it was ready")
+			}
+			return fmt.Errorf("redpanda exited before it was ready: %v", err)
+		case <-deadline:
+			// It's left running, e.g. in case it's just slow to
+			// replay its log.
+			return fmt.Errorf(
+				"redpanda wasn't ready after %s, and was left"+
+					" running. Last response: %s",
+				timeout,
+				last,
+			)
+		case <-ctx.Done():
+			return fmt.Errorf(
+				"redpanda wasn't ready before --%s elapsed. Last"+
+					" response: %s",
+				startTimeoutFlag,
+				last,
+			)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Starts redpanda with the args cached by --resolve-only, skipping the checks,
+// tuners and flag resolution.
+func startFromResolved(
+	fs afero.Fs,
+	mgr config.Manager,
+	launcher rp.Launcher,
+	path, configFile, installDirFlag, pidFile string,
+	args []string,
+) error {
+	rpArgs, err := loadResolvedArgs(fs, path, configFile, os.Hostname)
+	if err != nil {
+		return err
+	}
+	conf, err := mgr.Read(rpArgs.ConfigFilePath)
+	if err != nil {
+		return fmt.Errorf(
+			"couldn't read the config file '%s' the args in '%s' were"+
+				" resolved with: %v",
+			rpArgs.ConfigFilePath,
+			path,
+			err,
+		)
+	}
+	rpArgs.PIDFile = launcherPIDFile(conf, pidFile)
+	installDirectory, err := cli.GetOrFindInstallDir(
+		fs,
+		installDirFlag,
+		conf.Rpk.InstallDir,
+	)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		rpArgs.ExtraArgs = args
+	}
+	log.Infof("Using the args resolved in '%s'", path)
+	log.Info("Starting redpanda...")
+	return launcher.Start(installDirectory, rpArgs)
+}
+
+// Returns the file the launcher writes redpanda's PID to: the one passed with
+// --pidfile, if any, or the config's.
+func launcherPIDFile(conf *config.Config, pidFileFlag string) string {
+	if pidFileFlag != "" {
+		return pidFileFlag
+	}
+	return conf.LauncherPIDFile()
+}
+
+// Applies the given mode's defaults (see 'rpk redpanda mode') to the config,
+// keeping the values of the flags bound to it which were passed explicitly.
+func applyMode(
+	mode string, conf *config.Config, sFlags seastarFlags, flags *pflag.FlagSet,
+) (*config.Config, error) {
+	conf, err := config.SetMode(mode, conf)
+	if err != nil {
+		return nil, err
+	}
+	if flags.Changed(overprovisionedFlag) {
+		conf.Rpk.Overprovisioned = sFlags.overprovisioned
+	}
+	if flags.Changed(lockMemoryFlag) {
+		conf.Rpk.EnableMemoryLocking = sFlags.lockMemory
+	}
+	if m, _ := config.NormalizeMode(mode); m == config.ModeBenchmark {
+		log.Warn(strings.Repeat("*", 72))
+		log.Warn(
+			"Benchmark mode: these settings maximize throughput" +
+				" for performance testing and are NOT meant for" +
+				" production",
+		)
+		log.Warn(strings.Repeat("*", 72))
+	}
+	return conf, nil
+}
+
+func prestart(
+	fs afero.Fs,
+	args *rp.RedpandaArgs,
+	conf *config.Config,
+	prestartCfg prestartConfig,
+	installDir string,
+	timeout time.Duration,
+) ([]api.CheckPayload, []api.TunerPayload, error) {
+	var err error
+	checkPayloads := []api.CheckPayload{}
+	tunerPayloads := []api.TunerPayload{}
+	if prestartCfg.versionCheck {
+		err = checkRedpandaVersion(conf, installDir, timeout)
+		if err != nil {
+			return checkPayloads, tunerPayloads, err
+		}
+	}
+	// Raised before the checks, so that they see the new limit. Since it's
+	// inherited by redpanda, it's raised even if tuning is disabled.
+	if conf.Rpk.TuneFdLimit {
+		raiseFdLimit(fs, conf)
+	}
+	if prestartCfg.checkEnabled {
+		// The previous run's results aren't used, so there's no state
+		// to load them from.
+		filter, err := checkFilter(fs, "", prestartCfg.checks)
+		if err != nil {
+			return checkPayloads, tunerPayloads, err
+		}
+		checkPayloads, err = check(
+			fs,
+			conf,
+			timeout,
+			filter,
+			checkFailedActions(args, conf),
+			prestartCfg.failOnWarn,
+		)
+		if err != nil {
+			return checkPayloads, tunerPayloads, err
+		}
+		if cpuset, ok := args.SeastarFlags[cpuSetFlag]; ok {
+			err = validateCpuset(
+				hwloc.NewHwLocCmd(vos.NewProc(), timeout),
+				cpuSetFlag,
+				cpuset,
+			)
+			if err != nil {
+				return checkPayloads, tunerPayloads, err
+			}
+		}
+		log.Info("System check - PASSED")
+	}
+	// Checked after the rest, since they may disable --lock-memory.
+	if args.SeastarFlags[lockMemoryFlag] == "true" {
+		payload, err := ensureMemlockLimit(fs, args)
+		if prestartCfg.checkEnabled {
+			if payload != nil {
+				checkPayloads = append(checkPayloads, *payload)
+			}
+			if err != nil {
+				return checkPayloads, tunerPayloads, err
+			}
+		} else if err != nil {
+			log.Warn(err)
+		}
+	}
+	if prestartCfg.tuneEnabled {
+		cpuset := fmt.Sprint(args.SeastarFlags[cpuSetFlag])
+		confirm := confirmDisruptiveTuner(
+			prestartCfg.assumeYes,
+			ui.Interactive() && terminal.IsTerminal(int(os.Stdin.Fd())),
+			os.Stdin,
+		)
+		tunerPayloads, err = tuneAll(
+			fs,
+			cpuset,
+			conf,
+			prestartCfg.tuneProfile,
+			timeout,
+			confirm,
+		)
+		if err != nil {
+			return checkPayloads, tunerPayloads, err
+		}
+		log.Info("System tune - PASSED")
+	}
+	return checkPayloads, tunerPayloads, nil
+}
+
+// Raises RLIMIT_MEMLOCK if it's not enough to lock the memory redpanda will
+// use, and checks it, failing if it's still too low.
+func ensureMemlockLimit(
+	fs afero.Fs, args *rp.RedpandaArgs,
+) (*api.CheckPayload, error) {
+	required, err := memlockRequired(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	tuner := tuners.NewMemlockLimitTuner(
+		system.GetMemlockLimit,
+		system.SetMemlockLimit,
+		required,
+	)
+	res := tuner.Tune()
+	if res.IsFailed() {
+		log.Warn(res.Error())
+	}
+	checker := tuners.NewMemlockLimitChecker(system.GetMemlockLimit, required)
+	result := checker.Check()
+	payload := &api.CheckPayload{
+		Name:		result.Desc,
+		Current:	result.Current,
+		Required:	result.Required,
+	}
+	if result.Err != nil {
+		payload.ErrorMsg = result.Err.Error()
+		return payload, result.Err
+	}
+	if !result.IsOk {
+		return payload, fmt.Errorf(
+			"System check '%s' failed. Required: %v, Current %v",
+			result.Desc,
+			result.Required,
+			result.Current,
+		)
+	}
+	return payload, nil
+}
+
+// Raises rpk's RLIMIT_NOFILE, and therefore redpanda's, to rpk.min_fd_limit.
+// The file_descriptors tuner also installs a systemd drop-in, which isn't
+// needed here, since redpanda is about to be exec'd by rpk.
+func raiseFdLimit(fs afero.Fs, conf *config.Config) {
+	tuner := tuners.NewFdLimitTuner(
+		fs,
+		system.GetFdLimit,
+		system.SetFdLimit,
+		tuners.RequiredFdLimit(conf.Rpk),
+		nil,
+		executors.NewDirectExecutor(),
+	)
+	res := tuner.Tune()
+	if res.IsFailed() {
+		log.Warn(res.Error())
+	}
+}
+
+// Returns the memory redpanda will lock: --memory, or the total memory if it
+// isn't set, since redpanda then uses almost all of it.
+func memlockRequired(fs afero.Fs, args *rp.RedpandaArgs) (uint64, error) {
+	memory, ok := args.SeastarFlags[memoryFlag]
+	if !ok {
+		totalMB, err := system.GetMemTotalMB(fs)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(totalMB) * units.MiB, nil
+	}
+	bytes, err := units.RAMInBytes(memory)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s '%s': %v", memoryFlag, memory, err)
+	}
+	return uint64(bytes), nil
+}
+
+// Sets --cpuset to the given NUMA node's CPUs, checking that --memory fits in
+// the node's memory. Otherwise, part of it would be allocated on other nodes,
+// and accessing it would be slower.
+func pinToNUMANode(fs afero.Fs, args *rp.RedpandaArgs, node int) error {
+	if _, ok := args.SeastarFlags[cpuSetFlag]; ok {
+		return fmt.Errorf(
+			"--%s and --%s can't be set at the same time",
+			numaNodeFlag,
+			cpuSetFlag,
+		)
+	}
+	cpus, err := system.GetNUMANodeCPUList(fs, node)
+	if err != nil {
+		return err
+	}
+	memInfo, err := system.GetNUMANodeMemInfo(fs, node)
+	if err != nil {
+		return err
+	}
+	memory, ok := args.SeastarFlags[memoryFlag]
+	if !ok {
+		log.Warnf(
+			"--%s isn't set, so redpanda will use memory from all the"+
+				" NUMA nodes, not just node %d (%s)",
+			memoryFlag,
+			node,
+			units.BytesSize(float64(memInfo.MemTotal)),
+		)
+	} else {
+		requested, err := units.RAMInBytes(memory)
+		if err != nil {
+			return fmt.Errorf("invalid --%s '%s': %v", memoryFlag, memory, err)
+		}
+		available := memInfo.MemFree + memInfo.FilePages
+		if uint64(requested) > memInfo.MemTotal {
+			return fmt.Errorf(
+				"--%s %s doesn't fit in NUMA node %d, which has"+
+					" %s of memory (%s available)",
+				memoryFlag,
+				memory,
+				node,
+				units.BytesSize(float64(memInfo.MemTotal)),
+				units.BytesSize(float64(available)),
+			)
+		}
+		if uint64(requested) > available {
+			log.Warnf(
+				"--%s %s is more than NUMA node %d's available"+
+					" memory (%s of %s), so part of it may be"+
+					" allocated on other nodes",
+				memoryFlag,
+				memory,
+				node,
+				units.BytesSize(float64(available)),
+				units.BytesSize(float64(memInfo.MemTotal)),
+			)
+		}
+	}
+	log.Infof("Setting --%s to NUMA node %d's CPUs: %s", cpuSetFlag, node, cpus)
+	args.SeastarFlags[cpuSetFlag] = cpus
+	return nil
+}
+
+// Logs the path and version of the redpanda binary which will be started, so
+// that it's clear which one is used when there are several installs.
+func logRedpandaVersion(
+	launcher rp.Launcher, installDir string, timeout time.Duration,
+) {
+	binary, version, err := launcher.Version(installDir, timeout)
+	if err != nil {
+		log.Warnf("Couldn't get the version of redpanda in '%s': %v", installDir, err)
+		return
+	}
+	log.Infof("Using redpanda %s at '%s'", version, binary)
+}
+
+// Where the value of a flag passed to redpanda came from.
+type flagSource string
+
+const (
+	cliSource		flagSource	= "cli"
+	configSource		flagSource	= "config"
+	additionalFlagsSource	flagSource	= "rpk.additional_start_flags"
+	deducedSource		flagSource	= "deduced"
+	overrideSource		flagSource	= "override"
+	sandboxSource		flagSource	= "sandbox"
+	seastarFlagSource	flagSource	= "seastar-flag"
+	presetSource		flagSource	= "preset"
+)
+
+func buildRedpandaFlags(
+	fs afero.Fs, conf *config.Config, sFlags seastarFlags, flags *pflag.FlagSet,
+) (*rp.RedpandaArgs, error) {
+	args, _, err := buildRedpandaFlagsWithSources(
+		fs,
+		conf,
+		sFlags,
+		flags,
+		os.Getenv,
+	)
+	return args, err
+}
+
+// Like buildRedpandaFlags, but also returns where each of the resulting
+// flags' values came from.
+func buildRedpandaFlagsWithSources(
+	fs afero.Fs,
+	conf *config.Config,
+	sFlags seastarFlags,
+	flags *pflag.FlagSet,
+	getenv func(string) string,
+) (*rp.RedpandaArgs, map[string]flagSource, error) {
+	if flags.Changed(wellKnownIOFlag) {
+		conf.Rpk.WellKnownIo, _ = flags.GetString(wellKnownIOFlag)
+	}
+	if flags.Changed(strictIOPropsFlag) {
+		conf.Rpk.StrictIoProperties, _ = flags.GetBool(strictIOPropsFlag)
+	}
+	if flags.Changed(memoryPercentFlag) {
+		conf.Rpk.MemoryPercent, _ = flags.GetString(memoryPercentFlag)
+	}
+	if len(sFlags.ioPropertiesFiles) == 1 {
+		sFlags.ioPropertiesFile = sFlags.ioPropertiesFiles[0]
+	} else if len(sFlags.ioPropertiesFiles) > 1 {
+		var paths []string
+		for _, path := range sFlags.ioPropertiesFiles {
+			local, err := localIoPropertiesFile(fs, conf, path)
+			if err != nil {
+				return nil, nil, err
+			}
+			paths = append(paths, local)
+		}
+		merged, err := mergeIoPropertiesFiles(fs, conf, paths)
+		if err != nil {
+			return nil, nil, err
+		}
+		sFlags.ioPropertiesFile = merged
+	}
+	// The env vars are resolved first, since they count as set for the
+	// checks below just like the flags they stand for.
+	envValues := seastarFlagsFromEnv(flags, getenv)
+	err := validateLogLevels(envValues)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, ioPropsFileInEnv := envValues[ioPropertiesFileFlag]
+	_, ioPropsInEnv := envValues[ioPropertiesFlag]
+	wellKnownIOSet := conf.Rpk.WellKnownIo != ""
+	ioPropsSet := flags.Changed(ioPropertiesFileFlag) ||
+		flags.Changed(ioPropertiesFlag) ||
+		ioPropsFileInEnv ||
+		ioPropsInEnv
+	if wellKnownIOSet && ioPropsSet {
+		return nil, nil, errors.New(
+			"--well-known-io or (rpk.well_known_io) and" +
+				" --io-properties (or --io-properties-file, or" +
+				" their RPK_ env vars) can't be set at the same" +
+				" time",
+		)
+	}
+
+	// The flags whose values were deduced by rpk rather than passed
+	// explicitly, and which should therefore be kept even if unchanged.
+	deduced := map[string]bool{}
+	var deduceErr error
+	if !ioPropsSet {
+		// If --io-properties-file and --io-properties weren't set, try
+		// finding an IO props file in the default location.
+		sFlags.ioPropertiesFile = rp.GetIOConfigPath(
+			filepath.Dir(conf.ConfigFile),
+		)
+		if exists, _ := afero.Exists(fs, sFlags.ioPropertiesFile); !exists {
+			sFlags.ioPropertiesFile = ""
 		}
 		// Otherwise, try to deduce the IO props.
 		if sFlags.ioPropertiesFile == "" {
-			ioProps, err := resolveWellKnownIo(conf)
+			choose, _ := flags.GetBool(chooseIoProfileFlag)
+			refresh, _ := flags.GetBool(refreshIoFlag)
+			ioProps, err := cachedWellKnownIo(fs, conf, choose, refresh)
 			if err == nil {
-				yaml, err := iotune.ToYaml(*ioProps)
+				sFlags.ioProperties, err = ioPropertiesFlagValue(ioProps)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
-				sFlags.ioProperties = fmt.Sprintf("'%s'", yaml)
+				deduced[ioPropertiesFlag] = true
 			} else {
-				log.Warn(err)
+				// The IO props may still be set by one of the
+				// sources merged below, so it's checked once
+				// all of them have been.
+				deduceErr = err
+			}
+		}
+	}
+	flagsMap := flagsMap(sFlags)
+	for flag := range flagsMap {
+		if !flags.Changed(cliFlagName(flag)) && !deduced[flag] {
+			delete(flagsMap, flag)
+		}
+	}
+	flagsMap, err = flagsFromConf(fs, conf, flagsMap, flags, envValues)
+	if err != nil {
+		return nil, nil, err
+	}
+	sandboxed := map[string]bool{}
+	if flags.Lookup(sandboxFlag) != nil {
+		if sandbox, _ := flags.GetBool(sandboxFlag); sandbox {
+			sandboxed = applySandboxFlags(flagsMap, flags, conf)
+		}
+	}
+	err = validateLogLevels(flagsMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalFlags := map[string]string{}
+	sources := map[string]flagSource{}
+	for n := range mergeFlags(finalFlags, conf.Rpk.AdditionalStartFlags) {
+		sources[n] = additionalFlagsSource
+	}
+	for n, v := range flagsMap {
+		if _, alreadyPresent := finalFlags[n]; alreadyPresent {
+			return nil, nil, fmt.Errorf(
+				"Configuration conflict. Flag '--%s'"+
+					" is also present in"+
+					" 'rpk.additional_start_flags' in"+
+					" configuration file '%s'. Please"+
+					" remove it and pass '--%s' directly"+
+					" to `rpk start`.",
+				n,
+				conf.ConfigFile,
+				cliFlagName(n),
+			)
+		}
+		finalFlags[n] = fmt.Sprint(v)
+		switch {
+		case deduced[n]:
+			sources[n] = deducedSource
+		case flags.Changed(cliFlagName(n)):
+			sources[n] = cliSource
+		case sandboxed[n]:
+			sources[n] = sandboxSource
+		default:
+			sources[n] = configSource
+		}
+	}
+	for n, v := range envValues {
+		finalFlags[n] = fmt.Sprint(v)
+		sources[n] = envSource
+	}
+	if flags.Lookup(presetFlag) != nil {
+		names, _ := flags.GetStringArray(presetFlag)
+		presetValues, err := presetFlags(fs, conf, names)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = validateLogLevels(presetValues)
+		if err != nil {
+			return nil, nil, err
+		}
+		for n, v := range presetValues {
+			// The flags passed explicitly take precedence.
+			if flags.Changed(cliFlagName(n)) {
+				continue
+			}
+			finalFlags[n] = fmt.Sprint(v)
+			sources[n] = presetSource
+		}
+	}
+	if flags.Lookup(seastarFlagFlag) != nil {
+		values, _ := flags.GetStringArray(seastarFlagFlag)
+		passthrough, err := parseSeastarFlagValues(values)
+		if err != nil {
+			return nil, nil, err
+		}
+		for n, v := range passthrough {
+			if flags.Changed(cliFlagName(n)) {
+				log.Warnf(
+					"Ignoring --%s %s, since --%s was passed",
+					seastarFlagFlag,
+					n,
+					cliFlagName(n),
+				)
+				continue
+			}
+			finalFlags[n] = v
+			sources[n] = seastarFlagSource
+		}
+	}
+	// --override beats every other source, including the flags rpk
+	// computes itself.
+	if flags.Lookup(overrideFlag) != nil {
+		overrides, _ := flags.GetStringArray(overrideFlag)
+		for n := range mergeFlags(finalFlags, overrides) {
+			sources[n] = overrideSource
+		}
+	}
+	err = checkIoProps(finalFlags, sources, conf.Rpk.StrictIoProperties, deduceErr)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The IO properties file may be a URL, wherever it was set.
+	if path, ok := finalFlags[ioPropertiesFileFlag]; ok {
+		finalFlags[ioPropertiesFileFlag], err = localIoPropertiesFile(
+			fs,
+			conf,
+			path,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	dropConfigSmpWithCpuset(finalFlags, sources)
+	autoRename := false
+	if flags.Lookup(autoRenameFlagsFlag) != nil {
+		autoRename, _ = flags.GetBool(autoRenameFlagsFlag)
+	}
+	renameDeprecatedFlags(finalFlags, sources, renamedFlags, autoRename)
+	err = validateFlagCombinations(finalFlags, sources)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &rp.RedpandaArgs{
+		ConfigFilePath:	conf.RedpandaConfigFile(),
+		SeastarFlags:	finalFlags,
+	}, sources, nil
+}
+
+// Warns about the flags which have been renamed in seastar, replacing them
+// with their new names if autoRename is true. If both names are present, the
+// new one wins.
+func renameDeprecatedFlags(
+	finalFlags map[string]string,
+	sources map[string]flagSource,
+	renames map[string]string,
+	autoRename bool,
+) {
+	for old, renamed := range renames {
+		v, present := finalFlags[old]
+		if !present {
+			continue
+		}
+		if !autoRename {
+			log.Warnf(
+				"Flag '--%s' has been renamed to '--%s' and may"+
+					" not be supported in future versions of"+
+					" redpanda. Pass --%s to rename it"+
+					" automatically",
+				old,
+				renamed,
+				autoRenameFlagsFlag,
+			)
+			continue
+		}
+		delete(finalFlags, old)
+		source := sources[old]
+		delete(sources, old)
+		if _, exists := finalFlags[renamed]; exists {
+			log.Warnf(
+				"Dropping deprecated flag '--%s', as '--%s' is"+
+					" also set",
+				old,
+				renamed,
+			)
+			continue
+		}
+		log.Warnf("Renaming deprecated flag '--%s' to '--%s'", old, renamed)
+		finalFlags[renamed] = v
+		sources[renamed] = source
+	}
+}
+
+// Returns the name of the env var which sets the given seastar flag, e.g.
+// RPK_SMP for --smp.
+func envVarName(flag string) string {
+	name := strings.ReplaceAll(cliFlagName(flag), "-", "_")
+	return envFlagsPrefix + strings.ToUpper(name)
+}
+
+// Returns the values of the seastar flags set through RPK_<FLAG> env vars,
+// except for the ones passed on the command line, which take precedence.
+func seastarFlagsFromEnv(
+	flags *pflag.FlagSet, getenv func(string) string,
+) map[string]interface{} {
+	values := map[string]interface{}{}
+	for flag := range flagsMap(seastarFlags{}) {
+		v := getenv(envVarName(flag))
+		if v == "" || flags.Changed(cliFlagName(flag)) {
+			continue
+		}
+		values[flag] = v
+	}
+	return values
+}
+
+// Warns about the RPK_ env vars in environ which don't match any flag.
+func warnUnknownEnvFlags(environ []string) {
+	known := map[string]bool{}
+	for flag := range flagsMap(seastarFlags{}) {
+		known[envVarName(flag)] = true
+	}
+	known[cli.InstallDirEnv] = true
+	for _, kv := range environ {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if strings.HasPrefix(name, envFlagsPrefix) && !known[name] {
+			log.Warnf(
+				"Ignoring env var '%s', which doesn't match any"+
+					" of the flags passed to redpanda",
+				name,
+			)
+		}
+	}
+}
+
+func flagsFromConf(
+	fs afero.Fs,
+	conf *config.Config,
+	flagsMap map[string]interface{},
+	flags *pflag.FlagSet,
+	envValues map[string]interface{},
+) (map[string]interface{}, error) {
+	flagsMap[overprovisionedFlag] = conf.Rpk.Overprovisioned
+	flagsMap[lockMemoryFlag] = conf.Rpk.EnableMemoryLocking
+	// Setting SMP to 0 doesn't make sense.
+	if !flags.Changed(smpFlag) && conf.Rpk.SMP != nil && *conf.Rpk.SMP != 0 {
+		flagsMap[smpFlag] = *conf.Rpk.SMP
+	}
+	if conf.Rpk.MemoryPercent != "" {
+		_, inConf := rp.ParseFlags(conf.Rpk.AdditionalStartFlags)[memoryFlag]
+		_, inEnv := envValues[memoryFlag]
+		if flags.Changed(memoryFlag) || inConf || inEnv {
+			return nil, fmt.Errorf(
+				"--memory-percent (or rpk.memory_percent) and"+
+					" --memory (or --memory in"+
+					" rpk.additional_start_flags, or %s)"+
+					" can't be set at the same time",
+				envVarName(memoryFlag),
+			)
+		}
+		memory, err := memoryFromPercent(fs, conf.Rpk.MemoryPercent)
+		if err != nil {
+			return nil, err
+		}
+		flagsMap[memoryFlag] = memory
+	}
+	if !flags.Changed(redpandaLogLevelFlag) && conf.Rpk.RedpandaLogLevel != "" {
+		flagsMap[defaultLogLevelFlag] = conf.Rpk.RedpandaLogLevel
+	}
+	if !flags.Changed(loggerLogLevelFlag) && len(conf.Rpk.LoggerLogLevels) > 0 {
+		flagsMap[loggerLogLevelFlag] = strings.Join(conf.Rpk.LoggerLogLevels, ":")
+	}
+	return flagsMap, nil
+}
+
+// Sets the --sandbox flags in flagsMap, overriding the config's values, except
+// for the ones passed explicitly or in rpk.additional_start_flags. Returns the
+// ones it set.
+func applySandboxFlags(
+	flagsMap map[string]interface{}, flags *pflag.FlagSet, conf *config.Config,
+) map[string]bool {
+	additional := rp.ParseFlags(conf.Rpk.AdditionalStartFlags)
+	sandboxed := map[string]bool{}
+	for n, v := range sandboxFlags {
+		if _, ok := additional[n]; ok || flags.Changed(cliFlagName(n)) {
+			continue
+		}
+		flagsMap[n] = v
+		sandboxed[n] = true
+	}
+	return sandboxed
+}
+
+// Drops --smp if it comes from rpk.smp and a cpuset is set, since the cpuset
+// already constrains the CPUs redpanda runs on. An --smp passed explicitly is
+// kept, and checked against the cpuset by validateFlagCombinations.
+func dropConfigSmpWithCpuset(
+	finalFlags map[string]string, sources map[string]flagSource,
+) {
+	_, cpusetSet := finalFlags[cpuSetFlag]
+	smp, smpSet := finalFlags[smpFlag]
+	if !cpusetSet || !smpSet || sources[smpFlag] != configSource {
+		return
+	}
+	log.Infof(
+		"Ignoring rpk.smp (%s), as --%s=%s (from %s) already"+
+			" constrains the CPUs redpanda runs on",
+		smp,
+		cpuSetFlag,
+		finalFlags[cpuSetFlag],
+		sources[cpuSetFlag],
+	)
+	delete(finalFlags, smpFlag)
+	delete(sources, smpFlag)
+}
+
+// Checks the IO properties against the final flags, so that the ones set in
+// rpk.additional_start_flags, --seastar-flag, --override or a preset count
+// too. Deduced IO properties yield to those, and if there are none and they
+// couldn't be deduced, starting fails when strict is set.
+func checkIoProps(
+	finalFlags map[string]string,
+	sources map[string]flagSource,
+	strict bool,
+	deduceErr error,
+) error {
+	ioFlags := []string{ioPropertiesFileFlag, ioPropertiesFlag}
+	explicit := false
+	for _, n := range ioFlags {
+		if _, ok := finalFlags[n]; ok && sources[n] != deducedSource {
+			explicit = true
+		}
+	}
+	if explicit {
+		for _, n := range ioFlags {
+			if sources[n] == deducedSource {
+				delete(finalFlags, n)
+				delete(sources, n)
+			}
+		}
+		return nil
+	}
+	if deduceErr == nil {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf(
+			"couldn't deduce the IO properties: %v. Pass"+
+				" --io-properties-file, --io-properties or"+
+				" --well-known-io, or unset --%s"+
+				" (rpk.strict_io_properties)",
+			deduceErr,
+			strictIOPropsFlag,
+		)
+	}
+	log.Warn(deduceErr)
+	return nil
+}
+
+// Checks --default-log-level and the levels in --logger-log-level, which
+// look like 'raft=debug:kafka=trace'.
+func validateLogLevels(flagsMap map[string]interface{}) error {
+	if level, ok := flagsMap[defaultLogLevelFlag]; ok {
+		err := validateLogLevel(fmt.Sprint(level))
+		if err != nil {
+			return err
+		}
+	}
+	loggers, ok := flagsMap[loggerLogLevelFlag]
+	if !ok {
+		return nil
+	}
+	for _, l := range strings.Split(fmt.Sprint(loggers), ":") {
+		parts := strings.SplitN(l, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf(
+				"invalid logger level '%s'. It must have the format"+
+					" <logger>=<level>",
+				l,
+			)
+		}
+		err := validateLogLevel(parts[1])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fails on the combinations of the resolved seastar flags which redpanda would
+// reject, or which wouldn't do what's expected, naming where each of the
+// conflicting flags came from.
+func validateFlagCombinations(
+	finalFlags map[string]string, sources map[string]flagSource,
+) error {
+	conflict := func(a, b, reason string) error {
+		return fmt.Errorf(
+			"--%s=%s (from %s) and --%s=%s (from %s) conflict: %s",
+			a,
+			finalFlags[a],
+			sources[a],
+			b,
+			finalFlags[b],
+			sources[b],
+			reason,
+		)
+	}
+	cpuset, cpusetSet := finalFlags[cpuSetFlag]
+	if smp, ok := finalFlags[smpFlag]; ok && cpusetSet {
+		cpus, err := hwloc.CpusInList(cpuset)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(smp)
+		if err != nil {
+			return fmt.Errorf("invalid --%s '%s'", smpFlag, smp)
+		}
+		if n > len(cpus) {
+			return conflict(
+				smpFlag,
+				cpuSetFlag,
+				fmt.Sprintf(
+					"there are more shards than the %d CPUs"+
+						" in the cpuset",
+					len(cpus),
+				),
+			)
+		}
+	}
+	if affinity, ok := finalFlags[threadAffinityFlag]; ok && cpusetSet {
+		enabled, err := strconv.ParseBool(affinity)
+		if err != nil {
+			return fmt.Errorf(
+				"invalid --%s '%s'",
+				threadAffinityFlag,
+				affinity,
+			)
+		}
+		if !enabled {
+			return conflict(
+				threadAffinityFlag,
+				cpuSetFlag,
+				"the shards aren't pinned to the cpuset's CPUs"+
+					" without thread affinity",
+			)
+		}
+	}
+	_, propsSet := finalFlags[ioPropertiesFlag]
+	_, propsFileSet := finalFlags[ioPropertiesFileFlag]
+	if propsSet && propsFileSet {
+		return fmt.Errorf(
+			"--%s (from %s) and --%s (from %s) conflict: only one"+
+				" of them may be set",
+			ioPropertiesFlag,
+			sources[ioPropertiesFlag],
+			ioPropertiesFileFlag,
+			sources[ioPropertiesFileFlag],
+		)
+	}
+	return nil
+}
+
+func validateLogLevel(level string) error {
+	for _, l := range logLevels {
+		if level == l {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"invalid log level '%s'. Available levels: %s",
+		level,
+		strings.Join(logLevels, ", "),
+	)
+}
+
+// Resolves a percentage of the total memory, such as "80%" or "80", to a
+// value for --memory, rounded down to the MiB.
+func memoryFromPercent(fs afero.Fs, percent string) (string, error) {
+	p, err := parseMemoryPercent(percent)
+	if err != nil {
+		return "", err
+	}
+	totalMB, err := system.GetMemTotalMB(fs)
+	if err != nil {
+		return "", err
+	}
+	memory := fmt.Sprintf("%dM", int(float64(totalMB)*p/100))
+	log.Infof(
+		"Setting --memory to %s (%s of %dM)",
+		memory,
+		percent,
+		totalMB,
+	)
+	return memory, nil
+}
+
+func parseMemoryPercent(percent string) (float64, error) {
+	p, err := strconv.ParseFloat(
+		strings.TrimSuffix(strings.TrimSpace(percent), "%"),
+		64,
+	)
+	if err != nil || p <= 0 || p > 100 {
+		return 0, fmt.Errorf(
+			"invalid memory percentage '%s'. It must be a number"+
+				" between 0 (exclusive) and 100, optionally followed"+
+				" by '%%'",
+			percent,
+		)
+	}
+	return p, nil
+}
+
+// Overlays the flags in overrides on current, so that they take precedence,
+// and returns them. overrides may be in any of the formats parseFlags accepts.
+func mergeFlags(
+	current map[string]string, overrides []string,
+) map[string]string {
+	parsed := rp.ParseFlags(overrides)
+	for k, v := range parsed {
+		current[k] = v
+	}
+	return parsed
+}
+
+// Merges the given IO properties files into one in the data directory,
+// overwriting the one merged on the previous start, and returns its path.
+func mergeIoPropertiesFiles(
+	fs afero.Fs, conf *config.Config, paths []string,
+) (string, error) {
+	disks, err := iotune.MergeFiles(fs, paths)
+	if err != nil {
+		return "", err
+	}
+	merged, err := iotune.DisksToYaml(disks)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(
+		conf.Redpanda.Directory,
+		ioPropertiesCacheDir,
+		mergedIoPropertiesFile,
+	)
+	err = fs.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return "", err
+	}
+	err = afero.WriteFile(fs, path, []byte(merged), 0644)
+	if err != nil {
+		return "", err
+	}
+	log.Infof(
+		"Merged the IO properties in %s into '%s'",
+		strings.Join(paths, ", "),
+		path,
+	)
+	return path, nil
+}
+
+// Returns a local path for the IO properties file at path. If it's an http(s)://
+// or s3:// URL, it's downloaded into the data directory, and the downloaded copy
+// is used on the following starts instead of downloading it again.
+func localIoPropertiesFile(
+	fs afero.Fs, conf *config.Config, path string,
+) (string, error) {
+	if !cloud.IsURL(path) {
+		return path, nil
+	}
+	sum := sha256.Sum256([]byte(path))
+	cached := filepath.Join(
+		conf.Redpanda.Directory,
+		ioPropertiesCacheDir,
+		hex.EncodeToString(sum[:8])+".yaml",
+	)
+	if exists, _ := afero.Exists(fs, cached); exists {
+		log.Infof(
+			"Using the IO properties downloaded from '%s' to '%s'."+
+				" Delete it to download them again",
+			path,
+			cached,
+		)
+		return cached, nil
+	}
+	bs, err := cloud.Fetch(path, ioPropertiesFetchTimeout)
+	if err != nil {
+		return "", fmt.Errorf(
+			"couldn't download the IO properties file from '%s': %v",
+			path,
+			err,
+		)
+	}
+	err = fs.MkdirAll(filepath.Dir(cached), 0755)
+	if err != nil {
+		return "", err
+	}
+	// Write it under a temporary name until it's validated, so that an
+	// invalid download isn't cached.
+	tmp := cached + ".tmp"
+	err = afero.WriteFile(fs, tmp, bs, 0644)
+	if err != nil {
+		return "", err
+	}
+	_, err = iotune.ReadFile(fs, tmp)
+	if err != nil {
+		fs.Remove(tmp)
+		return "", fmt.Errorf(
+			"the IO properties file downloaded from '%s' is invalid: %v",
+			path,
+			err,
+		)
+	}
+	err = fs.Rename(tmp, cached)
+	if err != nil {
+		return "", err
+	}
+	log.Infof("Downloaded the IO properties from '%s' to '%s'", path, cached)
+	return cached, nil
+}
+
+// Returns the flags set by the given presets, which are read from the presets
+// file next to the config. A preset overrides the flags set by the ones before
+// it.
+func presetFlags(
+	fs afero.Fs, conf *config.Config, names []string,
+) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if len(names) == 0 {
+		return values, nil
+	}
+	path := rp.GetPresetsPath(filepath.Dir(conf.ConfigFile))
+	presets, err := readPresets(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		preset, ok := presets[name]
+		if !ok {
+			available := make([]string, 0, len(presets))
+			for p := range presets {
+				available = append(available, p)
 			}
+			sort.Strings(available)
+			return nil, fmt.Errorf(
+				"unknown preset '%s'. The ones in '%s' are: %s",
+				name,
+				path,
+				strings.Join(available, ", "),
+			)
+		}
+		for n, v := range preset {
+			values[n] = v
 		}
 	}
-	flagsMap := flagsMap(sFlags)
-	for flag := range flagsMap {
-		if !flags.Changed(flag) {
-			delete(flagsMap, flag)
+	return values, nil
+}
+
+// Reads the presets file, a YAML map of preset names to maps of flag names to
+// values, e.g.
+//
+// latency:
+//   poll-mode: true
+//   idle-poll-time-us: 0
+//
+// The flags may be prefixed with dashes, which are dropped.
+func readPresets(
+	fs afero.Fs, path string,
+) (map[string]map[string]interface{}, error) {
+	if exists, _ := afero.Exists(fs, path); !exists {
+		return nil, fmt.Errorf(
+			"--%s was passed, but there's no presets file at '%s'",
+			presetFlag,
+			path,
+		)
+	}
+	bs, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]map[string]interface{}{}
+	err = yaml.Unmarshal(bs, &raw)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't parse the presets file '%s': %v",
+			path,
+			err,
+		)
+	}
+	presets := make(map[string]map[string]interface{}, len(raw))
+	for name, flags := range raw {
+		preset := make(map[string]interface{}, len(flags))
+		for n, v := range flags {
+			flag := strings.TrimLeft(n, "-")
+			if flag == "" {
+				return nil, fmt.Errorf(
+					"preset '%s' in '%s' has a flag with no"+
+						" name",
+					name,
+					path,
+				)
+			}
+			preset[flag] = v
 		}
+		presets[name] = preset
 	}
-	flagsMap = flagsFromConf(conf, flagsMap, flags)
-	finalFlags := parseFlags(conf.Rpk.AdditionalStartFlags)
-	for n, v := range flagsMap {
-		if _, alreadyPresent := finalFlags[n]; alreadyPresent {
-			return nil, fmt.Errorf(
-				"Configuration conflict. Flag '--%s'"+
-					" is also present in"+
-					" 'rpk.additional_start_flags' in"+
-					" configuration file '%s'. Please"+
-					" remove it and pass '--%s' directly"+
-					" to `rpk start`.",
-				n,
-				conf.ConfigFile,
-				n,
+	return presets, nil
+}
+
+// Returns the value for --io-properties. redpanda is exec'd directly, with
+// no shell in between, so the YAML is passed verbatim as a single argument and
+// mustn't be quoted.
+func ioPropertiesFlagValue(ioProps *iotune.IoProperties) (string, error) {
+	return iotune.ToYaml(*ioProps)
+}
+
+// The IO properties deduced from rpk.well_known_io or the detected cloud VM,
+// as cached in the data directory.
+type wellKnownIoCache struct {
+	DataDirectory	string	`yaml:"data_directory"`
+	// The rpk.well_known_io value they were deduced from. Empty if they
+	// were deduced from the detected cloud VM.
+	WellKnownIo	string	`yaml:"well_known_io"`
+	// The IO profile they belong to, <vendor>:<vm type>:<storage type>.
+	Profile		string			`yaml:"profile"`
+	Vendor		string			`yaml:"vendor"`
+	VmType		string			`yaml:"vm_type"`
+	VM		vmIdentity		`yaml:",inline"`
+	ResolvedAt	time.Time		`yaml:"resolved_at"`
+	Properties	iotune.IoProperties	`yaml:"properties"`
+}
+
+// Tells the VM the IO properties were detected on apart from others, e.g. if
+// the data directory's disk is moved to another one.
+type vmIdentity struct {
+	Hostname	string	`yaml:"hostname"`
+	// Empty if cloud-init didn't run on the VM.
+	InstanceID	string	`yaml:"instance_id"`
+}
+
+// Returns the current VM's identity, read locally so that it's cheap to check
+// on every start.
+func currentVmIdentity(fs afero.Fs) vmIdentity {
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Debugf("Couldn't read the hostname: %v", err)
+	}
+	id := ""
+	bs, err := afero.ReadFile(fs, cloudInitInstanceIDFile)
+	if err == nil {
+		id = strings.TrimSpace(string(bs))
+	}
+	return vmIdentity{Hostname: hostname, InstanceID: id}
+}
+
+// Whether the cached properties can be used with conf on the given VM at the
+// given time, i.e. they haven't expired and were deduced for the same data
+// directory and rpk.well_known_io. If they were deduced from the detected
+// cloud VM, it must be the same one, with a known vendor and VM type.
+func (c *wellKnownIoCache) validFor(
+	conf *config.Config, vm vmIdentity, now time.Time,
+) bool {
+	if c.WellKnownIo == "" &&
+		(c.Vendor == "" || c.VmType == "" || c.VM != vm) {
+		return false
+	}
+	return c.DataDirectory == conf.Redpanda.Directory &&
+		c.WellKnownIo == conf.Rpk.WellKnownIo &&
+		!now.Before(c.ResolvedAt) &&
+		now.Sub(c.ResolvedAt) < wellKnownIoCacheTTL
+}
+
+// Returns the IO properties cached in the data directory if they're still
+// valid, so that the cloud vendor's metadata API isn't queried on every start.
+// Otherwise, or if refresh or choose is true, they're deduced again and
+// cached. Failing to read or write the cache isn't an error.
+func cachedWellKnownIo(
+	fs afero.Fs, conf *config.Config, choose, refresh bool,
+) (*iotune.IoProperties, error) {
+	path := filepath.Join(conf.Redpanda.Directory, wellKnownIoCacheFile)
+	vm := currentVmIdentity(fs)
+	if !choose && !refresh {
+		cached, err := readWellKnownIoCache(fs, path)
+		if err != nil {
+			log.Debugf("Ignoring the cached IO properties: %v", err)
+		} else if cached != nil && cached.validFor(conf, vm, time.Now()) {
+			log.Infof(
+				"Using IO profile '%s', cached in '%s'. Pass --%s"+
+					" to deduce it again",
+				cached.Profile,
+				path,
+				refreshIoFlag,
 			)
+			return &cached.Properties, nil
 		}
-		finalFlags[n] = fmt.Sprint(v)
 	}
-	return &rp.RedpandaArgs{
-		ConfigFilePath:	conf.ConfigFile,
-		SeastarFlags:	finalFlags,
-	}, nil
+	candidate, err := resolveWellKnownIo(conf, choose)
+	if err != nil {
+		return nil, err
+	}
+	err = writeWellKnownIoCache(fs, path, &wellKnownIoCache{
+		DataDirectory:	conf.Redpanda.Directory,
+		WellKnownIo:	conf.Rpk.WellKnownIo,
+		Profile:	candidate.String(),
+		Vendor:		candidate.Vendor,
+		VmType:		candidate.VM,
+		VM:		vm,
+		ResolvedAt:	time.Now(),
+		Properties:	candidate.Props,
+	})
+	if err != nil {
+		log.Warnf("Couldn't cache the IO properties in '%s': %v", path, err)
+	}
+	return &candidate.Props, nil
 }
 
-func flagsFromConf(
-	conf *config.Config, flagsMap map[string]interface{}, flags *pflag.FlagSet,
-) map[string]interface{} {
-	flagsMap[overprovisionedFlag] = conf.Rpk.Overprovisioned
-	flagsMap[lockMemoryFlag] = conf.Rpk.EnableMemoryLocking
-	// Setting SMP to 0 doesn't make sense.
-	if !flags.Changed(smpFlag) && conf.Rpk.SMP != nil && *conf.Rpk.SMP != 0 {
-		flagsMap[smpFlag] = *conf.Rpk.SMP
+// Returns nil if there's no cache at path.
+func readWellKnownIoCache(fs afero.Fs, path string) (*wellKnownIoCache, error) {
+	if exists, _ := afero.Exists(fs, path); !exists {
+		return nil, nil
+	}
+	bs, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
 	}
-	return flagsMap
+	cached := &wellKnownIoCache{}
+	err = yaml.Unmarshal(bs, cached)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse '%s': %v", path, err)
+	}
+	return cached, nil
 }
 
-func mergeFlags(
-	current map[string]interface{}, overrides []string,
-) map[string]interface{} {
-	overridesMap := map[string]string{}
-	for _, o := range overrides {
-		pattern := regexp.MustCompile(`[\s=]+`)
-		parts := pattern.Split(o, 2)
-		flagName := strings.ReplaceAll(parts[0], "--", "")
-		if len(parts) == 2 {
-			overridesMap[flagName] = parts[1]
-		} else {
-			overridesMap[flagName] = ""
-		}
+func writeWellKnownIoCache(
+	fs afero.Fs, path string, cached *wellKnownIoCache,
+) error {
+	bs, err := yaml.Marshal(cached)
+	if err != nil {
+		return err
 	}
-	for k, v := range overridesMap {
-		current[k] = v
+	err = fs.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
 	}
-	return current
+	return afero.WriteFile(fs, path, bs, 0644)
 }
 
-func resolveWellKnownIo(conf *config.Config) (*iotune.IoProperties, error) {
-	var ioProps *iotune.IoProperties
+// Returns the IO profile to use, from rpk.well_known_io if it's set, or the
+// detected cloud VM otherwise.
+func resolveWellKnownIo(
+	conf *config.Config, choose bool,
+) (*iotune.Candidate, error) {
 	if conf.Rpk.WellKnownIo != "" {
-		wellKnownIoTokens := strings.Split(conf.Rpk.WellKnownIo, ":")
-		if len(wellKnownIoTokens) != 3 {
-			err := errors.New(
-				"--well-known-io should have the format '<vendor>:<vm type>:<storage type>'",
-			)
-			return nil, err
-		}
-		ioProps, err := iotune.DataFor(
+		return wellKnownIoCandidate(
 			conf.Redpanda.Directory,
-			wellKnownIoTokens[0],
-			wellKnownIoTokens[1],
-			wellKnownIoTokens[2],
+			conf.Rpk.WellKnownIo,
 		)
-		if err != nil {
-			// Log the error to let the user know that the data wasn't found
-			return nil, err
-		}
-		return ioProps, nil
 	}
 	log.Info("Detecting the current cloud vendor and VM")
 	vendor, err := cloud.AvailableVendor()
 	if err != nil {
 		return nil, errors.New("Could not detect the current cloud vendor")
 	}
-	ioProps, err = iotune.DataForVendor(conf.Redpanda.Directory, vendor)
+	candidates, err := iotune.CandidatesForVendor(
+		conf.Redpanda.Directory,
+		vendor,
+	)
 	if err != nil {
 		// Log the error to let the user know that the data wasn't found
 		return nil, err
 	}
-	return ioProps, nil
+	return selectIoCandidate(candidates, choose, os.Stdin)
+}
+
+// Returns the IO properties for a --well-known-io value. The storage type may
+// be omitted (e.g. 'aws:i3.large'), in which case the VM type's default or
+// most common one is picked.
+func wellKnownIoData(
+	mountPoint, wellKnownIo string,
+) (*iotune.IoProperties, error) {
+	candidate, err := wellKnownIoCandidate(mountPoint, wellKnownIo)
+	if err != nil {
+		return nil, err
+	}
+	return &candidate.Props, nil
+}
+
+// Returns the IO profile a --well-known-io value resolves to.
+func wellKnownIoCandidate(
+	mountPoint, wellKnownIo string,
+) (*iotune.Candidate, error) {
+	tokens := strings.Split(wellKnownIo, ":")
+	if len(tokens) < 2 || len(tokens) > 3 {
+		return nil, errors.New(
+			"--well-known-io should have the format '<vendor>:<vm type>:<storage type>'",
+		)
+	}
+	if tokens[1] == "" {
+		return nil, fmt.Errorf(
+			"--well-known-io requires the VM type, but '%s' has"+
+				" none. Run 'rpk iotune list-well-known --vendor"+
+				" %s' to list the known ones",
+			wellKnownIo,
+			tokens[0],
+		)
+	}
+	if len(tokens) == 3 && tokens[2] != "" {
+		props, err := iotune.DataFor(
+			mountPoint,
+			tokens[0],
+			tokens[1],
+			tokens[2],
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &iotune.Candidate{
+			Vendor:		tokens[0],
+			VM:		tokens[1],
+			Storage:	tokens[2],
+			Props:		*props,
+		}, nil
+	}
+	candidates, err := iotune.CandidatesFor(mountPoint, tokens[0], tokens[1])
+	if err != nil {
+		return nil, err
+	}
+	best, reason := iotune.BestCandidate(candidates)
+	log.Infof(
+		"Resolved --well-known-io '%s' to '%s', since %s. Set it to"+
+			" the latter to pin it",
+		wellKnownIo,
+		candidates[best],
+		reason,
+	)
+	return &candidates[best], nil
+}
+
+// Selects the IO profile to use among the ones which match the current VM. If
+// choose is true and there's more than one, the user is asked which one to
+// use. Otherwise, the best one is picked.
+func selectIoCandidate(
+	candidates []iotune.Candidate, choose bool, in io.Reader,
+) (*iotune.Candidate, error) {
+	best, reason := iotune.BestCandidate(candidates)
+	if choose && len(candidates) > 1 && ui.Interactive() {
+		i, err := promptIoCandidate(candidates, best, in)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Using IO profile '%s'", candidates[i])
+		return &candidates[i], nil
+	}
+	log.Infof("Using IO profile '%s', since %s", candidates[best], reason)
+	return &candidates[best], nil
+}
+
+// Asks the user which of the given IO profiles to use, defaulting to the one
+// at index def.
+func promptIoCandidate(
+	candidates []iotune.Candidate, def int, in io.Reader,
+) (int, error) {
+	scanner := bufio.NewScanner(in)
+	for {
+		log.Info("More than one IO profile matches this VM:")
+		for i, c := range candidates {
+			log.Infof("  %d) %s", i+1, c)
+		}
+		log.Infof(
+			"Which one should be used? (1-%d, default %d, q to quit)",
+			len(candidates),
+			def+1,
+		)
+		scanner.Scan()
+		if scanner.Err() != nil {
+			return 0, scanner.Err()
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			return def, nil
+		}
+		if strings.ToLower(text) == "q" {
+			return 0, errors.New("user exited")
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil || n < 1 || n > len(candidates) {
+			log.Infof("Unrecognized option '%s'", text)
+			continue
+		}
+		return n - 1, nil
+	}
+}
+
+// Prints the IO profiles which match the current VM, and which one would be
+// used.
+func printIoCandidates(conf *config.Config) error {
+	log.Info("Detecting the current cloud vendor and VM")
+	vendor, err := cloud.AvailableVendor()
+	if err != nil {
+		return errors.New("Could not detect the current cloud vendor")
+	}
+	candidates, err := iotune.CandidatesForVendor(
+		conf.Redpanda.Directory,
+		vendor,
+	)
+	if err != nil {
+		return err
+	}
+	printIoCandidatesTable(os.Stdout, candidates)
+	best, reason := iotune.BestCandidate(candidates)
+	log.Infof("rpk would use '%s', since %s", candidates[best], reason)
+	if conf.Rpk.WellKnownIo != "" {
+		log.Infof(
+			"rpk.well_known_io is set to '%s' though, so it's used"+
+				" instead",
+			conf.Rpk.WellKnownIo,
+		)
+	}
+	return nil
+}
+
+// Fails if any of the CPUs in cpuset (e.g. '0-3,8'), passed with the given
+// flag, isn't available on this machine according to hwloc, which seastar or
+// the tuners would otherwise fail on later.
+func validateCpuset(hw hwloc.HwLoc, flag, cpuset string) error {
+	if cpuset == "all" {
+		return nil
+	}
+	if !hw.IsSupported() {
+		log.Debugf(
+			"Skipping the --%s validation, since hwloc isn't installed",
+			flag,
+		)
+		return nil
+	}
+	all, err := hw.All()
+	if err != nil {
+		return err
+	}
+	missing, err := hwloc.CpusMissingFromMask(cpuset, all)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	cpus := make([]string, 0, len(missing))
+	for _, cpu := range missing {
+		cpus = append(cpus, fmt.Sprint(cpu))
+	}
+	return fmt.Errorf(
+		"--%s %s includes CPUs which aren't available on this machine: %s",
+		flag,
+		cpuset,
+		strings.Join(cpus, ", "),
+	)
+}
+
+// Fails if the redpanda binary in installDir is older than
+// rpk.min_redpanda_version.
+func checkRedpandaVersion(
+	conf *config.Config, installDir string, timeout time.Duration,
+) error {
+	if conf.Rpk.MinRedpandaVersion == "" {
+		log.Warnf(
+			"Skipping the version check, since"+
+				" rpk.min_redpanda_version isn't set in '%s'",
+			conf.ConfigFile,
+		)
+		return nil
+	}
+	err := rp.CheckMinVersion(
+		vos.NewProc(),
+		timeout,
+		rp.BinaryPath(installDir),
+		conf.Rpk.MinRedpandaVersion,
+	)
+	if err != nil {
+		return err
+	}
+	log.Info("Version check - PASSED")
+	return nil
+}
+
+// Returns a function which decides whether the given disruptive tuner should
+// run. If assumeYes is false, the user is asked for confirmation when running
+// interactively, and the tuner is skipped otherwise.
+func confirmDisruptiveTuner(
+	assumeYes bool, interactive bool, in io.Reader,
+) func(string) (bool, error) {
+	return func(tunerName string) (bool, error) {
+		if assumeYes {
+			return true, nil
+		}
+		if !interactive {
+			log.Warnf(
+				"Skipping disruptive tuner '%s'. Pass --assume-yes"+
+					" to run it",
+				tunerName,
+			)
+			return false, nil
+		}
+		return promptConfirmation(
+			fmt.Sprintf(
+				"Tuner '%s' may disrupt other workloads running on"+
+					" this machine. Run it anyway?",
+				tunerName,
+			),
+			in,
+		)
+	}
 }
 
 func tuneAll(
-	fs afero.Fs, cpuSet string, conf *config.Config, timeout time.Duration,
+	fs afero.Fs,
+	cpuSet string,
+	conf *config.Config,
+	profile string,
+	timeout time.Duration,
+	confirmDisruptive func(string) (bool, error),
 ) ([]api.TunerPayload, error) {
-	params := &factory.TunerParams{}
+	tuneProfile, err := tuners.ParseTuneProfile(profile)
+	if err != nil {
+		return []api.TunerPayload{}, err
+	}
+	params := &factory.TunerParams{Profile: tuneProfile}
 	tunerFactory := factory.NewDirectExecutorTunersFactory(fs, *conf, timeout)
 	hw := hwloc.NewHwLocCmd(vos.NewProc(), timeout)
 	if cpuSet == "" {
@@ -539,18 +2759,54 @@ func tuneAll(
 		if err != nil {
 			return []api.TunerPayload{}, err
 		}
+		err = validateCpuset(hw, cpuSetFlag, cpuSet)
+		if err != nil {
+			return []api.TunerPayload{}, err
+		}
 		params.CpuMask = cpuMask
 	}
 
-	err := factory.FillTunerParamsWithValuesFromConfig(params, conf)
+	err = factory.FillTunerParamsWithValuesFromConfig(params, conf)
 	if err != nil {
 		return []api.TunerPayload{}, err
 	}
+	for name := range conf.Rpk.TunerTimeouts {
+		if !factory.IsTunerAvailable(name) {
+			log.Warnf(
+				"Ignoring rpk.tuner_timeouts.%s, as there's no"+
+					" tuner named '%s'",
+				name,
+				name,
+			)
+		}
+	}
 
-	availableTuners := factory.AvailableTuners()
-	tunerPayloads := make([]api.TunerPayload, len(availableTuners))
+	return runTuners(
+		fs,
+		conf,
+		factory.AvailableTuners(),
+		tunerFactory,
+		params,
+		timeout,
+		confirmDisruptive,
+	)
+}
+
+// Runs the given tuners, returning a payload for each of them, including the
+// ones which are skipped, up to the first one which fails. Each tuner is given
+// timeout, unless it's overridden in rpk.tuner_timeouts.
+func runTuners(
+	fs afero.Fs,
+	conf *config.Config,
+	tunerNames []string,
+	tunerFactory factory.TunersFactory,
+	params *factory.TunerParams,
+	timeout time.Duration,
+	confirmDisruptive func(string) (bool, error),
+) ([]api.TunerPayload, error) {
+	tunerPayloads := make([]api.TunerPayload, 0, len(tunerNames))
 
-	for _, tunerName := range availableTuners {
+	for _, tunerName := range tunerNames {
 		enabled := factory.IsTunerEnabled(tunerName, conf.Rpk)
 		tuner := tunerFactory.CreateTuner(tunerName, params)
 		supported, reason := tuner.CheckIfSupported()
@@ -569,13 +2825,51 @@ func tuneAll(
 			tunerPayloads = append(tunerPayloads, payload)
 			continue
 		}
+		if tuners.IsDisruptive(tuner) {
+			confirmed, err := confirmDisruptive(tunerName)
+			if err != nil {
+				return tunerPayloads, err
+			}
+			if !confirmed {
+				payload.Skipped = true
+				tunerPayloads = append(tunerPayloads, payload)
+				continue
+			}
+		}
+		tunerTimeout, err := factory.TunerTimeout(tunerName, conf.Rpk, timeout)
+		if err != nil {
+			payload.ErrorMsg = err.Error()
+			tunerPayloads = append(tunerPayloads, payload)
+			return tunerPayloads, err
+		}
 		log.Debugf("Tuner parameters %+v", params)
-		result := tuner.Tune()
+		start := time.Now()
+		result, progress := tuners.TuneWithTimeout(tuner, tunerTimeout)
+		took := time.Since(start)
+		payload.DurationMs = took.Milliseconds()
+		log.Debugf("Tuner '%s' took %s", tunerName, took)
 		if result.IsFailed() {
 			payload.ErrorMsg = result.Error().Error()
+			payload.Progress = progress.String()
 			tunerPayloads = append(tunerPayloads, payload)
 			return tunerPayloads, result.Error()
 		}
+		payload.Details = tuners.TuneDetails(tuner)
+		err = tuners.RecordPreviousValues(
+			fs,
+			tuners.TuneStatePath(conf),
+			tunerName,
+			tuner,
+		)
+		if err != nil {
+			log.Warnf(
+				"Couldn't persist the values replaced by '%s', so it"+
+					" can't be reverted: %v",
+				tunerName,
+				err,
+			)
+		}
+		tunerPayloads = append(tunerPayloads, payload)
 	}
 	return tunerPayloads, nil
 }
@@ -583,10 +2877,20 @@ func tuneAll(
 type checkFailedAction func(*tuners.CheckResult)
 
 func checkFailedActions(
-	args *rp.RedpandaArgs,
+	args *rp.RedpandaArgs, conf *config.Config,
 ) map[tuners.CheckerID]checkFailedAction {
 	return map[tuners.CheckerID]checkFailedAction{
 		tuners.SwapChecker: func(*tuners.CheckResult) {
+			onSwapFailure := conf.Rpk.LockMemoryOnSwapFailure
+			if onSwapFailure != nil && !*onSwapFailure {
+				log.Warnf(
+					"Keeping --%s despite the swap check failing,"+
+						" since rpk.lock_memory_on_swap_failure"+
+						" is false",
+					lockMemoryFlag,
+				)
+				return
+			}
 			// Do not set --lock-memory flag when swap is disabled
 			args.SeastarFlags[lockMemoryFlag] = "false"
 		},
@@ -597,23 +2901,17 @@ func check(
 	fs afero.Fs,
 	conf *config.Config,
 	timeout time.Duration,
+	filter tuners.CheckFilter,
 	checkFailedActions map[tuners.CheckerID]checkFailedAction,
+	failOnWarn bool,
 ) ([]api.CheckPayload, error) {
 	payloads := make([]api.CheckPayload, 0)
-	results, err := tuners.Check(fs, conf, timeout)
+	results, err := tuners.CheckFiltered(fs, conf, timeout, filter)
 	if err != nil {
 		return payloads, err
 	}
 	for _, result := range results {
-		payload := api.CheckPayload{
-			Name:		result.Desc,
-			Current:	result.Current,
-			Required:	result.Required,
-		}
-		if result.Err != nil {
-			payload.ErrorMsg = result.Err.Error()
-		}
-		payloads = append(payloads, payload)
+		payloads = append(payloads, checkPayload(result))
 		if !result.IsOk {
 			if action, exists := checkFailedActions[result.CheckerId]; exists {
 				action(&result)
@@ -626,53 +2924,49 @@ func check(
 			log.Warn(msg)
 		}
 	}
+	if failOnWarn {
+		return payloads, failedChecksError(results)
+	}
 	return payloads, nil
 }
 
-func parseFlags(flags []string) map[string]string {
-	parsed := map[string]string{}
-	for i := 0; i < len(flags); i++ {
-		f := flags[i]
-		isFlag := strings.HasPrefix(f, "-")
-		trimmed := strings.Trim(f, " -")
-
-		// Filter out elements that aren't flags or are empty.
-		if !isFlag || trimmed == "" {
-			continue
-		}
-
-		// Check if it's in name=value format
-		parts := strings.Split(trimmed, "=")
-		if len(parts) >= 2 {
-			name := strings.Trim(parts[0], " ")
-			value := strings.Trim(parts[1], " ")
-			parsed[name] = value
-			continue
-		}
-		// Otherwise, it can be a boolean flag (i.e. -v) or in
-		// name<space>value format
+func checkPayload(result tuners.CheckResult) api.CheckPayload {
+	payload := api.CheckPayload{
+		Name:		result.Desc,
+		Current:	result.Current,
+		Required:	result.Required,
+		Details:	result.Details,
+	}
+	if result.Err != nil {
+		payload.ErrorMsg = result.Err.Error()
+	}
+	return payload
+}
 
-		if i == len(flags)-1 {
-			// We've reached the last element, so it's a single flag
-			parsed[trimmed] = "true"
-			continue
+// Parses the --seastar-flag values, in the format '<name>=<value>', or
+// '<name>' for boolean flags, into their names and values. The values are
+// passed to redpanda as they are.
+func parseSeastarFlagValues(values []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		name := strings.TrimLeft(strings.TrimSpace(parts[0]), "-")
+		if name == "" {
+			return nil, fmt.Errorf(
+				"--%s '%s' has no flag name. It must be in the"+
+					" format <name>=<value>, or <name> for"+
+					" boolean flags",
+				seastarFlagFlag,
+				v,
+			)
 		}
-
-		// Check if the next element starts with a hyphen
-		// If it does, it's another flag, and the current element is a
-		// boolean flag
-		next := flags[i+1]
-		if strings.HasPrefix(next, "-") {
-			parsed[trimmed] = "true"
+		if len(parts) == 1 {
+			parsed[name] = "true"
 			continue
 		}
-
-		// Otherwise, the current element is the name of the flag and
-		// the next one is its value
-		parsed[trimmed] = next
-		i += 1
+		parsed[name] = parts[1]
 	}
-	return parsed
+	return parsed, nil
 }
 
 func parseSeeds(seeds []string) ([]config.SeedServer, error) {
@@ -747,6 +3041,11 @@ func parseAddress(addr string, defaultPort int) (*config.SocketAddress, error) {
 	}, nil
 }
 
+// Sends the environment data, e.g. api.SendEnvironment.
+type envSender func(
+	fs afero.Fs, env api.EnvironmentPayload, conf config.Config, confJSON string,
+) error
+
 func sendEnv(
 	fs afero.Fs,
 	mgr config.Manager,
@@ -754,6 +3053,23 @@ func sendEnv(
 	conf *config.Config,
 	err error,
 ) {
+	sendEnvWith(api.SendEnvironment, fs, mgr, env, conf, err)
+}
+
+func sendEnvWith(
+	send envSender,
+	fs afero.Fs,
+	mgr config.Manager,
+	env api.EnvironmentPayload,
+	conf *config.Config,
+	err error,
+) {
+	// Don't bother reading the config and gathering the system's info if
+	// it won't be sent, e.g. in air-gapped clusters.
+	if !conf.Rpk.EnableUsageStats {
+		log.Debug("Sending usage stats is disabled.")
+		return
+	}
 	if err != nil {
 		env.ErrorMsg = err.Error()
 	}
@@ -776,7 +3092,7 @@ func sendEnv(
 		}
 		confJSON = string(confBytes)
 	}
-	err = api.SendEnvironment(fs, env, *conf, confJSON)
+	err = send(fs, env, *conf, confJSON)
 	if err != nil {
 		log.Debugf("couldn't send environment data: %v", err)
 	}
@@ -11,15 +11,31 @@ package redpanda
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
 	rp "github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/factory"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/hwloc"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/iotune"
+	"gopkg.in/yaml.v2"
 )
 
 type noopLauncher struct {
@@ -31,57 +47,105 @@ func (l *noopLauncher) Start(_ string, rpArgs *rp.RedpandaArgs) error {
 	return nil
 }
 
+func (*noopLauncher) Version(
+	installDir string, _ time.Duration,
+) (string, rp.Version, error) {
+	return rp.BinaryPath(installDir), rp.Version{Major: 21, Minor: 4, Patch: 2}, nil
+}
+
 func TestMergeFlags(t *testing.T) {
 	tests := []struct {
-		name		string
-		current		map[string]interface{}
-		overrides	[]string
-		expected	map[string]string
+		name			string
+		current			map[string]string
+		overrides		[]string
+		expected		map[string]string
+		expectedOverridden	map[string]string
 	}{
 		{
-			name:		"it should override the existent values",
-			current:	map[string]interface{}{"a": "true", "b": "2", "c": "127.0.0.1"},
-			overrides:	[]string{"--a false", "b 42"},
-			expected:	map[string]string{"a": "false", "b": "42", "c": "127.0.0.1"},
-		}, {
-			name:		"it should override the existent values (2)",
-			current:	map[string]interface{}{"lock-memory": "true", "cpumask": "0-1", "logger-log-level": "'exception=debug'"},
-			overrides: []string{"--overprovisioned", "--unsafe-bypass-fsync 1",
-				"--default-log-level=trace", "--logger-log-level='exception=debug'",
-				"--fail-on-abandoned-failed-futures"},
+			name:			"it should override the existent values",
+			current:		map[string]string{"a": "true", "b": "2", "c": "127.0.0.1"},
+			overrides:		[]string{"--a false", "--b=42"},
+			expected:		map[string]string{"a": "false", "b": "42", "c": "127.0.0.1"},
+			expectedOverridden:	map[string]string{"a": "false", "b": "42"},
+		},
+		{
+			name:		"it should create values not present in the current flags",
+			current:	map[string]string{"lock-memory": "true"},
+			overrides: []string{
+				"--overprovisioned",
+				"--unsafe-bypass-fsync", "1",
+				"--logger-log-level='exception=debug'",
+			},
 			expected: map[string]string{
-				"lock-memory":				"true",
-				"cpumask":				"0-1",
-				"logger-log-level":			"'exception=debug'",
-				"overprovisioned":			"",
-				"unsafe-bypass-fsync":			"1",
-				"default-log-level":			"trace",
-				"--fail-on-abandoned-failed-futures":	"",
+				"lock-memory":		"true",
+				"overprovisioned":	"true",
+				"unsafe-bypass-fsync":	"1",
+				"logger-log-level":	"'exception=debug'",
 			},
-		}, {
-			name:		"it should create values not present in the current flags",
-			current:	map[string]interface{}{},
-			overrides:	[]string{"b 42", "c 127.0.0.1"},
-			expected:	map[string]string{"b": "42", "c": "127.0.0.1"},
-		}, {
-			name:		"it shouldn't change the current flags if no overrides are given",
-			current:	map[string]interface{}{"b": "42", "c": "127.0.0.1"},
-			overrides:	[]string{},
-			expected:	map[string]string{"b": "42", "c": "127.0.0.1"},
+			expectedOverridden: map[string]string{
+				"overprovisioned":	"true",
+				"unsafe-bypass-fsync":	"1",
+				"logger-log-level":	"'exception=debug'",
+			},
+		},
+		{
+			name:			"it shouldn't change the current flags if no overrides are given",
+			current:		map[string]string{"b": "42", "c": "127.0.0.1"},
+			overrides:		[]string{},
+			expected:		map[string]string{"b": "42", "c": "127.0.0.1"},
+			expectedOverridden:	map[string]string{},
 		},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			flags := mergeFlags(tt.current, tt.overrides)
-			require.Equal(t, len(flags), len(tt.expected))
-			if len(flags) != len(tt.expected) {
-				t.Fatal("the flags dicts differ in size")
-			}
+		t.Run(tt.name, func(st *testing.T) {
+			overridden := mergeFlags(tt.current, tt.overrides)
+			require.Equal(st, tt.expected, tt.current)
+			require.Equal(st, tt.expectedOverridden, overridden)
+		})
+	}
+}
 
-			for k, v := range flags {
-				require.Equal(t, tt.expected[k], v)
+func TestParseSeastarFlagValues(t *testing.T) {
+	tests := []struct {
+		name		string
+		values		[]string
+		expected	map[string]string
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should parse names and values, and bare names as booleans",
+			values: []string{
+				"max-networking-io-control-blocks=2000",
+				"--logger-log-level=exception=debug",
+				"abort-on-seastar-bad-alloc",
+			},
+			expected: map[string]string{
+				"max-networking-io-control-blocks":	"2000",
+				"logger-log-level":			"exception=debug",
+				"abort-on-seastar-bad-alloc":		"true",
+			},
+		},
+		{
+			name:		"it should keep empty values",
+			values:		[]string{"blocked-reactor-notify-ms="},
+			expected:	map[string]string{"blocked-reactor-notify-ms": ""},
+		},
+		{
+			name:		"it should fail if a flag has no name",
+			values:		[]string{"=4G"},
+			expectedErrMsg:	"--seastar-flag '=4G' has no flag name. It must be in the format <name>=<value>, or <name> for boolean flags",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			parsed, err := parseSeastarFlagValues(tt.values)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
 			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, parsed)
 		})
 	}
 }
@@ -833,15 +897,66 @@ func TestStartCommand(t *testing.T) {
 		},
 		expectedErrMsg:	"Configuration conflict. Flag '--memory' is also present in 'rpk.additional_start_flags' in configuration file '/etc/redpanda/redpanda.yaml'. Please remove it and pass '--memory' directly to `rpk start`.",
 	}, {
-		name:	"it should pass the last instance of a duplicate flag set in rpk.additional_start_flags",
+		name:	"--sandbox should set low-resource flags over the config's",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda", "--sandbox",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			smp := 4
+			conf.Rpk.SMP = &smp
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "1", rpArgs.SeastarFlags["smp"])
+			require.Equal(st, "1G", rpArgs.SeastarFlags["memory"])
+			require.Equal(st, "0M", rpArgs.SeastarFlags["reserve-memory"])
+			require.Equal(st, "true", rpArgs.SeastarFlags["overprovisioned"])
+		},
+	}, {
+		name:	"explicit flags should beat --sandbox",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--sandbox",
+			"--smp", "2",
+			"--overprovisioned=false",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.AdditionalStartFlags = []string{"--memory=2G"}
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "2", rpArgs.SeastarFlags["smp"])
+			require.Equal(st, "2G", rpArgs.SeastarFlags["memory"])
+			require.Equal(st, "0M", rpArgs.SeastarFlags["reserve-memory"])
+			require.Equal(st, "false", rpArgs.SeastarFlags["overprovisioned"])
+		},
+	}, {
+		name:	"--override should beat rpk.additional_start_flags and the CLI flags",
 		args: []string{
 			"--install-dir", "/var/lib/redpanda",
+			"--memory", "2G",
+			"--override", "--memory=4G",
+			"--override", "--default-log-level trace",
+			"--override", "--unsafe-bypass-fsync",
 		},
 		before: func(fs afero.Fs) error {
 			mgr := config.NewManager(fs)
 			conf := config.Default()
 			conf.Rpk.AdditionalStartFlags = []string{
-				"--smp=3", "--smp=55",
+				"--default-log-level=info",
+				"--abort-on-seastar-bad-alloc",
 			}
 			return mgr.Write(conf)
 		},
@@ -850,51 +965,2350 @@ func TestStartCommand(t *testing.T) {
 			rpArgs *rp.RedpandaArgs,
 			st *testing.T,
 		) {
-			require.Equal(st, "55", rpArgs.SeastarFlags["smp"])
+			require.Equal(st, "4G", rpArgs.SeastarFlags["memory"])
+			require.Equal(st, "trace", rpArgs.SeastarFlags["default-log-level"])
+			require.Equal(st, "true", rpArgs.SeastarFlags["unsafe-bypass-fsync"])
+			require.Equal(st, "true", rpArgs.SeastarFlags["abort-on-seastar-bad-alloc"])
 		},
 	}, {
-		name:	"it should pass the last instance of a duplicate flag passed to rpk start",
+		name:	"--seastar-flag should beat the config but not the CLI flags",
 		args: []string{
 			"--install-dir", "/var/lib/redpanda",
-			"--memory", "1G", "--memory", "4G",
+			"--memory", "2G",
+			"--seastar-flag", "memory=4G",
+			"--seastar-flag", "default-log-level=trace",
+			"--seastar-flag", "max-networking-io-control-blocks=2000",
+			"--seastar-flag", "unsafe-bypass-fsync",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.AdditionalStartFlags = []string{
+				"--default-log-level=info",
+			}
+			return mgr.Write(conf)
 		},
 		postCheck: func(
 			_ afero.Fs,
 			rpArgs *rp.RedpandaArgs,
 			st *testing.T,
 		) {
-			require.Equal(st, "4G", rpArgs.SeastarFlags["memory"])
+			require.Equal(st, "2G", rpArgs.SeastarFlags["memory"])
+			require.Equal(st, "trace", rpArgs.SeastarFlags["default-log-level"])
+			require.Equal(st, "2000", rpArgs.SeastarFlags["max-networking-io-control-blocks"])
+			require.Equal(st, "true", rpArgs.SeastarFlags["unsafe-bypass-fsync"])
 		},
-	}}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(st *testing.T) {
-			if tt.after != nil {
-				defer tt.after()
+	}, {
+		name:	"it should fail if a --seastar-flag has no name",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--seastar-flag", "=2000",
+		},
+		expectedErrMsg:	"--seastar-flag '=2000' has no flag name. It must be in the format <name>=<value>, or <name> for boolean flags",
+	}, {
+		name:	"--preset should beat the config but not the CLI flags, and merge in order",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--memory", "2G",
+			"--preset", "throughput",
+			"--preset", "latency",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.AdditionalStartFlags = []string{
+				"--default-log-level=info",
 			}
-			fs := afero.NewMemMapFs()
+			err := mgr.Write(conf)
+			if err != nil {
+				return err
+			}
+			presets := `throughput:
+  memory: 8G
+  default-log-level: warn
+  max-networking-io-control-blocks: 4000
+latency:
+  --poll-mode: true
+  max-networking-io-control-blocks: 2000
+`
+			return afero.WriteFile(
+				fs,
+				rp.GetPresetsPath(filepath.Dir(conf.ConfigFile)),
+				[]byte(presets),
+				0644,
+			)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "2G", rpArgs.SeastarFlags["memory"])
+			require.Equal(st, "warn", rpArgs.SeastarFlags["default-log-level"])
+			require.Equal(st, "2000", rpArgs.SeastarFlags["max-networking-io-control-blocks"])
+			require.Equal(st, "true", rpArgs.SeastarFlags["poll-mode"])
+		},
+	}, {
+		name:	"it should fail if a --preset is unknown",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--preset", "fast",
+		},
+		before: func(fs afero.Fs) error {
 			mgr := config.NewManager(fs)
-			var launcher rp.Launcher = &noopLauncher{}
-			if tt.launcher != nil {
-				launcher = tt.launcher
+			err := mgr.Write(config.Default())
+			if err != nil {
+				return err
 			}
-			if tt.before != nil {
-				require.NoError(st, tt.before(fs))
+			presets := "throughput:\n  poll-mode: false\nlatency:\n  poll-mode: true\n"
+			return afero.WriteFile(
+				fs,
+				"/etc/redpanda/presets.yaml",
+				[]byte(presets),
+				0644,
+			)
+		},
+		expectedErrMsg:	"unknown preset 'fast'. The ones in '/etc/redpanda/presets.yaml' are: latency, throughput",
+	}, {
+		name:	"it should fail if --preset is passed but there's no presets file",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--preset", "latency",
+		},
+		expectedErrMsg:	"--preset was passed, but there's no presets file at '/etc/redpanda/presets.yaml'",
+	}, {
+		name:	"it should fail if --smp exceeds the CPUs in the --cpuset set in the config file",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda", "--smp", "4",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.AdditionalStartFlags = []string{"--cpuset=0-1"}
+			return mgr.Write(conf)
+		},
+		expectedErrMsg:	"--smp=4 (from cli) and --cpuset=0-1 (from rpk.additional_start_flags) conflict: there are more shards than the 2 CPUs in the cpuset",
+	}, {
+		name:	"it should ignore rpk.smp if --cpuset is passed",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda", "--cpuset", "0-1",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			smp := 4
+			conf.Rpk.SMP = &smp
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "0-1", rpArgs.SeastarFlags["cpuset"])
+			require.NotContains(st, rpArgs.SeastarFlags, "smp")
+		},
+	}, {
+		name:	"it should keep --smp if the cpuset is set in the config file",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda", "--smp", "2",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.AdditionalStartFlags = []string{"--cpuset=0-1"}
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "0-1", rpArgs.SeastarFlags["cpuset"])
+			require.Equal(st, "2", rpArgs.SeastarFlags["smp"])
+		},
+	}, {
+		name:	"it should ignore rpk.smp if the cpuset is set in the config file",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			smp := 4
+			conf.Rpk.SMP = &smp
+			conf.Rpk.AdditionalStartFlags = []string{"--cpuset=0-1"}
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "0-1", rpArgs.SeastarFlags["cpuset"])
+			require.NotContains(st, rpArgs.SeastarFlags, "smp")
+		},
+	}, {
+		name:	"it should pass the last instance of a duplicate flag set in rpk.additional_start_flags",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.AdditionalStartFlags = []string{
+				"--smp=3", "--smp=55",
 			}
-			var out bytes.Buffer
-			logrus.SetOutput(&out)
-			c := NewStartCommand(fs, mgr, launcher)
-			c.SetArgs(tt.args)
-			err := c.Execute()
-			if tt.expectedErrMsg != "" {
-				require.EqualError(st, err, tt.expectedErrMsg)
-				return
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "55", rpArgs.SeastarFlags["smp"])
+		},
+	}, {
+		name:	"it shouldn't rename flags which were replaced by others with different semantics",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--num-io-queues", "4", "--auto-rename-flags",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.AdditionalStartFlags = []string{
+				"--reserve-additional-memory=1G",
 			}
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "4", rpArgs.SeastarFlags["num-io-queues"])
+			require.Equal(st, "1G", rpArgs.SeastarFlags["reserve-additional-memory"])
+			require.NotContains(st, rpArgs.SeastarFlags, "num-io-groups")
+			require.NotContains(st, rpArgs.SeastarFlags, "reserve-additional-memory-per-shard")
+		},
+	}, {
+		name:	"it should enable RPC TLS and persist the files if --rpc-tls-* are passed",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--rpc-tls-cert-file", "/etc/redpanda/certs/node.crt",
+			"--rpc-tls-key-file", "/etc/redpanda/certs/node.key",
+			"--check=false",
+		},
+		postCheck: func(fs afero.Fs, _ *rp.RedpandaArgs, st *testing.T) {
+			mgr := config.NewManager(fs)
+			conf, err := mgr.Read(config.Default().ConfigFile)
 			require.NoError(st, err)
-			if tt.postCheck != nil {
-				l := launcher.(*noopLauncher)
-				tt.postCheck(fs, l.rpArgs, st)
+			expected := config.ServerTLS{
+				CertFile:	"/etc/redpanda/certs/node.crt",
+				KeyFile:	"/etc/redpanda/certs/node.key",
+				Enabled:	true,
+			}
+			require.Exactly(st, expected, conf.Redpanda.RPCServerTLS)
+		},
+	}, {
+		name:	"it should resolve rpk.memory_percent to --memory",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			// Limit the available memory to 2GiB through cgroups.
+			err := afero.WriteFile(
+				fs,
+				"/proc/self/cgroup",
+				[]byte("1:name=systemd:/user.slice\n"),
+				0644,
+			)
+			if err != nil {
+				return err
+			}
+			err = afero.WriteFile(
+				fs,
+				"/sys/fs/cgroup/memory/memory.limit_in_bytes",
+				[]byte("2147483648"),
+				0644,
+			)
+			if err != nil {
+				return err
 			}
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.MemoryPercent = "50%"
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "1024M", rpArgs.SeastarFlags["memory"])
+		},
+	}, {
+		name:	"it should resolve --memory-percent to --memory",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--memory-percent", "25%",
+		},
+		before: func(fs afero.Fs) error {
+			err := afero.WriteFile(
+				fs,
+				"/proc/self/cgroup",
+				[]byte("1:name=systemd:/user.slice\n"),
+				0644,
+			)
+			if err != nil {
+				return err
+			}
+			err = afero.WriteFile(
+				fs,
+				"/sys/fs/cgroup/memory/memory.limit_in_bytes",
+				[]byte("2147483648"),
+				0644,
+			)
+			if err != nil {
+				return err
+			}
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.MemoryPercent = "50%"
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "512M", rpArgs.SeastarFlags["memory"])
+		},
+	}, {
+		name:	"it should fail if --memory-percent and --memory are set",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--memory", "2G", "--memory-percent", "80",
+		},
+		expectedErrMsg:	"--memory-percent (or rpk.memory_percent) and --memory (or --memory in rpk.additional_start_flags, or RPK_MEMORY) can't be set at the same time",
+	}, {
+		name:	"it should fail if rpk.memory_percent and --memory are set",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda", "--memory", "2G",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.MemoryPercent = "80"
+			return mgr.Write(conf)
+		},
+		expectedErrMsg:	"--memory-percent (or rpk.memory_percent) and --memory (or --memory in rpk.additional_start_flags, or RPK_MEMORY) can't be set at the same time",
+	}, {
+		name:	"it should fail if rpk.memory_percent is set and --memory is in the additional start flags",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.MemoryPercent = "80"
+			conf.Rpk.AdditionalStartFlags = []string{"--memory=2G"}
+			return mgr.Write(conf)
+		},
+		expectedErrMsg:	"--memory-percent (or rpk.memory_percent) and --memory (or --memory in rpk.additional_start_flags, or RPK_MEMORY) can't be set at the same time",
+	}, {
+		name:	"it should pass the io-properties deduced from --well-known-io unquoted",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--well-known-io", "aws:i3.xlarge:default",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Redpanda.Directory = "/var/lib/redpanda/it's data"
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			ioProps, err := iotune.DataFor(
+				"/var/lib/redpanda/it's data",
+				"aws",
+				"i3.xlarge",
+				"default",
+			)
+			require.NoError(st, err)
+			expected, err := iotune.ToYaml(*ioProps)
+			require.NoError(st, err)
+			require.Equal(
+				st,
+				expected,
+				rpArgs.SeastarFlags["io-properties"],
+			)
+		},
+	}, {
+		name:	"it should fail if the IO properties can't be deduced and --strict-io-properties is passed",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--well-known-io", "aws:unknown.vm:default",
+			"--strict-io-properties",
+		},
+		expectedErrMsg:	"couldn't deduce the IO properties: no iotune data found for VM 'unknown.vm', of vendor 'aws'. Pass --io-properties-file, --io-properties or --well-known-io, or unset --strict-io-properties (rpk.strict_io_properties)",
+	}, {
+		name:	"it should fail if the IO properties can't be deduced and rpk.strict_io_properties is set",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.WellKnownIo = "aws:unknown.vm:default"
+			conf.Rpk.StrictIoProperties = true
+			return mgr.Write(conf)
+		},
+		expectedErrMsg:	"couldn't deduce the IO properties: no iotune data found for VM 'unknown.vm', of vendor 'aws'. Pass --io-properties-file, --io-properties or --well-known-io, or unset --strict-io-properties (rpk.strict_io_properties)",
+	}, {
+		name:	"it shouldn't fail if strict and the IO properties file is in rpk.additional_start_flags",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.WellKnownIo = "aws:unknown.vm:default"
+			conf.Rpk.StrictIoProperties = true
+			conf.Rpk.AdditionalStartFlags = []string{
+				"--io-properties-file=/etc/redpanda/io.yaml",
+			}
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(
+				st,
+				"/etc/redpanda/io.yaml",
+				rpArgs.SeastarFlags["io-properties-file"],
+			)
+			_, ok := rpArgs.SeastarFlags["io-properties"]
+			require.False(st, ok)
+		},
+	}, {
+		name:	"it shouldn't fail if strict and the IO properties are passed with --seastar-flag",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--well-known-io", "aws:unknown.vm:default",
+			"--strict-io-properties",
+			"--seastar-flag", "io-properties-file=/etc/redpanda/io.yaml",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(
+				st,
+				"/etc/redpanda/io.yaml",
+				rpArgs.SeastarFlags["io-properties-file"],
+			)
+		},
+	}, {
+		name:	"it should pass the IO properties from --override instead of the deduced ones",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--well-known-io", "aws:i3.xlarge:default",
+			"--override", "--io-properties-file=/etc/redpanda/io.yaml",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(
+				st,
+				"/etc/redpanda/io.yaml",
+				rpArgs.SeastarFlags["io-properties-file"],
+			)
+			_, ok := rpArgs.SeastarFlags["io-properties"]
+			require.False(st, ok)
+		},
+	}, {
+		name:	"it should start without IO properties if they can't be deduced by default",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--well-known-io", "aws:unknown.vm:default",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			_, ok := rpArgs.SeastarFlags["io-properties"]
+			require.False(st, ok)
+		},
+	}, {
+		name:	"it should pass the last instance of a duplicate flag passed to rpk start",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--memory", "1G", "--memory", "4G",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "4G", rpArgs.SeastarFlags["memory"])
+		},
+	}, {
+		name:	"it should apply the benchmark mode's defaults",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--mode", "benchmark",
+		},
+		postCheck: func(
+			fs afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "false", rpArgs.SeastarFlags["overprovisioned"])
+			conf, err := config.NewManager(fs).Read(config.Default().ConfigFile)
+			require.NoError(st, err)
+			require.True(st, conf.Rpk.EnableMemoryLocking)
+			require.True(st, conf.Rpk.TuneTransparentHugePages)
+			require.False(st, conf.Redpanda.DeveloperMode)
+		},
+	}, {
+		name:	"flags passed explicitly should override the mode's defaults",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--mode", "benchmark", "--overprovisioned",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "true", rpArgs.SeastarFlags["overprovisioned"])
+		},
+	}, {
+		name:	"it should fail if the mode is invalid",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--mode", "winning",
+		},
+		expectedErrMsg:	"'winning' is not a supported mode. Available modes: dev, development, prod, production, benchmark",
+	}, {
+		name:	"it shouldn't start redpanda if --print-operator-config is passed",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--print-operator-config",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Nil(st, rpArgs)
+		},
+	}, {
+		name:	"it should fail if --print-operator-config and --resolve-only are passed",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--print-operator-config",
+			"--resolve-only", "/tmp/args.json",
+		},
+		expectedErrMsg:	"--print-operator-config can't be passed with --resolve-only or --from-resolved",
+	}, {
+		name:	"it shouldn't start redpanda if --dry-run is passed",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--dry-run",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Nil(st, rpArgs)
+		},
+	}, {
+		name:	"it should fail if --validate is passed without --dry-run",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--validate",
+		},
+		expectedErrMsg:	"--validate can only be passed with --dry-run",
+	}, {
+		name:	"it should take the flags from the RPK_ env vars over the config",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			os.Setenv("RPK_SMP", "3")
+			os.Setenv("RPK_MAX_IO_REQUESTS", "128")
+			os.Setenv("RPK_REDPANDA_LOG_LEVEL", "debug")
+			conf := config.Default()
+			smp := 2
+			conf.Rpk.SMP = &smp
+			conf.Rpk.AdditionalStartFlags = []string{"--max-io-requests=64"}
+			mgr := config.NewManager(fs)
+			return mgr.Write(conf)
+		},
+		after: func() {
+			os.Unsetenv("RPK_SMP")
+			os.Unsetenv("RPK_MAX_IO_REQUESTS")
+			os.Unsetenv("RPK_REDPANDA_LOG_LEVEL")
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "3", rpArgs.SeastarFlags["smp"])
+			require.Equal(st, "128", rpArgs.SeastarFlags["max-io-requests"])
+			require.Equal(st, "debug", rpArgs.SeastarFlags["default-log-level"])
+		},
+	}, {
+		name:	"it should take the flags passed on the command line over the RPK_ env vars",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--smp", "4",
+		},
+		before: func(_ afero.Fs) error {
+			os.Setenv("RPK_SMP", "3")
+			return nil
+		},
+		after: func() {
+			os.Unsetenv("RPK_SMP")
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "4", rpArgs.SeastarFlags["smp"])
+		},
+	}, {
+		name:	"it should fail if the log level in RPK_REDPANDA_LOG_LEVEL is invalid",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(_ afero.Fs) error {
+			os.Setenv("RPK_REDPANDA_LOG_LEVEL", "loud")
+			return nil
+		},
+		after: func() {
+			os.Unsetenv("RPK_REDPANDA_LOG_LEVEL")
+		},
+		expectedErrMsg:	"invalid log level 'loud'. Available levels: trace, debug, info, warn, error",
+	}, {
+		name:	"it shouldn't deduce the IO properties if RPK_IO_PROPERTIES_FILE is set",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(_ afero.Fs) error {
+			os.Setenv("RPK_IO_PROPERTIES_FILE", "/etc/redpanda/nvme.yaml")
+			return nil
+		},
+		after: func() {
+			os.Unsetenv("RPK_IO_PROPERTIES_FILE")
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(
+				st,
+				"/etc/redpanda/nvme.yaml",
+				rpArgs.SeastarFlags["io-properties-file"],
+			)
+			_, ok := rpArgs.SeastarFlags["io-properties"]
+			require.False(st, ok)
+		},
+	}, {
+		name:	"it should fail if RPK_IO_PROPERTIES and --well-known-io are set",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--well-known-io", "aws:i3.xlarge:default",
+		},
+		before: func(_ afero.Fs) error {
+			os.Setenv("RPK_IO_PROPERTIES", "{disks: []}")
+			return nil
+		},
+		after: func() {
+			os.Unsetenv("RPK_IO_PROPERTIES")
+		},
+		expectedErrMsg:	"--well-known-io or (rpk.well_known_io) and --io-properties (or --io-properties-file, or their RPK_ env vars) can't be set at the same time",
+	}, {
+		name:	"it should fail if rpk.memory_percent and RPK_MEMORY are set",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			os.Setenv("RPK_MEMORY", "2G")
+			conf := config.Default()
+			conf.Rpk.MemoryPercent = "80"
+			mgr := config.NewManager(fs)
+			return mgr.Write(conf)
+		},
+		after: func() {
+			os.Unsetenv("RPK_MEMORY")
+		},
+		expectedErrMsg:	"--memory-percent (or rpk.memory_percent) and --memory (or --memory in rpk.additional_start_flags, or RPK_MEMORY) can't be set at the same time",
+	}, {
+		name:	"it should skip --version-check if there's no minimum version",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--version-check",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.NotNil(st, rpArgs)
+		},
+	}, {
+		name:	"it should merge the files passed in --io-properties-file",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--io-properties-file", "/etc/redpanda/nvme.yaml",
+			"--io-properties-file", "/etc/redpanda/ebs.yaml",
+		},
+		before: func(fs afero.Fs) error {
+			err := afero.WriteFile(
+				fs,
+				"/etc/redpanda/nvme.yaml",
+				[]byte("disks:\n- mountpoint: /mnt/nvme\n  read_iops: 2\n"),
+				0644,
+			)
+			if err != nil {
+				return err
+			}
+			err = afero.WriteFile(
+				fs,
+				"/etc/redpanda/ebs.yaml",
+				[]byte("disks:\n- mountpoint: /mnt/ebs\n  read_iops: 1\n"),
+				0644,
+			)
+			if err != nil {
+				return err
+			}
+			// The file merged on a previous start.
+			return afero.WriteFile(
+				fs,
+				"/var/lib/redpanda/data/.rpk_io_properties/merged.yaml",
+				[]byte("disks:\n- mountpoint: /mnt/old\n  read_iops: 3\n"),
+				0644,
+			)
+		},
+		postCheck: func(
+			fs afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			path := rpArgs.SeastarFlags["io-properties-file"]
+			require.Equal(
+				st,
+				"/var/lib/redpanda/data/.rpk_io_properties/merged.yaml",
+				path,
+			)
+			disks, err := iotune.ReadFile(fs, path)
+			require.NoError(st, err)
+			require.Equal(
+				st,
+				[]iotune.IoProperties{
+					{MountPoint: "/mnt/nvme", ReadIops: 2},
+					{MountPoint: "/mnt/ebs", ReadIops: 1},
+				},
+				disks,
+			)
+		},
+	}, {
+		name:	"it should pass a single --io-properties-file as is",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--io-properties-file", "/etc/redpanda/nvme.yaml",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(
+				st,
+				"/etc/redpanda/nvme.yaml",
+				rpArgs.SeastarFlags["io-properties-file"],
+			)
+		},
+	}, {
+		name:	"it should fail if --oom-score-adj is out of range",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--oom-score-adj", "2000",
+		},
+		expectedErrMsg:	"invalid OOM score adjustment 2000. It must be between -1000 and 1000",
+	}, {
+		name:	"it should set the OOM score adjustment",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--oom-score-adj", "-900",
+		},
+		postCheck: func(fs afero.Fs, _ *rp.RedpandaArgs, st *testing.T) {
+			adj, err := afero.ReadFile(fs, system.OomScoreAdjFile)
+			require.NoError(st, err)
+			require.Equal(st, "-900", string(adj))
+		},
+	}, {
+		name:	"it should set the OOM score adjustment from the config",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			conf := config.Default()
+			adj := -500
+			conf.Rpk.OomScoreAdj = &adj
+			mgr := config.NewManager(fs)
+			return mgr.Write(conf)
+		},
+		postCheck: func(fs afero.Fs, _ *rp.RedpandaArgs, st *testing.T) {
+			adj, err := afero.ReadFile(fs, system.OomScoreAdjFile)
+			require.NoError(st, err)
+			require.Equal(st, "-500", string(adj))
+		},
+	}, {
+		name:	"it should start redpanda with the --set overrides without persisting them",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--set", "redpanda.node_id=5",
+			"--set", "rpk.overprovisioned=false",
+		},
+		postCheck: func(
+			fs afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			// It's true by default in dev mode.
+			require.Equal(st, "false", rpArgs.SeastarFlags["overprovisioned"])
+			mgr := config.NewManager(fs)
+			conf, err := mgr.Read(config.Default().ConfigFile)
+			require.NoError(st, err)
+			require.Equal(st, 0, conf.Redpanda.Id)
+			require.True(st, conf.Rpk.Overprovisioned)
+
+			require.Equal(
+				st,
+				"/var/lib/redpanda/data/.rpk_overrides/redpanda.yaml",
+				rpArgs.ConfigFilePath,
+			)
+			info, err := fs.Stat("/var/lib/redpanda/data/.rpk_overrides")
+			require.NoError(st, err)
+			require.Equal(st, os.FileMode(0700), info.Mode().Perm())
+			overridden, err := config.NewManager(fs).Read(rpArgs.ConfigFilePath)
+			require.NoError(st, err)
+			require.Equal(st, 5, overridden.Redpanda.Id)
+			require.False(st, overridden.Rpk.Overprovisioned)
+		},
+	}, {
+		name:	"it should start redpanda with the config merged with the included files",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before:	writeIncludingConfig,
+		postCheck: func(
+			fs afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(
+				st,
+				"/etc/redpanda/redpanda.merged.yaml",
+				rpArgs.ConfigFilePath,
+			)
+			requireIncludedValues(st, fs, rpArgs.ConfigFilePath, 3)
+		},
+	}, {
+		name:	"it should start redpanda with the included files and the --set overrides",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--set", "redpanda.node_id=5",
+		},
+		before:	writeIncludingConfig,
+		postCheck: func(
+			fs afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(
+				st,
+				"/var/lib/redpanda/base/.rpk_overrides/redpanda.merged.yaml",
+				rpArgs.ConfigFilePath,
+			)
+			requireIncludedValues(st, fs, rpArgs.ConfigFilePath, 5)
+		},
+	}, {
+		name:	"it should persist the --set overrides with --save",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--set", "redpanda.node_id=5",
+			"--save",
+		},
+		postCheck: func(
+			fs afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			mgr := config.NewManager(fs)
+			conf, err := mgr.Read(config.Default().ConfigFile)
+			require.NoError(st, err)
+			require.Equal(st, 5, conf.Redpanda.Id)
+			require.Equal(st, conf.ConfigFile, rpArgs.ConfigFilePath)
+		},
+	}, {
+		name:	"it should fail if a --set key isn't in the config",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--set", "rpk.nope=1",
+		},
+		expectedErrMsg:	"unknown config key 'rpk.nope'",
+	}, {
+		name:	"it should fail if --save is passed without --set",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--save",
+		},
+		expectedErrMsg:	"--save can only be passed with --set",
+	}, {
+		name:	"it should pass the log levels to redpanda",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--redpanda-log-level", "debug",
+			"--logger-log-level", "raft=trace",
+			"--logger-log-level", "kafka=info",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "debug", rpArgs.SeastarFlags["default-log-level"])
+			require.Equal(st, "raft=trace:kafka=info", rpArgs.SeastarFlags["logger-log-level"])
+		},
+	}, {
+		name:	"it should take the log levels from the config",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--logger-log-level", "raft=trace",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.RedpandaLogLevel = "warn"
+			conf.Rpk.LoggerLogLevels = []string{"kafka=debug"}
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "warn", rpArgs.SeastarFlags["default-log-level"])
+			require.Equal(st, "raft=trace", rpArgs.SeastarFlags["logger-log-level"])
+		},
+	}, {
+		name:	"it should fail if the log level is invalid",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--redpanda-log-level", "verbose",
+		},
+		expectedErrMsg:	"invalid log level 'verbose'. Available levels: trace, debug, info, warn, error",
+	}, {
+		name:	"it should fail if a logger level is invalid",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--logger-log-level", "raft",
+		},
+		expectedErrMsg:	"invalid logger level 'raft'. It must have the format <logger>=<level>",
+	}, {
+		name:	"it should fail if the tune profile is invalid",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--check=false", "--tune",
+			"--tune-profile", "reckless",
+		},
+		expectedErrMsg:	"'reckless' is not a valid tune profile. Available profiles: conservative, balanced, aggressive",
+	}, {
+		name:	"it should write the PID to redpanda.pid in the data dir by default",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "/var/lib/redpanda/data/redpanda.pid", rpArgs.PIDFile)
+		},
+	}, {
+		name:	"it should take the PID file from rpk.pid_file",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.PIDFile = "/run/redpanda/redpanda.pid"
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "/run/redpanda/redpanda.pid", rpArgs.PIDFile)
+		},
+	}, {
+		name:	"--pidfile should override rpk.pid_file",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+			"--pidfile", "/tmp/redpanda.pid",
+		},
+		before: func(fs afero.Fs) error {
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.PIDFile = "/run/redpanda/redpanda.pid"
+			return mgr.Write(conf)
+		},
+		postCheck: func(
+			_ afero.Fs,
+			rpArgs *rp.RedpandaArgs,
+			st *testing.T,
+		) {
+			require.Equal(st, "/tmp/redpanda.pid", rpArgs.PIDFile)
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			if tt.after != nil {
+				defer tt.after()
+			}
+			fs := afero.NewMemMapFs()
+			mgr := config.NewManager(fs)
+			var launcher rp.Launcher = &noopLauncher{}
+			if tt.launcher != nil {
+				launcher = tt.launcher
+			}
+			if tt.before != nil {
+				require.NoError(st, tt.before(fs))
+			}
+			var out bytes.Buffer
+			logrus.SetOutput(&out)
+			c := NewStartCommand(fs, mgr, launcher)
+			c.SetArgs(tt.args)
+			err := c.Execute()
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			if tt.postCheck != nil {
+				l := launcher.(*noopLauncher)
+				tt.postCheck(fs, l.rpArgs, st)
+			}
+		})
+	}
+}
+
+// Writes a config file which includes another one, setting the data directory
+// and the Kafka API.
+func writeIncludingConfig(fs afero.Fs) error {
+	err := afero.WriteFile(
+		fs,
+		"/etc/redpanda/base.yaml",
+		[]byte(`redpanda:
+  data_directory: /var/lib/redpanda/base
+  kafka_api:
+    address: 10.0.0.1
+    port: 9093
+`),
+		0644,
+	)
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(
+		fs,
+		config.Default().ConfigFile,
+		[]byte("include: [base.yaml]\nredpanda:\n  node_id: 3\n"),
+		0644,
+	)
+}
+
+// Checks that the config file redpanda is started with has the values from
+// the file written by writeIncludingConfig, and no includes.
+func requireIncludedValues(
+	t *testing.T, fs afero.Fs, path string, nodeID int,
+) {
+	bs, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	written := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(bs, &written))
+	require.NotContains(t, written, "include")
+	conf, err := config.NewManager(fs).Read(path)
+	require.NoError(t, err)
+	require.Equal(t, nodeID, conf.Redpanda.Id)
+	require.Equal(t, "/var/lib/redpanda/base", conf.Redpanda.Directory)
+	require.Equal(
+		t,
+		config.SocketAddress{Address: "10.0.0.1", Port: 9093},
+		conf.Redpanda.KafkaApi,
+	)
+}
+
+func TestConfirmDisruptiveTuner(t *testing.T) {
+	tests := []struct {
+		name		string
+		assumeYes	bool
+		interactive	bool
+		input		string
+		noInteractive	bool
+		expected	bool
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should run the tuner if --assume-yes is passed",
+			assumeYes:	true,
+			expected:	true,
+		},
+		{
+			name:		"it should skip the tuner if not running interactively",
+			expected:	false,
+		},
+		{
+			name:		"it should run the tuner if the user confirms",
+			interactive:	true,
+			input:		"y\n",
+			expected:	true,
+		},
+		{
+			name:		"it should skip the tuner if the user declines",
+			interactive:	true,
+			input:		"n\n",
+			expected:	false,
+		},
+		{
+			name:		"it should fail if the user quits",
+			interactive:	true,
+			input:		"q\n",
+			expectedErrMsg:	"user exited",
+		},
+		{
+			name:		"it should skip the tuner without prompting if --no-interactive is set",
+			interactive:	true,
+			noInteractive:	true,
+			input:		"y\n",
+			expected:	false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			ui.SetInteractive(!tt.noInteractive)
+			defer ui.SetInteractive(true)
+			confirm := confirmDisruptiveTuner(
+				tt.assumeYes,
+				tt.interactive,
+				strings.NewReader(tt.input),
+			)
+			confirmed, err := confirm("net")
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, confirmed)
+		})
+	}
+}
+
+func TestRenameDeprecatedFlags(t *testing.T) {
+	// seastar's renames are hypothetical here, since none are listed yet.
+	renames := map[string]string{
+		"old-flag":	"new-flag",
+		"other-flag":	"another-flag",
+	}
+	tests := []struct {
+		name		string
+		flags		map[string]string
+		autoRename	bool
+		expected	map[string]string
+		expectedSources	map[string]flagSource
+	}{
+		{
+			name:		"it should leave the flags as they are if autoRename is false",
+			flags:		map[string]string{"old-flag": "2", "smp": "2"},
+			expected:	map[string]string{"old-flag": "2", "smp": "2"},
+			expectedSources: map[string]flagSource{
+				"old-flag":	cliSource,
+				"smp":		cliSource,
+			},
+		},
+		{
+			name:		"it should rename the deprecated flags",
+			flags:		map[string]string{"old-flag": "2", "smp": "2"},
+			autoRename:	true,
+			expected:	map[string]string{"new-flag": "2", "smp": "2"},
+			expectedSources: map[string]flagSource{
+				"new-flag":	cliSource,
+				"smp":		cliSource,
+			},
+		},
+		{
+			name:	"it should keep the new flag if both are set",
+			flags: map[string]string{
+				"other-flag":	"1G",
+				"another-flag":	"2G",
+			},
+			autoRename:	true,
+			expected: map[string]string{
+				"another-flag": "2G",
+			},
+			expectedSources: map[string]flagSource{
+				"another-flag": cliSource,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			sources := map[string]flagSource{}
+			for n := range tt.flags {
+				sources[n] = cliSource
+			}
+			renameDeprecatedFlags(tt.flags, sources, renames, tt.autoRename)
+			require.Equal(st, tt.expected, tt.flags)
+			require.Equal(st, tt.expectedSources, sources)
+		})
+	}
+}
+
+func TestValidateFlagCombinations(t *testing.T) {
+	tests := []struct {
+		name		string
+		flags		map[string]string
+		expectedErrMsg	string
+	}{{
+		name:	"it should allow --smp up to the CPUs in --cpuset",
+		flags: map[string]string{
+			"cpuset":	"0-1,4",
+			"smp":		"3",
+		},
+	}, {
+		name:	"it should fail if --smp exceeds the CPUs in --cpuset",
+		flags: map[string]string{
+			"cpuset":	"0-1,4",
+			"smp":		"4",
+		},
+		expectedErrMsg:	"--smp=4 (from cli) and --cpuset=0-1,4 (from cli) conflict: there are more shards than the 3 CPUs in the cpuset",
+	}, {
+		name:	"it should fail if --cpuset is invalid",
+		flags: map[string]string{
+			"cpuset":	"1-0",
+			"smp":		"1",
+		},
+		expectedErrMsg:	"configured cpuset '1-0' is invalid: range '1-0' is reversed. Its first CPU must be lower than its last one, e.g. '0-1'",
+	}, {
+		name:	"it should allow --thread-affinity without --cpuset",
+		flags: map[string]string{
+			"thread-affinity": "false",
+		},
+	}, {
+		name:	"it should fail if --thread-affinity is disabled with --cpuset",
+		flags: map[string]string{
+			"cpuset":		"0-3",
+			"thread-affinity":	"0",
+		},
+		expectedErrMsg:	"--thread-affinity=0 (from cli) and --cpuset=0-3 (from cli) conflict: the shards aren't pinned to the cpuset's CPUs without thread affinity",
+	}, {
+		name:	"it should fail if both --io-properties and --io-properties-file are set",
+		flags: map[string]string{
+			"io-properties":	"{disks: []}",
+			"io-properties-file":	"/etc/redpanda/io-config.yaml",
+		},
+		expectedErrMsg:	"--io-properties (from cli) and --io-properties-file (from cli) conflict: only one of them may be set",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			sources := map[string]flagSource{}
+			for n := range tt.flags {
+				sources[n] = cliSource
+			}
+			err := validateFlagCombinations(tt.flags, sources)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+		})
+	}
+}
+
+func TestParseMemoryPercent(t *testing.T) {
+	tests := []struct {
+		name		string
+		percent		string
+		expected	float64
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should parse a percentage with a '%' suffix",
+			percent:	"80%",
+			expected:	80,
+		},
+		{
+			name:		"it should parse a plain number",
+			percent:	"62.5",
+			expected:	62.5,
+		},
+		{
+			name:		"it should fail if the percentage is over 100",
+			percent:	"120%",
+			expectedErrMsg:	"invalid memory percentage '120%'. It must be a number between 0 (exclusive) and 100, optionally followed by '%'",
+		},
+		{
+			name:		"it should fail if the value isn't a number",
+			percent:	"lots",
+			expectedErrMsg:	"invalid memory percentage 'lots'. It must be a number between 0 (exclusive) and 100, optionally followed by '%'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			p, err := parseMemoryPercent(tt.percent)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, p)
+		})
+	}
+}
+
+func TestPinToNUMANode(t *testing.T) {
+	const meminfo = `Node 1 MemTotal:        4194304 kB
+Node 1 MemFree:         1048576 kB
+Node 1 MemUsed:         3145728 kB
+Node 1 FilePages:       1048576 kB
+`
+	tests := []struct {
+		name		string
+		flags		map[string]string
+		expectedCpuset	string
+		expectedErrMsg	string
+		expectedWarning	string
+	}{
+		{
+			name:		"it should set the cpuset if the memory fits",
+			flags:		map[string]string{"memory": "1G"},
+			expectedCpuset:	"8-15",
+		},
+		{
+			name:			"it should warn if the memory isn't available",
+			flags:			map[string]string{"memory": "3G"},
+			expectedCpuset:		"8-15",
+			expectedWarning:	"--memory 3G is more than NUMA node 1's available memory (2GiB of 4GiB), so part of it may be allocated on other nodes",
+		},
+		{
+			name:			"it should warn if the memory isn't set",
+			flags:			map[string]string{},
+			expectedCpuset:		"8-15",
+			expectedWarning:	"--memory isn't set, so redpanda will use memory from all the NUMA nodes, not just node 1 (4GiB)",
+		},
+		{
+			name:		"it should fail if the memory doesn't fit",
+			flags:		map[string]string{"memory": "5G"},
+			expectedErrMsg:	"--memory 5G doesn't fit in NUMA node 1, which has 4GiB of memory (2GiB available)",
+		},
+		{
+			name:		"it should fail if the cpuset is also set",
+			flags:		map[string]string{"cpuset": "0-3"},
+			expectedErrMsg:	"--numa-node and --cpuset can't be set at the same time",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			err := afero.WriteFile(
+				fs,
+				"/sys/devices/system/node/node1/cpulist",
+				[]byte("8-15\n"),
+				0644,
+			)
+			require.NoError(st, err)
+			err = afero.WriteFile(
+				fs,
+				"/sys/devices/system/node/node1/meminfo",
+				[]byte(meminfo),
+				0644,
+			)
+			require.NoError(st, err)
+			var out bytes.Buffer
+			logrus.SetOutput(&out)
+			args := &rp.RedpandaArgs{SeastarFlags: tt.flags}
+			err = pinToNUMANode(fs, args, 1)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expectedCpuset, args.SeastarFlags["cpuset"])
+			if tt.expectedWarning != "" {
+				require.Contains(st, out.String(), tt.expectedWarning)
+			}
+		})
+	}
+}
+
+func TestOperatorConfig(t *testing.T) {
+	args := &rp.RedpandaArgs{
+		ConfigFilePath:	"/etc/redpanda/redpanda.yaml",
+		SeastarFlags: map[string]string{
+			"smp":			"2",
+			"memory":		"4G",
+			"overprovisioned":	"false",
+			"mbind":		"true",
+		},
+		ExtraArgs:	[]string{"--default-log-level=debug"},
+	}
+	expected := `additionalConfiguration:
+  rpk.additional_start_flags: --mbind=true --memory=4G --smp=2 --default-log-level=debug
+`
+	out, err := operatorConfig(args)
+	require.NoError(t, err)
+	require.Equal(t, expected, out)
+}
+
+func TestWellKnownIoData(t *testing.T) {
+	i3Large, err := iotune.DataFor("/var/lib/redpanda", "aws", "i3.large", "default")
+	require.NoError(t, err)
+	tests := []struct {
+		name		string
+		wellKnownIo	string
+		expected	*iotune.IoProperties
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should resolve a full triple",
+			wellKnownIo:	"aws:i3.large:default",
+			expected:	i3Large,
+		},
+		{
+			name:		"it should pick the storage type if it's omitted",
+			wellKnownIo:	"aws:i3.large",
+			expected:	i3Large,
+		},
+		{
+			name:		"it should pick the storage type if it's empty",
+			wellKnownIo:	"aws:i3.large:",
+			expected:	i3Large,
+		},
+		{
+			name:		"it should fail if the VM type is missing",
+			wellKnownIo:	"aws::nvme",
+			expectedErrMsg:	"--well-known-io requires the VM type, but 'aws::nvme' has none. Run 'rpk iotune list-well-known --vendor aws' to list the known ones",
+		},
+		{
+			name:		"it should fail if the VM type is unknown",
+			wellKnownIo:	"aws:unknown.vm",
+			expectedErrMsg:	"no iotune data found for VM 'unknown.vm', of vendor 'aws'",
+		},
+		{
+			name:		"it should fail if there's only the vendor",
+			wellKnownIo:	"aws",
+			expectedErrMsg:	"--well-known-io should have the format '<vendor>:<vm type>:<storage type>'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			props, err := wellKnownIoData("/var/lib/redpanda", tt.wellKnownIo)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, props)
+		})
+	}
+}
+
+func TestCachedWellKnownIo(t *testing.T) {
+	const dataDir = "/var/lib/redpanda/data"
+	i3Large, err := iotune.DataFor(dataDir, "aws", "i3.large", "default")
+	require.NoError(t, err)
+	// Differs from i3Large, to tell whether the cache was used.
+	cachedProps := iotune.IoProperties{MountPoint: dataDir, ReadIops: 1}
+	tests := []struct {
+		name		string
+		cached		*wellKnownIoCache
+		refresh		bool
+		expected	*iotune.IoProperties
+	}{
+		{
+			name:		"it should deduce and cache the props if there's no cache",
+			expected:	i3Large,
+		},
+		{
+			name:	"it should use the cached props if they're still valid",
+			cached: &wellKnownIoCache{
+				DataDirectory:	dataDir,
+				WellKnownIo:	"aws:i3.large:default",
+				ResolvedAt:	time.Now().Add(-time.Hour),
+				Properties:	cachedProps,
+			},
+			expected:	&cachedProps,
+		},
+		{
+			name:	"it should deduce the props again if they're expired",
+			cached: &wellKnownIoCache{
+				DataDirectory:	dataDir,
+				WellKnownIo:	"aws:i3.large:default",
+				ResolvedAt:	time.Now().Add(-wellKnownIoCacheTTL - time.Hour),
+				Properties:	cachedProps,
+			},
+			expected:	i3Large,
+		},
+		{
+			name:	"it should deduce the props again if well_known_io changed",
+			cached: &wellKnownIoCache{
+				DataDirectory:	dataDir,
+				WellKnownIo:	"aws:i3.xlarge:default",
+				ResolvedAt:	time.Now().Add(-time.Hour),
+				Properties:	cachedProps,
+			},
+			expected:	i3Large,
+		},
+		{
+			name:	"it should deduce the props again if the data dir changed",
+			cached: &wellKnownIoCache{
+				DataDirectory:	"/mnt/redpanda",
+				WellKnownIo:	"aws:i3.large:default",
+				ResolvedAt:	time.Now().Add(-time.Hour),
+				Properties:	cachedProps,
+			},
+			expected:	i3Large,
+		},
+		{
+			name:	"it should deduce the props again if refresh is true",
+			cached: &wellKnownIoCache{
+				DataDirectory:	dataDir,
+				WellKnownIo:	"aws:i3.large:default",
+				ResolvedAt:	time.Now().Add(-time.Hour),
+				Properties:	cachedProps,
+			},
+			refresh:	true,
+			expected:	i3Large,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			conf := config.Default()
+			conf.Redpanda.Directory = dataDir
+			conf.Rpk.WellKnownIo = "aws:i3.large:default"
+			path := filepath.Join(dataDir, wellKnownIoCacheFile)
+			if tt.cached != nil {
+				require.NoError(st, writeWellKnownIoCache(fs, path, tt.cached))
+			}
+			props, err := cachedWellKnownIo(fs, conf, false, tt.refresh)
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, props)
+
+			cached, err := readWellKnownIoCache(fs, path)
+			require.NoError(st, err)
+			require.NotNil(st, cached)
+			require.True(
+				st,
+				cached.validFor(conf, currentVmIdentity(fs), time.Now()),
+			)
+			require.Equal(st, *tt.expected, cached.Properties)
+			if tt.expected == i3Large {
+				require.Equal(st, "aws:i3.large:default", cached.Profile)
+			}
+		})
+	}
+}
+
+func TestWellKnownIoCacheValidFor(t *testing.T) {
+	const dataDir = "/var/lib/redpanda/data"
+	vm := vmIdentity{Hostname: "redpanda-0", InstanceID: "i-0123456789"}
+	detected := func() *wellKnownIoCache {
+		return &wellKnownIoCache{
+			DataDirectory:	dataDir,
+			Profile:	"aws:i3.large:default",
+			Vendor:		"aws",
+			VmType:		"i3.large",
+			VM:		vm,
+			ResolvedAt:	time.Now().Add(-time.Hour),
+		}
+	}
+	tests := []struct {
+		name		string
+		cached		func() *wellKnownIoCache
+		expected	bool
+	}{
+		{
+			name:		"it should take the props detected on the same VM",
+			cached:		detected,
+			expected:	true,
+		},
+		{
+			name:	"it shouldn't take the props detected on another host",
+			cached: func() *wellKnownIoCache {
+				c := detected()
+				c.VM.Hostname = "redpanda-1"
+				return c
+			},
+		},
+		{
+			name:	"it shouldn't take the props detected on another instance",
+			cached: func() *wellKnownIoCache {
+				c := detected()
+				c.VM.InstanceID = "i-9876543210"
+				return c
+			},
+		},
+		{
+			name:	"it shouldn't take the props if the detected VM is unknown",
+			cached: func() *wellKnownIoCache {
+				c := detected()
+				c.Vendor, c.VmType = "", ""
+				return c
+			},
+		},
+		{
+			name:	"it should take the props from well_known_io on another host",
+			cached: func() *wellKnownIoCache {
+				c := detected()
+				c.WellKnownIo = "aws:i3.large:default"
+				c.VM.Hostname = "redpanda-1"
+				return c
+			},
+			expected:	true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			conf := config.Default()
+			conf.Redpanda.Directory = dataDir
+			cached := tt.cached()
+			conf.Rpk.WellKnownIo = cached.WellKnownIo
+			require.Equal(
+				st,
+				tt.expected,
+				cached.validFor(conf, vm, time.Now()),
+			)
+		})
+	}
+}
+
+func TestCurrentVmIdentity(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	require.Equal(t, vmIdentity{Hostname: hostname}, currentVmIdentity(fs))
+
+	err = afero.WriteFile(
+		fs,
+		cloudInitInstanceIDFile,
+		[]byte("i-0123456789\n"),
+		0644,
+	)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		vmIdentity{Hostname: hostname, InstanceID: "i-0123456789"},
+		currentVmIdentity(fs),
+	)
+}
+
+func TestLocalIoPropertiesFile(t *testing.T) {
+	props := `disks:
+- mountpoint: /var/lib/redpanda/data
+  read_iops: 200000
+  read_bandwidth: 1000000000
+  write_iops: 100000
+  write_bandwidth: 500000000
+`
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, props)
+		},
+	))
+	defer server.Close()
+
+	fs := afero.NewMemMapFs()
+	conf := config.Default()
+
+	path, err := localIoPropertiesFile(fs, conf, "/etc/redpanda/io-config.yaml")
+	require.NoError(t, err)
+	require.Equal(t, "/etc/redpanda/io-config.yaml", path)
+
+	url := server.URL + "/io-config.yaml"
+	path, err = localIoPropertiesFile(fs, conf, url)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(path, "/var/lib/redpanda/data/.rpk_io_properties/"))
+	bs, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Equal(t, props, string(bs))
+
+	// The next starts should use the downloaded copy.
+	server.Close()
+	cached, err := localIoPropertiesFile(fs, conf, url)
+	require.NoError(t, err)
+	require.Equal(t, path, cached)
+}
+
+func TestLocalIoPropertiesFileInvalid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/invalid.yaml" {
+				fmt.Fprint(w, "not: [valid")
+				return
+			}
+			http.NotFound(w, r)
+		},
+	))
+	defer server.Close()
+	fs := afero.NewMemMapFs()
+	conf := config.Default()
+
+	_, err := localIoPropertiesFile(fs, conf, server.URL+"/missing.yaml")
+	require.EqualError(
+		t,
+		err,
+		fmt.Sprintf(
+			"couldn't download the IO properties file from '%s/missing.yaml': request failed with status 404 Not Found",
+			server.URL,
+		),
+	)
+
+	_, err = localIoPropertiesFile(fs, conf, server.URL+"/invalid.yaml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is invalid")
+	// The invalid download shouldn't be cached.
+	files, err := afero.ReadDir(fs, "/var/lib/redpanda/data/.rpk_io_properties")
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestSelectIoCandidate(t *testing.T) {
+	candidates := []iotune.Candidate{{
+		Vendor:		"aws",
+		VM:		"m5d.large",
+		Storage:	"ebs",
+		Props:		iotune.IoProperties{WriteIops: 100},
+	}, {
+		Vendor:		"aws",
+		VM:		"m5d.large",
+		Storage:	"nvme",
+		Props:		iotune.IoProperties{WriteIops: 1000},
+	}}
+	tests := []struct {
+		name		string
+		choose		bool
+		input		string
+		expected	string
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should pick the best one if choose is false",
+			input:		"1\n",
+			expected:	"aws:m5d.large:nvme",
+		},
+		{
+			name:		"it should use the chosen one",
+			choose:		true,
+			input:		"1\n",
+			expected:	"aws:m5d.large:ebs",
+		},
+		{
+			name:		"it should default to the best one",
+			choose:		true,
+			input:		"\n",
+			expected:	"aws:m5d.large:nvme",
+		},
+		{
+			name:		"it should ask again if the option is invalid",
+			choose:		true,
+			input:		"3\nfoo\n1\n",
+			expected:	"aws:m5d.large:ebs",
+		},
+		{
+			name:		"it should fail if the user quits",
+			choose:		true,
+			input:		"q\n",
+			expectedErrMsg:	"user exited",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			c, err := selectIoCandidate(
+				candidates,
+				tt.choose,
+				strings.NewReader(tt.input),
+			)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, c.String())
+		})
+	}
+}
+
+func TestStartPrintInstallDir(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mgr := config.NewManager(fs)
+	conf := config.Default()
+	conf.Rpk.InstallDir = "/opt/redpanda"
+	require.NoError(t, mgr.Write(conf))
+	err := afero.WriteFile(fs, rp.BinaryPath("/opt/redpanda"), []byte{}, 0755)
+	require.NoError(t, err)
+
+	launcher := &noopLauncher{}
+	var out bytes.Buffer
+	c := NewStartCommand(fs, mgr, launcher)
+	c.SetOut(&out)
+	c.SetArgs([]string{"--print-install-dir"})
+	require.NoError(t, c.Execute())
+	require.Nil(t, launcher.rpArgs)
+	require.Equal(t, "/opt/redpanda\n", out.String())
+}
+
+func TestStartLogsRedpandaVersion(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mgr := config.NewManager(fs)
+	require.NoError(t, mgr.Write(config.Default()))
+
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	defer logrus.SetOutput(os.Stderr)
+	c := NewStartCommand(fs, mgr, &noopLauncher{})
+	c.SetArgs([]string{"--install-dir", "/var/lib/redpanda", "--check=false"})
+	require.NoError(t, c.Execute())
+	require.Contains(
+		t,
+		out.String(),
+		"Using redpanda v21.4.2 at '/var/lib/redpanda/bin/redpanda'",
+	)
+}
+
+func TestStartDryRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mgr := config.NewManager(fs)
+	conf := config.Default()
+	conf.Rpk.AdditionalStartFlags = []string{"--memory=2G"}
+	conf.Rpk.EnableMemoryLocking = true
+	conf.Rpk.WellKnownIo = "aws:i3.large:default"
+	require.NoError(t, mgr.Write(conf))
+
+	launcher := &noopLauncher{}
+	var out bytes.Buffer
+	c := NewStartCommand(fs, mgr, launcher)
+	c.SetOut(&out)
+	c.SetArgs([]string{
+		"--install-dir", "/var/lib/redpanda",
+		"--dry-run",
+		"--smp", "4",
+		"--", "--default-log-level=debug",
+	})
+	require.NoError(t, c.Execute())
+	require.Nil(t, launcher.rpArgs)
+
+	cmdLine := out.String()
+	require.True(
+		t,
+		strings.HasPrefix(cmdLine, "/var/lib/redpanda/bin/redpanda "),
+		cmdLine,
+	)
+	// The values from the config, such as rpk.additional_start_flags and
+	// rpk.well_known_io, should be resolved along with the CLI flags.
+	require.Contains(t, cmdLine, "--memory=2G")
+	require.Contains(t, cmdLine, "--lock-memory=true")
+	require.Contains(t, cmdLine, "--smp=4")
+	require.Contains(t, cmdLine, "--io-properties=")
+	require.True(
+		t,
+		strings.HasSuffix(cmdLine, " --default-log-level=debug\n"),
+		cmdLine,
+	)
+}
+
+func TestWarnUnknownEnvFlags(t *testing.T) {
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	warnUnknownEnvFlags([]string{
+		"RPK_SMP=2",
+		"RPK_MAX_IO_REQUESTS=128",
+		"RPK_SMPP=2",
+		"REDPANDA_SEEDS=host+1",
+	})
+	require.Contains(
+		t,
+		out.String(),
+		"Ignoring env var 'RPK_SMPP', which doesn't match any of the flags passed to redpanda",
+	)
+	require.NotContains(t, out.String(), "'RPK_SMP'")
+	require.NotContains(t, out.String(), "RPK_MAX_IO_REQUESTS")
+	require.NotContains(t, out.String(), "REDPANDA_SEEDS")
+}
+
+type fakeDisruptiveTuner struct {
+	fakeTuner
+}
+
+func (*fakeDisruptiveTuner) IsDisruptive() bool {
+	return true
+}
+
+func TestRunTuners(t *testing.T) {
+	tunersFactory := fakeTunersFactory{
+		"aio_events":	&fakeTuner{supported: true},
+		"clocksource":	&fakeTuner{supported: true},
+		"cpu":		&fakeTuner{supported: false},
+		"net":		&fakeDisruptiveTuner{fakeTuner{supported: true}},
+		"swappiness":	&fakeTuner{supported: true, err: errors.New("boom")},
+	}
+	conf := config.Default()
+	conf.Rpk.TuneAioEvents = true
+	conf.Rpk.TuneClocksource = false
+	conf.Rpk.TuneCpu = true
+	conf.Rpk.TuneNetwork = true
+	conf.Rpk.TuneSwappiness = true
+	names := []string{"aio_events", "clocksource", "cpu", "net", "swappiness"}
+	skipDisruptive := func(string) (bool, error) { return false, nil }
+
+	payloads, err := runTuners(
+		afero.NewMemMapFs(),
+		conf,
+		names,
+		tunersFactory,
+		&factory.TunerParams{},
+		time.Second,
+		skipDisruptive,
+	)
+	require.EqualError(t, err, "boom")
+	// There should be a payload for each tuner, and no empty ones.
+	require.Len(t, payloads, len(names))
+	for i, p := range payloads {
+		require.Equal(t, names[i], p.Name)
+	}
+	require.True(t, payloads[0].Enabled)
+	require.False(t, payloads[1].Enabled)
+	require.False(t, payloads[2].Supported)
+	require.True(t, payloads[3].Skipped)
+	require.Equal(t, "boom", payloads[4].ErrorMsg)
+}
+
+// Records the time it's given to run, and takes a while to run.
+type deadlineTuner struct {
+	fakeTuner
+	given	time.Duration
+}
+
+func (t *deadlineTuner) TuneWithContext(
+	ctx context.Context, _ *tuners.Progress,
+) tuners.TuneResult {
+	deadline, _ := ctx.Deadline()
+	t.given = time.Until(deadline)
+	time.Sleep(5 * time.Millisecond)
+	return t.Tune()
+}
+
+func TestRunTunersTimeouts(t *testing.T) {
+	tests := []struct {
+		name		string
+		timeouts	map[string]string
+		expectedErrMsg	string
+		check		func(*testing.T, *deadlineTuner, *deadlineTuner)
+	}{
+		{
+			name:	"it should give the tuners the global timeout by default",
+			check: func(st *testing.T, aio, swappiness *deadlineTuner) {
+				require.InDelta(st, time.Second, aio.given, float64(100*time.Millisecond))
+				require.InDelta(st, time.Second, swappiness.given, float64(100*time.Millisecond))
+			},
+		},
+		{
+			name:		"it should give a tuner its override from rpk.tuner_timeouts",
+			timeouts:	map[string]string{"swappiness": "1m"},
+			check: func(st *testing.T, aio, swappiness *deadlineTuner) {
+				require.InDelta(st, time.Second, aio.given, float64(100*time.Millisecond))
+				require.InDelta(st, time.Minute, swappiness.given, float64(100*time.Millisecond))
+			},
+		},
+		{
+			name:		"it should fail if an override is invalid",
+			timeouts:	map[string]string{"swappiness": "soon"},
+			expectedErrMsg:	"invalid timeout 'soon' for tuner 'swappiness' in rpk.tuner_timeouts. It must be a positive duration, e.g. '5m'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			aio := &deadlineTuner{fakeTuner: fakeTuner{supported: true}}
+			swappiness := &deadlineTuner{fakeTuner: fakeTuner{supported: true}}
+			tunersFactory := fakeTunersFactory{
+				"aio_events":	aio,
+				"swappiness":	swappiness,
+			}
+			conf := config.Default()
+			conf.Rpk.TuneAioEvents = true
+			conf.Rpk.TuneSwappiness = true
+			conf.Rpk.TunerTimeouts = tt.timeouts
+
+			payloads, err := runTuners(
+				afero.NewMemMapFs(),
+				conf,
+				[]string{"aio_events", "swappiness"},
+				tunersFactory,
+				&factory.TunerParams{},
+				time.Second,
+				func(string) (bool, error) { return true, nil },
+			)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				require.Equal(st, tt.expectedErrMsg, payloads[len(payloads)-1].ErrorMsg)
+				return
+			}
+			require.NoError(st, err)
+			tt.check(st, aio, swappiness)
+			// How long each tuner took should be recorded.
+			for _, p := range payloads {
+				require.GreaterOrEqual(st, p.DurationMs, int64(5))
+			}
+		})
+	}
+}
+
+// Fakes hwloc's view of the machine's CPUs.
+type cpusHwLocMock struct {
+	hwloc.HwLoc
+	all		string
+	supported	bool
+}
+
+func (m *cpusHwLocMock) All() (string, error) {
+	return m.all, nil
+}
+
+func (m *cpusHwLocMock) IsSupported() bool {
+	return m.supported
+}
+
+func TestValidateCpuset(t *testing.T) {
+	tests := []struct {
+		name		string
+		hw		*cpusHwLocMock
+		cpuset		string
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it shouldn't fail if all the CPUs are available",
+			hw:	&cpusHwLocMock{all: "0x000000ff", supported: true},
+			cpuset:	"0-3,7",
+		},
+		{
+			name:		"it should list the CPUs which aren't available",
+			hw:		&cpusHwLocMock{all: "0x0000000f", supported: true},
+			cpuset:		"2-5,9",
+			expectedErrMsg:	"--cpuset 2-5,9 includes CPUs which aren't available on this machine: 4, 5, 9",
+		},
+		{
+			name:	"it should skip the validation if hwloc isn't installed",
+			hw:	&cpusHwLocMock{all: "0x0000000f"},
+			cpuset:	"2-5,9",
+		},
+		{
+			name:	"it shouldn't validate 'all'",
+			hw:	&cpusHwLocMock{all: "0x0000000f", supported: true},
+			cpuset:	"all",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			err := validateCpuset(tt.hw, cpuSetFlag, tt.cpuset)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+		})
+	}
+}
+
+func TestCheckFailedActions(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	tests := []struct {
+		name			string
+		onSwapFailure		*bool
+		expectedLockMemory	string
+	}{
+		{
+			name:			"it should disable --lock-memory by default",
+			expectedLockMemory:	"false",
+		},
+		{
+			name:			"it should disable --lock-memory if rpk.lock_memory_on_swap_failure is true",
+			onSwapFailure:		boolPtr(true),
+			expectedLockMemory:	"false",
+		},
+		{
+			name:			"it should keep --lock-memory if rpk.lock_memory_on_swap_failure is false",
+			onSwapFailure:		boolPtr(false),
+			expectedLockMemory:	"true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			conf := config.Default()
+			conf.Rpk.LockMemoryOnSwapFailure = tt.onSwapFailure
+			args := &rp.RedpandaArgs{
+				SeastarFlags: map[string]string{lockMemoryFlag: "true"},
+			}
+			action := checkFailedActions(args, conf)[tuners.SwapChecker]
+			action(&tuners.CheckResult{CheckerId: tuners.SwapChecker})
+			require.Equal(st, tt.expectedLockMemory, args.SeastarFlags[lockMemoryFlag])
+		})
+	}
+}
+
+func TestSendEnv(t *testing.T) {
+	tests := []struct {
+		name		string
+		enableStats	bool
+		expectedSent	bool
+	}{
+		{
+			name:		"it should send the environment if usage stats are enabled",
+			enableStats:	true,
+			expectedSent:	true,
+		},
+		{
+			name:	"it shouldn't send the environment if usage stats are disabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			conf.Rpk.EnableUsageStats = tt.enableStats
+			require.NoError(st, mgr.Write(conf))
+			env := api.EnvironmentPayload{
+				Checks:	[]api.CheckPayload{{Name: "swap"}},
+				Tuners:	[]api.TunerPayload{{Name: "cpu"}},
+			}
+			var sent *api.EnvironmentPayload
+			send := func(
+				_ afero.Fs,
+				e api.EnvironmentPayload,
+				_ config.Config,
+				_ string,
+			) error {
+				sent = &e
+				return nil
+			}
+			sendEnvWith(send, fs, mgr, env, conf, errors.New("failed"))
+			// The payloads are still there for local inspection.
+			require.Equal(st, []api.CheckPayload{{Name: "swap"}}, env.Checks)
+			require.Equal(st, []api.TunerPayload{{Name: "cpu"}}, env.Tuners)
+			if !tt.expectedSent {
+				require.Nil(st, sent)
+				return
+			}
+			require.NotNil(st, sent)
+			require.Equal(st, env.Checks, sent.Checks)
+			require.Equal(st, env.Tuners, sent.Tuners)
+			require.Equal(st, "failed", sent.ErrorMsg)
+		})
+	}
+}
+
+type fakeReadinessChecker struct {
+	responses	[]string
+	readyAt		int
+	calls		int
+}
+
+func (c *fakeReadinessChecker) Ready() (bool, string, error) {
+	i := c.calls
+	c.calls++
+	if c.readyAt >= 0 && i >= c.readyAt {
+		return true, "200 {\"status\":\"ready\"}", nil
+	}
+	if i < len(c.responses) {
+		return false, c.responses[i], nil
+	}
+	return false, "", errors.New("connection refused")
+}
+
+func TestWaitForReady(t *testing.T) {
+	tests := []struct {
+		name		string
+		checker		*fakeReadinessChecker
+		exit		bool
+		exitErr		error
+		cancelled	bool
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should return once redpanda is ready",
+			checker:	&fakeReadinessChecker{readyAt: 2},
+		},
+		{
+			name:	"it should fail with the last response if the timeout elapses",
+			checker: &fakeReadinessChecker{
+				readyAt:	-1,
+				responses: []string{
+					"503 {\"status\":\"booting\"}",
+					"503 {\"status\":\"booting\"}",
+				},
+			},
+			expectedErrMsg:	"redpanda wasn't ready after 50ms, and was left running. Last response: connection refused",
+		},
+		{
+			name:		"it should fail if redpanda exits before it's ready",
+			checker:	&fakeReadinessChecker{readyAt: -1},
+			exit:		true,
+			exitErr:	errors.New("exit status 1"),
+			expectedErrMsg:	"redpanda exited before it was ready: exit status 1",
+		},
+		{
+			name:		"it should fail with the last response if ctx is done",
+			checker:	&fakeReadinessChecker{readyAt: -1},
+			cancelled:	true,
+			expectedErrMsg:	"redpanda wasn't ready before --start-timeout elapsed. Last response: connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			exited := make(chan error, 1)
+			if tt.exit {
+				exited <- tt.exitErr
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+			err := waitForReady(
+				ctx,
+				tt.checker,
+				exited,
+				50*time.Millisecond,
+				time.Millisecond,
+			)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+		})
+	}
+}
+
+func TestStartAndWaitForReadyUnsupportedLauncher(t *testing.T) {
+	err := startAndWaitForReady(
+		&noopLauncher{},
+		"",
+		&rp.RedpandaArgs{},
+		&fakeReadinessChecker{readyAt: 0},
+		time.Second,
+		0,
+	)
+	require.EqualError(
+		t,
+		err,
+		"--wait-for-ready isn't supported, since redpanda can't be started in the background",
+	)
+}
+
+type fakeBackgroundLauncher struct {
+	noopLauncher
+	exited	chan error
+	killed	[]int
+}
+
+func (l *fakeBackgroundLauncher) StartInBackground(
+	ctx context.Context, _ string, rpArgs *rp.RedpandaArgs,
+) (int, <-chan error, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, nil, err
+	}
+	l.rpArgs = rpArgs
+	l.exited = make(chan error, 1)
+	return 42, l.exited, nil
+}
+
+func (l *fakeBackgroundLauncher) Kill(pid int) error {
+	l.killed = append(l.killed, pid)
+	l.exited <- errors.New("signal: killed")
+	return nil
+}
+
+func TestStartAndWaitForReadyStartTimeout(t *testing.T) {
+	tests := []struct {
+		name		string
+		checker		*fakeReadinessChecker
+		readyTimeout	time.Duration
+		startTimeout	time.Duration
+		expectedKilled	[]int
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it shouldn't kill redpanda if it's ready in time",
+			checker:	&fakeReadinessChecker{readyAt: 1},
+			readyTimeout:	time.Second,
+			startTimeout:	time.Second,
+		},
+		{
+			name:		"it should kill redpanda if it isn't ready before --start-timeout",
+			checker:	&fakeReadinessChecker{readyAt: -1},
+			readyTimeout:	time.Minute,
+			startTimeout:	50 * time.Millisecond,
+			expectedKilled:	[]int{42},
+			expectedErrMsg:	"redpanda wasn't ready before --start-timeout elapsed. Last response: connection refused. redpanda (PID 42) was killed",
+		},
+		{
+			name:		"it shouldn't kill redpanda if --ready-timeout elapses first",
+			checker:	&fakeReadinessChecker{readyAt: -1},
+			readyTimeout:	50 * time.Millisecond,
+			expectedErrMsg:	"redpanda wasn't ready after 50ms, and was left running. Last response: connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			launcher := &fakeBackgroundLauncher{}
+			err := startAndWaitForReady(
+				launcher,
+				"",
+				&rp.RedpandaArgs{},
+				tt.checker,
+				tt.readyTimeout,
+				tt.startTimeout,
+			)
+			require.Equal(st, tt.expectedKilled, launcher.killed)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
 		})
 	}
 }
@@ -0,0 +1,220 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api/admin"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+// The local node's status, and the cluster's as the node sees it.
+type nodeStatus struct {
+	NodeID		int	`json:"node_id"`
+	Running		bool	`json:"running"`
+	PID		int	`json:"pid,omitempty"`
+	AdminAPI	string	`json:"admin_api"`
+	Ready		bool	`json:"ready"`
+	Version		string	`json:"version,omitempty"`
+	// Unset if the admin API couldn't be queried for the cluster's info.
+	Cluster	*clusterStatus	`json:"cluster,omitempty"`
+	// What couldn't be queried, e.g. because the admin API is down.
+	Errors	[]string	`json:"errors,omitempty"`
+}
+
+type clusterStatus struct {
+	Brokers		int	`json:"brokers"`
+	AliveBrokers	int	`json:"alive_brokers"`
+	// The node which leads the controller. -1 if there's none, or if it
+	// couldn't be queried.
+	ControllerID	int	`json:"controller_id"`
+	Healthy		*bool	`json:"healthy,omitempty"`
+	NodesDown	[]int	`json:"nodes_down,omitempty"`
+}
+
+// The admin API calls the status is built from.
+type statusClient interface {
+	Ready() (bool, string, error)
+	Brokers() ([]admin.Broker, error)
+	HealthOverview() (*admin.HealthOverview, error)
+}
+
+func NewStatusCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
+	var (
+		configFile	string
+		format		string
+		timeout		time.Duration
+	)
+	command := &cobra.Command{
+		Use:	"status",
+		Short:	"Show whether the local redpanda is running, and its cluster's health",
+		Long: "Show whether redpanda is running on this machine, according" +
+			" to its PID file or /proc, and query its admin API for its" +
+			" version and the cluster's brokers, controller and health." +
+			" The cluster's info is left out if the admin API can't be" +
+			" reached.",
+		Args:		cobra.NoArgs,
+		SilenceUsage:	true,
+		RunE: func(ccmd *cobra.Command, _ []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("unsupported format '%s'", format)
+			}
+			conf, err := mgr.ReadOrFind(configFile)
+			if err != nil {
+				return err
+			}
+			url := admin.LocalURL(conf.Redpanda.AdminApi)
+			status := nodeStatus{
+				NodeID:		conf.Redpanda.Id,
+				AdminAPI:	url,
+			}
+			pid, found, err := findRedpandaPID(fs, conf)
+			if err != nil {
+				status.Errors = append(
+					status.Errors,
+					fmt.Sprintf("couldn't find redpanda's process: %v", err),
+				)
+			}
+			status.Running, status.PID = found, pid
+			queryStatus(&status, admin.NewClient(url, timeout))
+			return printStatus(ccmd.OutOrStdout(), status, format)
+		},
+	}
+	command.Flags().StringVar(
+		&configFile,
+		"config",
+		"",
+		"Redpanda config file, if not set the file will be searched for"+
+			" in the default locations",
+	)
+	command.Flags().StringVar(
+		&format,
+		"format",
+		"text",
+		"The output format. Can be 'text' or 'json'",
+	)
+	command.Flags().DurationVar(
+		&timeout,
+		"timeout",
+		5*time.Second,
+		"The maximum time to wait for each admin API request",
+	)
+	return command
+}
+
+// Fills in the status from the admin API. The requests which fail are
+// recorded in status.Errors, so that whatever could be queried is still shown.
+func queryStatus(status *nodeStatus, client statusClient) {
+	ready, _, err := client.Ready()
+	if err != nil {
+		status.Errors = append(
+			status.Errors,
+			fmt.Sprintf("couldn't reach the admin API: %v", err),
+		)
+		return
+	}
+	status.Ready = ready
+
+	brokers, err := client.Brokers()
+	if err != nil {
+		status.Errors = append(
+			status.Errors,
+			fmt.Sprintf("couldn't list the brokers: %v", err),
+		)
+		return
+	}
+	cluster := &clusterStatus{Brokers: len(brokers), ControllerID: -1}
+	for _, b := range brokers {
+		if b.IsAlive == nil || *b.IsAlive {
+			cluster.AliveBrokers++
+		}
+		if b.NodeID == status.NodeID {
+			status.Version = b.Version
+		}
+	}
+	status.Cluster = cluster
+
+	health, err := client.HealthOverview()
+	if err != nil {
+		status.Errors = append(
+			status.Errors,
+			fmt.Sprintf("couldn't get the cluster's health: %v", err),
+		)
+		return
+	}
+	cluster.ControllerID = health.ControllerID
+	cluster.Healthy = &health.IsHealthy
+	cluster.NodesDown = health.NodesDown
+}
+
+func printStatus(out io.Writer, status nodeStatus, format string) error {
+	if format == "json" {
+		bs, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(bs))
+		return nil
+	}
+	process := "not running"
+	if status.Running {
+		process = fmt.Sprintf("running (PID %d)", status.PID)
+	}
+	ready := "not ready"
+	if status.Ready {
+		ready = "ready"
+	}
+	fmt.Fprintf(out, "Node ID:\t%d\n", status.NodeID)
+	fmt.Fprintf(out, "Process:\t%s\n", process)
+	fmt.Fprintf(out, "Admin API:\t%s (%s)\n", status.AdminAPI, ready)
+	if status.Version != "" {
+		fmt.Fprintf(out, "Version:\t%s\n", status.Version)
+	}
+	if c := status.Cluster; c != nil {
+		fmt.Fprintf(
+			out,
+			"Brokers:\t%d (%d alive)\n",
+			c.Brokers,
+			c.AliveBrokers,
+		)
+		if c.ControllerID >= 0 {
+			fmt.Fprintf(out, "Controller:\tnode %d\n", c.ControllerID)
+		}
+		if c.Healthy != nil {
+			health := "healthy"
+			if !*c.Healthy {
+				health = "unhealthy"
+			}
+			if len(c.NodesDown) > 0 {
+				down := make([]string, 0, len(c.NodesDown))
+				for _, id := range c.NodesDown {
+					down = append(down, fmt.Sprint(id))
+				}
+				health += fmt.Sprintf(
+					" (nodes down: %s)",
+					strings.Join(down, ", "),
+				)
+			}
+			fmt.Fprintf(out, "Health:\t\t%s\n", health)
+		}
+	}
+	for _, e := range status.Errors {
+		log.Warn(e)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api/admin"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+type fakeStatusClient struct {
+	readyErr	error
+	brokers		[]admin.Broker
+	brokersErr	error
+	health		*admin.HealthOverview
+	healthErr	error
+}
+
+func (c *fakeStatusClient) Ready() (bool, string, error) {
+	return c.readyErr == nil, "", c.readyErr
+}
+
+func (c *fakeStatusClient) Brokers() ([]admin.Broker, error) {
+	return c.brokers, c.brokersErr
+}
+
+func (c *fakeStatusClient) HealthOverview() (*admin.HealthOverview, error) {
+	return c.health, c.healthErr
+}
+
+func TestQueryStatus(t *testing.T) {
+	alive, dead := true, false
+	healthy := true
+	tests := []struct {
+		name		string
+		client		*fakeStatusClient
+		expected	nodeStatus
+	}{
+		{
+			name:	"it should summarize the node and the cluster",
+			client: &fakeStatusClient{
+				brokers: []admin.Broker{
+					{NodeID: 0, IsAlive: &alive, Version: "v21.4.1"},
+					{NodeID: 1, IsAlive: &alive, Version: "v21.4.2"},
+					{NodeID: 2, IsAlive: &dead},
+				},
+				health: &admin.HealthOverview{
+					IsHealthy:	true,
+					ControllerID:	0,
+				},
+			},
+			expected: nodeStatus{
+				NodeID:		1,
+				Ready:		true,
+				Version:	"v21.4.2",
+				Cluster: &clusterStatus{
+					Brokers:	3,
+					AliveBrokers:	2,
+					ControllerID:	0,
+					Healthy:	&healthy,
+				},
+			},
+		},
+		{
+			name:	"it should keep the brokers if the health overview fails",
+			client: &fakeStatusClient{
+				brokers:	[]admin.Broker{{NodeID: 1}},
+				healthErr:	errors.New("404 not found"),
+			},
+			expected: nodeStatus{
+				NodeID:	1,
+				Ready:	true,
+				Cluster: &clusterStatus{
+					Brokers:	1,
+					AliveBrokers:	1,
+					ControllerID:	-1,
+				},
+				Errors: []string{
+					"couldn't get the cluster's health: 404 not found",
+				},
+			},
+		},
+		{
+			name:	"it should only report the local node if the admin API is down",
+			client: &fakeStatusClient{
+				readyErr: errors.New("connection refused"),
+			},
+			expected: nodeStatus{
+				NodeID:	1,
+				Errors: []string{
+					"couldn't reach the admin API: connection refused",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			status := nodeStatus{NodeID: 1}
+			queryStatus(&status, tt.client)
+			require.Equal(st, tt.expected, status)
+		})
+	}
+}
+
+func TestPrintStatus(t *testing.T) {
+	healthy := false
+	status := nodeStatus{
+		NodeID:		1,
+		Running:	true,
+		PID:		42,
+		AdminAPI:	"http://127.0.0.1:9644",
+		Ready:		true,
+		Version:	"v21.4.2",
+		Cluster: &clusterStatus{
+			Brokers:	3,
+			AliveBrokers:	2,
+			ControllerID:	0,
+			Healthy:	&healthy,
+			NodesDown:	[]int{2},
+		},
+	}
+	var out bytes.Buffer
+	require.NoError(t, printStatus(&out, status, "text"))
+	require.Equal(
+		t,
+		"Node ID:\t1\n"+
+			"Process:\trunning (PID 42)\n"+
+			"Admin API:\thttp://127.0.0.1:9644 (ready)\n"+
+			"Version:\tv21.4.2\n"+
+			"Brokers:\t3 (2 alive)\n"+
+			"Controller:\tnode 0\n"+
+			"Health:\t\tunhealthy (nodes down: 2)\n",
+		out.String(),
+	)
+
+	out.Reset()
+	require.NoError(t, printStatus(&out, nodeStatus{NodeID: 1}, "json"))
+	require.Equal(
+		t,
+		`{"node_id":1,"running":false,"admin_api":"","ready":false}`+"\n",
+		out.String(),
+	)
+}
+
+func TestFindRedpandaPID(t *testing.T) {
+	process := func(fs afero.Fs, pid int, comm, configFile string) {
+		files := map[string]string{
+			"stat":		fmt.Sprintf("%d (%s) S 1 1 1 0", pid, comm),
+			"comm":		comm + "\n",
+			"cmdline":	comm + "\x00--redpanda-cfg\x00" + configFile + "\x00",
+		}
+		for name, content := range files {
+			path := fmt.Sprintf("/proc/%d/%s", pid, name)
+			require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0644))
+		}
+	}
+	tests := []struct {
+		name		string
+		launcherPID	string
+		before		func(afero.Fs, *config.Config)
+		expectedPID	int
+		expectedFound	bool
+	}{
+		{
+			name:		"it should take the PID 'rpk start' wrote if it's this node's redpanda",
+			launcherPID:	"4321",
+			before: func(fs afero.Fs, conf *config.Config) {
+				process(fs, 4321, "redpanda", conf.ConfigFile)
+				// It takes precedence over the ones in /proc.
+				process(fs, 4322, "redpanda", conf.ConfigFile)
+			},
+			expectedPID:	4321,
+			expectedFound:	true,
+		},
+		{
+			name:		"it should ignore a stale PID which was reused by another process",
+			launcherPID:	"4321",
+			before: func(fs afero.Fs, conf *config.Config) {
+				process(fs, 4321, "bash", conf.ConfigFile)
+			},
+		},
+		{
+			name:		"it should ignore a PID which belongs to another node's redpanda",
+			launcherPID:	"4321",
+			before: func(fs afero.Fs, conf *config.Config) {
+				process(fs, 4321, "redpanda", "/etc/redpanda/other.yaml")
+			},
+		},
+		{
+			name:		"it should take redpanda started with the --set overrides' config",
+			before: func(fs afero.Fs, conf *config.Config) {
+				process(fs, 4322, "redpanda", conf.OverridesConfigFile())
+			},
+			expectedPID:	4322,
+			expectedFound:	true,
+		},
+		{
+			name:	"it should take redpanda started with the config merged with its includes",
+			before: func(fs afero.Fs, conf *config.Config) {
+				conf.Include = []string{"base.yaml"}
+				process(fs, 4322, "redpanda", conf.RedpandaConfigFile())
+			},
+			expectedPID:	4322,
+			expectedFound:	true,
+		},
+		{
+			name:		"it should look in /proc if the PID is stale",
+			launcherPID:	"4321",
+			before: func(fs afero.Fs, conf *config.Config) {
+				process(fs, 4322, "redpanda", conf.ConfigFile)
+			},
+			expectedPID:	4322,
+			expectedFound:	true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			conf := config.Default()
+			if tt.launcherPID != "" {
+				err := afero.WriteFile(
+					fs,
+					conf.LauncherPIDFile(),
+					[]byte(tt.launcherPID+"\n"),
+					0644,
+				)
+				require.NoError(st, err)
+			}
+			tt.before(fs, conf)
+			pid, found, err := findRedpandaPID(fs, conf)
+			require.NoError(st, err)
+			require.Equal(st, tt.expectedFound, found)
+			require.Equal(st, tt.expectedPID, pid)
+		})
+	}
+}
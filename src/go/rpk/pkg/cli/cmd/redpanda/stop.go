@@ -11,7 +11,10 @@ package redpanda
 
 import (
 	"errors"
+	"fmt"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -34,7 +37,13 @@ func NewStopCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
 		Long: `Stop a local redpanda process. 'rpk stop'
 first sends SIGINT, and waits for the specified timeout. Then, if redpanda
 hasn't stopped, it sends SIGTERM. Lastly, it sends SIGKILL if it's still
-running.`,
+running.
+
+redpanda's PID is read from its PID file. If the file isn't locked, e.g.
+because redpanda wasn't started with it, the PID 'rpk start' wrote to
+rpk.pid_file (redpanda.pid in the data directory by default) is used, if that
+process is still redpanda. Otherwise, it's looked up in /proc, among the
+redpanda processes started with the same config file.`,
 		SilenceUsage:	true,
 		RunE: func(ccmd *cobra.Command, args []string) error {
 			return executeStop(fs, mgr, configFile, timeout)
@@ -68,29 +77,150 @@ func executeStop(
 	if err != nil {
 		return err
 	}
+	pid, found, err := findRedpandaPID(fs, conf)
+	if err != nil {
+		return err
+	}
+	if !found {
+		log.Info("redpanda isn't running. Nothing to do.")
+		return nil
+	}
+	err = signalAndWait(fs, pid, timeout)
+	if err != nil {
+		return err
+	}
+	log.Infof("Stopped redpanda (PID %d)", pid)
+	// 'rpk start' can't remove it if it exited before redpanda, e.g.
+	// with --wait-for-ready.
+	if launcherPID, err := readLauncherPID(fs, conf); err == nil && launcherPID == pid {
+		err = fs.Remove(conf.LauncherPIDFile())
+		if err != nil {
+			log.Debugf("Couldn't remove '%s': %v", conf.LauncherPIDFile(), err)
+		}
+	}
+	return nil
+}
+
+// Returns the PID in the PID file if it's locked, since that means redpanda is
+// running. Otherwise, it's the one 'rpk start' wrote, as long as that process
+// is still this node's redpanda, since the file may be stale. Failing that,
+// redpanda is looked up in /proc, among the processes started with conf's
+// config file, so that other nodes' redpandas (e.g. in containers sharing the
+// host's PID namespace) aren't mistaken for this one.
+func findRedpandaPID(
+	fs afero.Fs, conf *config.Config,
+) (pid int, found bool, err error) {
 	pidFile := conf.PIDFile()
 	isLocked, err := os.CheckLocked(pidFile)
 	if err != nil {
 		log.Debugf("error checking if the PID file is locked: %v", err)
 	}
-	if !isLocked {
-		// If the file isn't locked or doesn't exist, that means
-		// redpanda isn't running, so there's nothing to do.
-		log.Debugf(
-			"'%s' isn't locked, which means redpanda isn't running. Nothing to do.",
-			pidFile,
-		)
-		return nil
+	if isLocked {
+		pidStr, err := utils.ReadEnsureSingleLine(fs, pidFile)
+		if err != nil {
+			return 0, false, err
+		}
+		pid, err := strconv.Atoi(pidStr)
+		return pid, err == nil, err
+	}
+	launcherPID, err := readLauncherPID(fs, conf)
+	if err == nil && isNodeRedpanda(fs, launcherPID, conf) {
+		return launcherPID, true, nil
 	}
-	pidStr, err := utils.ReadEnsureSingleLine(fs, pidFile)
+	log.Debugf(
+		"'%s' isn't locked, and '%s' doesn't hold this node's redpanda's"+
+			" PID. Looking for redpanda in /proc",
+		pidFile,
+		conf.LauncherPIDFile(),
+	)
+	pids, err := os.FindPIDs(fs, "redpanda")
 	if err != nil {
-		return err
+		return 0, false, err
+	}
+	matching := []int{}
+	for _, pid := range pids {
+		if isNodeRedpanda(fs, pid, conf) {
+			matching = append(matching, pid)
+		}
+	}
+	switch len(matching) {
+	case 0:
+		return 0, false, nil
+	case 1:
+		return matching[0], true, nil
 	}
-	pid, err := strconv.Atoi(pidStr)
+	return 0, false, fmt.Errorf(
+		"found several redpanda processes (PIDs %s) and none of them"+
+			" holds '%s'. Stop the right one with 'kill'",
+		strings.Trim(fmt.Sprint(matching), "[]"),
+		pidFile,
+	)
+}
+
+// Reads the PID 'rpk start' wrote to conf.LauncherPIDFile().
+func readLauncherPID(fs afero.Fs, conf *config.Config) (int, error) {
+	pidStr, err := utils.ReadEnsureSingleLine(fs, conf.LauncherPIDFile())
 	if err != nil {
-		return err
+		return 0, err
+	}
+	return strconv.Atoi(pidStr)
+}
+
+// Returns whether the process with the given PID is a running redpanda started
+// with conf's config file.
+func isNodeRedpanda(fs afero.Fs, pid int, conf *config.Config) bool {
+	comm, err := utils.ReadEnsureSingleLine(
+		fs,
+		fmt.Sprintf("/proc/%d/comm", pid),
+	)
+	if err != nil || comm != "redpanda" {
+		return false
+	}
+	if running, err := os.IsRunningPID(fs, pid); err != nil || !running {
+		return false
+	}
+	cmdline, err := os.ReadCmdline(fs, pid)
+	if err != nil {
+		// The process might have exited in the meantime.
+		log.Debugf("Couldn't read the command line of %d: %v", pid, err)
+		return false
+	}
+	if !startedWithConfig(cmdline, conf) {
+		log.Debugf(
+			"Ignoring redpanda (PID %d), since it wasn't started"+
+				" with '%s'",
+			pid,
+			conf.ConfigFile,
+		)
+		return false
+	}
+	return true
+}
+
+// Returns whether the --redpanda-cfg in a redpanda command line is conf's
+// config file, or the copy of it with the 'rpk start --set' overrides, or the
+// file either is merged into if it includes others.
+func startedWithConfig(cmdline []string, conf *config.Config) bool {
+	overridden := *conf
+	overridden.ConfigFile = conf.OverridesConfigFile()
+	files := map[string]bool{}
+	for _, c := range []*config.Config{conf, &overridden} {
+		files[filepath.Clean(c.ConfigFile)] = true
+		files[filepath.Clean(c.RedpandaConfigFile())] = true
+	}
+	for i, arg := range cmdline {
+		var path string
+		switch {
+		case arg == "--redpanda-cfg" && i+1 < len(cmdline):
+			path = cmdline[i+1]
+		case strings.HasPrefix(arg, "--redpanda-cfg="):
+			path = strings.TrimPrefix(arg, "--redpanda-cfg=")
+		default:
+			continue
+		}
+		return files[filepath.Clean(path)]
 	}
-	return signalAndWait(fs, pid, timeout)
+	return false
 }
 
 func signalAndWait(fs afero.Fs, pid int, timeout time.Duration) error {
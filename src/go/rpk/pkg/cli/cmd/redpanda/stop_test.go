@@ -94,3 +94,79 @@ func TestStopCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestStopCommandNotStarted(t *testing.T) {
+	redpanda := func(fs afero.Fs, pid int, configFile string) {
+		_, err := utils.WriteBytes(
+			fs,
+			[]byte(fmt.Sprintf("%d (redpanda) S 1 1 1 0", pid)),
+			fmt.Sprintf("/proc/%d/stat", pid),
+		)
+		require.NoError(t, err)
+		_, err = utils.WriteBytes(
+			fs,
+			[]byte("redpanda\n"),
+			fmt.Sprintf("/proc/%d/comm", pid),
+		)
+		require.NoError(t, err)
+		_, err = utils.WriteBytes(
+			fs,
+			[]byte("redpanda\x00--redpanda-cfg\x00"+configFile+"\x00"),
+			fmt.Sprintf("/proc/%d/cmdline", pid),
+		)
+		require.NoError(t, err)
+	}
+	tests := []struct {
+		name		string
+		pids		[]int
+		otherPids	[]int
+		expectedOut	string
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should do nothing if redpanda isn't running",
+			expectedOut:	"redpanda isn't running. Nothing to do.",
+		},
+		{
+			name:		"it should fail if there's more than one redpanda process",
+			pids:		[]int{4321, 4322},
+			expectedErrMsg:	"found several redpanda processes (PIDs 4321 4322) and none of them holds '/var/lib/redpanda/data/pid.lock'. Stop the right one with 'kill'",
+		},
+		{
+			name:		"it shouldn't stop a redpanda started with another config",
+			otherPids:	[]int{4321},
+			expectedOut:	"redpanda isn't running. Nothing to do.",
+		},
+		{
+			name:		"it should ignore the redpandas started with another config",
+			pids:		[]int{4321, 4322},
+			otherPids:	[]int{4323},
+			expectedErrMsg:	"found several redpanda processes (PIDs 4321 4322) and none of them holds '/var/lib/redpanda/data/pid.lock'. Stop the right one with 'kill'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			mgr := config.NewManager(fs)
+			conf := config.Default()
+			require.NoError(st, mgr.Write(conf))
+			for _, pid := range tt.pids {
+				redpanda(fs, pid, conf.ConfigFile)
+			}
+			for _, pid := range tt.otherPids {
+				redpanda(fs, pid, "/etc/redpanda/other.yaml")
+			}
+			var out bytes.Buffer
+			logrus.SetOutput(&out)
+			c := cmd.NewStopCommand(fs, mgr)
+			c.SetArgs([]string{"--config", conf.ConfigFile})
+			err := c.Execute()
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Contains(st, out.String(), tt.expectedOut)
+		})
+	}
+}
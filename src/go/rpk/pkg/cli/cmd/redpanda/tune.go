@@ -11,10 +11,10 @@ package redpanda
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,11 +24,15 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api"
 	tunecmd "github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/cmd/redpanda/tune"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/factory"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/hwloc"
+	"gopkg.in/yaml.v2"
 )
 
 type result struct {
@@ -37,6 +41,17 @@ type result struct {
 	enabled		bool
 	supported	bool
 	errMsg		string
+	details		map[string]string
+}
+
+func (r result) payload() api.TunerPayload {
+	return api.TunerPayload{
+		Name:		r.name,
+		ErrorMsg:	r.errMsg,
+		Enabled:	r.enabled,
+		Supported:	r.supported,
+		Details:	r.details,
+	}
 }
 
 func NewTuneCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
@@ -45,8 +60,15 @@ func NewTuneCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
 		configFile		string
 		outTuneScriptFile	string
 		cpuSet			string
+		cpuMask			string
+		topologyFile		string
 		timeout			time.Duration
 		interactive		bool
+		revertTuners		bool
+		dumpTopology		bool
+		format			string
+		profile			string
+		metricsFile		string
 	)
 	baseMsg := "Sets the OS parameters to tune system performance." +
 		" Available tuners: all, " +
@@ -57,6 +79,9 @@ func NewTuneCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
 		Long: baseMsg + ".\n In order to get more information about the" +
 			" tuners, run `rpk tune help <tuner name>`",
 		Args: func(cmd *cobra.Command, args []string) error {
+			if dumpTopology {
+				return nil
+			}
 			if len(args) < 1 {
 				return errors.New("requires the list of elements to tune")
 			}
@@ -77,20 +102,69 @@ func NewTuneCommand(fs afero.Fs, mgr config.Manager) *cobra.Command {
 			if !tunerParamsEmpty(&tunerParams) && configFile != "" {
 				return errors.New("Use either tuner params or redpanda config file")
 			}
-			var tuners []string
+			if format != "text" && format != "json" && format != "yaml" {
+				return fmt.Errorf("unsupported format '%s'", format)
+			}
+			if dumpTopology {
+				if topologyFile != "" {
+					err := hwloc.UseTopologyFile(fs, topologyFile)
+					if err != nil {
+						return err
+					}
+				}
+				return printTopology(
+					cmd.OutOrStdout(),
+					hwloc.NewHwLocCmd(vos.NewProc(), timeout),
+					cpuSet,
+					format,
+				)
+			}
+			var tunerNames []string
 			if args[0] == "all" {
-				tuners = factory.AvailableTuners()
+				tunerNames = factory.AvailableTuners()
 			} else {
-				tuners = strings.Split(args[0], ",")
+				tunerNames = strings.Split(args[0], ",")
+			}
+			if topologyFile != "" {
+				err := hwloc.UseTopologyFile(fs, topologyFile)
+				if err != nil {
+					return err
+				}
 			}
-			cpuMask, err := hwloc.TranslateToHwLocCpuSet(cpuSet)
+			if cmd.Flags().Changed("cpu-mask") {
+				if cmd.Flags().Changed("cpu-set") {
+					return errors.New(
+						"--cpu-mask and --cpu-set can't be used together",
+					)
+				}
+				err := hwloc.ValidateCpuMask(cpuMask)
+				if err != nil {
+					return err
+				}
+				tunerParams.CpuMask = cpuMask
+			} else {
+				mask, err := hwloc.TranslateToHwLocCpuSet(cpuSet)
+				if err != nil {
+					return err
+				}
+				err = validateCpuset(
+					hwloc.NewHwLocCmd(vos.NewProc(), timeout),
+					"cpu-set",
+					cpuSet,
+				)
+				if err != nil {
+					return err
+				}
+				tunerParams.CpuMask = mask
+			}
+			tuneProfile, err := tuners.ParseTuneProfile(profile)
 			if err != nil {
 				return err
 			}
-			tunerParams.CpuMask = cpuMask
+			tunerParams.Profile = tuneProfile
 			conf, err := mgr.FindOrGenerate(configFile)
 			if err != nil {
-				if !interactive {
+				if !interactive || !ui.Interactive() {
 					return err
 				}
 				msg := fmt.Sprintf(
@@ -108,14 +182,39 @@ Would you like to continue with the default configuration?`,
 				conf = config.Default()
 			}
 			var tunerFactory factory.TunersFactory
+			// The previous values are only persisted when the tuners
+			// change the system right away.
+			statePath := ""
 			if outTuneScriptFile != "" {
 				tunerFactory = factory.NewScriptRenderingTunersFactory(
 					fs, *conf, outTuneScriptFile, timeout)
 			} else {
 				tunerFactory = factory.NewDirectExecutorTunersFactory(
 					fs, *conf, timeout)
+				statePath = tuners.TuneStatePath(conf)
 			}
-			return tune(fs, conf, tuners, tunerFactory, &tunerParams)
+			if revertTuners {
+				return revert(
+					fs,
+					conf,
+					tunerNames,
+					tunerFactory,
+					&tunerParams,
+					format,
+					cmd.OutOrStdout(),
+				)
+			}
+			return tune(
+				fs,
+				conf,
+				tunerNames,
+				tunerFactory,
+				&tunerParams,
+				statePath,
+				metricsFile,
+				format,
+				cmd.OutOrStdout(),
+			)
 		},
 	}
 	command.Flags().StringVarP(&tunerParams.Mode,
@@ -125,6 +224,14 @@ Would you like to continue with the default configuration?`,
 		"cpu-set",
 		"all", "Set of CPUs for tuner to use in cpuset(7) format "+
 			"if not specified tuner will use all available CPUs")
+	command.Flags().StringVar(&cpuMask,
+		"cpu-mask", "",
+		"A CPU mask in hwloc's format (e.g. '0x0000ffff') for the tuners"+
+			" to use as is, instead of deriving it from --cpu-set")
+	command.Flags().StringVar(&topologyFile,
+		"topology-file", "",
+		"An hwloc XML topology file (e.g. exported with 'lstopo topo.xml')"+
+			" to compute the CPU masks from, for when /sys is masked")
 	command.Flags().StringSliceVarP(&tunerParams.Disks,
 		"disks", "d",
 		[]string{}, "Lists of devices to tune f.e. 'sda1'")
@@ -136,6 +243,34 @@ Would you like to continue with the default configuration?`,
 		[]string{}, "List of *data* directories. or places to store data."+
 			" i.e.: '/var/vectorized/redpanda/',"+
 			" usually your XFS filesystem on an NVMe SSD device")
+	command.Flags().StringVar(
+		&profile,
+		"tune-profile",
+		string(tuners.BalancedProfile),
+		fmt.Sprintf(
+			"How far the tuners push the values they set (e.g. aio-max-nr"+
+				" or the NICs' ring buffers): 'conservative' halves"+
+				" their targets and 'aggressive' quadruples them, up"+
+				" to the hardware maximums [%s]",
+			strings.Join(tuners.TuneProfiles(), ", "),
+		),
+	)
+	command.Flags().StringVar(
+		&tunerParams.DiskQuota,
+		"disk-quota",
+		"",
+		"The size of the XFS project quota the disk_quota tuner sets on"+
+			" the data directory (e.g. '500GiB'). Overrides"+
+			" rpk.disk_quota_size",
+	)
+	command.Flags().StringVar(
+		&tunerParams.BallastFileSize,
+		"ballast-file-size",
+		"",
+		"The size of the file the ballast_file tuner creates in the"+
+			" data directory (e.g. '1GiB'). Overrides"+
+			" rpk.ballast_file_size",
+	)
 	command.Flags().BoolVar(&tunerParams.RebootAllowed,
 		"reboot-allowed", false, "If set will allow tuners to tune boot paramters "+
 			" and request system reboot")
@@ -165,11 +300,47 @@ Would you like to continue with the default configuration?`,
 		"Ask for confirmation on every step (e.g. tuner execution,"+
 			" configuration generation)",
 	)
+	command.Flags().BoolVar(
+		&revertTuners,
+		"revert",
+		false,
+		"Restore the values the given tuners replaced when they last ran,"+
+			" for the tuners which support it",
+	)
+	command.Flags().BoolVar(
+		&dumpTopology,
+		"dump-topology",
+		false,
+		"Print the NUMA nodes, cores and PUs hwloc reports, and the CPU"+
+			" masks rpk computes from them, instead of tuning",
+	)
+	command.Flags().StringVar(
+		&format,
+		"format",
+		"text",
+		"The format the tuners' results or --dump-topology are printed"+
+			" in. Can be 'text', 'json' or 'yaml' ('yaml' isn't"+
+			" supported by --dump-topology)",
+	)
+	command.Flags().StringVar(
+		&metricsFile,
+		metricsFileFlag,
+		"",
+		"Write the tuners' results to this file in Prometheus' text"+
+			" format, e.g. for node_exporter's textfile collector"+
+			" (*.prom)",
+	)
 	command.AddCommand(tunecmd.NewHelpCommand())
 	return command
 }
 
+// Asks the user to confirm, unless prompts are disabled (--no-interactive),
+// in which case it doesn't confirm.
 func promptConfirmation(msg string, in io.Reader) (bool, error) {
+	if !ui.Interactive() {
+		log.Infof("%s Not confirming, since prompts are disabled", msg)
+		return false, nil
+	}
 	scanner := bufio.NewScanner(in)
 	for {
 		log.Info(fmt.Sprintf("%s (y/n/q)", msg))
@@ -205,6 +376,10 @@ func tune(
 	tunerNames []string,
 	tunersFactory factory.TunersFactory,
 	params *factory.TunerParams,
+	statePath string,
+	metricsFile string,
+	format string,
+	out io.Writer,
 ) error {
 	params, err := factory.MergeTunerParamsConfig(params, conf)
 	if err != nil {
@@ -214,27 +389,56 @@ func tune(
 
 	results := []result{}
 	includeErr := false
+	failed := false
 	for _, tunerName := range tunerNames {
 		enabled := factory.IsTunerEnabled(tunerName, conf.Rpk)
 		tuner := tunersFactory.CreateTuner(tunerName, params)
 		supported, reason := tuner.CheckIfSupported()
 		if !enabled || !supported {
 			includeErr = includeErr || !supported
-			results = append(results, result{tunerName, false, enabled, supported, reason})
+			results = append(results, result{tunerName, false, enabled, supported, reason, nil})
 			continue
 		}
 		log.Debugf("Tuner parameters %+v", params)
 		res := tuner.Tune()
 		includeErr = includeErr || res.IsFailed()
+		failed = failed || res.IsFailed()
 		rebootRequired = rebootRequired || res.IsRebootRequired()
 		errMsg := ""
+		var details map[string]string
 		if res.IsFailed() {
 			errMsg = res.Error().Error()
+		} else {
+			details = tuners.TuneDetails(tuner)
 		}
-		results = append(results, result{tunerName, !res.IsFailed(), enabled, supported, errMsg})
+		if !res.IsFailed() && statePath != "" {
+			err := tuners.RecordPreviousValues(fs, statePath, tunerName, tuner)
+			if err != nil {
+				log.Warnf(
+					"Couldn't persist the values replaced by '%s', so it"+
+						" can't be reverted: %v",
+					tunerName,
+					err,
+				)
+			}
+		}
+		results = append(results, result{tunerName, !res.IsFailed(), enabled, supported, errMsg, details})
 	}
 
-	printTuneResult(results, includeErr)
+	err = printTuneOutput(out, results, includeErr, format)
+	if err != nil {
+		return err
+	}
+	if metricsFile != "" {
+		payloads := make([]api.TunerPayload, 0, len(results))
+		for _, r := range results {
+			payloads = append(payloads, r.payload())
+		}
+		err = writeMetricsFile(fs, metricsFile, tunerMetrics(payloads))
+		if err != nil {
+			return err
+		}
+	}
 
 	if rebootRequired {
 		red := color.New(color.FgRed).SprintFunc()
@@ -244,6 +448,127 @@ func tune(
 			strings.Join(tunerNames, ","),
 		)
 	}
+	if failed && format != "text" {
+		return errors.New("one or more tuners failed")
+	}
+	return nil
+}
+
+// Restores the values the given tuners replaced when they last ran. Tuners
+// which can't be reverted, or which have nothing to revert, are skipped.
+func revert(
+	fs afero.Fs,
+	conf *config.Config,
+	tunerNames []string,
+	tunersFactory factory.TunersFactory,
+	params *factory.TunerParams,
+	format string,
+	out io.Writer,
+) error {
+	params, err := factory.MergeTunerParamsConfig(params, conf)
+	if err != nil {
+		return err
+	}
+	statePath := tuners.TuneStatePath(conf)
+	results := []result{}
+	includeErr := false
+	for _, tunerName := range tunerNames {
+		tuner := tunersFactory.CreateTuner(tunerName, params)
+		rt, ok := tuner.(tuners.RevertibleTunable)
+		if !ok {
+			log.Debugf("Tuner '%s' can't be reverted", tunerName)
+			continue
+		}
+		previous, found, err := tuners.LoadPreviousValues(
+			fs,
+			statePath,
+			tunerName,
+		)
+		if err != nil {
+			return err
+		}
+		if !found {
+			log.Infof("Tuner '%s' has nothing to revert", tunerName)
+			continue
+		}
+		enabled := factory.IsTunerEnabled(tunerName, conf.Rpk)
+		res := rt.Revert(previous)
+		errMsg := ""
+		if res.IsFailed() {
+			includeErr = true
+			errMsg = res.Error().Error()
+		} else {
+			err := tuners.ClearPreviousValues(fs, statePath, tunerName)
+			if err != nil {
+				return err
+			}
+		}
+		results = append(results, result{tunerName, !res.IsFailed(), enabled, true, errMsg, nil})
+	}
+	err = printTuneOutput(out, results, includeErr, format)
+	if err != nil {
+		return err
+	}
+	if includeErr && format != "text" {
+		return errors.New("one or more tuners failed to revert")
+	}
+	return nil
+}
+
+// Prints the topology the tuners work from, which explains the CPU masks
+// they compute, e.g. when hwloc only sees part of the machine.
+func printTopology(
+	out io.Writer, hw hwloc.HwLoc, cpuSet, format string,
+) error {
+	if !hw.IsSupported() {
+		return errors.New("hwloc isn't installed")
+	}
+	cpuMask, err := hwloc.TranslateToHwLocCpuSet(cpuSet)
+	if err != nil {
+		return err
+	}
+	summary, err := hwloc.Summarize(hw, cpuMask)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		bs, err := json.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(bs))
+	case "text":
+		source := "discovered by hwloc"
+		if summary.TopologyFile != "" {
+			source = summary.TopologyFile
+		}
+		t := ui.NewRpkTable(out)
+		t.Append([]string{"Topology", source})
+		t.Append([]string{"All mask", summary.AllMask})
+		t.Append([]string{"CPU set mask", summary.CpuSetMask})
+		t.Append([]string{"Cores", fmt.Sprint(summary.Cores)})
+		t.Append([]string{"PUs", fmt.Sprint(summary.PUs)})
+		t.Append([]string{"NUMA nodes", fmt.Sprint(len(summary.NUMANodes))})
+		t.Render()
+		if len(summary.NUMANodes) == 0 {
+			return nil
+		}
+		fmt.Fprintln(out)
+		t = ui.NewRpkTable(out)
+		t.SetHeader([]string{"NUMA node", "Mask", "Cores", "PUs"})
+		for _, n := range summary.NUMANodes {
+			t.Append([]string{
+				fmt.Sprint(n.Index),
+				n.Mask,
+				fmt.Sprint(n.Cores),
+				fmt.Sprint(n.PUs),
+			})
+		}
+		t.Render()
+	default:
+		return fmt.Errorf("unsupported format '%s'", format)
+	}
 	return nil
 }
 
@@ -253,10 +578,38 @@ func tunerParamsEmpty(params *factory.TunerParams) bool {
 		len(params.Nics) == 0
 }
 
-func printTuneResult(results []result, includeErr bool) {
+// Prints the tuners' results as a table, or as a list of api.TunerPayload if
+// format is 'json' or 'yaml', for automation.
+func printTuneOutput(
+	out io.Writer, results []result, includeErr bool, format string,
+) error {
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].name < results[j].name
 	})
+	payloads := make([]api.TunerPayload, 0, len(results))
+	for _, r := range results {
+		payloads = append(payloads, r.payload())
+	}
+	switch format {
+	case "json":
+		bs, err := json.Marshal(payloads)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(bs))
+	case "yaml":
+		bs, err := yaml.Marshal(payloads)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, string(bs))
+	default:
+		printTuneResult(out, results, includeErr)
+	}
+	return nil
+}
+
+func printTuneResult(out io.Writer, results []result, includeErr bool) {
 	headers := []string{
 		"Tuner",
 		"Applied",
@@ -267,7 +620,7 @@ func printTuneResult(results []result, includeErr bool) {
 		headers = append(headers, "Error")
 	}
 
-	t := ui.NewRpkTable(os.Stdout)
+	t := ui.NewRpkTable(out)
 	t.SetHeader(headers)
 	red := color.New(color.FgRed).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
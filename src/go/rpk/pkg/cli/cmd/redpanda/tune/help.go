@@ -22,19 +22,34 @@ func NewHelpCommand() *cobra.Command {
 	tunersHelp := map[string]string{
 		"cpu":				cpuTunerHelp,
 		"disk_irq":			diskIrqTunerHelp,
+		"nvme_irq":			nvmeIrqTunerHelp,
 		"disk_scheduler":		diskSchedulerTunerHelp,
 		"net":				netTunerHelp,
 		"swappiness":			swappinessTunerHelp,
+		"overcommit":			overcommitTunerHelp,
 		"fstrim":			fstrimTunerHelp,
 		"aio_events":			aioEventsTunerHelp,
 		"transparent_hugepages":	transparentHugepagesTunerHelp,
 		"clocksource":			clocksourceTunerHelp,
 		"nomerges":			nomergesTunerHelp,
+		"prealloc":			preallocTunerHelp,
+		"smt":				smtTunerHelp,
+		"rq_affinity":			rqAffinityTunerHelp,
+		"cstate":			cStateTunerHelp,
+		"ring_buffer":			ringBufferTunerHelp,
+		"numa_hugepages":		numaHugepagesTunerHelp,
+		"disk_quota":			diskQuotaTunerHelp,
+		"ballast_file":			ballastFileTunerHelp,
+		"coredump":			coredumpTunerHelp,
+		"nic_irq_affinity":		nicIrqAffinityTunerHelp,
+		"io_scheduler":			ioSchedulerTunerHelp,
+		"file_descriptors":		fileDescriptorsTunerHelp,
 	}
 
 	return &cobra.Command{
-		Use:	"help <tuner>",
-		Short:	"Display detailed infromation about the tuner",
+		Use:		"help <tuner>",
+		Short:		"Display detailed infromation about the tuner",
+		ValidArgs:	utils.GetKeysFromStringMap(tunersHelp),
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return errors.New("requires the tuner name")
@@ -96,6 +111,8 @@ This tuner performs the following operations:
 	- Increase socket listen backlog
 	- Increase number of remembered connection requests
 	- Ban the IRQ Balance service from moving distributed IRQs
+	- Setup loopback (lo) RPS, only if 'rpk.tune_loopback_rps' is set and
+	  lo has RPS queues
 
 Modes description:
 
@@ -165,9 +182,155 @@ If there isn't any mode given script will use a default mode:
 	  is lower than 4 - use the 'sq' mode.
 	- Otherwise use the ‘sq-split’ mode.`
 
+const nvmeIrqTunerHelp = `
+This tuner distributes the IRQs of the NVMe queues of the given devices (or of
+the devices the given directories are stored on) across the CPUs redpanda runs
+on, so that IO completions are handled by the CPUs which issued the IO. The
+IRQs are found in /proc/interrupts by the NVMe controller name (e.g. 'nvme0q1'
+for nvme0n1).
+
+The CPUs the network tuner assigns the NIC IRQs to for the given mode are left
+out, so both tuners don't compete for the same CPUs:
+
+	sq - all CPUs allowed by the CPU mask but CPU0
+
+	sq_split - all CPUs allowed by the CPU mask but CPU0 and its HT siblings
+
+	mq - all CPUs allowed by the CPU mask
+
+If there isn't any mode given, the network tuner's default mode for a
+hardware interface is used. The IRQs are banned from the IRQ Balance service,
+which is then restarted.
+
+While rpk.tune_nvme_irq is enabled, 'disk_irq' leaves the NVMe IRQs to this
+tuner, and its checker expects them to be distributed as this tuner does it.`
+
+const preallocTunerHelp = `
+Preallocates rpk.prealloc_size (e.g. '20GiB') of disk space in the data
+directory, using fallocate where it's supported, so that the space is reserved
+upfront. Fails if the data partition wouldn't have at least rpk.min_free_disk
+(10GiB by default) free afterwards. It only runs when rpk.prealloc_size is set.
+`
+
+const smtTunerHelp = `
+Turns simultaneous multithreading (hyperthreading) on or off through
+/sys/devices/system/cpu/smt/control, as recommended by rpk.recommend_smt. Turning
+it off takes the sibling CPUs offline. It only runs when rpk.tune_smt is set, and
+isn't supported if SMT can't be changed at runtime (e.g. it was disabled on the
+kernel command line).
+`
+
+const rqAffinityTunerHelp = `
+Sets /sys/block/<device>/queue/rq_affinity for the data devices to
+rpk.rq_affinity, which controls the CPU I/O completions are processed on. 2 is
+recommended: completions are processed on the CPU which submitted the request,
+which is the seastar shard waiting for them. It only runs when rpk.rq_affinity
+is set, and 'rpk tune rq_affinity --revert' restores the previous values.
+
+It complements 'nvme_irq': the NVMe IRQs are steered to the CPUs redpanda runs
+on, and rq_affinity=2 makes sure a completion which was still raised on another
+of them is handed over to the submitting one, rather than processed wherever the
+IRQ landed.
+`
+
+const ioSchedulerTunerHelp = `
+Sets /sys/block/<device>/queue/scheduler for the devices backing the data
+directories, resolved through LVM and md, according to their type:
+
+	none        - for NVMe devices, whose many hardware queues don't benefit
+	              from the kernel reordering requests
+	mq-deadline - for the other devices, e.g. SATA SSDs, which do
+
+'noop' and 'deadline' are used instead on kernels without multi-queue
+schedulers. It only runs when rpk.tune_io_scheduler is true, and isn't supported
+if a device's type can't be determined, or if a data directory isn't on a block
+device (e.g. it's on tmpfs or overlay). 'rpk tune io_scheduler --revert'
+restores the previous schedulers.
+
+It supersedes 'disk_scheduler', which sets 'none' or 'noop' regardless of the
+device type, so only one of them should be enabled.
+`
+
+const cStateTunerHelp = `
+Disables the CPU idle states (C-states) whose exit latency is higher than
+rpk.cstate_max_latency_us (in microseconds) on the CPUs redpanda runs on, by
+writing to /sys/devices/system/cpu/cpu<n>/cpuidle/state<m>/disable. Waking up
+from deep idle states adds latency to requests which arrive while a CPU is idle,
+at the cost of higher power usage.
+
+It only runs when rpk.cstate_max_latency_us is set, and
+'rpk tune cstate --revert' re-enables the states it disabled.
+`
+
+const ringBufferTunerHelp = `
+Sets the RX and TX ring buffer sizes of the NICs with 'ethtool -G', to their
+hardware maximums, or to rpk.ring_buffer_size if it's set (capped at the
+maximums). Larger rings absorb bursts of traffic instead of dropping packets,
+at the cost of some memory and, possibly, latency.
+
+It only runs when rpk.tune_ring_buffers is true, and
+'rpk tune ring_buffer --revert' restores the previous sizes.
+`
+
+const numaHugepagesTunerHelp = `
+Allocates rpk.numa_hugepages (e.g. "4GiB") worth of 2MiB hugepages on NUMA node
+rpk.numa_hugepages_node, through
+/sys/devices/system/node/node<N>/hugepages/hugepages-2048kB/nr_hugepages,
+instead of spreading them across all the nodes. Use it with
+'rpk start --numa-node', so that redpanda's memory is local to its CPUs.
+
+It fails if the kernel can't allocate all the pages on the node, and
+'rpk tune numa_hugepages --revert' restores the previous number of pages.
+`
+
+const diskQuotaTunerHelp = `
+Sets an XFS project quota of rpk.disk_quota_size (e.g. "500GiB"), or
+--disk-quota, on the data directory with xfs_quota, so that redpanda can't fill
+the whole volume and starve the OS.
+
+It only runs when rpk.tune_disk_quota is true, and it's not supported unless
+the data directory is on an XFS filesystem mounted with project quotas
+enabled (prjquota).
+`
+
+const ballastFileTunerHelp = `
+Creates a file of rpk.ballast_file_size (1GiB by default), or
+--ballast-file-size, at <data directory>/.rpk_ballast, reserving its space with
+fallocate. If the disk fills up, deleting it frees up enough space for redpanda
+to recover.
+
+It only runs when rpk.tune_ballast_file is true, and it's not supported unless
+the data directory's filesystem supports fallocate. It does nothing if the file
+already has the configured size, and fails if the data partition wouldn't have
+at least rpk.min_free_disk (10GiB by default) free afterwards.
+`
+
+const nicIrqAffinityTunerHelp = `
+Sets the affinity of the IRQs of the NIC with redpanda's advertised Kafka API
+address (or of the NICs given with --nic, if there's none) to the CPUs redpanda
+doesn't run on, through /proc/irq/<n>/smp_affinity, so that handling network
+interrupts doesn't take time away from redpanda. Its CPUs are taken from
+--cpu-set/--cpu-mask, or from the --cpuset in rpk.additional_start_flags.
+
+It only runs when rpk.tune_nic_irq_affinity is true. It's not supported while
+irqbalance is running, since it would override the affinity, nor if redpanda
+runs on all the CPUs. It also conflicts with the net tuner, which distributes
+the same IRQs differently. 'rpk tune nic_irq_affinity --revert' restores the
+previous affinity.
+`
+
 const swappinessTunerHelp = `
 Tunes the kernel to keep process data in-memory for as long as possible, instead
-of swapping it out to disk.
+of swapping it out to disk, by setting vm.swappiness to rpk.swappiness (1 by
+default). 'rpk tune swappiness --revert' restores the previous value.
+`
+
+const overcommitTunerHelp = `
+Sets the kernel's memory overcommit mode (vm.overcommit_memory) to heuristic
+overcommit (0). With 'always overcommit' (1), allocations which can't be backed
+by memory succeed anyway, and the broker may later be OOM-killed. With 'never
+overcommit' (2), the commit limit depends on vm.overcommit_ratio, which may be
+too low for the memory redpanda allocates upfront.
 `
 
 const fstrimTunerHelp = `
@@ -196,6 +359,12 @@ Enables Transparent Hugepages. This allows the kernel to index larger pages
 (2MB, as opposed to the standard 4KB) in the CPU's TLB (if it supports it, which
 is the case for most current CPUs). This results in fewer cache misses, which
 means less time is spent searching and loading pages.
+
+rpk.transparent_hugepages_mode sets the mode instead: 'always' (the default),
+'madvise', which only uses them for the memory regions that ask for it, or
+'never', which avoids the latency spikes caused by compacting memory into huge
+pages. It's not supported if the kernel doesn't have
+/sys/kernel/mm/transparent_hugepage/enabled, or doesn't support the mode.
 `
 
 const clocksourceTunerHelp = `
@@ -204,9 +373,39 @@ efficiently via the Virtual Dynamic Shared Object. Most VMs run on Xen, with
 'xen' as the default clock source, which doesn't support reading the time in
 userspace via the vDSO, requiring making an actual syscall with the overhead it
 entails.
+
+It only runs when rpk.tune_clocksource is true (the default), and isn't
+supported if 'tsc' isn't listed in
+/sys/devices/system/clocksource/clocksource0/available_clocksource, e.g. because
+the kernel found it unstable. 'rpk check' warns if the clock source isn't 'tsc'.
 `
 
 const nomergesTunerHelp = `
 Disables merging adjacent IO requests, which would require checking outstanding
 IO requests to batch them where possible, incurring in some CPU overhead.
 `
+
+const fileDescriptorsTunerHelp = `
+Raises the max open files (ulimit -n) to rpk.min_fd_limit (1048576 by default),
+since redpanda keeps many segments and connections open. The limit is
+per-process, so it's raised for rpk's process, which 'rpk start' execs into
+redpanda, and, if redpanda runs as the redpanda systemd service, a drop-in
+setting its LimitNOFILE is installed at
+/etc/systemd/system/redpanda.service.d/rpk-fd-limit.conf, which applies once the
+service is restarted. Raising the hard limit requires CAP_SYS_RESOURCE.
+
+It only runs when rpk.tune_fd_limit is true, in which case 'rpk start' also
+raises the limit before starting redpanda, even without --tune.
+`
+
+const coredumpTunerHelp = `
+Sets /proc/sys/kernel/core_pattern to pipe core dumps to a script which saves
+them to rpk.coredump_dir (by default, the coredump directory next to the data
+directory), and raises the max core size (ulimit -c) to unlimited, or to the
+hard limit if it can't be raised further, so that redpanda's crashes can be
+debugged.
+
+It only runs when rpk.tune_coredump is true, it's not supported if core_pattern
+isn't writable (e.g. in containers), and 'rpk tune coredump --revert' restores
+the previous core_pattern.
+`
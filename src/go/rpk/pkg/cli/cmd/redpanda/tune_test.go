@@ -11,14 +11,22 @@ package redpanda
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"log"
 	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/api"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/factory"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+	"gopkg.in/yaml.v2"
 )
 
 func TestInteractive(t *testing.T) {
@@ -27,6 +35,7 @@ func TestInteractive(t *testing.T) {
 		input		string
 		expectedOutput	string
 		expectErr	bool
+		noInteractive	bool
 	}{
 		{
 			name:	"it should return if the user enters 'n'",
@@ -48,10 +57,18 @@ func TestInteractive(t *testing.T) {
 			input:		"\ny\n",
 			expectedOutput:	"Please choose an option",
 		},
+		{
+			name:		"it should fail without prompting if --no-interactive is set",
+			input:		"y\n",
+			expectErr:	true,
+			noInteractive:	true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			ui.SetInteractive(!tt.noInteractive)
+			defer ui.SetInteractive(true)
 			conf := config.Default()
 			fs := afero.NewMemMapFs()
 			mgr := config.NewManager(fs)
@@ -92,3 +109,91 @@ func TestInteractive(t *testing.T) {
 		})
 	}
 }
+
+type fakeTuner struct {
+	supported	bool
+	err		error
+}
+
+func (t *fakeTuner) CheckIfSupported() (bool, string) {
+	if !t.supported {
+		return false, "not supported here"
+	}
+	return true, ""
+}
+
+func (t *fakeTuner) Tune() tuners.TuneResult {
+	if t.err != nil {
+		return tuners.NewTuneError(t.err)
+	}
+	return tuners.NewTuneResult(false)
+}
+
+type fakeTunersFactory map[string]tuners.Tunable
+
+func (f fakeTunersFactory) CreateTuner(
+	name string, _ *factory.TunerParams,
+) tuners.Tunable {
+	return f[name]
+}
+
+func TestTuneOutput(t *testing.T) {
+	tunersFactory := fakeTunersFactory{
+		"aio_events":	&fakeTuner{supported: true},
+		"swappiness":	&fakeTuner{supported: true, err: errors.New("boom")},
+		"cpu":		&fakeTuner{supported: false},
+		"clocksource":	&fakeTuner{supported: true},
+	}
+	conf := config.Default()
+	conf.Rpk.TuneAioEvents = true
+	conf.Rpk.TuneSwappiness = true
+	conf.Rpk.TuneCpu = true
+	conf.Rpk.TuneClocksource = false
+	expected := []api.TunerPayload{{
+		Name:		"aio_events",
+		Enabled:	true,
+		Supported:	true,
+	}, {
+		Name:	"clocksource",
+		// Disabled tuners are included too.
+		Supported:	true,
+	}, {
+		Name:		"cpu",
+		ErrorMsg:	"not supported here",
+		Enabled:	true,
+	}, {
+		Name:		"swappiness",
+		ErrorMsg:	"boom",
+		Enabled:	true,
+		Supported:	true,
+	}}
+	names := []string{"aio_events", "swappiness", "cpu", "clocksource"}
+	params := &factory.TunerParams{Nics: []string{"eth0"}}
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(st *testing.T) {
+			var out bytes.Buffer
+			err := tune(
+				afero.NewMemMapFs(),
+				conf,
+				names,
+				tunersFactory,
+				params,
+				"",
+				"",
+				format,
+				&out,
+			)
+			// The output should be printed even if a tuner failed.
+			require.EqualError(st, err, "one or more tuners failed")
+			var payloads []api.TunerPayload
+			if format == "json" {
+				err = json.Unmarshal(out.Bytes(), &payloads)
+			} else {
+				err = yaml.Unmarshal(out.Bytes(), &payloads)
+			}
+			require.NoError(st, err)
+			require.Equal(st, expected, payloads)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/iotune"
+)
+
+func NewListWellKnownIoCommand() *cobra.Command {
+	var vendor string
+	command := &cobra.Command{
+		Use:	"list-well-known",
+		Short:	"List the well-known IO profiles --well-known-io accepts",
+		Long: "Lists the <vendor>:<vm type>:<storage type> profiles rpk" +
+			" has IO properties for, which 'rpk start' accepts in" +
+			" --well-known-io (or rpk.well_known_io) and deduces when" +
+			" running on one of them, along with their measured IOPS" +
+			" and bandwidth.",
+		Args:	cobra.NoArgs,
+		RunE: func(ccmd *cobra.Command, _ []string) error {
+			candidates, err := iotune.WellKnown(vendor)
+			if err != nil {
+				return err
+			}
+			printIoCandidatesTable(ccmd.OutOrStdout(), candidates)
+			return nil
+		},
+	}
+	command.Flags().StringVar(
+		&vendor,
+		"vendor",
+		"",
+		"Only list the profiles of the given cloud vendor (e.g. 'aws')",
+	)
+	return command
+}
+
+func printIoCandidatesTable(w io.Writer, candidates []iotune.Candidate) {
+	t := ui.NewRpkTable(w)
+	t.SetHeader([]string{
+		"Profile",
+		"Read IOPS",
+		"Read bandwidth",
+		"Write IOPS",
+		"Write bandwidth",
+	})
+	for _, c := range candidates {
+		t.Append([]string{
+			c.String(),
+			fmt.Sprint(c.Props.ReadIops),
+			units.BytesSize(float64(c.Props.ReadBandwidth)) + "/s",
+			fmt.Sprint(c.Props.WriteIops),
+			units.BytesSize(float64(c.Props.WriteBandwidth)) + "/s",
+		})
+	}
+	t.Render()
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListWellKnownIoCommand(t *testing.T) {
+	var out bytes.Buffer
+	c := NewListWellKnownIoCommand()
+	c.SetOut(&out)
+	c.SetArgs([]string{"--vendor", "aws"})
+	require.NoError(t, c.Execute())
+	require.Contains(t, out.String(), "aws:i3.large:default")
+	require.Contains(t, out.String(), "111000")
+
+	c = NewListWellKnownIoCommand()
+	c.SetOut(&out)
+	c.SetArgs([]string{"--vendor", "unsupported"})
+	require.EqualError(
+		t,
+		c.Execute(),
+		"no iotune data found for vendor 'unsupported'",
+	)
+}
@@ -10,46 +10,70 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/Shopify/sarama"
-	"github.com/fatih/color"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/cmd/common"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/cmd/profile"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/cmd/redpanda"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cli/ui"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
 func Execute() {
 	verbose := false
+	logFormat := cli.TextLogFormat
+	logLevel := log.InfoLevel.String()
+	noColor := false
+	noInteractive := false
+	profileName := ""
 	fs := afero.NewOsFs()
 	mgr := config.NewManager(fs)
 
-	if !terminal.IsTerminal(int(os.Stdout.Fd())) {
-		color.NoColor = true
-	}
+	isTerminal := terminal.IsTerminal(int(os.Stdout.Fd()))
+	ui.SetColor(ui.ColorEnabled(noColor, isTerminal))
 	log.SetFormatter(cli.NewRpkLogFormatter())
 	cobra.OnInitialize(func() {
 		// This is only executed when a subcommand (e.g. rpk check) is
 		// specified.
+		ui.SetColor(ui.ColorEnabled(noColor, isTerminal))
+		ui.SetInteractive(!noInteractive)
+		formatter, err := cli.NewLogFormatter(logFormat)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.SetFormatter(formatter)
+		level, err := log.ParseLevel(logLevel)
+		if err != nil {
+			log.Fatalf(
+				"invalid log level '%s'. Available levels: %s",
+				logLevel,
+				strings.Join(logLevels(), ", "),
+			)
+		}
 		if verbose {
-			log.SetLevel(log.DebugLevel)
+			level = log.DebugLevel
+		}
+		log.SetLevel(level)
+		if level >= log.DebugLevel {
 			// Make sure we enable verbose logging for sarama client
 			// we configure the Sarama logger only for verbose output as sarama
 			// logger use no severities. It is either enabled or disabled.
 			sarama.Logger = &log.Logger{
 				Out:		os.Stderr,
-				Formatter:	cli.NewRpkLogFormatter(),
+				Formatter:	formatter,
 				Hooks:		make(log.LevelHooks),
 				Level:		log.DebugLevel,
 				ExitFunc:	os.Exit,
 				ReportCaller:	false,
 			}
-		} else {
-			log.SetLevel(log.InfoLevel)
 		}
 	})
 
@@ -59,8 +83,31 @@ func Execute() {
 		Long:	"",
 	}
 	rootCmd.SilenceUsage = true
+	rootCmd.BashCompletionFunction = redpanda.BashCompletionFunctions()
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose",
-		"v", false, "enable verbose logging (default false)")
+		"v", false, "enable verbose logging (default false). Same as"+
+			" --log-level debug")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format",
+		cli.TextLogFormat, fmt.Sprintf(
+			"the format rpk logs in. 'json' prints each entry as a"+
+				" JSON object, e.g. for log aggregators [%s]",
+			strings.Join(cli.LogFormats(), ", "),
+		))
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level",
+		log.InfoLevel.String(), fmt.Sprintf(
+			"the minimum level of the entries rpk logs [%s]",
+			strings.Join(logLevels(), ", "),
+		))
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false,
+		"disable colorized output. It's also disabled when the output isn't"+
+			" a terminal, or if the "+ui.NoColorEnv+" env var is set")
+	rootCmd.PersistentFlags().BoolVar(&noInteractive, "no-interactive", false,
+		"never prompt for input nor print feedback messages, e.g. for"+
+			" CI. Prompts take their non-interactive default, and"+
+			" disruptive tuners are skipped unless --assume-yes is passed")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "",
+		"the profile to take the values of the flags which aren't passed"+
+			" from (see 'rpk profile'), instead of the active one")
 
 	rootCmd.AddCommand(NewModeCommand(mgr))
 	rootCmd.AddCommand(NewGenerateCommand(mgr))
@@ -69,11 +116,26 @@ func Execute() {
 	rootCmd.AddCommand(NewWasmCommand(fs, mgr))
 	rootCmd.AddCommand(NewContainerCommand())
 	rootCmd.AddCommand(NewTopicCommand(fs, mgr))
+	rootCmd.AddCommand(NewProfileCommand(fs))
 
 	addPlatformDependentCmds(fs, mgr, rootCmd)
 
+	cobra.OnInitialize(func() {
+		// The profiles are only read for the commands which take them,
+		// so that e.g. 'rpk profile delete' still works if the file is
+		// broken.
+		cmd, _, err := rootCmd.Find(os.Args[1:])
+		if err != nil || !profile.TakesProfile(cmd) {
+			return
+		}
+		err = applyProfile(fs, cmd, profileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+	})
+
 	err := rootCmd.Execute()
-	if len(os.Args) > 1 {
+	if len(os.Args) > 1 && ui.Interactive() {
 		switch os.Args[1] {
 		case "check":
 			fallthrough
@@ -85,3 +147,46 @@ func Execute() {
 		os.Exit(1)
 	}
 }
+
+// Sets the values of cmd's flags which weren't passed from the profile named
+// name, or from the active one if name is empty. Nothing is changed if there's
+// no active profile.
+func applyProfile(fs afero.Fs, cmd *cobra.Command, name string) error {
+	path, err := config.ProfilesFile()
+	if err != nil {
+		if name == "" {
+			log.Debug(err)
+			return nil
+		}
+		return err
+	}
+	profiles, err := config.ReadProfiles(fs, path)
+	if err != nil {
+		return err
+	}
+	p := profiles.Active()
+	if name != "" {
+		p = profiles.Get(name)
+		if p == nil {
+			return fmt.Errorf(
+				"profile '%s' doesn't exist. Run 'rpk profile list'"+
+					" to list them",
+				name,
+			)
+		}
+	}
+	if p == nil {
+		return nil
+	}
+	log.Debugf("Using profile '%s'", p.Name)
+	return profile.ApplyProfile(cmd, p)
+}
+
+// Returns the levels --log-level takes, from the most to the least verbose.
+func logLevels() []string {
+	levels := []string{}
+	for i := len(log.AllLevels) - 1; i >= 0; i-- {
+		levels = append(levels, log.AllLevels[i].String())
+	}
+	return levels
+}
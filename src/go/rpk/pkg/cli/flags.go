@@ -11,16 +11,65 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
 )
 
-func GetOrFindInstallDir(fs afero.Fs, installDir string) (string, error) {
+// The env var which sets the install directory when --install-dir isn't
+// passed.
+const InstallDirEnv = "RPK_INSTALL_DIR"
+
+// Returns the redpanda install directory. In order of precedence, it's
+// installDir (i.e. --install-dir), RPK_INSTALL_DIR, confInstallDir
+// (rpk.install_dir), or the directory rpk is installed in.
+func GetOrFindInstallDir(
+	fs afero.Fs, installDir, confInstallDir string,
+) (string, error) {
+	return getOrFindInstallDir(
+		fs,
+		installDir,
+		confInstallDir,
+		os.Getenv,
+		redpanda.FindInstallDir,
+	)
+}
+
+func getOrFindInstallDir(
+	fs afero.Fs,
+	installDir, confInstallDir string,
+	getenv func(string) string,
+	find func(afero.Fs) (string, error),
+) (string, error) {
 	if installDir != "" {
 		return installDir, nil
 	}
-	foundConfig, err := redpanda.FindInstallDir(fs)
+	sources := []struct {
+		name	string
+		dir	string
+	}{
+		{InstallDirEnv, getenv(InstallDirEnv)},
+		{"rpk.install_dir", confInstallDir},
+	}
+	for _, s := range sources {
+		if s.dir == "" {
+			continue
+		}
+		binary := redpanda.BinaryPath(s.dir)
+		if exists, _ := afero.Exists(fs, binary); !exists {
+			return "", fmt.Errorf(
+				"%s is set to '%s', but '%s' doesn't exist",
+				s.name,
+				s.dir,
+				binary,
+			)
+		}
+		log.Debugf("Using the install directory set by %s: '%s'", s.name, s.dir)
+		return s.dir, nil
+	}
+	foundConfig, err := find(fs)
 	if err != nil {
 		return "", fmt.Errorf("Unable to find redpanda installation. " +
 			"Please provide the install directory with flag --install-dir")
@@ -0,0 +1,108 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
+)
+
+func TestGetOrFindInstallDir(t *testing.T) {
+	const (
+		flagDir		= "/opt/flag"
+		envDir		= "/opt/env"
+		confDir		= "/opt/conf"
+		defaultDir	= "/opt/redpanda"
+	)
+	tests := []struct {
+		name		string
+		installed	[]string
+		flag		string
+		env		string
+		conf		string
+		findErr		error
+		expected	string
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should prefer the flag",
+			installed:	[]string{flagDir, envDir, confDir, defaultDir},
+			flag:		flagDir,
+			env:		envDir,
+			conf:		confDir,
+			expected:	flagDir,
+		},
+		{
+			name:		"it should prefer the env var over the config",
+			installed:	[]string{envDir, confDir, defaultDir},
+			env:		envDir,
+			conf:		confDir,
+			expected:	envDir,
+		},
+		{
+			name:		"it should prefer the config over the default search",
+			installed:	[]string{confDir, defaultDir},
+			conf:		confDir,
+			expected:	confDir,
+		},
+		{
+			name:		"it should search the default locations",
+			installed:	[]string{defaultDir},
+			expected:	defaultDir,
+		},
+		{
+			name:		"it should fail if the env var's dir doesn't contain redpanda",
+			installed:	[]string{confDir, defaultDir},
+			env:		envDir,
+			conf:		confDir,
+			expectedErrMsg:	"RPK_INSTALL_DIR is set to '/opt/env', but '/opt/env/bin/redpanda' doesn't exist",
+		},
+		{
+			name:		"it should fail if the config's dir doesn't contain redpanda",
+			installed:	[]string{defaultDir},
+			conf:		confDir,
+			expectedErrMsg:	"rpk.install_dir is set to '/opt/conf', but '/opt/conf/bin/redpanda' doesn't exist",
+		},
+		{
+			name:		"it should fail if redpanda isn't found anywhere",
+			findErr:	errors.New("not found"),
+			expectedErrMsg:	"Unable to find redpanda installation. Please provide the install directory with flag --install-dir",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			for _, dir := range tt.installed {
+				_, err := fs.Create(redpanda.BinaryPath(dir))
+				require.NoError(st, err)
+			}
+			getenv := func(name string) string {
+				if name == InstallDirEnv {
+					return tt.env
+				}
+				return ""
+			}
+			find := func(afero.Fs) (string, error) {
+				return defaultDir, tt.findErr
+			}
+			dir, err := getOrFindInstallDir(fs, tt.flag, tt.conf, getenv, find)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, dir)
+		})
+	}
+}
@@ -13,11 +13,47 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/sirupsen/logrus"
 )
 
+// The formats rpk's logs can be printed in, with --log-format.
+const (
+	TextLogFormat	= "text"
+	JSONLogFormat	= "json"
+)
+
+var logFormats = []string{TextLogFormat, JSONLogFormat}
+
+func LogFormats() []string {
+	return logFormats
+}
+
+// Returns the formatter for the given --log-format.
+func NewLogFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case TextLogFormat:
+		return NewRpkLogFormatter(), nil
+	case JSONLogFormat:
+		return NewJSONLogFormatter(), nil
+	}
+	return nil, fmt.Errorf(
+		"unsupported log format '%s'. Available formats: %s",
+		format,
+		strings.Join(logFormats, ", "),
+	)
+}
+
+// Prints each entry as a JSON object on its own line, with its time, level
+// and message under the 'time', 'level' and 'msg' keys, along with the
+// entry's fields.
+func NewJSONLogFormatter() logrus.Formatter {
+	return &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+}
+
 type noopFormatter struct{}
 
 func (*noopFormatter) Format(e *logrus.Entry) ([]byte, error) {
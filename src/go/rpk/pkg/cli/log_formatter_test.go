@@ -0,0 +1,68 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogFormatter(t *testing.T) {
+	tests := []struct {
+		name		string
+		format		string
+		check		func(*testing.T, string)
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should print the message as is with 'text'",
+			format:	"text",
+			check: func(st *testing.T, out string) {
+				require.Equal(st, "Starting redpanda...\n", out)
+			},
+		},
+		{
+			name:	"it should print a JSON object per entry with 'json'",
+			format:	"json",
+			check: func(st *testing.T, out string) {
+				entry := map[string]string{}
+				require.NoError(st, json.Unmarshal([]byte(out), &entry))
+				require.Equal(st, "Starting redpanda...", entry["msg"])
+				require.Equal(st, "info", entry["level"])
+				require.NotEmpty(st, entry["time"])
+			},
+		},
+		{
+			name:		"it should fail if the format isn't supported",
+			format:		"xml",
+			expectedErrMsg:	"unsupported log format 'xml'. Available formats: text, json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			formatter, err := NewLogFormatter(tt.format)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			var out bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(&out)
+			logger.SetFormatter(formatter)
+			logger.Info("Starting redpanda...")
+			tt.check(st, out.String())
+		})
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package ui
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+)
+
+// The environment variable which, when set to a non-empty value, disables
+// colorized output (see https://no-color.org).
+const NoColorEnv = "NO_COLOR"
+
+// Returns whether output should be colorized: only when it's written to a
+// terminal, and neither --no-color nor NO_COLOR were set.
+func ColorEnabled(noColorFlag, isTerminal bool) bool {
+	return isTerminal && !noColorFlag && os.Getenv(NoColorEnv) == ""
+}
+
+// Enables or disables colorized output for all of rpk's output.
+func SetColor(enabled bool) {
+	color.NoColor = !enabled
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package ui
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name		string
+		noColorFlag	bool
+		isTerminal	bool
+		noColorEnv	string
+		expected	bool
+	}{
+		{
+			name:		"it should enable color on a terminal",
+			isTerminal:	true,
+			expected:	true,
+		},
+		{
+			name:		"it should disable color when the output is piped",
+			isTerminal:	false,
+			expected:	false,
+		},
+		{
+			name:		"it should disable color if --no-color is passed",
+			noColorFlag:	true,
+			isTerminal:	true,
+			expected:	false,
+		},
+		{
+			name:		"it should disable color if NO_COLOR is set",
+			isTerminal:	true,
+			noColorEnv:	"1",
+			expected:	false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, wasSet := os.LookupEnv(NoColorEnv)
+			defer func() {
+				if wasSet {
+					os.Setenv(NoColorEnv, prev)
+				} else {
+					os.Unsetenv(NoColorEnv)
+				}
+			}()
+			os.Setenv(NoColorEnv, tt.noColorEnv)
+			require.Equal(t, tt.expected, ColorEnabled(tt.noColorFlag, tt.isTerminal))
+		})
+	}
+}
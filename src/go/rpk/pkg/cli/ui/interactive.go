@@ -0,0 +1,24 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package ui
+
+var interactive = true
+
+// Enables or disables all of rpk's prompts and feedback messages. When
+// disabled (--no-interactive), prompts take their non-interactive default, so
+// that rpk never blocks on input.
+func SetInteractive(enabled bool) {
+	interactive = enabled
+}
+
+// Returns whether rpk may prompt the user or print feedback messages.
+func Interactive() bool {
+	return interactive
+}
@@ -11,6 +11,7 @@ package aws
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
@@ -36,10 +37,17 @@ func (v *AwsVendor) Init() (vendor.InitializedVendor, error) {
 		return nil, err
 	}
 	client := ec2metadata.New(s)
-	if available(client, 500*time.Millisecond) {
+	ok, timedOut := available(client, 500*time.Millisecond)
+	if ok {
 		return &InitializedAwsVendor{client}, nil
 	}
-	return nil, errors.New("vendor AWS couldn't be initialized")
+	if timedOut {
+		return nil, errors.New("vendor AWS couldn't be initialized: timed out")
+	}
+	return nil, fmt.Errorf(
+		"vendor AWS couldn't be initialized: %w",
+		vendor.ErrUnavailable,
+	)
 }
 
 func (v *InitializedAwsVendor) VmType() (string, error) {
@@ -50,8 +58,12 @@ func (v *InitializedAwsVendor) Name() string {
 	return name
 }
 
-func available(client *ec2metadata.EC2Metadata, timeout time.Duration) bool {
-	result := make(chan bool)
+// Returns whether the metadata endpoint is available, and whether it timed out
+// instead of answering.
+func available(
+	client *ec2metadata.EC2Metadata, timeout time.Duration,
+) (ok, timedOut bool) {
+	result := make(chan bool, 1)
 
 	go func(c *ec2metadata.EC2Metadata, res chan<- bool) {
 		res <- c.Available()
@@ -59,8 +71,8 @@ func available(client *ec2metadata.EC2Metadata, timeout time.Duration) bool {
 
 	select {
 	case res := <-result:
-		return res
+		return res, false
 	case <-time.After(timeout):
-		return false
+		return false, true
 	}
 }
@@ -0,0 +1,52 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package aws
+
+import (
+	"context"
+	"io/ioutil"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Returns the contents of the given S3 object, using the default credentials
+// chain (e.g. the env, ~/.aws or the instance's role). If no region is
+// configured, the bucket's is looked up.
+func GetObject(bucket, key string, timeout time.Duration) ([]byte, error) {
+	s, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conf := aws.NewConfig()
+	if aws.StringValue(s.Config.Region) == "" {
+		region, err := s3manager.GetBucketRegion(ctx, s, bucket, "us-east-1")
+		if err != nil {
+			return nil, err
+		}
+		conf = conf.WithRegion(region)
+	}
+	out, err := s3.New(s, conf).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket:	aws.String(bucket),
+		Key:	aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return ioutil.ReadAll(out.Body)
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package azure
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/vendor"
+)
+
+const (
+	name	= "azure"
+
+	// The Azure Instance Metadata Service (IMDS) endpoint.
+	// See https://docs.microsoft.com/en-us/azure/virtual-machines/linux/instance-metadata-service
+	metadataURL	= "http://169.254.169.254/metadata/instance"
+	apiVersion	= "2020-09-01"
+)
+
+type AzureVendor struct{}
+
+type InitializedAzureVendor struct {
+	client	*http.Client
+	url	string
+}
+
+func (v *AzureVendor) Name() string {
+	return name
+}
+
+func (v *AzureVendor) Init() (vendor.InitializedVendor, error) {
+	return initVendor(metadataURL, 500*time.Millisecond)
+}
+
+func initVendor(url string, timeout time.Duration) (vendor.InitializedVendor, error) {
+	iv := &InitializedAzureVendor{&http.Client{Timeout: timeout}, url}
+	_, err := iv.get("compute/vmSize")
+	if err == nil {
+		return iv, nil
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return nil, errors.New("vendor Azure couldn't be initialized: timed out")
+	}
+	return nil, fmt.Errorf(
+		"vendor Azure couldn't be initialized: %v: %w",
+		err,
+		vendor.ErrUnavailable,
+	)
+}
+
+func (v *InitializedAzureVendor) VmType() (string, error) {
+	return v.get("compute/vmSize")
+}
+
+func (v *InitializedAzureVendor) Name() string {
+	return name
+}
+
+// Returns the value of the given instance metadata path, as text.
+func (v *InitializedAzureVendor) get(path string) (string, error) {
+	req, err := http.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("%s/%s?api-version=%s&format=text", v.url, path, apiVersion),
+		nil,
+	)
+	if err != nil {
+		return "", err
+	}
+	// Required by IMDS, so that requests can't be forged through the VM
+	// (e.g. SSRF).
+	req.Header.Set("Metadata", "true")
+	res, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(
+			"the instance metadata request failed. Status: %d",
+			res.StatusCode,
+		)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
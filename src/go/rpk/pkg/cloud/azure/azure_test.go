@@ -0,0 +1,63 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package azure
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/vendor"
+)
+
+func TestInitVendor(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata") != "true" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			require.Equal(t, "/metadata/instance/compute/vmSize", r.URL.Path)
+			require.Equal(t, "text", r.URL.Query().Get("format"))
+			w.Write([]byte("Standard_L8s_v2\n"))
+		}))
+	defer ts.Close()
+
+	v, err := initVendor(ts.URL+"/metadata/instance", time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "azure", v.Name())
+	vmType, err := v.VmType()
+	require.NoError(t, err)
+	require.Equal(t, "Standard_L8s_v2", vmType)
+}
+
+func TestInitVendorUnavailable(t *testing.T) {
+	// e.g. another vendor's metadata server, which doesn't know the path.
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	_, err := initVendor(ts.URL+"/metadata/instance", time.Second)
+	require.True(t, errors.Is(err, vendor.ErrUnavailable))
+}
+
+func TestInitVendorTimeout(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}))
+	defer ts.Close()
+
+	_, err := initVendor(ts.URL+"/metadata/instance", 10*time.Millisecond)
+	require.EqualError(t, err, "vendor Azure couldn't be initialized: timed out")
+	require.False(t, errors.Is(err, vendor.ErrUnavailable))
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package cloud
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/aws"
+)
+
+// The URL schemes Fetch supports.
+var fetchSchemes = []string{"http://", "https://", "s3://"}
+
+// Returns whether path is a URL Fetch can download, rather than a local path.
+func IsURL(path string) bool {
+	for _, scheme := range fetchSchemes {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// Downloads the contents at the given http(s):// or s3://<bucket>/<key> URL.
+func Fetch(rawURL string, timeout time.Duration) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		client := &http.Client{Timeout: timeout}
+		res, err := client.Get(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("request failed with status %s", res.Status)
+		}
+		return ioutil.ReadAll(res.Body)
+	case "s3":
+		key := strings.TrimPrefix(u.Path, "/")
+		if u.Host == "" || key == "" {
+			return nil, fmt.Errorf(
+				"'%s' should have the format s3://<bucket>/<key>",
+				rawURL,
+			)
+		}
+		return aws.GetObject(u.Host, key, timeout)
+	}
+	return nil, fmt.Errorf(
+		"unsupported URL scheme '%s'. Supported schemes: %s",
+		u.Scheme,
+		strings.Join(fetchSchemes, ", "),
+	)
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package cloud
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsURL(t *testing.T) {
+	require.True(t, IsURL("https://example.com/io-config.yaml"))
+	require.True(t, IsURL("http://example.com/io-config.yaml"))
+	require.True(t, IsURL("s3://bucket/io-config.yaml"))
+	require.False(t, IsURL("/etc/redpanda/io-config.yaml"))
+	require.False(t, IsURL("io-config.yaml"))
+}
+
+func TestFetchInvalidURLs(t *testing.T) {
+	_, err := Fetch("s3://bucket", time.Second)
+	require.EqualError(t, err, "'s3://bucket' should have the format s3://<bucket>/<key>")
+
+	_, err = Fetch("ftp://example.com/io-config.yaml", time.Second)
+	require.EqualError(t, err, "unsupported URL scheme 'ftp'. Supported schemes: http://, https://, s3://")
+}
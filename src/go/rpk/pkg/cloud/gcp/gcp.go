@@ -11,6 +11,8 @@ package gcp
 
 import (
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"path/filepath"
 	"time"
@@ -34,10 +36,17 @@ func (v *GcpVendor) Name() string {
 func (v *GcpVendor) Init() (vendor.InitializedVendor, error) {
 	timeout := 500 * time.Millisecond
 	client := metadata.NewClient(&http.Client{Timeout: timeout})
-	if available(client, timeout) {
+	ok, timedOut := available(client, timeout)
+	if ok {
 		return &InitializedGcpVendor{client}, nil
 	}
-	return nil, errors.New("vendor GCP couldn't be initialized")
+	if timedOut {
+		return nil, errors.New("vendor GCP couldn't be initialized: timed out")
+	}
+	return nil, fmt.Errorf(
+		"vendor GCP couldn't be initialized: %w",
+		vendor.ErrUnavailable,
+	)
 }
 
 func (v *InitializedGcpVendor) VmType() (string, error) {
@@ -56,18 +65,27 @@ func (v *InitializedGcpVendor) Name() string {
 	return name
 }
 
-func available(client *metadata.Client, timeout time.Duration) bool {
-	result := make(chan bool)
+// Returns whether the metadata endpoint is available, and whether it timed out
+// instead of answering.
+func available(
+	client *metadata.Client, timeout time.Duration,
+) (ok, timedOut bool) {
+	result := make(chan error, 1)
 
-	go func(c *metadata.Client, res chan<- bool) {
+	go func(c *metadata.Client, res chan<- error) {
 		_, err := c.ProjectID()
-		res <- err == nil
+		res <- err
 	}(client, result)
 
 	select {
-	case res := <-result:
-		return res
+	case err := <-result:
+		// The HTTP client's own timeout may fire first.
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return false, true
+		}
+		return err == nil, false
 	case <-time.After(timeout):
-		return false
+		return false, true
 	}
 }
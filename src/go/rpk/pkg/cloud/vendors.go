@@ -11,10 +11,11 @@ package cloud
 
 import (
 	"errors"
-	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/aws"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/azure"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/gcp"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/vendor"
 )
@@ -25,51 +26,107 @@ func vendors() map[string]vendor.Vendor {
 	vendors[awsVendor.Name()] = awsVendor
 	gcpVendor := &gcp.GcpVendor{}
 	vendors[gcpVendor.Name()] = gcpVendor
+	azureVendor := &azure.AzureVendor{}
+	vendors[azureVendor.Name()] = azureVendor
 
 	return vendors
 }
 
+const (
+	// How many times, and for how long, detecting the vendor is retried
+	// by default when their metadata endpoints time out.
+	DefaultDetectionAttempts	= 3
+	DefaultDetectionTimeout		= 2 * time.Second
+
+	initialDetectionBackoff	= 100 * time.Millisecond
+)
+
 // Tries to initializes the vendors and returns the one available, or an error
 // if none could be initialized.
 func AvailableVendor() (vendor.InitializedVendor, error) {
-	return availableVendorFrom(vendors())
+	return AvailableVendorWithRetries(
+		DefaultDetectionAttempts,
+		DefaultDetectionTimeout,
+	)
+}
+
+// Like AvailableVendor, but retries initializing the vendors whose metadata
+// endpoints timed out up to the given number of attempts, backing off
+// exponentially in between, as long as the timeout isn't exceeded. Vendors
+// whose endpoints clearly aren't there (vendor.ErrUnavailable) aren't
+// retried, so that it's still fast on bare metal.
+func AvailableVendorWithRetries(
+	attempts int, timeout time.Duration,
+) (vendor.InitializedVendor, error) {
+	return availableVendorFrom(
+		vendors(),
+		attempts,
+		timeout,
+		initialDetectionBackoff,
+	)
 }
 
 func availableVendorFrom(
 	vendors map[string]vendor.Vendor,
+	attempts int,
+	timeout time.Duration,
+	backoff time.Duration,
 ) (vendor.InitializedVendor, error) {
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		v, retriable := initVendors(vendors)
+		if v != nil {
+			return v, nil
+		}
+		if len(retriable) == 0 ||
+			attempt >= attempts ||
+			time.Now().Add(backoff).After(deadline) {
+			break
+		}
+		log.Debugf(
+			"Retrying the cloud vendor detection in %s (attempt %d/%d)",
+			backoff,
+			attempt+1,
+			attempts,
+		)
+		time.Sleep(backoff)
+		backoff *= 2
+		vendors = retriable
+	}
+	return nil, errors.New("The cloud vendor couldn't be detected")
+}
+
+// Initializes the vendors concurrently, returning the first one available, if
+// any, or else the ones which failed with errors other than
+// vendor.ErrUnavailable.
+func initVendors(
+	vendors map[string]vendor.Vendor,
+) (vendor.InitializedVendor, map[string]vendor.Vendor) {
 	type initResult struct {
+		name	string
 		vendor	vendor.InitializedVendor
 		err	error
 	}
-	initAsync := func(v vendor.Vendor, c chan<- initResult) {
-		iv, err := v.Init()
-		c <- initResult{iv, err}
-	}
-	var wg sync.WaitGroup
-	wg.Add(len(vendors))
-
-	ch := make(chan initResult)
-	go func() {
-		wg.Wait()
-		close(ch)
-	}()
-
-	for _, v := range vendors {
-		go initAsync(v, ch)
+	// Buffered, so that the vendors still initializing don't block once
+	// one is found.
+	ch := make(chan initResult, len(vendors))
+	for name, v := range vendors {
+		go func(name string, v vendor.Vendor) {
+			iv, err := v.Init()
+			ch <- initResult{name, iv, err}
+		}(name, v)
 	}
 
-	var v vendor.InitializedVendor
-	for res := range ch {
+	retriable := map[string]vendor.Vendor{}
+	for range vendors {
+		res := <-ch
 		if res.err == nil {
-			v = res.vendor
-		} else {
-			log.Debug(res.err)
+			return res.vendor, nil
+		}
+		log.Debug(res.err)
+		if !errors.Is(res.err, vendor.ErrUnavailable) {
+			retriable[res.name] = vendors[res.name]
 		}
-		wg.Done()
-	}
-	if v == nil {
-		return nil, errors.New("The cloud vendor couldn't be detected")
 	}
-	return v, nil
+	return nil, retriable
 }
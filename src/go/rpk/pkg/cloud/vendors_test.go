@@ -10,8 +10,10 @@
 package cloud
 
 import (
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/vendor"
@@ -49,7 +51,7 @@ func TestAvailableVendor(t *testing.T) {
 	vendors[name2] = &mockVendor{true, name2, ""}
 	vendors[name3] = &mockVendor{false, name3, ""}
 
-	availableVendor, err := availableVendorFrom(vendors)
+	availableVendor, err := availableVendorFrom(vendors, 3, time.Second, time.Millisecond)
 	require.NoError(t, err)
 	require.Equal(t, name2, availableVendor.Name())
 }
@@ -65,6 +67,93 @@ func TestUnvailableVendor(t *testing.T) {
 	vendors[name2] = &mockVendor{false, name2, ""}
 	vendors[name3] = &mockVendor{false, name3, ""}
 
-	_, err := availableVendorFrom(vendors)
+	_, err := availableVendorFrom(vendors, 3, time.Second, time.Millisecond)
 	require.EqualError(t, err, "The cloud vendor couldn't be detected")
 }
+
+// Fails with the given error until it's been initialized failures times.
+type flakyVendor struct {
+	name		string
+	failures	int
+	err		error
+	inits		int
+}
+
+func (v *flakyVendor) Name() string {
+	return v.name
+}
+
+func (v *flakyVendor) Init() (vendor.InitializedVendor, error) {
+	v.inits++
+	if v.inits <= v.failures {
+		return nil, v.err
+	}
+	return &mockVendor{true, v.name, ""}, nil
+}
+
+func TestAvailableVendorRetries(t *testing.T) {
+	timeoutErr := errors.New("timed out")
+	unavailableErr := fmt.Errorf("not here: %w", vendor.ErrUnavailable)
+	tests := []struct {
+		name		string
+		vendor		*flakyVendor
+		attempts	int
+		timeout		time.Duration
+		expectedInits	int
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should retry if the vendor times out",
+			vendor:		&flakyVendor{failures: 2, err: timeoutErr},
+			attempts:	3,
+			timeout:	time.Second,
+			expectedInits:	3,
+		},
+		{
+			name:		"it should give up after the given attempts",
+			vendor:		&flakyVendor{failures: 3, err: timeoutErr},
+			attempts:	3,
+			timeout:	time.Second,
+			expectedInits:	3,
+			expectedErrMsg:	"The cloud vendor couldn't be detected",
+		},
+		{
+			name:		"it should give up if the timeout would be exceeded",
+			vendor:		&flakyVendor{failures: 3, err: timeoutErr},
+			attempts:	3,
+			timeout:	time.Millisecond,
+			expectedInits:	1,
+			expectedErrMsg:	"The cloud vendor couldn't be detected",
+		},
+		{
+			name:		"it shouldn't retry if the vendor clearly isn't available",
+			vendor:		&flakyVendor{failures: 1, err: unavailableErr},
+			attempts:	3,
+			timeout:	time.Second,
+			expectedInits:	1,
+			expectedErrMsg:	"The cloud vendor couldn't be detected",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			tt.vendor.name = "flaky"
+			vendors := map[string]vendor.Vendor{
+				"flaky":	tt.vendor,
+				"other":	&mockVendor{false, "other", ""},
+			}
+			v, err := availableVendorFrom(
+				vendors,
+				tt.attempts,
+				tt.timeout,
+				10*time.Millisecond,
+			)
+			require.Equal(st, tt.expectedInits, tt.vendor.inits)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, "flaky", v.Name())
+		})
+	}
+}
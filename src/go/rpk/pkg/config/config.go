@@ -15,6 +15,7 @@ import (
 	"os"
 	fp "path/filepath"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 	log "github.com/sirupsen/logrus"
@@ -26,6 +27,7 @@ import (
 const (
 	ModeDev		= "dev"
 	ModeProd	= "prod"
+	ModeBenchmark	= "benchmark"
 )
 
 func InitViper(fs afero.Fs) *viper.Viper {
@@ -37,6 +39,24 @@ func InitViper(fs afero.Fs) *viper.Viper {
 	return v
 }
 
+// Returns the format of the config file at path, given by its extension:
+// "json" for .json files, "toml" for .toml files, and "yaml" otherwise.
+func configType(path string) string {
+	switch strings.ToLower(fp.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	}
+	return "yaml"
+}
+
+// Sets the file v reads the config from, along with its format.
+func setConfigFile(v *viper.Viper, path string) {
+	v.SetConfigFile(path)
+	v.SetConfigType(configType(path))
+}
+
 func addConfigPaths(v *viper.Viper) {
 	v.AddConfigPath("$HOME")
 	v.AddConfigPath(fp.Join("etc", "redpanda"))
@@ -136,6 +156,9 @@ func SetMode(mode string, conf *Config) (*Config, error) {
 	case ModeProd:
 		return setProduction(conf), nil
 
+	case ModeBenchmark:
+		return setBenchmark(conf), nil
+
 	default:
 		err := fmt.Errorf(
 			"'%s' is not a supported mode. Available modes: %s",
@@ -164,16 +187,32 @@ func setProduction(conf *Config) *Config {
 	conf.Rpk.TuneDiskScheduler = true
 	conf.Rpk.TuneNomerges = true
 	conf.Rpk.TuneDiskIrq = true
+	conf.Rpk.TuneNvmeIrq = true
 	conf.Rpk.TuneFstrim = true
 	conf.Rpk.TuneCpu = true
 	conf.Rpk.TuneAioEvents = true
 	conf.Rpk.TuneClocksource = true
 	conf.Rpk.TuneSwappiness = true
+	conf.Rpk.TuneOvercommit = true
 	conf.Rpk.Overprovisioned = false
 	conf.Rpk.TuneDiskWriteCache = true
 	return conf
 }
 
+// Throughput-oriented settings for performance testing, not meant for
+// production: on top of the production settings, it enables the transparent
+// hugepages and coredump tuners and locks redpanda's memory. The other opt-in
+// tuners (e.g. SMT or the ring buffers) are left as they are, since they
+// change the host in ways a benchmark shouldn't assume. Not overprovisioning
+// keeps seastar's thread affinity on and an IO queue per shard.
+func setBenchmark(conf *Config) *Config {
+	conf = setProduction(conf)
+	conf.Rpk.TuneTransparentHugePages = true
+	conf.Rpk.TuneCoredump = true
+	conf.Rpk.EnableMemoryLocking = true
+	return conf
+}
+
 func NormalizeMode(mode string) (string, error) {
 	switch mode {
 	case "":
@@ -184,6 +223,9 @@ func NormalizeMode(mode string) (string, error) {
 	case "production", ModeProd:
 		return ModeProd, nil
 
+	case ModeBenchmark:
+		return ModeBenchmark, nil
+
 	default:
 		err := fmt.Errorf(
 			"'%s' is not a supported mode. Available modes: %s",
@@ -200,6 +242,7 @@ func AvailableModes() []string {
 		"development",
 		ModeProd,
 		"production",
+		ModeBenchmark,
 	}
 }
 
@@ -308,6 +351,17 @@ func checkRpkConfig(v *viper.Viper) []error {
 			"rpk.coredump_dir can't be empty"
 		errs = append(errs, errors.New(msg))
 	}
+	for tuner, timeout := range v.GetStringMapString("rpk.tuner_timeouts") {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf(
+				"rpk.tuner_timeouts.%s must be a positive duration,"+
+					" e.g. '5m', but it's '%s'",
+				tuner,
+				timeout,
+			))
+		}
+	}
 	return errs
 }
 
@@ -318,5 +372,36 @@ func toMap(conf *Config) (map[string]interface{}, error) {
 		return mapConf, err
 	}
 	err = yaml.Unmarshal(bs, &mapConf)
-	return mapConf, err
+	if err != nil {
+		return mapConf, err
+	}
+	for k, v := range mapConf {
+		mapConf[k] = stringKeys(v)
+	}
+	return mapConf, nil
+}
+
+// Converts the map[interface{}]interface{} values yaml.Unmarshal leaves in
+// v, including the ones in lists (e.g. the seed servers), to
+// map[string]interface{}, which the TOML encoder requires.
+func stringKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = stringKeys(val)
+		}
+		return m
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = stringKeys(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = stringKeys(val)
+		}
+		return t
+	}
+	return v
 }
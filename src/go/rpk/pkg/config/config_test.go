@@ -10,7 +10,11 @@
 package config
 
 import (
+	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -41,11 +45,13 @@ func getValidConfig() *Config {
 		TuneDiskWriteCache:		true,
 		TuneNomerges:			true,
 		TuneDiskIrq:			true,
+		TuneNvmeIrq:			true,
 		TuneFstrim:			true,
 		TuneCpu:			true,
 		TuneAioEvents:			true,
 		TuneClocksource:		true,
 		TuneSwappiness:			true,
+		TuneOvercommit:			true,
 		TuneTransparentHugePages:	true,
 		EnableMemoryLocking:		true,
 		TuneCoredump:			true,
@@ -111,10 +117,12 @@ func TestSet(t *testing.T) {
 				"tune_disk_scheduler":		false,
 				"tune_disk_nomerges":		false,
 				"tune_disk_irq":		true,
+				"tune_nvme_irq":		false,
 				"tune_cpu":			false,
 				"tune_aio_events":		false,
 				"tune_clocksource":		false,
 				"tune_swappiness":		false,
+				"tune_overcommit":		false,
 				"tune_transparent_hugepages":	false,
 				"enable_memory_locking":	false,
 				"tune_fstrim":			false,
@@ -196,6 +204,135 @@ func TestSet(t *testing.T) {
 	}
 }
 
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name		string
+		before		func(*Config)
+		key		string
+		values		[]string
+		format		string
+		check		func(*testing.T, *Config)
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should append to a list which isn't set",
+			key:	"rpk.additional_start_flags",
+			values:	[]string{"--abort-on-seastar-bad-alloc"},
+			format:	"single",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(
+					st,
+					[]string{"--abort-on-seastar-bad-alloc"},
+					c.Rpk.AdditionalStartFlags,
+				)
+			},
+		},
+		{
+			name:	"it should preserve the order and skip duplicates",
+			before: func(c *Config) {
+				c.Rpk.AdditionalStartFlags = []string{"--smp=2", "--memory=4G"}
+			},
+			key:	"rpk.additional_start_flags",
+			values:	[]string{"--memory=4G", "--overprovisioned", "--smp=2"},
+			format:	"single",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(
+					st,
+					[]string{"--smp=2", "--memory=4G", "--overprovisioned"},
+					c.Rpk.AdditionalStartFlags,
+				)
+			},
+		},
+		{
+			name:	"it should append objects (json)",
+			before: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{
+					{SocketAddress{"10.0.0.1", 33145}, 1},
+				}
+			},
+			key:	"redpanda.seed_servers",
+			values: []string{
+				`{"node_id": 2, "host": {"address": "10.0.0.2", "port": 33145}}`,
+				`{"node_id": 1, "host": {"address": "10.0.0.1", "port": 33145}}`,
+			},
+			format:	"json",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(
+					st,
+					[]SeedServer{
+						{SocketAddress{"10.0.0.1", 33145}, 1},
+						{SocketAddress{"10.0.0.2", 33145}, 2},
+					},
+					c.Redpanda.SeedServers,
+				)
+			},
+		},
+		{
+			name:	"it should append objects (yaml)",
+			key:	"redpanda.seed_servers",
+			values: []string{`node_id: 3
+host:
+  address: 10.0.0.3
+  port: 33145`},
+			format:	"yaml",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(
+					st,
+					[]SeedServer{{SocketAddress{"10.0.0.3", 33145}, 3}},
+					c.Redpanda.SeedServers,
+				)
+			},
+		},
+		{
+			name:		"it should fail if the key isn't a list",
+			key:		"redpanda.node_id",
+			values:		[]string{"1"},
+			format:		"single",
+			expectedErrMsg:	"'redpanda.node_id' isn't a list",
+		},
+		{
+			name:		"it should fail if the key doesn't exist",
+			key:		"rpk.start_flags",
+			values:		[]string{"--smp=1"},
+			format:		"single",
+			expectedErrMsg:	"unknown config key 'rpk.start_flags'",
+		},
+		{
+			name:		"it should fail if an object is passed as a single value",
+			key:		"redpanda.seed_servers",
+			values:		[]string{"10.0.0.1"},
+			format:		"single",
+			expectedErrMsg:	"invalid value for 'redpanda.seed_servers': only single values and lists of strings can be set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			mgr := NewManager(fs)
+			conf := Default()
+			if tt.before != nil {
+				tt.before(conf)
+			}
+			require.NoError(st, mgr.Write(conf))
+			for _, value := range tt.values {
+				err := mgr.Append(tt.key, value, tt.format, conf.ConfigFile)
+				if tt.expectedErrMsg != "" {
+					require.EqualError(st, err, tt.expectedErrMsg)
+					return
+				}
+				require.NoError(st, err)
+			}
+			// The written config should still be loadable.
+			mgr = NewManager(fs)
+			confJSON, err := mgr.ReadAsJSON(conf.ConfigFile)
+			require.NoError(st, err)
+			actual := &Config{}
+			require.NoError(st, yaml.Unmarshal([]byte(confJSON), actual))
+			tt.check(st, actual)
+		})
+	}
+}
+
 func TestDefault(t *testing.T) {
 	defaultConfig := Default()
 	expected := &Config{
@@ -325,6 +462,8 @@ rpk:
   tune_disk_write_cache: true
   tune_fstrim: true
   tune_network: true
+  tune_nvme_irq: true
+  tune_overcommit: true
   tune_swappiness: true
   tune_transparent_hugepages: true
   well_known_io: vendor:vm:storage
@@ -382,6 +521,8 @@ rpk:
   tune_disk_write_cache: true
   tune_fstrim: true
   tune_network: true
+  tune_nvme_irq: true
+  tune_overcommit: true
   tune_swappiness: true
   tune_transparent_hugepages: true
   well_known_io: vendor:vm:storage
@@ -433,6 +574,8 @@ rpk:
   tune_disk_write_cache: false
   tune_fstrim: false
   tune_network: false
+  tune_nvme_irq: false
+  tune_overcommit: false
   tune_swappiness: false
   tune_transparent_hugepages: false
 `,
@@ -488,6 +631,8 @@ rpk:
   tune_disk_write_cache: true
   tune_fstrim: true
   tune_network: true
+  tune_nvme_irq: true
+  tune_overcommit: true
   tune_swappiness: true
   tune_transparent_hugepages: true
   well_known_io: vendor:vm:storage
@@ -566,6 +711,8 @@ rpk:
   tune_disk_write_cache: true
   tune_fstrim: true
   tune_network: true
+  tune_nvme_irq: true
+  tune_overcommit: true
   tune_swappiness: true
   tune_transparent_hugepages: true
   well_known_io: vendor:vm:storage
@@ -634,6 +781,8 @@ rpk:
   tune_disk_write_cache: true
   tune_fstrim: true
   tune_network: true
+  tune_nvme_irq: true
+  tune_overcommit: true
   tune_swappiness: true
   tune_transparent_hugepages: true
   well_known_io: vendor:vm:storage
@@ -727,6 +876,341 @@ func TestInitConfig(t *testing.T) {
 	}
 }
 
+// Updates a Kubernetes-like projected volume in dir the way the kubelet does:
+// the new version is written to a timestamped dir, which a temporary symlink
+// is pointed at, and which is then renamed over ..data, atomically swapping
+// the version the files in dir point to.
+func swapProjectedVolume(fs afero.Fs, dir, version string, conf *Config) error {
+	bs, err := yaml.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	err = fs.MkdirAll(filepath.Join(dir, version), 0755)
+	if err != nil {
+		return err
+	}
+	err = afero.WriteFile(
+		fs,
+		filepath.Join(dir, version, "redpanda.yaml"),
+		bs,
+		0644,
+	)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	err = os.Symlink(version, tmp)
+	if err != nil {
+		return err
+	}
+	return fs.Rename(tmp, filepath.Join(dir, "..data"))
+}
+
+// An OS filesystem which runs swap right before the first file is opened, as
+// if the kubelet swapped the projected volume after its symlinks were
+// resolved but before the file was read.
+type swapBeforeOpenFs struct {
+	*afero.OsFs
+	swap	func() error
+}
+
+func (fs *swapBeforeOpenFs) Open(name string) (afero.File, error) {
+	if fs.swap != nil {
+		err := fs.swap()
+		fs.swap = nil
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fs.OsFs.Open(name)
+}
+
+func TestReadOrGenerateSymlinkSwap(t *testing.T) {
+	// Symlinks aren't supported by afero's in-memory FS, so lay out a
+	// Kubernetes-like projected volume in a temp dir:
+	// redpanda.yaml -> ..data/redpanda.yaml, ..data -> <version dir>
+	osFs := &afero.OsFs{}
+	dir, err := afero.TempDir(osFs, "", "rpk-config")
+	require.NoError(t, err)
+	defer osFs.RemoveAll(dir)
+	path := filepath.Join(dir, "redpanda.yaml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "redpanda.yaml"), path))
+
+	first := getValidConfig()
+	first.Redpanda.Id = 1
+	require.NoError(t, swapProjectedVolume(osFs, dir, "..2021_01_01", first))
+	conf, err := NewManager(osFs).ReadOrGenerate(path)
+	require.NoError(t, err)
+	require.Equal(t, 1, conf.Redpanda.Id)
+
+	// The kubelet swaps the volume and removes the previous version
+	// between the symlinks being resolved and the file being read, so
+	// reading the resolved path fails and has to be retried.
+	second := getValidConfig()
+	second.Redpanda.Id = 2
+	fs := &swapBeforeOpenFs{
+		OsFs:	osFs,
+		swap: func() error {
+			err := swapProjectedVolume(osFs, dir, "..2021_01_02", second)
+			if err != nil {
+				return err
+			}
+			return osFs.RemoveAll(filepath.Join(dir, "..2021_01_01"))
+		},
+	}
+	conf, err = NewManager(fs).ReadOrGenerate(path)
+	require.NoError(t, err)
+	// The config should have been read from the swapped in file rather
+	// than generated.
+	require.Nil(t, fs.swap)
+	require.Equal(t, 2, conf.Redpanda.Id)
+	require.Equal(t, second.Redpanda, conf.Redpanda)
+	fi, err := os.Lstat(path)
+	require.NoError(t, err)
+	require.NotZero(t, fi.Mode()&os.ModeSymlink)
+}
+
+func TestReadIncludes(t *testing.T) {
+	tests := []struct {
+		name		string
+		files		map[string]string
+		check		func(*testing.T, *Config)
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should merge the included files, nested ones first",
+			files: map[string]string{
+				"/etc/redpanda/redpanda.yaml": `include: [conf.d/node.yaml]
+redpanda:
+  node_id: 3
+`,
+				"/etc/redpanda/conf.d/node.yaml": `include: [/etc/redpanda/base.yaml]
+redpanda:
+  node_id: 2
+  data_directory: /var/lib/redpanda/node
+`,
+				"/etc/redpanda/base.yaml": `redpanda:
+  node_id: 1
+  data_directory: /var/lib/redpanda/base
+  kafka_api:
+    address: 10.0.0.1
+    port: 9093
+rpk:
+  tune_cpu: true
+`,
+			},
+			check: func(st *testing.T, conf *Config) {
+				// The including file overrides the included ones.
+				require.Equal(st, 3, conf.Redpanda.Id)
+				require.Equal(st, "/var/lib/redpanda/node", conf.Redpanda.Directory)
+				require.Equal(st, SocketAddress{"10.0.0.1", 9093}, conf.Redpanda.KafkaApi)
+				require.True(st, conf.Rpk.TuneCpu)
+				// Unset values still fall back to the defaults.
+				require.Equal(st, Default().Redpanda.RPCServer, conf.Redpanda.RPCServer)
+				require.Equal(st, []string{"conf.d/node.yaml"}, conf.Include)
+			},
+		},
+		{
+			name:	"it should fail if there's an include cycle",
+			files: map[string]string{
+				"/etc/redpanda/redpanda.yaml":	"include: [a.yaml]\n",
+				"/etc/redpanda/a.yaml":		"include: [b.yaml]\n",
+				"/etc/redpanda/b.yaml":		"include: [redpanda.yaml]\n",
+			},
+			expectedErrMsg:	"config include cycle: /etc/redpanda/redpanda.yaml -> /etc/redpanda/a.yaml -> /etc/redpanda/b.yaml -> /etc/redpanda/redpanda.yaml",
+		},
+		{
+			name:	"it should fail if an included file doesn't exist",
+			files: map[string]string{
+				"/etc/redpanda/redpanda.yaml": "include: [missing.yaml]\n",
+			},
+			expectedErrMsg:	"open /etc/redpanda/missing.yaml: file does not exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				err := afero.WriteFile(fs, path, []byte(content), 0644)
+				require.NoError(st, err)
+			}
+			conf, err := NewManager(fs).Read("/etc/redpanda/redpanda.yaml")
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			tt.check(st, conf)
+		})
+	}
+}
+
+func TestWriteIncludes(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/etc/redpanda/redpanda.yaml"
+	base := "/etc/redpanda/base.yaml"
+	files := map[string]string{
+		path: `include: [base.yaml]
+redpanda:
+  node_id: 3
+`,
+		base: `redpanda:
+  data_directory: /var/lib/redpanda/base
+  kafka_api:
+    address: 10.0.0.1
+    port: 9093
+`,
+	}
+	for p, content := range files {
+		require.NoError(t, afero.WriteFile(fs, p, []byte(content), 0644))
+	}
+	mgr := NewManager(fs)
+	conf, err := mgr.Read(path)
+	require.NoError(t, err)
+	conf.Redpanda.Id = 4
+	require.NoError(t, mgr.Write(conf))
+
+	bs, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	written := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(bs, &written))
+	require.Equal(t, []interface{}{"base.yaml"}, written["include"])
+	redpanda := written["redpanda"].(map[interface{}]interface{})
+	require.EqualValues(t, 4, redpanda["node_id"])
+	// The included settings shouldn't have been copied over.
+	require.NotContains(t, redpanda, "data_directory")
+	require.NotContains(t, redpanda, "kafka_api")
+
+	// redpanda is started with the whole config, without the includes.
+	require.Equal(t, "/etc/redpanda/redpanda.merged.yaml", conf.RedpandaConfigFile())
+	merged, err := NewManager(fs).Read(conf.RedpandaConfigFile())
+	require.NoError(t, err)
+	require.Empty(t, merged.Include)
+	require.Equal(t, 4, merged.Redpanda.Id)
+	require.Equal(t, "/var/lib/redpanda/base", merged.Redpanda.Directory)
+	require.Equal(t, SocketAddress{"10.0.0.1", 9093}, merged.Redpanda.KafkaApi)
+
+	// So later changes to the included files still apply.
+	err = afero.WriteFile(fs, base, []byte(`redpanda:
+  data_directory: /var/lib/redpanda/new
+  kafka_api:
+    address: 10.0.0.2
+    port: 9093
+`), 0644)
+	require.NoError(t, err)
+	conf, err = NewManager(fs).Read(path)
+	require.NoError(t, err)
+	require.Equal(t, 4, conf.Redpanda.Id)
+	require.Equal(t, "/var/lib/redpanda/new", conf.Redpanda.Directory)
+	require.Equal(t, SocketAddress{"10.0.0.2", 9093}, conf.Redpanda.KafkaApi)
+	require.Equal(t, []string{"base.yaml"}, conf.Include)
+}
+
+func TestReadFragments(t *testing.T) {
+	const dir = "/etc/redpanda/conf.d"
+	tests := []struct {
+		name		string
+		files		map[string]string
+		check		func(*testing.T, *Config)
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should merge the fragments in lexical order",
+			files: map[string]string{
+				dir + "/10-base.yaml": `redpanda:
+  node_id: 1
+  data_directory: /var/lib/redpanda/base
+  seed_servers:
+  - host:
+      address: 10.0.0.1
+      port: 33145
+    node_id: 1
+rpk:
+  tune_cpu: true
+`,
+				dir + "/20-node.yaml": `redpanda:
+  node_id: 2
+  seed_servers:
+  - host:
+      address: 10.0.0.2
+      port: 33145
+    node_id: 2
+`,
+				dir + "/README.md":	"not a fragment",
+			},
+			check: func(st *testing.T, conf *Config) {
+				require.Equal(st, 2, conf.Redpanda.Id)
+				require.Equal(st, "/var/lib/redpanda/base", conf.Redpanda.Directory)
+				require.True(st, conf.Rpk.TuneCpu)
+				// Lists are replaced.
+				require.Equal(st, []SeedServer{{
+					Host:	SocketAddress{"10.0.0.2", 33145},
+					Id:	2,
+				}}, conf.Redpanda.SeedServers)
+				require.Equal(st, Default().Redpanda.RPCServer, conf.Redpanda.RPCServer)
+				require.Equal(st, "/etc/redpanda/conf.d.merged.yaml", conf.ConfigFile)
+			},
+		},
+		{
+			name:	"it should append the lists under keys ending in '+'",
+			files: map[string]string{
+				dir + "/a.yaml": `redpanda:
+  seed_servers:
+  - host:
+      address: 10.0.0.1
+      port: 33145
+    node_id: 1
+`,
+				dir + "/b.yaml": `redpanda:
+  seed_servers+:
+  - host:
+      address: 10.0.0.2
+      port: 33145
+    node_id: 2
+`,
+			},
+			check: func(st *testing.T, conf *Config) {
+				require.Equal(st, []SeedServer{{
+					Host:	SocketAddress{"10.0.0.1", 33145},
+					Id:	1,
+				}, {
+					Host:	SocketAddress{"10.0.0.2", 33145},
+					Id:	2,
+				}}, conf.Redpanda.SeedServers)
+			},
+		},
+		{
+			name:	"it should fail if there are no fragments",
+			files: map[string]string{
+				dir + "/README.md": "not a fragment",
+			},
+			expectedErrMsg:	"An error happened while trying to read /etc/redpanda/conf.d: no config fragments (*.yaml) found in '/etc/redpanda/conf.d'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				err := afero.WriteFile(fs, path, []byte(content), 0644)
+				require.NoError(st, err)
+			}
+			mgr := NewManager(fs)
+			conf, err := mgr.FindOrGenerate(dir)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			tt.check(st, conf)
+			// The merged config is written next to the directory.
+			require.NoError(st, mgr.Write(conf))
+			written, err := NewManager(fs).Read(conf.ConfigFile)
+			require.NoError(st, err)
+			require.Equal(st, conf, written)
+		})
+	}
+}
+
 func TestSetMode(t *testing.T) {
 	fillRpkConfig := func(mode string) *Config {
 		conf := Default()
@@ -738,11 +1222,13 @@ func TestSetMode(t *testing.T) {
 			TuneNomerges:		val,
 			TuneDiskWriteCache:	val,
 			TuneDiskIrq:		val,
+			TuneNvmeIrq:		val,
 			TuneFstrim:		val,
 			TuneCpu:		val,
 			TuneAioEvents:		val,
 			TuneClocksource:	val,
 			TuneSwappiness:		val,
+			TuneOvercommit:		val,
 			CoredumpDir:		conf.Rpk.CoredumpDir,
 			Overprovisioned:	!val,
 		}
@@ -780,10 +1266,21 @@ func TestSetMode(t *testing.T) {
 			mode:		ModeProd,
 			expectedConfig:	fillRpkConfig(ModeProd),
 		},
+		{
+			name:	"it should enable the THP and coredump tuners and lock memory for benchmark mode",
+			mode:	ModeBenchmark,
+			expectedConfig: func() *Config {
+				conf := fillRpkConfig(ModeProd)
+				conf.Rpk.TuneTransparentHugePages = true
+				conf.Rpk.TuneCoredump = true
+				conf.Rpk.EnableMemoryLocking = true
+				return conf
+			}(),
+		},
 		{
 			name:		"it should return an error for invalid modes",
 			mode:		"winning",
-			expectedErrMsg:	"'winning' is not a supported mode. Available modes: dev, development, prod, production",
+			expectedErrMsg:	"'winning' is not a supported mode. Available modes: dev, development, prod, production, benchmark",
 		},
 	}
 
@@ -801,6 +1298,44 @@ func TestSetMode(t *testing.T) {
 	}
 }
 
+func TestBenchmarkModeTuners(t *testing.T) {
+	conf, err := SetMode(ModeBenchmark, Default())
+	require.NoError(t, err)
+	var enabled []string
+	v := reflect.ValueOf(conf.Rpk)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		field := v.Field(i)
+		if strings.HasPrefix(name, "Tune") &&
+			field.Kind() == reflect.Bool &&
+			field.Bool() {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	require.Equal(
+		t,
+		[]string{
+			"TuneAioEvents",
+			"TuneClocksource",
+			"TuneCoredump",
+			"TuneCpu",
+			"TuneDiskIrq",
+			"TuneDiskScheduler",
+			"TuneDiskWriteCache",
+			"TuneFstrim",
+			"TuneNetwork",
+			"TuneNomerges",
+			"TuneNvmeIrq",
+			"TuneOvercommit",
+			"TuneSwappiness",
+			"TuneTransparentHugePages",
+		},
+		enabled,
+	)
+	require.True(t, conf.Rpk.EnableMemoryLocking)
+}
+
 func TestCheckConfig(t *testing.T) {
 	tests := []struct {
 		name		string
@@ -906,6 +1441,19 @@ func TestCheckConfig(t *testing.T) {
 			expected: []string{"if rpk.tune_coredump is set to true," +
 				"rpk.coredump_dir can't be empty"},
 		},
+		{
+			name:	"shall return an error when a tuner timeout isn't a positive duration",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Rpk.TunerTimeouts = map[string]string{
+					"disk_irq":	"5m",
+					"cpu":		"-1s",
+				}
+				return c
+			},
+			expected: []string{"rpk.tuner_timeouts.cpu must be a positive" +
+				" duration, e.g. '5m', but it's '-1s'"},
+		},
 		{
 			name: "shall return no error if setup is empty," +
 				"but coredump_dir is empty",
@@ -945,7 +1493,7 @@ func TestReadAsJSON(t *testing.T) {
 				return mgr.Write(conf)
 			},
 			path:		Default().ConfigFile,
-			expected:	`{"config_file":"/etc/redpanda/redpanda.yaml","redpanda":{"admin":{"address":"0.0.0.0","port":9644},"data_directory":"/var/lib/redpanda/data","developer_mode":true,"kafka_api":{"address":"0.0.0.0","port":9092},"node_id":0,"rpc_server":{"address":"0.0.0.0","port":33145},"seed_servers":[]},"rpk":{"coredump_dir":"/var/lib/redpanda/coredump","enable_memory_locking":false,"enable_usage_stats":false,"overprovisioned":false,"tune_aio_events":false,"tune_clocksource":false,"tune_coredump":false,"tune_cpu":false,"tune_disk_irq":false,"tune_disk_nomerges":false,"tune_disk_scheduler":false,"tune_disk_write_cache":false,"tune_fstrim":false,"tune_network":false,"tune_swappiness":false,"tune_transparent_hugepages":false}}`,
+			expected:	`{"config_file":"/etc/redpanda/redpanda.yaml","redpanda":{"admin":{"address":"0.0.0.0","port":9644},"data_directory":"/var/lib/redpanda/data","developer_mode":true,"kafka_api":{"address":"0.0.0.0","port":9092},"node_id":0,"rpc_server":{"address":"0.0.0.0","port":33145},"seed_servers":[]},"rpk":{"coredump_dir":"/var/lib/redpanda/coredump","enable_memory_locking":false,"enable_usage_stats":false,"overprovisioned":false,"tune_aio_events":false,"tune_clocksource":false,"tune_coredump":false,"tune_cpu":false,"tune_disk_irq":false,"tune_disk_nomerges":false,"tune_disk_scheduler":false,"tune_disk_write_cache":false,"tune_fstrim":false,"tune_network":false,"tune_nvme_irq":false,"tune_overcommit":false,"tune_swappiness":false,"tune_transparent_hugepages":false}}`,
 		},
 		{
 			name:		"it should fail if the the config isn't found",
@@ -991,12 +1539,14 @@ func TestReadFlat(t *testing.T) {
 		"rpk.tune_coredump":			"false",
 		"rpk.tune_cpu":				"false",
 		"rpk.tune_disk_irq":			"false",
+		"rpk.tune_nvme_irq":			"false",
 		"rpk.tune_disk_nomerges":		"false",
 		"rpk.tune_disk_scheduler":		"false",
 		"rpk.tune_disk_write_cache":		"false",
 		"rpk.tune_fstrim":			"false",
 		"rpk.tune_network":			"false",
 		"rpk.tune_swappiness":			"false",
+		"rpk.tune_overcommit":			"false",
 		"rpk.tune_transparent_hugepages":	"false",
 	}
 	fs := afero.NewMemMapFs()
@@ -1040,3 +1590,30 @@ func TestWriteAndGenerateNodeUuid(t *testing.T) {
 	require.NoError(t, err)
 	require.Exactly(t, conf, readConf)
 }
+
+func TestReadFormats(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	for _, ext := range []string{"yaml", "yml", "json", "toml"} {
+		t.Run(ext, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			path := filepath.Join(wd, "redpanda."+ext)
+			expected := getValidConfig()
+			expected.ConfigFile = path
+			require.NoError(st, NewManager(fs).Write(expected))
+
+			conf, err := NewManager(fs).Read(path)
+			require.NoError(st, err)
+			require.Exactly(st, expected, conf)
+
+			conf, err = NewManager(fs).FindOrGenerate("")
+			require.NoError(st, err)
+			require.Exactly(st, expected, conf)
+
+			// It should be returned as JSON regardless of the format.
+			confJSON, err := NewManager(fs).ReadAsJSON(path)
+			require.NoError(st, err)
+			require.Contains(st, confJSON, `"well_known_io":"vendor:vm:storage"`)
+		})
+	}
+}
@@ -19,11 +19,15 @@ type Config struct {
 	ConfigFile	string		`yaml:"config_file" mapstructure:"config_file" json:"configFile"`
 	Redpanda	RedpandaConfig	`yaml:"redpanda" mapstructure:"redpanda" json:"redpanda"`
 	Rpk		RpkConfig	`yaml:"rpk" mapstructure:"rpk" json:"rpk"`
+	// Config files loaded before this one, which overrides them. Relative
+	// paths are resolved against this file's directory.
+	Include		[]string	`yaml:"include,omitempty" mapstructure:"include,omitempty" json:"include,omitempty"`
 }
 
 type RedpandaConfig struct {
 	Directory		string		`yaml:"data_directory" mapstructure:"data_directory" json:"dataDirectory"`
 	RPCServer		SocketAddress	`yaml:"rpc_server" mapstructure:"rpc_server" json:"rpcServer"`
+	RPCServerTLS		ServerTLS	`yaml:"rpc_server_tls,omitempty" mapstructure:"rpc_server_tls,omitempty" json:"rpcServerTls"`
 	AdvertisedRPCAPI	*SocketAddress	`yaml:"advertised_rpc_api,omitempty" mapstructure:"advertised_rpc_api,omitempty" json:"advertisedRpcApi,omitempty"`
 	KafkaApi		SocketAddress	`yaml:"kafka_api" mapstructure:"kafka_api" json:"kafkaApi"`
 	AdvertisedKafkaApi	*SocketAddress	`yaml:"advertised_kafka_api,omitempty" mapstructure:"advertised_kafka_api,omitempty" json:"advertisedKafkaApi,omitempty"`
@@ -62,24 +66,91 @@ type RpkConfig struct {
 	AdditionalStartFlags		[]string	`yaml:"additional_start_flags,omitempty" mapstructure:"additional_start_flags,omitempty" json:"additionalStartFlags"`
 	EnableUsageStats		bool		`yaml:"enable_usage_stats" mapstructure:"enable_usage_stats" json:"enableUsageStats"`
 	TuneNetwork			bool		`yaml:"tune_network" mapstructure:"tune_network" json:"tuneNetwork"`
+	TuneLoopbackRps			bool		`yaml:"tune_loopback_rps,omitempty" mapstructure:"tune_loopback_rps,omitempty" json:"tuneLoopbackRps,omitempty"`
 	TuneDiskScheduler		bool		`yaml:"tune_disk_scheduler" mapstructure:"tune_disk_scheduler" json:"tuneDiskScheduler"`
 	TuneNomerges			bool		`yaml:"tune_disk_nomerges" mapstructure:"tune_disk_nomerges" json:"tuneNomerges"`
 	TuneDiskWriteCache		bool		`yaml:"tune_disk_write_cache" mapstructure:"tune_disk_write_cache" json:"tuneDiskWriteCache"`
 	TuneDiskIrq			bool		`yaml:"tune_disk_irq" mapstructure:"tune_disk_irq" json:"tuneDiskIrq"`
+	TuneNvmeIrq			bool		`yaml:"tune_nvme_irq" mapstructure:"tune_nvme_irq" json:"tuneNvmeIrq"`
 	TuneFstrim			bool		`yaml:"tune_fstrim" mapstructure:"tune_fstrim" json:"tuneFstrim"`
 	TuneCpu				bool		`yaml:"tune_cpu" mapstructure:"tune_cpu" json:"tuneCpu"`
 	TuneAioEvents			bool		`yaml:"tune_aio_events" mapstructure:"tune_aio_events" json:"tuneAioEvents"`
 	TuneClocksource			bool		`yaml:"tune_clocksource" mapstructure:"tune_clocksource" json:"tuneClocksource"`
 	TuneSwappiness			bool		`yaml:"tune_swappiness" mapstructure:"tune_swappiness" json:"tuneSwappiness"`
+	TuneOvercommit			bool		`yaml:"tune_overcommit" mapstructure:"tune_overcommit" json:"tuneOvercommit"`
 	TuneTransparentHugePages	bool		`yaml:"tune_transparent_hugepages" mapstructure:"tune_transparent_hugepages" json:"tuneTransparentHugePages"`
+	TransparentHugePagesMode	string		`yaml:"transparent_hugepages_mode,omitempty" mapstructure:"transparent_hugepages_mode,omitempty" json:"transparentHugePagesMode,omitempty"`
 	EnableMemoryLocking		bool		`yaml:"enable_memory_locking" mapstructure:"enable_memory_locking" json:"enableMemoryLocking"`
 	TuneCoredump			bool		`yaml:"tune_coredump" mapstructure:"tune_coredump" json:"tuneCoredump"`
 	CoredumpDir			string		`yaml:"coredump_dir,omitempty" mapstructure:"coredump_dir,omitempty" json:"coredumpDir"`
+	InstallDir			string		`yaml:"install_dir,omitempty" mapstructure:"install_dir,omitempty" json:"installDir,omitempty"`
 	WellKnownIo			string		`yaml:"well_known_io,omitempty" mapstructure:"well_known_io,omitempty" json:"wellKnownIo"`
 	Overprovisioned			bool		`yaml:"overprovisioned" mapstructure:"overprovisioned" json:"overprovisioned"`
 	SMP				*int		`yaml:"smp,omitempty" mapstructure:"smp,omitempty" json:"smp,omitempty"`
+	MemoryPercent			string		`yaml:"memory_percent,omitempty" mapstructure:"memory_percent,omitempty" json:"memoryPercent,omitempty"`
+	PreallocSize			string		`yaml:"prealloc_size,omitempty" mapstructure:"prealloc_size,omitempty" json:"preallocSize,omitempty"`
+	RecommendSMT			*bool		`yaml:"recommend_smt,omitempty" mapstructure:"recommend_smt,omitempty" json:"recommendSmt,omitempty"`
+	TuneSMT				bool		`yaml:"tune_smt,omitempty" mapstructure:"tune_smt,omitempty" json:"tuneSmt,omitempty"`
+	RqAffinity			*int		`yaml:"rq_affinity,omitempty" mapstructure:"rq_affinity,omitempty" json:"rqAffinity,omitempty"`
+	CStateMaxLatency		*int		`yaml:"cstate_max_latency_us,omitempty" mapstructure:"cstate_max_latency_us,omitempty" json:"cstateMaxLatencyUs,omitempty"`
+	StrictIoProperties		bool		`yaml:"strict_io_properties,omitempty" mapstructure:"strict_io_properties,omitempty" json:"strictIoProperties,omitempty"`
+	TuneRingBuffers			bool		`yaml:"tune_ring_buffers,omitempty" mapstructure:"tune_ring_buffers,omitempty" json:"tuneRingBuffers,omitempty"`
+	TuneNicIrqAffinity		bool		`yaml:"tune_nic_irq_affinity,omitempty" mapstructure:"tune_nic_irq_affinity,omitempty" json:"tuneNicIrqAffinity,omitempty"`
+	RingBufferSize			int		`yaml:"ring_buffer_size,omitempty" mapstructure:"ring_buffer_size,omitempty" json:"ringBufferSize,omitempty"`
+	NUMAHugepages			string		`yaml:"numa_hugepages,omitempty" mapstructure:"numa_hugepages,omitempty" json:"numaHugepages,omitempty"`
+	NUMAHugepagesNode		int		`yaml:"numa_hugepages_node,omitempty" mapstructure:"numa_hugepages_node,omitempty" json:"numaHugepagesNode,omitempty"`
+	RedpandaLogLevel		string		`yaml:"redpanda_log_level,omitempty" mapstructure:"redpanda_log_level,omitempty" json:"redpandaLogLevel,omitempty"`
+	LoggerLogLevels			[]string	`yaml:"logger_log_levels,omitempty" mapstructure:"logger_log_levels,omitempty" json:"loggerLogLevels,omitempty"`
+	OomScoreAdj			*int		`yaml:"oom_score_adj,omitempty" mapstructure:"oom_score_adj,omitempty" json:"oomScoreAdj,omitempty"`
+	MinRedpandaVersion		string		`yaml:"min_redpanda_version,omitempty" mapstructure:"min_redpanda_version,omitempty" json:"minRedpandaVersion,omitempty"`
+	TuneDiskQuota			bool		`yaml:"tune_disk_quota,omitempty" mapstructure:"tune_disk_quota,omitempty" json:"tuneDiskQuota,omitempty"`
+	DiskQuotaSize			string		`yaml:"disk_quota_size,omitempty" mapstructure:"disk_quota_size,omitempty" json:"diskQuotaSize,omitempty"`
+	TuneBallastFile			bool		`yaml:"tune_ballast_file,omitempty" mapstructure:"tune_ballast_file,omitempty" json:"tuneBallastFile,omitempty"`
+	BallastFileSize			string		`yaml:"ballast_file_size,omitempty" mapstructure:"ballast_file_size,omitempty" json:"ballastFileSize,omitempty"`
+	TuneIOScheduler			bool		`yaml:"tune_io_scheduler,omitempty" mapstructure:"tune_io_scheduler,omitempty" json:"tuneIoScheduler,omitempty"`
+	Swappiness			*int		`yaml:"swappiness,omitempty" mapstructure:"swappiness,omitempty" json:"swappiness,omitempty"`
+	MinFreeDisk			string		`yaml:"min_free_disk,omitempty" mapstructure:"min_free_disk,omitempty" json:"minFreeDisk,omitempty"`
+	MinFreeDiskFatal		bool		`yaml:"min_free_disk_fatal,omitempty" mapstructure:"min_free_disk_fatal,omitempty" json:"minFreeDiskFatal,omitempty"`
+	LockMemoryOnSwapFailure		*bool		`yaml:"lock_memory_on_swap_failure,omitempty" mapstructure:"lock_memory_on_swap_failure,omitempty" json:"lockMemoryOnSwapFailure,omitempty"`
+	PIDFile				string		`yaml:"pid_file,omitempty" mapstructure:"pid_file,omitempty" json:"pidFile,omitempty"`
+	TuneFdLimit			bool		`yaml:"tune_fd_limit,omitempty" mapstructure:"tune_fd_limit,omitempty" json:"tuneFdLimit,omitempty"`
+	MinFdLimit			uint64		`yaml:"min_fd_limit,omitempty" mapstructure:"min_fd_limit,omitempty" json:"minFdLimit,omitempty"`
+	// Per-tuner overrides of the timeout the tuners are given, keyed by
+	// the tuner's name, e.g. 'disk_irq: 5m'.
+	TunerTimeouts	map[string]string	`yaml:"tuner_timeouts,omitempty" mapstructure:"tuner_timeouts,omitempty" json:"tunerTimeouts,omitempty"`
 }
 
 func (conf *Config) PIDFile() string {
 	return path.Join(conf.Redpanda.Directory, "pid.lock")
 }
+
+// Returns the file 'rpk start' writes redpanda's PID to, rpk.pid_file, which
+// defaults to redpanda.pid in the data directory. Unlike PIDFile, which
+// redpanda locks while it runs, it's written as soon as redpanda is started.
+func (conf *Config) LauncherPIDFile() string {
+	if conf.Rpk.PIDFile != "" {
+		return conf.Rpk.PIDFile
+	}
+	return path.Join(conf.Redpanda.Directory, "redpanda.pid")
+}
+
+// Returns the file redpanda is started with. If the config file includes
+// others, which redpanda doesn't support, it's the one Write merges them into,
+// next to it.
+func (conf *Config) RedpandaConfigFile() string {
+	if len(conf.Include) == 0 {
+		return conf.ConfigFile
+	}
+	return includesMergedPath(conf.ConfigFile)
+}
+
+// Returns the copy of the config file, with the 'rpk start --set' overrides,
+// which redpanda is started with unless they're saved. It's kept in the data
+// directory, so that it's specific to the node.
+func (conf *Config) OverridesConfigFile() string {
+	return path.Join(
+		conf.Redpanda.Directory,
+		".rpk_overrides",
+		path.Base(conf.ConfigFile),
+	)
+}
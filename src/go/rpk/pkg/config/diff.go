@@ -0,0 +1,125 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+type KeyDiffKind string
+
+const (
+	// The key is in the file, but Config doesn't have it (e.g. because
+	// of a typo), so rpk ignores it. Keys under 'redpanda' may still be
+	// read by redpanda itself.
+	KeyUnknown	KeyDiffKind	= "unknown"
+	// Config has a value for the key, but it isn't in the file, so it
+	// comes from the defaults or an included file.
+	KeyDefaulted	KeyDiffKind	= "defaulted"
+)
+
+type KeyDiff struct {
+	// The dotted path to the key, e.g. 'rpk.tune_network'.
+	Key	string		`json:"key"`
+	Kind	KeyDiffKind	`json:"kind"`
+	// The value in the file for unknown keys, and in conf for defaulted
+	// ones.
+	Value	string	`json:"value"`
+}
+
+// Compares the config file at path with conf, which should have been read
+// from it, returning the keys rpk ignored and the ones it took from
+// somewhere else, sorted by key. Lists are compared as single values.
+func Diff(fs afero.Fs, path string, conf *Config) ([]KeyDiff, error) {
+	bs, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	var file interface{}
+	err = yaml.Unmarshal(bs, &file)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse '%s': %v", path, err)
+	}
+	fileKeys := map[string]interface{}{}
+	diffs := []KeyDiff{}
+	flattenKnown(file, reflect.TypeOf(Config{}), "", fileKeys, &diffs)
+
+	bs, err = yaml.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	var typed interface{}
+	err = yaml.Unmarshal(bs, &typed)
+	if err != nil {
+		return nil, err
+	}
+	typedKeys := map[string]interface{}{}
+	flattenKnown(typed, reflect.TypeOf(Config{}), "", typedKeys, &diffs)
+	for key, value := range typedKeys {
+		// It's set to the path the config was read from.
+		if key == "config_file" {
+			continue
+		}
+		if _, ok := fileKeys[key]; !ok {
+			diffs = append(diffs, KeyDiff{
+				Key:	key,
+				Kind:	KeyDefaulted,
+				Value:	fmt.Sprintf("%v", value),
+			})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs, nil
+}
+
+// Flattens the YAML value into keys, following t's fields as long as they're
+// structs. The keys t doesn't have are appended to unknown.
+func flattenKnown(
+	value interface{},
+	t reflect.Type,
+	prefix string,
+	keys map[string]interface{},
+	unknown *[]KeyDiff,
+) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	m, ok := value.(map[interface{}]interface{})
+	if t.Kind() != reflect.Struct || !ok {
+		if prefix != "" {
+			keys[prefix] = value
+		}
+		return
+	}
+	for k, v := range m {
+		name := fmt.Sprintf("%v", k)
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		i, ok := fieldIndex(t, name)
+		if !ok {
+			*unknown = append(*unknown, KeyDiff{
+				Key:	key,
+				Kind:	KeyUnknown,
+				Value:	fmt.Sprintf("%v", v),
+			})
+			continue
+		}
+		flattenKnown(v, t.Field(i).Type, key, keys, unknown)
+	}
+}
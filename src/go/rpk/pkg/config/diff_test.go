@@ -0,0 +1,111 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name		string
+		file		string
+		expected	[]KeyDiff
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should report unknown and defaulted keys",
+			file: `config_file: /etc/redpanda/redpanda.yaml
+redpanda:
+  data_directory: /var/lib/redpanda/data
+  node_id: 1
+  rpc_server:
+    address: 0.0.0.0
+    port: 33145
+    tls: true
+  kafka_api:
+    address: 0.0.0.0
+    port: 9092
+  admin:
+    address: 0.0.0.0
+    port: 9644
+  seed_servers: []
+  developer_mode: true
+  auto_create_topics_enabled: true
+rpk:
+  tune_netwrok: true
+  coredump_dir: /var/lib/redpanda/coredump
+`,
+			expected: []KeyDiff{
+				{"redpanda.auto_create_topics_enabled", KeyUnknown, "true"},
+				{"redpanda.rpc_server.tls", KeyUnknown, "true"},
+				{"rpk.enable_memory_locking", KeyDefaulted, "false"},
+				{"rpk.enable_usage_stats", KeyDefaulted, "false"},
+				{"rpk.overprovisioned", KeyDefaulted, "false"},
+				{"rpk.tune_aio_events", KeyDefaulted, "false"},
+				{"rpk.tune_clocksource", KeyDefaulted, "false"},
+				{"rpk.tune_coredump", KeyDefaulted, "false"},
+				{"rpk.tune_cpu", KeyDefaulted, "false"},
+				{"rpk.tune_disk_irq", KeyDefaulted, "false"},
+				{"rpk.tune_disk_nomerges", KeyDefaulted, "false"},
+				{"rpk.tune_disk_scheduler", KeyDefaulted, "false"},
+				{"rpk.tune_disk_write_cache", KeyDefaulted, "false"},
+				{"rpk.tune_fstrim", KeyDefaulted, "false"},
+				{"rpk.tune_network", KeyDefaulted, "false"},
+				{"rpk.tune_netwrok", KeyUnknown, "true"},
+				{"rpk.tune_nvme_irq", KeyDefaulted, "false"},
+				{"rpk.tune_overcommit", KeyDefaulted, "false"},
+				{"rpk.tune_swappiness", KeyDefaulted, "false"},
+				{"rpk.tune_transparent_hugepages", KeyDefaulted, "false"},
+			},
+		},
+		{
+			name:	"it should report nothing if the file matches the config",
+			file: func() string {
+				fs := afero.NewMemMapFs()
+				mgr := NewManager(fs)
+				conf := Default()
+				require.NoError(t, mgr.Write(conf))
+				bs, err := afero.ReadFile(fs, conf.ConfigFile)
+				require.NoError(t, err)
+				return string(bs)
+			}(),
+			expected:	[]KeyDiff{},
+		},
+		{
+			name:		"it should fail if the file isn't valid YAML",
+			file:		"redpanda: [",
+			expectedErrMsg:	"couldn't parse '/etc/redpanda/redpanda.yaml': yaml: line 1: did not find expected node content",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			path := Default().ConfigFile
+			err := afero.WriteFile(fs, path, []byte(tt.file), 0644)
+			require.NoError(st, err)
+			conf := Default()
+			if tt.expectedErrMsg == "" {
+				conf, err = NewManager(fs).Read(path)
+				require.NoError(st, err)
+			}
+			diffs, err := Diff(fs, path, conf)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, diffs)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// Returns the value at key, a dotted path like the ones 'rpk config set'
+// takes (e.g. 'redpanda.data_directory'), with list elements selected by
+// their index (e.g. 'redpanda.seed_servers.0.host'). The keys conf doesn't
+// model are read from the file it was read from, so that they can be queried
+// too. Objects are returned as map[string]interface{}.
+func Get(fs afero.Fs, conf *Config, key string) (interface{}, error) {
+	bs, err := yaml.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	var typed interface{}
+	err = yaml.Unmarshal(bs, &typed)
+	if err != nil {
+		return nil, err
+	}
+	value := stringKeys(typed)
+	exists, _ := afero.Exists(fs, conf.ConfigFile)
+	if exists {
+		bs, err = afero.ReadFile(fs, conf.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		var file interface{}
+		err = yaml.Unmarshal(bs, &file)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"couldn't parse '%s': %v",
+				conf.ConfigFile,
+				err,
+			)
+		}
+		value = mergeMissing(value, stringKeys(file))
+	}
+
+	path := strings.Split(key, ".")
+	for i, name := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var ok bool
+			value, ok = v[name]
+			if ok {
+				continue
+			}
+		case []interface{}:
+			idx, err := strconv.Atoi(name)
+			if err == nil && idx >= 0 && idx < len(v) {
+				value = v[idx]
+				continue
+			}
+		}
+		return nil, fmt.Errorf(
+			"'%s' doesn't exist in the config",
+			strings.Join(path[:i+1], "."),
+		)
+	}
+	return value, nil
+}
+
+// Adds the keys in from which aren't in to, recursively.
+func mergeMissing(to, from interface{}) interface{} {
+	toMap, ok := to.(map[string]interface{})
+	if !ok {
+		return to
+	}
+	fromMap, ok := from.(map[string]interface{})
+	if !ok {
+		return to
+	}
+	for k, v := range fromMap {
+		if existing, ok := toMap[k]; ok {
+			toMap[k] = mergeMissing(existing, v)
+			continue
+		}
+		toMap[k] = v
+	}
+	return toMap
+}
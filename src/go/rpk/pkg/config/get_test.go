@@ -0,0 +1,89 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mgr := NewManager(fs)
+	conf := Default()
+	conf.Redpanda.SeedServers = []SeedServer{{
+		Host:	SocketAddress{Address: "192.168.0.1", Port: 33145},
+		Id:	1,
+	}}
+	require.NoError(t, mgr.Write(conf))
+	err := mgr.Set(
+		"redpanda.auto_create_topics_enabled",
+		"true",
+		"single",
+		conf.ConfigFile,
+	)
+	require.NoError(t, err)
+	conf, err = mgr.Read(conf.ConfigFile)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name		string
+		key		string
+		expected	interface{}
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should get a single value",
+			key:		"redpanda.data_directory",
+			expected:	"/var/lib/redpanda/data",
+		},
+		{
+			name:	"it should get an object",
+			key:	"redpanda.kafka_api",
+			expected: map[string]interface{}{
+				"address":	"0.0.0.0",
+				"port":		9092,
+			},
+		},
+		{
+			name:		"it should get list elements by their index",
+			key:		"redpanda.seed_servers.0.host.address",
+			expected:	"192.168.0.1",
+		},
+		{
+			name:		"it should get the keys Config doesn't have",
+			key:		"redpanda.auto_create_topics_enabled",
+			expected:	true,
+		},
+		{
+			name:		"it should fail if the key doesn't exist",
+			key:		"redpanda.kafka_api.tls.enabled",
+			expectedErrMsg:	"'redpanda.kafka_api.tls' doesn't exist in the config",
+		},
+		{
+			name:		"it should fail if the index is out of range",
+			key:		"redpanda.seed_servers.1",
+			expectedErrMsg:	"'redpanda.seed_servers.1' doesn't exist in the config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			value, err := Get(fs, conf, tt.key)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, value)
+		})
+	}
+}
@@ -10,14 +10,18 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"os"
 	fp "path/filepath"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
@@ -37,6 +41,10 @@ type Manager interface {
 	// Reads the config from path, sets key to the given value (parsing it
 	// according to the format), and writes the config back
 	Set(key, value, format, path string) error
+	// Reads the config from path, appends the value (parsing it according
+	// to the format) to the list at key unless it's already in it, and
+	// writes the config back
+	Append(key, value, format, path string) error
 	// If path is empty, tries to find the file in the default locations.
 	// Otherwise, it tries to read the file and load it. If the file doesn't
 	// exist, it tries to create it with the default configuration.
@@ -59,19 +67,45 @@ type Manager interface {
 	BindFlag(key string, flag *pflag.Flag) error
 }
 
+const (
+	// How many times reading a symlinked config file is retried if its
+	// target is missing, and how long to wait in between.
+	symlinkReadRetries		= 10
+	symlinkReadRetryInterval	= 50 * time.Millisecond
+
+	includeKey	= "include"
+	// The suffix of the list keys in config fragments whose elements are
+	// appended to the ones in the previous fragments, e.g. 'seed_servers+'.
+	appendSuffix	= "+"
+)
+
 type manager struct {
 	fs	afero.Fs
 	v	*viper.Viper
+	// If the config read includes other files, the settings set in the
+	// file itself and the ones merged from the included files, so that
+	// Write only persists the former.
+	own		map[string]interface{}
+	included	map[string]interface{}
 }
 
 func NewManager(fs afero.Fs) Manager {
-	return &manager{fs, InitViper(fs)}
+	return &manager{fs: fs, v: InitViper(fs)}
 }
 
 func (m *manager) FindOrGenerate(path string) (*Config, error) {
 	if path == "" {
 		addConfigPaths(m.v)
 		err := m.v.ReadInConfig()
+		found := m.v.ConfigFileUsed()
+		if os.IsNotExist(err) || configType(found) != "yaml" {
+			// The file was found, but was gone by the time it was
+			// read, e.g. because it's a symlink being swapped, or
+			// it isn't YAML, which is what viper read it as.
+			err = m.readInConfig(found)
+		} else if err == nil {
+			err = m.mergeIncludes(m.v.ConfigFileUsed())
+		}
 		if err != nil {
 			_, notFound := err.(viper.ConfigFileNotFoundError)
 			if !notFound {
@@ -92,8 +126,7 @@ func (m *manager) FindOrGenerate(path string) (*Config, error) {
 }
 
 func (m *manager) ReadOrGenerate(path string) (*Config, error) {
-	m.v.SetConfigFile(path)
-	err := m.v.ReadInConfig()
+	err := m.readInConfig(path)
 	if err == nil {
 		// The config file's there, there's nothing to do.
 		return unmarshal(m.v)
@@ -135,7 +168,7 @@ func (m *manager) ReadOrFind(path string) (*Config, error) {
 }
 
 func (m *manager) ReadFlat(path string) (map[string]string, error) {
-	m.v.SetConfigFile(path)
+	setConfigFile(m.v, path)
 	err := m.v.ReadInConfig()
 	if err != nil {
 		return nil, err
@@ -209,8 +242,7 @@ func (m *manager) Read(path string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	m.v.SetConfigFile(abs)
-	err = m.v.ReadInConfig()
+	err = m.readInConfig(abs)
 	if err != nil {
 		return nil, err
 	}
@@ -223,7 +255,7 @@ func (m *manager) Read(path string) (*Config, error) {
 }
 
 func (m *manager) readMap(path string) (map[string]interface{}, error) {
-	m.v.SetConfigFile(path)
+	setConfigFile(m.v, path)
 	err := m.v.ReadInConfig()
 	if err != nil {
 		return nil, err
@@ -231,6 +263,290 @@ func (m *manager) readMap(path string) (map[string]interface{}, error) {
 	return m.v.AllSettings(), nil
 }
 
+// Loads the config file at path into viper, reading it in one shot with
+// readFileAtomic. If path is a directory, the fragments in it are loaded
+// instead.
+func (m *manager) readInConfig(path string) error {
+	if isDir, _ := afero.IsDir(m.fs, path); isDir {
+		return m.readFragments(path)
+	}
+	setConfigFile(m.v, path)
+	bs, err := readFileAtomic(m.fs, path)
+	if err != nil {
+		return err
+	}
+	err = m.v.ReadConfig(bytes.NewReader(bs))
+	if err != nil {
+		return err
+	}
+	return m.mergeIncludes(path)
+}
+
+// Loads the *.yaml files in dir (e.g. /etc/redpanda/conf.d) into viper,
+// merged in lexical order, so that later files override the earlier ones.
+// Since redpanda can only be started with a single file, the config file is
+// set to <dir>.merged.yaml, next to dir, where the merged config is written.
+func (m *manager) readFragments(dir string) error {
+	abs, err := absPath(dir)
+	if err != nil {
+		return err
+	}
+	files, err := afero.ReadDir(m.fs, abs)
+	if err != nil {
+		return err
+	}
+	merged := map[string]interface{}{}
+	fragments := 0
+	for _, f := range files {
+		if f.IsDir() || fp.Ext(f.Name()) != ".yaml" {
+			continue
+		}
+		path := fp.Join(abs, f.Name())
+		settings, err := m.resolveIncludes(path, []string{path})
+		if err != nil {
+			return err
+		}
+		mergeFragment(merged, settings)
+		fragments++
+	}
+	if fragments == 0 {
+		return fmt.Errorf("no config fragments (*.yaml) found in '%s'", abs)
+	}
+	// The fragments' includes were resolved already.
+	delete(merged, includeKey)
+	// The merged config is written as a whole, to its own file.
+	m.own, m.included = nil, nil
+	setConfigFile(m.v, fragmentsMergedPath(abs))
+	// Clear what was read, keeping the defaults.
+	err = m.v.ReadConfig(bytes.NewReader(nil))
+	if err != nil {
+		return err
+	}
+	return m.v.MergeConfigMap(merged)
+}
+
+func fragmentsMergedPath(dir string) string {
+	return fp.Clean(dir) + ".merged.yaml"
+}
+
+// Returns the file the config file at path is written to merged with the files
+// it includes, e.g. /etc/redpanda/redpanda.merged.yaml for
+// /etc/redpanda/redpanda.yaml.
+func includesMergedPath(path string) string {
+	return strings.TrimSuffix(path, fp.Ext(path)) + ".merged.yaml"
+}
+
+// Merges the settings in src into dst, recursing into nested sections. Other
+// values in src replace the ones in dst, except for the lists under keys ending
+// in appendSuffix, which are appended to the ones under the key without it.
+func mergeFragment(dst, src map[string]interface{}) {
+	appends := []string{}
+	for k, v := range src {
+		if _, isList := v.([]interface{}); isList &&
+			strings.HasSuffix(k, appendSuffix) {
+			appends = append(appends, k)
+			continue
+		}
+		if srcSection, ok := v.(map[string]interface{}); ok {
+			dstSection, ok := dst[k].(map[string]interface{})
+			if !ok {
+				dstSection = map[string]interface{}{}
+				dst[k] = dstSection
+			}
+			mergeFragment(dstSection, srcSection)
+			continue
+		}
+		dst[k] = v
+	}
+	// Appended after the keys are set, so that a fragment setting both
+	// 'k' and 'k+' appends to its own 'k'.
+	sort.Strings(appends)
+	for _, k := range appends {
+		key := strings.TrimSuffix(k, appendSuffix)
+		current, _ := dst[key].([]interface{})
+		list := append([]interface{}{}, current...)
+		dst[key] = append(list, src[k].([]interface{})...)
+	}
+}
+
+// If the config loaded from path has an include directive, replaces it with
+// the result of merging the included files, recursively, and then the config
+// itself on top.
+func (m *manager) mergeIncludes(path string) error {
+	m.own, m.included = nil, nil
+	if len(m.v.GetStringSlice(includeKey)) == 0 {
+		return nil
+	}
+	abs, err := absPath(path)
+	if err != nil {
+		return err
+	}
+	own, included, err := m.readWithIncludes(abs, []string{abs})
+	if err != nil {
+		return err
+	}
+	// Clear what was read, keeping the defaults.
+	err = m.v.ReadConfig(bytes.NewReader(nil))
+	if err != nil {
+		return err
+	}
+	err = m.v.MergeConfigMap(included)
+	if err != nil {
+		return err
+	}
+	m.own, m.included = own, included
+	return m.v.MergeConfigMap(own)
+}
+
+// Returns the settings in the config file at path, merged on top of the ones
+// in the files it includes. stack holds the files being included, starting
+// with the top-level one, to detect cycles.
+func (m *manager) resolveIncludes(
+	path string, stack []string,
+) (map[string]interface{}, error) {
+	own, included, err := m.readWithIncludes(path, stack)
+	if err != nil {
+		return nil, err
+	}
+	merged := viper.New()
+	err = merged.MergeConfigMap(included)
+	if err != nil {
+		return nil, err
+	}
+	err = merged.MergeConfigMap(own)
+	if err != nil {
+		return nil, err
+	}
+	return merged.AllSettings(), nil
+}
+
+// Returns the settings in the config file at path, and the result of merging
+// the files it includes, recursively, in order.
+func (m *manager) readWithIncludes(
+	path string, stack []string,
+) (own, included map[string]interface{}, err error) {
+	bs, err := readFileAtomic(m.fs, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := viper.New()
+	v.SetConfigType(configType(path))
+	err = v.ReadConfig(bytes.NewReader(bs))
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't parse %s: %v", path, err)
+	}
+	merged := viper.New()
+	for _, include := range v.GetStringSlice(includeKey) {
+		if !fp.IsAbs(include) {
+			include = fp.Join(fp.Dir(path), include)
+		}
+		include = fp.Clean(include)
+		for _, p := range stack {
+			if p == include {
+				return nil, nil, fmt.Errorf(
+					"config include cycle: %s -> %s",
+					strings.Join(stack, " -> "),
+					include,
+				)
+			}
+		}
+		settings, err := m.resolveIncludes(
+			include,
+			append(stack[:len(stack):len(stack)], include),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = merged.MergeConfigMap(settings)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return v.AllSettings(), merged.AllSettings(), nil
+}
+
+// Returns the settings which belong in the including config file: the ones it
+// sets itself, and the ones which aren't in the included files or whose value
+// differs from theirs, e.g. because they were changed since being read.
+func withoutIncluded(
+	settings, own, included map[string]interface{},
+) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range settings {
+		ownV, isOwn := own[k]
+		inc, isIncluded := included[k]
+		section, isSection := v.(map[string]interface{})
+		incSection, incIsSection := inc.(map[string]interface{})
+		if isSection && incIsSection {
+			ownSection, _ := ownV.(map[string]interface{})
+			section = withoutIncluded(section, ownSection, incSection)
+			if len(section) > 0 || isOwn {
+				result[k] = section
+			}
+			continue
+		}
+		if isIncluded && !isOwn && sameValue(v, inc) {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// Returns whether a and b are the same value, regardless of the types of the
+// maps and numbers in them.
+func sameValue(a, b interface{}) bool {
+	aBs, aErr := yaml.Marshal(a)
+	bBs, bErr := yaml.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aBs, bBs)
+}
+
+// Reads the file at path, resolving it first if it's a symlink, as with
+// Kubernetes' projected volumes (e.g. ConfigMaps). Those are updated by
+// swapping symlinks, so if the target is missing (the swap window), reading it
+// is retried for a little while.
+func readFileAtomic(fs afero.Fs, path string) ([]byte, error) {
+	if !isSymlink(fs, path) {
+		return afero.ReadFile(fs, path)
+	}
+	var err error
+	for i := 0; i <= symlinkReadRetries; i++ {
+		if i > 0 {
+			log.Debugf(
+				"Couldn't read symlinked config file %s: %v."+
+					" Retrying",
+				path,
+				err,
+			)
+			time.Sleep(symlinkReadRetryInterval)
+		}
+		// isSymlink only reports the symlinks on filesystems which
+		// can lstat them, i.e. the OS', so they can be resolved.
+		var resolved string
+		resolved, err = fp.EvalSymlinks(path)
+		if err == nil {
+			var bs []byte
+			bs, err = afero.ReadFile(fs, resolved)
+			if err == nil {
+				return bs, nil
+			}
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func isSymlink(fs afero.Fs, path string) bool {
+	lstater, ok := fs.(afero.Lstater)
+	if !ok {
+		return false
+	}
+	fi, lstatCalled, err := lstater.LstatIfPossible(path)
+	return err == nil && lstatCalled && fi.Mode()&os.ModeSymlink != 0
+}
+
 func (m *manager) WriteNodeUUID(conf *Config) error {
 	id, err := uuid.NewUUID()
 	if err != nil {
@@ -251,7 +567,39 @@ func (m *manager) Write(conf *Config) error {
 	v := InitViper(m.fs)
 	v.MergeConfigMap(m.v.AllSettings())
 	v.MergeConfigMap(confMap)
-	return checkAndWrite(m.fs, v, conf.ConfigFile)
+	if m.included == nil {
+		return checkAndWrite(m.fs, v, conf.ConfigFile)
+	}
+	// The settings from the included files are left in them, so that
+	// later changes to them still apply. The whole config is checked,
+	// since the including file may rely on them.
+	err = checkConfig(v)
+	if err != nil {
+		return err
+	}
+	own := viper.New()
+	own.SetFs(m.fs)
+	err = own.MergeConfigMap(
+		withoutIncluded(v.AllSettings(), m.own, m.included),
+	)
+	if err != nil {
+		return err
+	}
+	err = backupAndWrite(m.fs, own, conf.ConfigFile)
+	if err != nil {
+		return err
+	}
+	// redpanda doesn't support includes, so it's started with the whole
+	// config, written to its own file.
+	settings := v.AllSettings()
+	delete(settings, includeKey)
+	merged := viper.New()
+	merged.SetFs(m.fs)
+	err = merged.MergeConfigMap(settings)
+	if err != nil {
+		return err
+	}
+	return write(merged, includesMergedPath(conf.ConfigFile))
 }
 
 func write(v *viper.Viper, path string) error {
@@ -304,7 +652,81 @@ func (m *manager) Set(key, value, format, path string) error {
 	return err
 }
 
+func (m *manager) Append(key, value, format, path string) error {
+	confMap, err := m.readMap(path)
+	if err != nil {
+		return err
+	}
+	m.v.MergeConfigMap(confMap)
+	list, err := appendElement(m.v.Get(key), key, value, format)
+	if err != nil {
+		return err
+	}
+	m.v.Set(key, list)
+	err = checkAndWrite(m.fs, m.v, path)
+	if err == nil {
+		checkAndPrintRestartWarning(key)
+	}
+	return err
+}
+
+// Decodes current into the type of the list field at key, appends the value
+// to it if it's not already there, and returns the result as a generic list,
+// which viper can write.
+func appendElement(current interface{}, key, value, format string) (interface{}, error) {
+	field, err := fieldByKey(reflect.ValueOf(&Config{}).Elem(), key)
+	if err != nil {
+		return nil, err
+	}
+	if field.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("'%s' isn't a list", key)
+	}
+	list := reflect.New(field.Type())
+	bs, err := yaml.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(bs, list.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the current '%s': %v", key, err)
+	}
+	elem := reflect.New(field.Type().Elem())
+	switch strings.ToLower(format) {
+	case "single":
+		err = setField(elem.Elem(), value)
+	case "yaml", "json":
+		// JSON is valid YAML, and the YAML tags are the keys in the file.
+		err = yaml.UnmarshalStrict([]byte(value), elem.Interface())
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for '%s': %v", key, err)
+	}
+	for i := 0; i < list.Elem().Len(); i++ {
+		if reflect.DeepEqual(list.Elem().Index(i).Interface(), elem.Elem().Interface()) {
+			log.Infof("'%s' already contains '%s'", key, value)
+			return current, nil
+		}
+	}
+	list.Elem().Set(reflect.Append(list.Elem(), elem.Elem()))
+	bs, err = yaml.Marshal(list.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	return generic, yaml.Unmarshal(bs, &generic)
+}
+
 func checkAndWrite(fs afero.Fs, v *viper.Viper, path string) error {
+	err := checkConfig(v)
+	if err != nil {
+		return err
+	}
+	return backupAndWrite(fs, v, path)
+}
+
+func checkConfig(v *viper.Viper) error {
 	ok, errs := check(v)
 	if !ok {
 		reasons := []string{}
@@ -313,6 +735,12 @@ func checkAndWrite(fs afero.Fs, v *viper.Viper, path string) error {
 		}
 		return errors.New(strings.Join(reasons, ", "))
 	}
+	return nil
+}
+
+// Writes the config in v to path, backing up the current file first, if
+// there's one, and restoring it if writing fails.
+func backupAndWrite(fs afero.Fs, v *viper.Viper, path string) error {
 	lastBackupFile, err := findBackup(fs, fp.Dir(path))
 	if err != nil {
 		return err
@@ -0,0 +1,199 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Returns a copy of conf with the given overrides set. Each override has the
+// form <key>=<value>, where key is a dotted path like the ones
+// 'rpk config set' takes (e.g. 'rpk.tune_network=false'), and the value is
+// parsed according to the type of the field it's set on. conf isn't modified.
+func ApplyOverrides(conf *Config, overrides []string) (*Config, error) {
+	overridden, err := copyConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		key, value, err := ParseOverride(o)
+		if err != nil {
+			return nil, err
+		}
+		field, err := fieldByKey(reflect.ValueOf(overridden).Elem(), key)
+		if err != nil {
+			return nil, err
+		}
+		err = setField(field, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for '%s': %v", key, err)
+		}
+	}
+	return overridden, nil
+}
+
+// Returns a copy of conf where the keys set by the overrides have their
+// values in orig, i.e. the config before ApplyOverrides, so that they aren't
+// persisted.
+func RevertOverrides(conf, orig *Config, overrides []string) (*Config, error) {
+	reverted, err := copyConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		key, _, err := ParseOverride(o)
+		if err != nil {
+			return nil, err
+		}
+		err = copyField(
+			reflect.ValueOf(reverted).Elem(),
+			reflect.ValueOf(orig).Elem(),
+			key,
+			strings.Split(key, "."),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return reverted, nil
+}
+
+// Splits an override in the form <key>=<value>.
+func ParseOverride(override string) (key, value string, err error) {
+	parts := strings.SplitN(override, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", fmt.Errorf(
+			"invalid override '%s', expected <key>=<value>",
+			override,
+		)
+	}
+	return parts[0], parts[1], nil
+}
+
+func copyConfig(conf *Config) (*Config, error) {
+	bs, err := yaml.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	return c, yaml.Unmarshal(bs, c)
+}
+
+// Returns the field the dotted key refers to, allocating the structs it goes
+// through if they're nil pointers.
+func fieldByKey(v reflect.Value, key string) (reflect.Value, error) {
+	for _, name := range strings.Split(key, ".") {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown config key '%s'", key)
+		}
+		i, ok := fieldIndex(v.Type(), name)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unknown config key '%s'", key)
+		}
+		v = v.Field(i)
+	}
+	return v, nil
+}
+
+// Sets dst's field at the given path to the value of src's, setting the
+// pointers along it to nil if they're nil in src.
+func copyField(dst, src reflect.Value, key string, path []string) error {
+	if src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		src, dst = src.Elem(), dst.Elem()
+	}
+	if len(path) == 0 {
+		dst.Set(src)
+		return nil
+	}
+	i, ok := fieldIndex(src.Type(), path[0])
+	if src.Kind() != reflect.Struct || !ok {
+		return fmt.Errorf("unknown config key '%s'", key)
+	}
+	return copyField(dst.Field(i), src.Field(i), key, path[1:])
+}
+
+// Returns the index of the struct field with the given YAML name.
+func fieldIndex(t reflect.Type, name string) (int, bool) {
+	if t.Kind() != reflect.Struct {
+		return 0, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func setField(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		err := setField(p.Elem(), value)
+		if err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("'%s' isn't a bool", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("'%s' isn't an int", value)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("'%s' isn't an unsigned int", value)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("'%s' isn't a number", value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("only single values and lists of strings can be set")
+		}
+		// e.g. 'rpk.logger_log_levels=raft=debug,rpc=trace'
+		v.Set(reflect.ValueOf(strings.Split(value, ",")))
+	default:
+		return fmt.Errorf("only single values and lists of strings can be set")
+	}
+	return nil
+}
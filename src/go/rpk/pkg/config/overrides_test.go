@@ -0,0 +1,114 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	smp := 2
+	tests := []struct {
+		name		string
+		overrides	[]string
+		expected	func(*Config)
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should set values of each type",
+			overrides: []string{
+				"redpanda.node_id=3",
+				"redpanda.data_directory=/mnt/data",
+				"rpk.tune_network=true",
+				"rpk.smp=2",
+				"rpk.logger_log_levels=raft=debug,rpc=trace",
+			},
+			expected: func(c *Config) {
+				c.Redpanda.Id = 3
+				c.Redpanda.Directory = "/mnt/data"
+				c.Rpk.TuneNetwork = true
+				c.Rpk.SMP = &smp
+				c.Rpk.LoggerLogLevels = []string{"raft=debug", "rpc=trace"}
+			},
+		},
+		{
+			name:		"it should allocate the structs along the key",
+			overrides:	[]string{"redpanda.advertised_kafka_api.port=9093"},
+			expected: func(c *Config) {
+				c.Redpanda.AdvertisedKafkaApi = &SocketAddress{Port: 9093}
+			},
+		},
+		{
+			name:		"it should fail if the key isn't in the config",
+			overrides:	[]string{"rpk.tune_everything=true"},
+			expectedErrMsg:	"unknown config key 'rpk.tune_everything'",
+		},
+		{
+			name:		"it should fail if the key goes past a value",
+			overrides:	[]string{"redpanda.node_id.value=1"},
+			expectedErrMsg:	"unknown config key 'redpanda.node_id.value'",
+		},
+		{
+			name:		"it should fail if the value doesn't match the key's type",
+			overrides:	[]string{"redpanda.node_id=one"},
+			expectedErrMsg:	"invalid value for 'redpanda.node_id': 'one' isn't an int",
+		},
+		{
+			name:		"it should fail if the key is a section",
+			overrides:	[]string{"redpanda.kafka_api=0.0.0.0:9092"},
+			expectedErrMsg:	"invalid value for 'redpanda.kafka_api': only single values and lists of strings can be set",
+		},
+		{
+			name:		"it should fail if the override has no value",
+			overrides:	[]string{"redpanda.node_id"},
+			expectedErrMsg:	"invalid override 'redpanda.node_id', expected <key>=<value>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			conf := Default()
+			overridden, err := ApplyOverrides(conf, tt.overrides)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, Default(), conf)
+			// The copy's seed servers are empty rather than nil.
+			expected, err := copyConfig(Default())
+			require.NoError(st, err)
+			tt.expected(expected)
+			require.Equal(st, expected, overridden)
+		})
+	}
+}
+
+func TestRevertOverrides(t *testing.T) {
+	orig := Default()
+	overrides := []string{
+		"redpanda.node_id=3",
+		"redpanda.advertised_kafka_api.port=9093",
+		"rpk.smp=2",
+	}
+	overridden, err := ApplyOverrides(orig, overrides)
+	require.NoError(t, err)
+	// Changed after the overrides were applied, so it should be kept.
+	overridden.Rpk.TuneCpu = true
+
+	reverted, err := RevertOverrides(overridden, orig, overrides)
+	require.NoError(t, err)
+	expected, err := copyConfig(Default())
+	require.NoError(t, err)
+	expected.Rpk.TuneCpu = true
+	require.Equal(t, expected, reverted)
+	require.Equal(t, 3, overridden.Redpanda.Id)
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// The env var which overrides the path to the profiles file.
+const ProfilesFileEnv = "RPK_PROFILES_FILE"
+
+// A named set of the settings rpk commands would otherwise take as flags. The
+// active profile's values are used for the flags which aren't passed.
+type Profile struct {
+	Name		string		`yaml:"name"`
+	ConfigFile	string		`yaml:"config,omitempty"`
+	InstallDir	string		`yaml:"install_dir,omitempty"`
+	Brokers		[]string	`yaml:"brokers,omitempty"`
+}
+
+type Profiles struct {
+	// The name of the active profile, if any.
+	Current		string		`yaml:"current,omitempty"`
+	Profiles	[]Profile	`yaml:"profiles,omitempty"`
+}
+
+// Returns the path to the profiles file: $RPK_PROFILES_FILE if it's set, or
+// rpk/profiles.yaml in the user's config dir (e.g. ~/.config) otherwise.
+func ProfilesFile() (string, error) {
+	if path := os.Getenv(ProfilesFileEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf(
+			"couldn't find the profiles file: %v. Set %s to its path",
+			err,
+			ProfilesFileEnv,
+		)
+	}
+	return filepath.Join(dir, "rpk", "profiles.yaml"), nil
+}
+
+// Reads the profiles at path. If the file doesn't exist, there are none.
+func ReadProfiles(fs afero.Fs, path string) (*Profiles, error) {
+	profiles := &Profiles{}
+	exists, err := afero.Exists(fs, path)
+	if err != nil || !exists {
+		return profiles, err
+	}
+	bs, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(bs, profiles)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse '%s': %v", path, err)
+	}
+	return profiles, nil
+}
+
+func WriteProfiles(fs afero.Fs, path string, profiles *Profiles) error {
+	bs, err := yaml.Marshal(profiles)
+	if err != nil {
+		return err
+	}
+	err = fs.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, path, bs, 0644)
+}
+
+// Returns the profile with the given name, or nil if there's none.
+func (p *Profiles) Get(name string) *Profile {
+	for i := range p.Profiles {
+		if p.Profiles[i].Name == name {
+			return &p.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// Returns the active profile, or nil if there's none.
+func (p *Profiles) Active() *Profile {
+	if p.Current == "" {
+		return nil
+	}
+	return p.Get(p.Current)
+}
+
+// Adds the profile, keeping them sorted by name.
+func (p *Profiles) Add(profile Profile) error {
+	if profile.Name == "" {
+		return errors.New("the profile's name can't be empty")
+	}
+	if p.Get(profile.Name) != nil {
+		return fmt.Errorf("profile '%s' already exists", profile.Name)
+	}
+	p.Profiles = append(p.Profiles, profile)
+	sort.Slice(p.Profiles, func(i, j int) bool {
+		return p.Profiles[i].Name < p.Profiles[j].Name
+	})
+	return nil
+}
+
+// Deletes the profile with the given name, deactivating it if it's active.
+func (p *Profiles) Delete(name string) error {
+	for i := range p.Profiles {
+		if p.Profiles[i].Name == name {
+			p.Profiles = append(p.Profiles[:i], p.Profiles[i+1:]...)
+			if p.Current == name {
+				p.Current = ""
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("profile '%s' doesn't exist", name)
+}
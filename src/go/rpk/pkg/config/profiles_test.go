@@ -0,0 +1,45 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/home/user/.config/rpk/profiles.yaml"
+
+	profiles, err := ReadProfiles(fs, path)
+	require.NoError(t, err)
+	require.Nil(t, profiles.Active())
+
+	require.NoError(t, profiles.Add(Profile{Name: "prod", InstallDir: "/opt/redpanda"}))
+	require.NoError(t, profiles.Add(Profile{Name: "dev", Brokers: []string{"localhost:9092"}}))
+	require.EqualError(t, profiles.Add(Profile{Name: "dev"}), "profile 'dev' already exists")
+	require.EqualError(t, profiles.Add(Profile{}), "the profile's name can't be empty")
+	profiles.Current = "prod"
+	require.NoError(t, WriteProfiles(fs, path, profiles))
+
+	read, err := ReadProfiles(fs, path)
+	require.NoError(t, err)
+	require.Equal(t, profiles, read)
+	// They should be sorted by name.
+	require.Equal(t, "dev", read.Profiles[0].Name)
+	require.Equal(t, "/opt/redpanda", read.Active().InstallDir)
+
+	require.NoError(t, read.Delete("prod"))
+	require.Nil(t, read.Active())
+	require.Equal(t, "", read.Current)
+	require.EqualError(t, read.Delete("prod"), "profile 'prod' doesn't exist")
+}
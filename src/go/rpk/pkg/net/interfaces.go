@@ -45,6 +45,42 @@ func GetInterfacesByIps(addresses ...string) ([]string, error) {
 	return utils.GetKeys(nics), nil
 }
 
+// Returns the name of the interface which has the given address, which may be
+// a hostname, or an empty string if there's none.
+func GetInterfaceByAddress(address string) (string, error) {
+	ips := []net.IP{net.ParseIP(address)}
+	if ips[0] == nil {
+		var err error
+		ips, err = net.LookupIP(address)
+		if err != nil {
+			return "", err
+		}
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return "", err
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			for _, ip := range ips {
+				if ipNet.IP.Equal(ip) {
+					log.Debugf("'%s' has address '%s'", iface.Name, ip)
+					return iface.Name, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
 func GetFreePort() (uint, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
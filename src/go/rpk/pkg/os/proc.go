@@ -16,6 +16,7 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,6 +30,12 @@ type Proc interface {
 	IsRunning(timeout time.Duration, processName string) bool
 }
 
+// Implemented by the Procs which can run commands with extra env vars, which
+// are set only for the commands and not for rpk itself.
+type EnvProc interface {
+	RunWithSystemLdPathAndEnv(timeout time.Duration, env []string, command string, args ...string) ([]string, error)
+}
+
 func NewProc() Proc {
 	return &proc{}
 }
@@ -38,7 +45,15 @@ type proc struct{}
 func (proc *proc) RunWithSystemLdPath(
 	timeout time.Duration, command string, args ...string,
 ) ([]string, error) {
-	return runWithSystemLdPath(timeout, command, args...)
+	return runWithSystemLdPath(timeout, nil, command, args...)
+}
+
+// Like RunWithSystemLdPath, but also sets the given env vars, which look like
+// 'KEY=value', for the command.
+func (proc *proc) RunWithSystemLdPathAndEnv(
+	timeout time.Duration, env []string, command string, args ...string,
+) ([]string, error) {
+	return runWithSystemLdPath(timeout, env, command, args...)
 }
 
 func (proc *proc) IsRunning(timeout time.Duration, processName string) bool {
@@ -78,8 +93,49 @@ func IsRunningPID(fs afero.Fs, pid int) (bool, error) {
 	return true, nil
 }
 
+// Returns the PIDs of the running processes with the given name, as found in
+// /proc/<pid>/comm.
+func FindPIDs(fs afero.Fs, name string) ([]int, error) {
+	entries, err := afero.ReadDir(fs, "/proc")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	pids := []int{}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		comm, err := utils.ReadEnsureSingleLine(
+			fs,
+			fmt.Sprintf("/proc/%d/comm", pid),
+		)
+		// The process might have exited since /proc was listed.
+		if err != nil || comm != name {
+			continue
+		}
+		if running, err := IsRunningPID(fs, pid); err == nil && running {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// Returns the command line of the process with the given PID, as found in
+// /proc/<pid>/cmdline.
+func ReadCmdline(fs afero.Fs, pid int) ([]string, error) {
+	bs, err := afero.ReadFile(fs, fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(string(bs), "\x00"), "\x00"), nil
+}
+
 func runWithSystemLdPath(
-	timeout time.Duration, command string, args ...string,
+	timeout time.Duration, extraEnv []string, command string, args ...string,
 ) ([]string, error) {
 	var env []string
 	ldLibraryPathPattern := regexp.MustCompile("^LD_LIBRARY_PATH=.*$")
@@ -88,6 +144,8 @@ func runWithSystemLdPath(
 			env = append(env, v)
 		}
 	}
+	// The later values of duplicate keys take precedence.
+	env = append(env, extraEnv...)
 	return run(timeout, command, env, args...)
 }
 
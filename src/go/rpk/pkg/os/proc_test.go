@@ -11,11 +11,13 @@ package os_test
 
 import (
 	"fmt"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
-	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
 )
 
@@ -79,7 +81,7 @@ func TestIsRunningPID(t *testing.T) {
 				err := tt.before(fs)
 				require.NoError(t, err)
 			}
-			running, err := os.IsRunningPID(fs, pid)
+			running, err := vos.IsRunningPID(fs, pid)
 			if tt.expectedErr != "" {
 				require.EqualError(t, err, tt.expectedErr)
 				return
@@ -89,3 +91,42 @@ func TestIsRunningPID(t *testing.T) {
 		})
 	}
 }
+
+func TestFindPIDs(t *testing.T) {
+	process := func(fs afero.Fs, pid int, comm, state string) {
+		stat := fmt.Sprintf("%d (%s) %s 1 1 1 0", pid, comm, state)
+		_, err := utils.WriteBytes(fs, []byte(stat), fmt.Sprintf("/proc/%d/stat", pid))
+		require.NoError(t, err)
+		_, err = utils.WriteBytes(fs, []byte(comm+"\n"), fmt.Sprintf("/proc/%d/comm", pid))
+		require.NoError(t, err)
+	}
+	fs := afero.NewMemMapFs()
+	process(fs, 1, "systemd", "S")
+	process(fs, 42, "redpanda", "S")
+	process(fs, 43, "redpanda", "Z")
+	process(fs, 100, "redpanda", "R")
+	process(fs, 101, "rpk", "R")
+	_, err := utils.WriteBytes(fs, []byte("1\n"), "/proc/sys/kernel/core_uses_pid")
+	require.NoError(t, err)
+
+	pids, err := vos.FindPIDs(fs, "redpanda")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int{42, 100}, pids)
+
+	pids, err = vos.FindPIDs(afero.NewMemMapFs(), "redpanda")
+	require.NoError(t, err)
+	require.Empty(t, pids)
+}
+
+func TestRunWithSystemLdPathAndEnv(t *testing.T) {
+	proc := vos.NewProc().(vos.EnvProc)
+	out, err := proc.RunWithSystemLdPathAndEnv(
+		time.Second,
+		[]string{"RPK_TEST_ENV=set"},
+		"sh", "-c", "echo $RPK_TEST_ENV",
+	)
+	require.NoError(t, err)
+	require.Equal(t, "set", out[0])
+	// The env var is only set for the command.
+	require.Empty(t, os.Getenv("RPK_TEST_ENV"))
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+)
+
+// Where redpanda is installed by the packages, besides the dirs in $PATH.
+var knownInstallDirs = []string{"/opt/redpanda", "/usr/local", "/usr"}
+
+type Binary struct {
+	Path	string	`json:"path"`
+	Version	string	`json:"version"`
+	// Whether it's the one 'rpk redpanda start' runs.
+	Used	bool	`json:"used"`
+}
+
+// Returns the redpanda binaries found in installDir, the known install
+// locations and the dirs in pathEnv (formatted like $PATH), in that order.
+// Binaries which are links to one already found are skipped.
+func FindBinaries(
+	fs afero.Fs,
+	proc vos.Proc,
+	timeout time.Duration,
+	installDir, pathEnv string,
+) []Binary {
+	var dirs []string
+	if installDir != "" {
+		dirs = append(dirs, filepath.Join(installDir, "bin"))
+	}
+	for _, dir := range knownInstallDirs {
+		dirs = append(dirs, filepath.Join(dir, "bin"))
+	}
+	dirs = append(dirs, filepath.SplitList(pathEnv)...)
+
+	seen := map[string]bool{}
+	var binaries []Binary
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, "redpanda")
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			resolved = path
+		}
+		if seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+		info, err := fs.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		binaries = append(binaries, Binary{
+			Path:		path,
+			Version:	binaryVersion(proc, timeout, path),
+			Used:		installDir != "" && filepath.Dir(path) == filepath.Join(installDir, "bin"),
+		})
+	}
+	return binaries
+}
+
+// Returns a warning listing the binaries if there's more than one, or "".
+func MultipleBinariesWarning(binaries []Binary) string {
+	if len(binaries) < 2 {
+		return ""
+	}
+	lines := []string{"Found more than one redpanda binary:"}
+	used := ""
+	for _, b := range binaries {
+		lines = append(lines, fmt.Sprintf("  %s (%s)", b.Path, b.Version))
+		if b.Used {
+			used = b.Path
+		}
+	}
+	if used != "" {
+		lines = append(lines, fmt.Sprintf("'%s' will be used.", used))
+	} else {
+		lines = append(
+			lines,
+			"None of them is in the install directory, so"+
+				" 'rpk redpanda start' won't use them.",
+		)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func binaryVersion(proc vos.Proc, timeout time.Duration, path string) string {
+	lines, err := proc.RunWithSystemLdPath(timeout, path, "--version")
+	if err != nil || len(lines) == 0 {
+		log.Debugf("Couldn't get the version of '%s': %v", path, err)
+		return "unknown version"
+	}
+	return strings.TrimSpace(lines[0])
+}
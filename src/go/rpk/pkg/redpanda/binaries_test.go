@@ -0,0 +1,112 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+type versionProcMock struct {
+	versions map[string]string
+}
+
+func (m *versionProcMock) RunWithSystemLdPath(
+	_ time.Duration, command string, _ ...string,
+) ([]string, error) {
+	v, ok := m.versions[command]
+	if !ok {
+		return nil, errors.New("exec format error")
+	}
+	return []string{v}, nil
+}
+
+func (*versionProcMock) IsRunning(time.Duration, string) bool {
+	return false
+}
+
+func TestFindBinaries(t *testing.T) {
+	tests := []struct {
+		name		string
+		installDir	string
+		files		[]string
+		expected	[]Binary
+		expectedWarning	string
+	}{
+		{
+			name:		"it shouldn't warn if there's a single binary",
+			installDir:	"/opt/redpanda",
+			files:		[]string{"/opt/redpanda/bin/redpanda"},
+			expected: []Binary{
+				{"/opt/redpanda/bin/redpanda", "v21.4.2 (rev a1b2c3)", true},
+			},
+		},
+		{
+			name:		"it should list every binary and the one which will be used",
+			installDir:	"/opt/redpanda",
+			files: []string{
+				"/opt/redpanda/bin/redpanda",
+				"/home/user/bin/redpanda",
+				"/usr/local/bin/redpanda",
+			},
+			expected: []Binary{
+				{"/opt/redpanda/bin/redpanda", "v21.4.2 (rev a1b2c3)", true},
+				{"/usr/local/bin/redpanda", "unknown version", false},
+				{"/home/user/bin/redpanda", "v21.3.1 (rev d4e5f6)", false},
+			},
+			expectedWarning: `Found more than one redpanda binary:
+  /opt/redpanda/bin/redpanda (v21.4.2 (rev a1b2c3))
+  /usr/local/bin/redpanda (unknown version)
+  /home/user/bin/redpanda (v21.3.1 (rev d4e5f6))
+'/opt/redpanda/bin/redpanda' will be used.`,
+		},
+		{
+			name:	"it should say if none of them will be used",
+			files: []string{
+				"/usr/local/bin/redpanda",
+				"/home/user/bin/redpanda",
+			},
+			expected: []Binary{
+				{"/usr/local/bin/redpanda", "unknown version", false},
+				{"/home/user/bin/redpanda", "v21.3.1 (rev d4e5f6)", false},
+			},
+			expectedWarning: `Found more than one redpanda binary:
+  /usr/local/bin/redpanda (unknown version)
+  /home/user/bin/redpanda (v21.3.1 (rev d4e5f6))
+None of them is in the install directory, so 'rpk redpanda start' won't use them.`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			for _, f := range tt.files {
+				err := afero.WriteFile(fs, f, []byte{}, 0755)
+				require.NoError(st, err)
+			}
+			proc := &versionProcMock{map[string]string{
+				"/opt/redpanda/bin/redpanda":	"v21.4.2 (rev a1b2c3)",
+				"/home/user/bin/redpanda":	"v21.3.1 (rev d4e5f6)",
+			}}
+			binaries := FindBinaries(
+				fs,
+				proc,
+				time.Second,
+				tt.installDir,
+				"/home/user/bin:/opt/redpanda/bin:/usr/local/bin",
+			)
+			require.Equal(st, tt.expected, binaries)
+			require.Equal(st, tt.expectedWarning, MultipleBinariesWarning(binaries))
+		})
+	}
+}
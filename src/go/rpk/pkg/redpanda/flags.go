@@ -0,0 +1,56 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Separates a flag's name from its value within a single element, e.g.
+// '--memory=4G' or '--memory 4G'.
+var flagValueSeparator = regexp.MustCompile(`\s*=\s*|\s+`)
+
+// Parses a list of flags, such as rpk.additional_start_flags, into their names
+// and values. A flag's value may follow its name in the same element (e.g.
+// '--memory=4G' or '--memory 4G') or in the next one. Flags without a value
+// (e.g. '--overprovisioned') are set to "true", and the elements which aren't
+// flags are skipped. If a flag is repeated, the last value wins.
+func ParseFlags(flags []string) map[string]string {
+	parsed := map[string]string{}
+	for i := 0; i < len(flags); i++ {
+		f := strings.TrimSpace(flags[i])
+		trimmed := strings.TrimLeft(f, "-")
+
+		// Filter out elements that aren't flags or are empty.
+		if !strings.HasPrefix(f, "-") || trimmed == "" {
+			continue
+		}
+
+		// Check if the value is in the same element.
+		parts := flagValueSeparator.Split(trimmed, 2)
+		if len(parts) == 2 {
+			parsed[parts[0]] = parts[1]
+			continue
+		}
+
+		// Otherwise, it's a boolean flag (i.e. -v) if it's the last
+		// element or the next one is another flag, or the next element
+		// is its value.
+		if i == len(flags)-1 ||
+			strings.HasPrefix(strings.TrimSpace(flags[i+1]), "-") {
+			parsed[trimmed] = "true"
+			continue
+		}
+		parsed[trimmed] = strings.TrimSpace(flags[i+1])
+		i += 1
+	}
+	return parsed
+}
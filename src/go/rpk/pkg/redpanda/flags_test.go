@@ -0,0 +1,61 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name		string
+		flags		[]string
+		expected	map[string]string
+	}{
+		{
+			name: "it should parse each format",
+			flags: []string{
+				"--memory=4G",
+				"--smp 2",
+				"--cpuset", "0-1",
+				"--overprovisioned",
+				"--logger-log-level=exception=debug",
+				"--default-log-level = trace",
+				"--unsafe-bypass-fsync",
+			},
+			expected: map[string]string{
+				"memory":		"4G",
+				"smp":			"2",
+				"cpuset":		"0-1",
+				"overprovisioned":	"true",
+				"logger-log-level":	"exception=debug",
+				"default-log-level":	"trace",
+				"unsafe-bypass-fsync":	"true",
+			},
+		},
+		{
+			name:		"it should skip the elements which aren't flags",
+			flags:		[]string{"smp", "--", " ", "--memory=1G"},
+			expected:	map[string]string{"memory": "1G"},
+		},
+		{
+			name:		"it should keep the last value of repeated flags",
+			flags:		[]string{"--smp=1", "--smp", "2"},
+			expected:	map[string]string{"smp": "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			require.Equal(st, tt.expected, ParseFlags(tt.flags))
+		})
+	}
+}
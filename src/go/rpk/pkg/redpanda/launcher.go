@@ -10,20 +10,44 @@
 package redpanda
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
 	"golang.org/x/sys/unix"
 )
 
 type Launcher interface {
 	Start(installDir string, args *RedpandaArgs) error
+	// Returns the path to the redpanda binary in installDir, and its
+	// version.
+	Version(installDir string, timeout time.Duration) (string, Version, error)
+}
+
+// Implemented by the launchers which can start redpanda as a child process,
+// instead of replacing rpk's, so that rpk can keep running (e.g. to wait for
+// redpanda to be ready).
+type BackgroundLauncher interface {
+	// Starts redpanda without waiting for it to exit, returning its PID and
+	// a channel which receives its exit error when it exits. It isn't
+	// started if ctx is already done. Since it outlives rpk, its output goes
+	// to args.LogFile rather than rpk's.
+	StartInBackground(
+		ctx context.Context, installDir string, args *RedpandaArgs,
+	) (int, <-chan error, error)
+	// Kills the redpanda process started by StartInBackground.
+	Kill(pid int) error
 }
 
 type launcher struct{}
@@ -32,6 +56,11 @@ type RedpandaArgs struct {
 	ConfigFilePath	string
 	SeastarFlags	map[string]string
 	ExtraArgs	[]string
+	// If set, the launcher writes redpanda's PID to it once it's started.
+	PIDFile	string
+	// The file redpanda's output is appended to when it's started in the
+	// background. It's discarded if unset.
+	LogFile	string
 }
 
 func NewLauncher() Launcher {
@@ -39,18 +68,139 @@ func NewLauncher() Launcher {
 }
 
 func (l *launcher) Start(installDir string, args *RedpandaArgs) error {
-	binary, err := getBinary(installDir)
+	binary, redpandaArgs, rpEnv, err := prepare(installDir, args)
 	if err != nil {
 		return err
 	}
+	// rpk's process becomes redpanda's, so its PID is redpanda's.
+	err = writePIDFile(args.PIDFile, os.Getpid())
+	if err != nil {
+		return err
+	}
+	err = unix.Exec(binary, redpandaArgs, rpEnv)
+	removePIDFile(args.PIDFile)
+	return err
+}
+
+func (l *launcher) Version(
+	installDir string, timeout time.Duration,
+) (string, Version, error) {
+	binary, err := getBinary(installDir)
+	if err != nil {
+		return "", Version{}, err
+	}
+	version, err := BinaryVersion(vos.NewProc(), timeout, binary)
+	return binary, version, err
+}
+
+func (l *launcher) StartInBackground(
+	ctx context.Context, installDir string, args *RedpandaArgs,
+) (int, <-chan error, error) {
+	binary, redpandaArgs, rpEnv, err := prepare(installDir, args)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err = ctx.Err(); err != nil {
+		return 0, nil, fmt.Errorf("redpanda wasn't started: %v", err)
+	}
+	// If redpanda kept rpk's stdout and stderr, whoever reads them (e.g.
+	// 'out=$(rpk redpanda start --wait-for-ready)') would wait for
+	// redpanda to exit too, and it'd get SIGPIPE if they stopped reading.
+	out, err := openLogFile(args.LogFile)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer out.Close()
+	cmd := exec.Command(binary, redpandaArgs[1:]...)
+	cmd.Env = rpEnv
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// In its own session, so that it keeps running after rpk exits.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	err = cmd.Start()
+	if err != nil {
+		return 0, nil, err
+	}
+	if args.LogFile != "" {
+		log.Infof("redpanda's output goes to '%s'", args.LogFile)
+	}
+	pid := cmd.Process.Pid
+	err = writePIDFile(args.PIDFile, pid)
+	if err != nil {
+		log.Warn(err)
+	}
+	// Reaped as soon as it exits, so that there are no zombies left behind
+	// while rpk is still running.
+	exited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		removePIDFile(args.PIDFile)
+		exited <- err
+	}()
+	return pid, exited, nil
+}
+
+func (l *launcher) Kill(pid int) error {
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
+
+// Opens the file redpanda's output is appended to, or /dev/null if path is
+// empty.
+func openLogFile(path string) (*os.File, error) {
+	if path == "" {
+		return os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create the log file '%s': %v", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open the log file '%s': %v", path, err)
+	}
+	return f, nil
+}
+
+func writePIDFile(path string, pid int) error {
+	if path == "" {
+		return nil
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(path, []byte(strconv.Itoa(pid)+"\n"), 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't write the PID file '%s': %v", path, err)
+	}
+	log.Debugf("Wrote redpanda's PID (%d) to '%s'", pid, path)
+	return nil
+}
+
+func removePIDFile(path string) {
+	if path == "" {
+		return
+	}
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		log.Warnf("Couldn't remove the PID file '%s': %v", path, err)
+	}
+}
+
+// Returns the binary, args and environment to start redpanda with.
+func prepare(
+	installDir string, args *RedpandaArgs,
+) (binary string, redpandaArgs []string, rpEnv []string, err error) {
+	binary, err = getBinary(installDir)
+	if err != nil {
+		return "", nil, nil, err
+	}
 
 	if args.ConfigFilePath == "" {
-		return errors.New("Redpanda config file is required")
+		return "", nil, nil, errors.New("Redpanda config file is required")
 	}
-	redpandaArgs := collectRedpandaArgs(args)
+	redpandaArgs = collectRedpandaArgs(args)
 	log.Debugf("Starting '%s' with arguments '%v'", binary, redpandaArgs)
 
-	var rpEnv []string
 	ldLibraryPathPattern := regexp.MustCompile("^LD_LIBRARY_PATH=.*$")
 	for _, ev := range os.Environ() {
 		if !ldLibraryPathPattern.MatchString(ev) {
@@ -58,11 +208,11 @@ func (l *launcher) Start(installDir string, args *RedpandaArgs) error {
 		}
 	}
 	log.Infof("Running:\n%s %s %s", strings.Join(rpEnv, " "), binary, strings.Join(redpandaArgs, " "))
-	return unix.Exec(binary, redpandaArgs, rpEnv)
+	return binary, redpandaArgs, rpEnv, nil
 }
 
 func getBinary(installDir string) (string, error) {
-	path, err := exec.LookPath(filepath.Join(installDir, "bin", "redpanda"))
+	path, err := exec.LookPath(BinaryPath(installDir))
 	if err != nil {
 		return "", err
 	}
@@ -75,6 +225,14 @@ func collectRedpandaArgs(args *RedpandaArgs) []string {
 		"--redpanda-cfg",
 		args.ConfigFilePath,
 	}
+	redpandaArgs = append(redpandaArgs, SeastarArgs(args.SeastarFlags)...)
+	return append(redpandaArgs, args.ExtraArgs...)
+}
+
+// Returns the given seastar flags as redpanda's command-line args, e.g.
+// --smp=1.
+func SeastarArgs(flags map[string]string) []string {
+	args := []string{}
 
 	singleFlags := []string{"overprovisioned"}
 
@@ -87,7 +245,7 @@ func collectRedpandaArgs(args *RedpandaArgs) []string {
 		return false
 	}
 
-	for flag, value := range args.SeastarFlags {
+	for flag, value := range flags {
 		single := isSingle(flag)
 		if single && value != "true" {
 			// If it's a 'single'-type flag and it's set to false,
@@ -95,13 +253,10 @@ func collectRedpandaArgs(args *RedpandaArgs) []string {
 			continue
 		}
 		if single || value == "" {
-			redpandaArgs = append(redpandaArgs, "--"+flag)
+			args = append(args, "--"+flag)
 			continue
 		}
-		redpandaArgs = append(
-			redpandaArgs,
-			fmt.Sprintf("--%s=%s", flag, value),
-		)
+		args = append(args, fmt.Sprintf("--%s=%s", flag, value))
 	}
-	return append(redpandaArgs, args.ExtraArgs...)
+	return args
 }
@@ -10,8 +10,13 @@
 package redpanda
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -79,6 +84,21 @@ func Test_collectRedpandaArgs(t *testing.T) {
 				"--io-properties-file=/etc/redpanda/io-config.yaml",
 			},
 		},
+		{
+			name:	"shall pass io-properties verbatim as a single argument",
+			args: RedpandaArgs{
+				ConfigFilePath:	"/etc/redpanda/redpanda.yaml",
+				SeastarFlags: map[string]string{
+					"io-properties": "disks:\n- mountpoint: /mnt/it's here\n",
+				},
+			},
+			want: []string{
+				"redpanda",
+				"--redpanda-cfg",
+				"/etc/redpanda/redpanda.yaml",
+				"--io-properties=disks:\n- mountpoint: /mnt/it's here\n",
+			},
+		},
 		{
 			name:	"shall include memory lock",
 			args: RedpandaArgs{
@@ -102,3 +122,77 @@ func Test_collectRedpandaArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestPIDFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpk-pidfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run", "redpanda.pid")
+
+	err = writePIDFile(path, 1234)
+	require.NoError(t, err)
+	bs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "1234\n", string(bs))
+
+	removePIDFile(path)
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	// Removing it again is a no-op.
+	removePIDFile(path)
+
+	require.NoError(t, writePIDFile("", 1234))
+}
+
+func TestLauncherVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpk-install-dir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	binary := BinaryPath(dir)
+	require.NoError(t, os.MkdirAll(filepath.Dir(binary), 0755))
+	err = ioutil.WriteFile(
+		binary,
+		[]byte("#!/bin/sh\necho 'v21.4.2 (rev a1b2c3)'\n"),
+		0755,
+	)
+	require.NoError(t, err)
+
+	path, version, err := NewLauncher().Version(dir, 5*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, binary, path)
+	require.Equal(t, Version{Major: 21, Minor: 4, Patch: 2}, version)
+
+	_, _, err = NewLauncher().Version(
+		filepath.Join(dir, "missing"),
+		5*time.Second,
+	)
+	require.Error(t, err)
+}
+
+func TestStartInBackgroundLogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpk-install-dir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	binary := BinaryPath(dir)
+	require.NoError(t, os.MkdirAll(filepath.Dir(binary), 0755))
+	err = ioutil.WriteFile(
+		binary,
+		[]byte("#!/bin/sh\necho out\necho err >&2\n"),
+		0755,
+	)
+	require.NoError(t, err)
+	logFile := filepath.Join(dir, "data", "redpanda.log")
+
+	l := NewLauncher().(BackgroundLauncher)
+	_, exited, err := l.StartInBackground(
+		context.Background(),
+		dir,
+		&RedpandaArgs{ConfigFilePath: "/etc/redpanda/redpanda.yaml", LogFile: logFile},
+	)
+	require.NoError(t, err)
+	require.NoError(t, <-exited)
+	// redpanda's output should go to the log file instead of rpk's.
+	bs, err := ioutil.ReadFile(logFile)
+	require.NoError(t, err)
+	require.Equal(t, "out\nerr\n", string(bs))
+}
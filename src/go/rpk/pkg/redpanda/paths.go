@@ -30,6 +30,18 @@ func GetIOConfigPath(configFileDirectory string) string {
 	return filepath.Join(configFileDirectory, "io-config.yaml")
 }
 
+// Returns the path of the file with the presets of flags 'rpk start
+// --preset' takes.
+func GetPresetsPath(configFileDirectory string) string {
+	return filepath.Join(configFileDirectory, "presets.yaml")
+}
+
+// Returns the path of the file redpanda's output is written to when rpk
+// starts it in the background, e.g. with 'rpk start --wait-for-ready'.
+func GetLogPath(dataDirectory string) string {
+	return filepath.Join(dataDirectory, "redpanda.log")
+}
+
 func FindInstallDir(fs afero.Fs) (string, error) {
 	log.Debugf("Looking for redpanda install directory")
 	execPath, err := os.Executable()
@@ -0,0 +1,67 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+)
+
+// The flag which makes redpanda check its config and flags and exit.
+const CheckConfigFlag = "--check-config"
+
+// Returns the path of the redpanda binary in the given install directory.
+func BinaryPath(installDir string) string {
+	return filepath.Join(installDir, "bin", "redpanda")
+}
+
+// Returns the command line redpanda would be started with, for display.
+func CommandLine(installDir string, args *RedpandaArgs) string {
+	redpandaArgs := collectRedpandaArgs(args)
+	return strings.Join(
+		append([]string{BinaryPath(installDir)}, redpandaArgs[1:]...),
+		" ",
+	)
+}
+
+// Returns whether the given redpanda binary supports --check-config, going by
+// its --help output.
+func SupportsCheckConfig(
+	proc vos.Proc, timeout time.Duration, binary string,
+) bool {
+	lines, err := proc.RunWithSystemLdPath(timeout, binary, "--help")
+	if err != nil {
+		return false
+	}
+	for _, line := range lines {
+		if strings.Contains(line, CheckConfigFlag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Runs the given redpanda binary with --check-config and the given args, so
+// that it validates them and its config file without starting.
+func ValidateArgs(
+	proc vos.Proc, timeout time.Duration, binary string, args *RedpandaArgs,
+) error {
+	redpandaArgs := collectRedpandaArgs(args)[1:]
+	redpandaArgs = append(redpandaArgs, CheckConfigFlag)
+	_, err := proc.RunWithSystemLdPath(timeout, binary, redpandaArgs...)
+	if err != nil {
+		return fmt.Errorf("redpanda rejected the config or flags: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Fakes a redpanda binary's --help and --check-config.
+type checkConfigProcMock struct {
+	help	[]string
+	// The error --check-config fails with, if any.
+	invalid	error
+	args	[]string
+}
+
+func (m *checkConfigProcMock) RunWithSystemLdPath(
+	_ time.Duration, _ string, args ...string,
+) ([]string, error) {
+	m.args = args
+	if len(args) == 1 && args[0] == "--help" {
+		return m.help, nil
+	}
+	return nil, m.invalid
+}
+
+func (*checkConfigProcMock) IsRunning(time.Duration, string) bool {
+	return false
+}
+
+func TestSupportsCheckConfig(t *testing.T) {
+	proc := &checkConfigProcMock{help: []string{
+		"  --redpanda-cfg arg    config file",
+		"  --check-config        validate the config and exit",
+	}}
+	require.True(t, SupportsCheckConfig(proc, time.Second, "redpanda"))
+
+	proc.help = []string{"  --redpanda-cfg arg    config file"}
+	require.False(t, SupportsCheckConfig(proc, time.Second, "redpanda"))
+}
+
+func TestValidateArgs(t *testing.T) {
+	args := &RedpandaArgs{
+		ConfigFilePath:	"/etc/redpanda/redpanda.yaml",
+		SeastarFlags:	map[string]string{"smp": "1"},
+	}
+	proc := &checkConfigProcMock{}
+	err := ValidateArgs(proc, time.Second, "/opt/redpanda/bin/redpanda", args)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		"--redpanda-cfg /etc/redpanda/redpanda.yaml --smp=1 --check-config",
+		strings.Join(proc.args, " "),
+	)
+
+	proc.invalid = errors.New("err=exit status 1, stderr=unknown property 'kafka_apii'")
+	err = ValidateArgs(proc, time.Second, "/opt/redpanda/bin/redpanda", args)
+	require.EqualError(
+		t,
+		err,
+		"redpanda rejected the config or flags: err=exit status 1, stderr=unknown property 'kafka_apii'",
+	)
+}
+
+func TestCommandLine(t *testing.T) {
+	args := &RedpandaArgs{
+		ConfigFilePath:	"/etc/redpanda/redpanda.yaml",
+		SeastarFlags:	map[string]string{"smp": "1"},
+		ExtraArgs:	[]string{"--default-log-level=debug"},
+	}
+	require.Equal(
+		t,
+		"/opt/redpanda/bin/redpanda --redpanda-cfg /etc/redpanda/redpanda.yaml --smp=1 --default-log-level=debug",
+		CommandLine("/opt/redpanda", args),
+	)
+}
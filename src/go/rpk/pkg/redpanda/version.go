@@ -0,0 +1,100 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+)
+
+// Matches versions like 'v21.4.2', '21.4.2-beta1' or '21.4.2+a1b2c3', so
+// that the pre-release and build metadata suffixes are ignored.
+var versionPattern = regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)`)
+
+type Version struct {
+	Major	int
+	Minor	int
+	Patch	int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+// Parses the first <major>.<minor>.<patch> version found in s, such as
+// 'v21.4.2 (rev a1b2c3)', the format redpanda --version prints.
+func ParseVersion(s string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf(
+			"couldn't find a version in '%s'",
+			strings.TrimSpace(s),
+		)
+	}
+	// The pattern only matches digits, so these can't fail.
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return Version{major, minor, patch}, nil
+}
+
+// Runs the given redpanda binary with --version, and parses its output.
+func BinaryVersion(
+	proc vos.Proc, timeout time.Duration, binary string,
+) (Version, error) {
+	lines, err := proc.RunWithSystemLdPath(timeout, binary, "--version")
+	if err != nil {
+		return Version{}, fmt.Errorf(
+			"couldn't get the version of '%s': %v",
+			binary,
+			err,
+		)
+	}
+	return ParseVersion(strings.Join(lines, "\n"))
+}
+
+// Fails if the given redpanda binary's version is older than min.
+func CheckMinVersion(
+	proc vos.Proc, timeout time.Duration, binary, min string,
+) error {
+	minVersion, err := ParseVersion(min)
+	if err != nil {
+		return fmt.Errorf("invalid minimum redpanda version: %v", err)
+	}
+	version, err := BinaryVersion(proc, timeout, binary)
+	if err != nil {
+		return err
+	}
+	if version.Less(minVersion) {
+		return fmt.Errorf(
+			"'%s' is redpanda %s, but the config requires at least %s"+
+				" (rpk.min_redpanda_version)",
+			binary,
+			version,
+			minVersion,
+		)
+	}
+	return nil
+}
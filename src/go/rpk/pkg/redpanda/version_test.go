@@ -0,0 +1,113 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package redpanda
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name		string
+		input		string
+		expected	Version
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should parse a version with a leading 'v'",
+			input:		"v21.4.2 (rev a1b2c3d)",
+			expected:	Version{21, 4, 2},
+		},
+		{
+			name:		"it should parse a version without a leading 'v'",
+			input:		"21.4.2",
+			expected:	Version{21, 4, 2},
+		},
+		{
+			name:		"it should ignore the pre-release and build metadata",
+			input:		"v21.5.0-beta1+a1b2c3d",
+			expected:	Version{21, 5, 0},
+		},
+		{
+			name:		"it should fail if there's no version",
+			input:		"dev build\n",
+			expectedErrMsg:	"couldn't find a version in 'dev build'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			v, err := ParseVersion(tt.input)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, v)
+		})
+	}
+}
+
+func TestCheckMinVersion(t *testing.T) {
+	tests := []struct {
+		name		string
+		version		string
+		min		string
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it shouldn't fail if the version is the minimum",
+			version:	"v21.4.2 (rev a1b2c3d)",
+			min:		"21.4.2",
+		},
+		{
+			name:	"it shouldn't fail if the version is newer",
+			version:	"v21.10.0 (rev a1b2c3d)",
+			min:		"v21.9.3",
+		},
+		{
+			name:		"it should fail if the version is older",
+			version:	"v21.4.2 (rev a1b2c3d)",
+			min:		"21.5.0",
+			expectedErrMsg:	"'/opt/redpanda/bin/redpanda' is redpanda v21.4.2, but the config requires at least v21.5.0 (rpk.min_redpanda_version)",
+		},
+		{
+			name:		"it should fail if the minimum version is invalid",
+			min:		"latest",
+			expectedErrMsg:	"invalid minimum redpanda version: couldn't find a version in 'latest'",
+		},
+		{
+			name:		"it should fail if the binary can't be run",
+			min:		"21.5.0",
+			expectedErrMsg:	"couldn't get the version of '/opt/redpanda/bin/redpanda': exec format error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			proc := &versionProcMock{map[string]string{}}
+			if tt.version != "" {
+				proc.versions["/opt/redpanda/bin/redpanda"] = tt.version
+			}
+			err := CheckMinVersion(
+				proc,
+				time.Second,
+				"/opt/redpanda/bin/redpanda",
+				tt.min,
+			)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+		})
+	}
+}
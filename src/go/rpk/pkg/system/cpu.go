@@ -66,3 +66,27 @@ func CpuInfo(fs afero.Fs) ([]*CPUInfo, error) {
 	}
 	return cpus, nil
 }
+
+const (
+	SMTActiveFile	= "/sys/devices/system/cpu/smt/active"
+	SMTControlFile	= "/sys/devices/system/cpu/smt/control"
+)
+
+// Returns whether simultaneous multithreading (hyperthreading) is active.
+func IsSMTActive(fs afero.Fs) (bool, error) {
+	bs, err := afero.ReadFile(fs, SMTActiveFile)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(bs)) == "1", nil
+}
+
+// Returns the SMT control state: on, off, forceoff, notsupported or
+// notimplemented.
+func GetSMTControl(fs afero.Fs) (string, error) {
+	bs, err := afero.ReadFile(fs, SMTControlFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bs)), nil
+}
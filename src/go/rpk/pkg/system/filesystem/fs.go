@@ -10,6 +10,7 @@
 package filesystem
 
 import (
+	"errors"
 	"path/filepath"
 	"syscall"
 
@@ -17,6 +18,9 @@ import (
 	"github.com/spf13/afero"
 )
 
+// Returned by Fallocate when the file or the platform doesn't support it.
+var ErrFallocateUnsupported = errors.New("fallocate isn't supported")
+
 func DirectoryIsWriteable(fs afero.Fs, path string) (bool, error) {
 	if exists, _ := afero.Exists(fs, path); !exists {
 		err := fs.MkdirAll(path, 0755)
@@ -45,3 +49,23 @@ func GetFreeDiskSpaceGB(path string) (float64, error) {
 	}
 	return float64(statFs.Bfree*uint64(statFs.Bsize)) / units.GiB, nil
 }
+
+// Checks whether fallocate is supported in dir, by preallocating a byte for a
+// probe file, which is removed afterwards.
+func FallocateSupported(fs afero.Fs, dir string) (bool, error) {
+	probe := filepath.Join(dir, ".rpk_fallocate_probe")
+	file, err := fs.Create(probe)
+	if err != nil {
+		return false, err
+	}
+	defer fs.Remove(probe)
+	defer file.Close()
+	err = Fallocate(file, 1)
+	if err == ErrFallocateUnsupported {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
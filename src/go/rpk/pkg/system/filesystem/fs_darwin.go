@@ -9,8 +9,16 @@
 
 package filesystem
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/spf13/afero"
+)
 
 func GetFilesystemType(path string) (FsType, error) {
 	return Unknown, errors.New("Filesystem detection not available for MacOS")
 }
+
+func Fallocate(file afero.File, size int64) error {
+	return ErrFallocateUnsupported
+}
@@ -10,8 +10,10 @@
 package filesystem
 
 import (
+	"os"
 	"syscall"
 
+	"github.com/spf13/afero"
 	"golang.org/x/sys/unix"
 )
 
@@ -34,3 +36,17 @@ func GetFilesystemType(path string) (FsType, error) {
 		return Unknown, nil
 	}
 }
+
+// Allocates size bytes of disk space for the given file. It's only supported
+// for files backed by the OS filesystem.
+func Fallocate(file afero.File, size int64) error {
+	osFile, ok := file.(*os.File)
+	if !ok {
+		return ErrFallocateUnsupported
+	}
+	err := unix.Fallocate(int(osFile.Fd()), 0, 0, size)
+	if err == unix.EOPNOTSUPP {
+		return ErrFallocateUnsupported
+	}
+	return err
+}
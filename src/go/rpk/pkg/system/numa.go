@@ -0,0 +1,89 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package system
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+)
+
+const NUMANodesDir = "/sys/devices/system/node"
+
+// The memory of a NUMA node, in bytes.
+type NUMANodeMemInfo struct {
+	MemTotal	uint64
+	MemFree		uint64
+	// The page cache, most of which can be reclaimed.
+	FilePages	uint64
+}
+
+// Returns the sysfs directory of the given NUMA node.
+func NUMANodeDir(node int) string {
+	return filepath.Join(NUMANodesDir, fmt.Sprintf("node%d", node))
+}
+
+// Returns the CPUs of the given NUMA node, in cpuset(7) list format.
+func GetNUMANodeCPUList(fs afero.Fs, node int) (string, error) {
+	path := filepath.Join(NUMANodeDir(node), "cpulist")
+	bs, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read NUMA node %d's CPUs: %v", node, err)
+	}
+	cpus := strings.TrimSpace(string(bs))
+	if cpus == "" {
+		return "", fmt.Errorf("NUMA node %d has no CPUs", node)
+	}
+	return cpus, nil
+}
+
+// Returns the memory of the given NUMA node, read from its meminfo file, where
+// each line looks like 'Node 0 MemTotal:       32768000 kB'.
+func GetNUMANodeMemInfo(fs afero.Fs, node int) (*NUMANodeMemInfo, error) {
+	path := filepath.Join(NUMANodeDir(node), "meminfo")
+	lines, err := utils.ReadFileLines(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't read NUMA node %d's memory: %v",
+			node,
+			err,
+		)
+	}
+	info := &NUMANodeMemInfo{}
+	fields := map[string]*uint64{
+		"MemTotal:":	&info.MemTotal,
+		"MemFree:":	&info.MemFree,
+		"FilePages:":	&info.FilePages,
+	}
+	for _, line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 4 {
+			continue
+		}
+		field, ok := fields[parts[2]]
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(parts[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse '%s' in %s: %v", line, path, err)
+		}
+		*field = v * units.KiB
+	}
+	if info.MemTotal == 0 {
+		return nil, fmt.Errorf("couldn't find MemTotal in %s", path)
+	}
+	return info, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package system
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/afero"
+)
+
+// The current process' OOM score adjustment. Like the rlimits, it's inherited
+// by redpanda, since rpk execs into it.
+const OomScoreAdjFile = "/proc/self/oom_score_adj"
+
+const (
+	MinOomScoreAdj	= -1000
+	MaxOomScoreAdj	= 1000
+)
+
+func ValidateOomScoreAdj(value int) error {
+	if value < MinOomScoreAdj || value > MaxOomScoreAdj {
+		return fmt.Errorf(
+			"invalid OOM score adjustment %d. It must be between %d"+
+				" and %d",
+			value,
+			MinOomScoreAdj,
+			MaxOomScoreAdj,
+		)
+	}
+	return nil
+}
+
+// Sets the current process' oom_score_adj. -1000 keeps the OOM killer from
+// ever killing it, and 1000 makes it its first choice.
+func SetOomScoreAdj(fs afero.Fs, value int) error {
+	err := ValidateOomScoreAdj(value)
+	if err != nil {
+		return err
+	}
+	err = afero.WriteFile(fs, OomScoreAdjFile, []byte(strconv.Itoa(value)), 0644)
+	if os.IsPermission(err) {
+		return fmt.Errorf(
+			"couldn't set the OOM score adjustment to %d: permission"+
+				" denied. Lowering it requires root or"+
+				" CAP_SYS_RESOURCE",
+			value,
+		)
+	}
+	return err
+}
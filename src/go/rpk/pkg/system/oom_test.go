@@ -0,0 +1,59 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package system
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOomScoreAdj(t *testing.T) {
+	tests := []struct {
+		name		string
+		value		int
+		readOnly	bool
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should write the value",
+			value:	-1000,
+		},
+		{
+			name:		"it should fail if the value is out of range",
+			value:		1001,
+			expectedErrMsg:	"invalid OOM score adjustment 1001. It must be between -1000 and 1000",
+		},
+		{
+			name:		"it should fail with a hint if it's not allowed",
+			value:		-500,
+			readOnly:	true,
+			expectedErrMsg:	"couldn't set the OOM score adjustment to -500: permission denied. Lowering it requires root or CAP_SYS_RESOURCE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			var fs afero.Fs = afero.NewMemMapFs()
+			if tt.readOnly {
+				fs = afero.NewReadOnlyFs(fs)
+			}
+			err := SetOomScoreAdj(fs, tt.value)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			adj, err := afero.ReadFile(fs, OomScoreAdjFile)
+			require.NoError(st, err)
+			require.Equal(st, "-1000", string(adj))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package system
+
+import "golang.org/x/sys/unix"
+
+// Returns the current process' RLIMIT_MEMLOCK (ulimit -l), in bytes. It's
+// inherited by redpanda, since rpk execs into it.
+func GetMemlockLimit() (*unix.Rlimit, error) {
+	limit := &unix.Rlimit{}
+	err := unix.Getrlimit(unix.RLIMIT_MEMLOCK, limit)
+	return limit, err
+}
+
+func SetMemlockLimit(limit *unix.Rlimit) error {
+	return unix.Setrlimit(unix.RLIMIT_MEMLOCK, limit)
+}
+
+// Returns the current process' RLIMIT_CORE (ulimit -c), in bytes.
+func GetCoreLimit() (*unix.Rlimit, error) {
+	limit := &unix.Rlimit{}
+	err := unix.Getrlimit(unix.RLIMIT_CORE, limit)
+	return limit, err
+}
+
+func SetCoreLimit(limit *unix.Rlimit) error {
+	return unix.Setrlimit(unix.RLIMIT_CORE, limit)
+}
+
+// Returns the current process' RLIMIT_NOFILE (ulimit -n), the max number of
+// file descriptors it can open.
+func GetFdLimit() (*unix.Rlimit, error) {
+	limit := &unix.Rlimit{}
+	err := unix.Getrlimit(unix.RLIMIT_NOFILE, limit)
+	return limit, err
+}
+
+func SetFdLimit(limit *unix.Rlimit) error {
+	return unix.Setrlimit(unix.RLIMIT_NOFILE, limit)
+}
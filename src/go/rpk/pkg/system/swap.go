@@ -0,0 +1,76 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package system
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+)
+
+const SwapsFile = "/proc/swaps"
+
+// A swap area, as listed in /proc/swaps. Sizes are in KiB.
+type SwapDevice struct {
+	Filename	string
+	// 'partition' or 'file'.
+	Type		string
+	Size		uint64
+	Used		uint64
+	// Areas with a higher priority are used first.
+	Priority	int
+}
+
+// Returns the active swap areas.
+func GetSwapDevices(fs afero.Fs) ([]SwapDevice, error) {
+	lines, err := utils.ReadFileLines(fs, SwapsFile)
+	if err != nil {
+		return nil, err
+	}
+	var devices []SwapDevice
+	// The first line is the header.
+	for i := 1; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 5 {
+			return nil, fmt.Errorf(
+				"couldn't parse line '%s' in %s",
+				lines[i],
+				SwapsFile,
+			)
+		}
+		size, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		used, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		priority, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, SwapDevice{
+			// Spaces in the path are escaped as \040.
+			Filename:	strings.ReplaceAll(fields[0], `\040`, " "),
+			Type:		fields[1],
+			Size:		size,
+			Used:		used,
+			Priority:	priority,
+		})
+	}
+	return devices, nil
+}
@@ -135,6 +135,16 @@ func UnitPath(name string) string {
 	return "/etc/systemd/system/" + name
 }
 
+// Returns the name of a drop-in for the given unit, relative to the units'
+// directory, e.g. 'redpanda.service.d/limits.conf'.
+func DropInName(unit, name string) string {
+	return unit + ".d/" + name
+}
+
+func DropInPath(unit, name string) string {
+	return UnitPath(DropInName(unit, name))
+}
+
 func IsLoaded(s LoadState) bool {
 	return s == LoadStateLoaded
 }
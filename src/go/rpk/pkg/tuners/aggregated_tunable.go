@@ -9,12 +9,26 @@
 
 package tuners
 
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
 func NewAggregatedTunable(tunables []Tunable) Tunable {
-	return &aggregatedTunable{tunables}
+	return newAggregatedTunable(tunables, "steps")
+}
+
+func newAggregatedTunable(
+	tunables []Tunable, unit string,
+) *aggregatedTunable {
+	return &aggregatedTunable{tunables: tunables, unit: unit}
 }
 
 type aggregatedTunable struct {
-	tunables []Tunable
+	tunables	[]Tunable
+	// The unit the tunables are counted in when reporting progress.
+	unit	string
 }
 
 func (t *aggregatedTunable) CheckIfSupported() (supported bool, reason string) {
@@ -28,8 +42,18 @@ func (t *aggregatedTunable) CheckIfSupported() (supported bool, reason string) {
 }
 
 func (t *aggregatedTunable) Tune() TuneResult {
+	return t.TuneWithContext(context.Background(), NewProgress())
+}
+
+func (t *aggregatedTunable) TuneWithContext(
+	ctx context.Context, progress *Progress,
+) TuneResult {
 	var needReboot = false
+	progress.Start(len(t.tunables), t.unit)
 	for _, tunable := range t.tunables {
+		if ctx.Err() != nil {
+			return NewTuneError(interruptedError(ctx, progress))
+		}
 		result := tunable.Tune()
 		if result.IsFailed() {
 			return result
@@ -37,6 +61,49 @@ func (t *aggregatedTunable) Tune() TuneResult {
 		if result.IsRebootRequired() {
 			needReboot = true
 		}
+		progress.Step()
 	}
 	return NewTuneResult(needReboot)
 }
+
+// Merges the details of the aggregated tunables.
+func (t *aggregatedTunable) Details() map[string]string {
+	var details map[string]string
+	for _, tunable := range t.tunables {
+		for k, v := range TuneDetails(tunable) {
+			if details == nil {
+				details = map[string]string{}
+			}
+			details[k] = v
+		}
+	}
+	return details
+}
+
+// Wraps one of the steps of an aggregated tunable which is skipped if it's
+// not supported, instead of making the whole aggregate unsupported.
+func newOptionalTunable(tunable Tunable, name string) Tunable {
+	return &optionalTunable{tunable: tunable, name: name}
+}
+
+type optionalTunable struct {
+	tunable	Tunable
+	name	string
+}
+
+func (t *optionalTunable) CheckIfSupported() (supported bool, reason string) {
+	return true, ""
+}
+
+func (t *optionalTunable) Tune() TuneResult {
+	supported, reason := t.tunable.CheckIfSupported()
+	if !supported {
+		log.Infof("Skipping %s: %s", t.name, reason)
+		return NewTuneResult(false)
+	}
+	return t.tunable.Tune()
+}
+
+func (t *optionalTunable) Details() map[string]string {
+	return TuneDetails(t.tunable)
+}
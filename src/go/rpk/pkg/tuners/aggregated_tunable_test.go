@@ -10,8 +10,10 @@
 package tuners
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -111,7 +113,7 @@ func Test_aggregatedTunable_Tune(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tunable := &aggregatedTunable{tt.fields.tunables}
+			tunable := &aggregatedTunable{tunables: tt.fields.tunables}
 			got := tunable.Tune()
 			require.Exactly(t, tt.want, got)
 		})
@@ -172,10 +174,88 @@ func Test_aggregatedTunable_CheckIfSupported(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tunable := &aggregatedTunable{tt.tunables}
+			tunable := &aggregatedTunable{tunables: tt.tunables}
 			gotSupported, gotReason := tunable.CheckIfSupported()
 			require.Equal(t, tt.wantSupported, gotSupported)
 			require.Equal(t, tt.wantReason, gotReason)
 		})
 	}
 }
+
+func Test_aggregatedTunable_TuneWithTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tunables := []Tunable{}
+	for i := 0; i < 4; i++ {
+		i := i
+		tunables = append(tunables, &mockedTunable{
+			tune: func() TuneResult {
+				// Simulate the deadline being hit while the 2nd
+				// tunable runs.
+				if i == 1 {
+					cancel()
+				}
+				return NewTuneResult(false)
+			},
+		})
+	}
+	tunable := newAggregatedTunable(tunables, "disks")
+	progress := NewProgress()
+	result := tunable.TuneWithContext(ctx, progress)
+	require.True(t, result.IsFailed())
+	require.Contains(t, result.Error().Error(), "applied 2 of 4 disks")
+	require.Equal(t, "applied 2 of 4 disks", progress.String())
+}
+
+func TestTuneWithTimeout(t *testing.T) {
+	tunable := newAggregatedTunable([]Tunable{
+		&mockedTunable{
+			tune: func() TuneResult {
+				return NewTuneResult(false)
+			},
+		},
+		&mockedTunable{
+			tune: func() TuneResult {
+				time.Sleep(50 * time.Millisecond)
+				return NewTuneResult(false)
+			},
+		},
+		&mockedTunable{
+			tune: func() TuneResult {
+				return NewTuneResult(false)
+			},
+		},
+	}, "steps")
+	result, progress := TuneWithTimeout(tunable, 10*time.Millisecond)
+	require.True(t, result.IsFailed())
+	require.Equal(t, "applied 2 of 3 steps", progress.String())
+}
+
+func TestOptionalTunable(t *testing.T) {
+	tuned := false
+	tunable := NewAggregatedTunable([]Tunable{
+		&mockedTunable{
+			checkIfSupported: func() (bool, string) {
+				return true, ""
+			},
+			tune: func() TuneResult {
+				return NewTuneResult(false)
+			},
+		},
+		newOptionalTunable(&mockedTunable{
+			checkIfSupported: func() (bool, string) {
+				return false, "not supported"
+			},
+			tune: func() TuneResult {
+				tuned = true
+				return NewTuneError(fmt.Errorf("shouldn't be tuned"))
+			},
+		}, "the optional step"),
+	})
+	supported, reason := tunable.CheckIfSupported()
+	require.True(t, supported)
+	require.Empty(t, reason)
+	result := tunable.Tune()
+	require.False(t, result.IsFailed())
+	require.False(t, tuned)
+}
@@ -23,15 +23,19 @@ const maxAIOEvents = 1048576
 const maxAIOEventsFile = "/proc/sys/fs/aio-max-nr"
 
 func NewMaxAIOEventsChecker(fs afero.Fs) Checker {
+	return newMaxAIOEventsChecker(fs, maxAIOEvents)
+}
+
+func newMaxAIOEventsChecker(fs afero.Fs, min int) Checker {
 	return NewIntChecker(
 		MaxAIOEvents,
 		"Max AIO Events",
 		Warning,
 		func(current int) bool {
-			return current >= maxAIOEvents
+			return current >= min
 		},
 		func() string {
-			return fmt.Sprintf(">= %d", maxAIOEvents)
+			return fmt.Sprintf(">= %d", min)
 		},
 		func() (int, error) {
 			return utils.ReadIntFromFile(fs, maxAIOEventsFile)
@@ -39,16 +43,20 @@ func NewMaxAIOEventsChecker(fs afero.Fs) Checker {
 	)
 }
 
-func NewMaxAIOEventsTuner(fs afero.Fs, executor executors.Executor) Tunable {
+// Raises fs.aio-max-nr to 1048576, scaled to the given profile.
+func NewMaxAIOEventsTuner(
+	fs afero.Fs, profile TuneProfile, executor executors.Executor,
+) Tunable {
+	events := profile.Scale(maxAIOEvents)
 	return NewCheckedTunable(
-		NewMaxAIOEventsChecker(fs),
+		newMaxAIOEventsChecker(fs, events),
 		func() TuneResult {
-			log.Debugf("Setting max AIO events to %d", maxAIOEvents)
+			log.Debugf("Setting max AIO events to %d", events)
 			err := executor.Execute(
 				commands.NewWriteFileCmd(
 					fs,
 					maxAIOEventsFile,
-					fmt.Sprint(maxAIOEvents),
+					fmt.Sprint(events),
 				),
 			)
 			if err != nil {
@@ -26,6 +26,7 @@ func TestMaxAIOEventsCheck(t *testing.T) {
 	tests := []struct {
 		name		string
 		before		func(fs afero.Fs) error
+		profile		tuners.TuneProfile
 		expectChange	bool
 		expected	int
 		expectedErrMsg	string
@@ -65,6 +66,20 @@ func TestMaxAIOEventsCheck(t *testing.T) {
 			expectChange:	true,
 			expected:	1048576,
 		},
+		{
+			name:	"it should set the value scaled to the profile",
+			before: func(fs afero.Fs) error {
+				_, err := utils.WriteBytes(
+					fs,
+					[]byte("1048576"),
+					maxAIOEventsFile,
+				)
+				return err
+			},
+			profile:	tuners.AggressiveProfile,
+			expectChange:	true,
+			expected:	4194304,
+		},
 		{
 			name:		"it should fail if the file is missing",
 			expectedErrMsg:	"/proc/sys/fs/aio-max-nr",
@@ -79,7 +94,7 @@ func TestMaxAIOEventsCheck(t *testing.T) {
 				err := tt.before(fs)
 				require.NoError(st, err)
 			}
-			tuner := tuners.NewMaxAIOEventsTuner(fs, exec)
+			tuner := tuners.NewMaxAIOEventsTuner(fs, tt.profile, exec)
 			res := tuner.Tune()
 			if tt.expectedErrMsg != "" {
 				require.Contains(st, res.Error().Error(), tt.expectedErrMsg)
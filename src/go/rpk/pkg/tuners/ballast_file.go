@@ -0,0 +1,151 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/docker/go-units"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+)
+
+const (
+	// The file in the data directory the ballast space is allocated to.
+	BallastFile	= ".rpk_ballast"
+	// The size of the ballast file if rpk.ballast_file_size isn't set.
+	DefaultBallastFileSize	= "1GiB"
+)
+
+// Allocates a file of the configured size in the data directory, which can be
+// deleted to free up space when the disk fills up, giving redpanda room to
+// recover (e.g. while retention catches up).
+type ballastFileTuner struct {
+	fs			afero.Fs
+	path			string
+	size			string
+	minFree			string
+	fallocateSupported	func(dir string) (bool, error)
+	freeSpaceGB		func(path string) (float64, error)
+	executor		executors.Executor
+	allocated		int64
+}
+
+func NewBallastFileTuner(
+	fs afero.Fs,
+	path string,
+	size string,
+	minFree string,
+	fallocateSupported func(dir string) (bool, error),
+	freeSpaceGB func(path string) (float64, error),
+	executor executors.Executor,
+) Tunable {
+	return &ballastFileTuner{
+		fs:			fs,
+		path:			path,
+		size:			size,
+		minFree:		minFree,
+		fallocateSupported:	fallocateSupported,
+		freeSpaceGB:		freeSpaceGB,
+		executor:		executor,
+	}
+}
+
+func BallastFilePath(dataDir string) string {
+	return filepath.Join(dataDir, BallastFile)
+}
+
+func (t *ballastFileTuner) CheckIfSupported() (supported bool, reason string) {
+	dir := filepath.Dir(t.path)
+	if exists, _ := afero.DirExists(t.fs, dir); !exists {
+		return false, fmt.Sprintf("Directory '%s' doesn't exist", dir)
+	}
+	ok, err := t.fallocateSupported(dir)
+	if err != nil {
+		return false, err.Error()
+	}
+	if !ok {
+		return false, fmt.Sprintf(
+			"fallocate isn't supported by the filesystem '%s' is on,"+
+				" so the ballast file's space can't be reserved",
+			dir,
+		)
+	}
+	return true, ""
+}
+
+func (t *ballastFileTuner) Tune() TuneResult {
+	size, err := units.RAMInBytes(t.size)
+	if err != nil || size <= 0 {
+		return NewTuneError(fmt.Errorf("invalid rpk.ballast_file_size '%s'", t.size))
+	}
+	var current int64
+	if info, err := t.fs.Stat(t.path); err == nil {
+		current = info.Size()
+	}
+	if current == size {
+		log.Debugf("Ballast file '%s' already has %d bytes", t.path, current)
+		t.allocated = current
+		return NewTuneResult(false)
+	}
+	if current > size {
+		return NewTuneError(fmt.Errorf(
+			"ballast file '%s' is larger than %s. Delete it to"+
+				" resize it",
+			t.path,
+			units.BytesSize(float64(size)),
+		))
+	}
+	minFreeGB, err := parseMinFreeDiskGB(t.minFree)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	freeGB, err := t.freeSpaceGB(filepath.Dir(t.path))
+	if err != nil {
+		return NewTuneError(err)
+	}
+	neededGB := float64(size-current) / units.GiB
+	if freeGB-neededGB < minFreeGB {
+		return NewTuneError(fmt.Errorf(
+			"not enough free space to create the ballast file '%s'"+
+				" of %s: %.2f GiB available, and at least %s"+
+				" must remain free",
+			t.path,
+			units.BytesSize(float64(size)),
+			freeGB,
+			t.minFree,
+		))
+	}
+	err = t.executor.Execute(commands.NewFallocateCmd(t.fs, t.path, size))
+	if err != nil {
+		return NewTuneError(err)
+	}
+	t.allocated = size
+	log.Infof(
+		"Created the ballast file '%s' of %s. Delete it to free up"+
+			" space if the disk fills up",
+		t.path,
+		units.BytesSize(float64(size)),
+	)
+	return NewTuneResult(false)
+}
+
+func (t *ballastFileTuner) Details() map[string]string {
+	if t.allocated == 0 {
+		return nil
+	}
+	return map[string]string{
+		"path":	t.path,
+		"size":	units.BytesSize(float64(t.allocated)),
+	}
+}
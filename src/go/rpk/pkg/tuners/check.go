@@ -20,8 +20,51 @@ import (
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
 )
 
+// Decides whether the checker with the given ID should run.
+type CheckFilter func(id CheckerID) bool
+
+// Returns a filter which lets only the given checkers run.
+func OnlyCheckers(ids []CheckerID) CheckFilter {
+	allowed := make(map[CheckerID]bool, len(ids))
+	for _, id := range ids {
+		allowed[id] = true
+	}
+	return func(id CheckerID) bool {
+		return allowed[id]
+	}
+}
+
+// Returns a filter which lets all checkers run but the given ones.
+func SkipCheckers(ids []CheckerID) CheckFilter {
+	skipped := OnlyCheckers(ids)
+	return func(id CheckerID) bool {
+		return !skipped(id)
+	}
+}
+
+// Returns a filter which lets a checker run only if all the given filters do.
+// Nil filters are ignored.
+func AllCheckFilters(filters ...CheckFilter) CheckFilter {
+	return func(id CheckerID) bool {
+		for _, f := range filters {
+			if f != nil && !f(id) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
 func Check(
 	fs afero.Fs, conf *config.Config, timeout time.Duration,
+) ([]CheckResult, error) {
+	return CheckFiltered(fs, conf, timeout, nil)
+}
+
+// Like Check, but only runs the checkers accepted by filter. A nil filter
+// runs all of them.
+func CheckFiltered(
+	fs afero.Fs, conf *config.Config, timeout time.Duration, filter CheckFilter,
 ) ([]CheckResult, error) {
 	var results []CheckResult
 	ioConfigFile := redpanda.GetIOConfigPath(filepath.Dir(conf.ConfigFile))
@@ -30,9 +73,14 @@ func Check(
 		return results, err
 	}
 
-	for _, checkers := range checkersMap {
+	for id, checkers := range checkersMap {
+		if filter != nil && !filter(id) {
+			log.Debugf("Skipping checker '%s'", id)
+			continue
+		}
 		for _, c := range checkers {
 			result := c.Check()
+			result.Category = c.Id().Category()
 			if result.Err != nil {
 				if c.GetSeverity() == Fatal {
 					return results, result.Err
@@ -0,0 +1,89 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+)
+
+const checkStateFile = ".rpk_check_state.json"
+
+// The outcome of each checker in the last `rpk check` run.
+type checkState struct {
+	Passed	[]string	`json:"passed"`
+	Failed	[]string	`json:"failed"`
+}
+
+// Returns the path of the file the last check results are persisted to,
+// which lives next to the config file.
+func CheckStatePath(conf *config.Config) string {
+	return filepath.Join(filepath.Dir(conf.ConfigFile), checkStateFile)
+}
+
+// Persists which checkers passed and which failed. A checker which returned
+// several results (e.g. one per NIC) failed if any of them did.
+func SaveCheckState(fs afero.Fs, path string, results []CheckResult) error {
+	passed := map[string]bool{}
+	for _, r := range results {
+		name := r.CheckerId.String()
+		ok, seen := passed[name]
+		passed[name] = r.IsOk && r.Err == nil && (!seen || ok)
+	}
+	state := checkState{Passed: []string{}, Failed: []string{}}
+	for name, ok := range passed {
+		if ok {
+			state.Passed = append(state.Passed, name)
+		} else {
+			state.Failed = append(state.Failed, name)
+		}
+	}
+	sort.Strings(state.Passed)
+	sort.Strings(state.Failed)
+	bs, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = utils.WriteBytes(fs, bs, path)
+	return err
+}
+
+// Returns the checkers which failed in the last persisted run. found is false
+// if there's no persisted state.
+func LoadFailedCheckers(
+	fs afero.Fs, path string,
+) (failed []CheckerID, found bool, err error) {
+	exists, err := afero.Exists(fs, path)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+	bs, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, false, err
+	}
+	state := checkState{}
+	if err := json.Unmarshal(bs, &state); err != nil {
+		return nil, false, err
+	}
+	for _, name := range state.Failed {
+		id, err := CheckerIDFromName(name)
+		if err != nil {
+			// The checker may have been removed or renamed since.
+			continue
+		}
+		failed = append(failed, id)
+	}
+	return failed, true, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+)
+
+func TestCheckState(t *testing.T) {
+	tests := []struct {
+		name		string
+		results		[]tuners.CheckResult
+		expected	[]tuners.CheckerID
+	}{
+		{
+			name:	"it should load the checkers which failed",
+			results: []tuners.CheckResult{
+				{CheckerId: tuners.SwapChecker, IsOk: false},
+				{CheckerId: tuners.ConfigFileChecker, IsOk: true},
+				{CheckerId: tuners.NtpChecker, IsOk: true, Err: errors.New("timeout")},
+			},
+			expected:	[]tuners.CheckerID{tuners.NtpChecker, tuners.SwapChecker},
+		},
+		{
+			name:	"it should consider a checker failed if any of its results failed",
+			results: []tuners.CheckResult{
+				{CheckerId: tuners.NicRfsChecker, IsOk: true},
+				{CheckerId: tuners.NicRfsChecker, IsOk: false},
+				{CheckerId: tuners.NicRfsChecker, IsOk: true},
+			},
+			expected:	[]tuners.CheckerID{tuners.NicRfsChecker},
+		},
+		{
+			name:	"it should load no checkers if all passed",
+			results: []tuners.CheckResult{
+				{CheckerId: tuners.SwapChecker, IsOk: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			path := "/etc/redpanda/.rpk_check_state.json"
+			err := tuners.SaveCheckState(fs, path, tt.results)
+			require.NoError(t, err)
+			failed, found, err := tuners.LoadFailedCheckers(fs, path)
+			require.NoError(t, err)
+			require.True(t, found)
+			require.ElementsMatch(t, tt.expected, failed)
+		})
+	}
+}
+
+func TestLoadFailedCheckersNoState(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	failed, found, err := tuners.LoadFailedCheckers(fs, "/etc/redpanda/.rpk_check_state.json")
+	require.NoError(t, err)
+	require.False(t, found)
+	require.Empty(t, failed)
+}
+
+func TestCheckerIDFromName(t *testing.T) {
+	for _, name := range tuners.CheckerNames() {
+		id, err := tuners.CheckerIDFromName(name)
+		require.NoError(t, err)
+		require.Equal(t, name, id.String())
+	}
+	_, err := tuners.CheckerIDFromName("nope")
+	require.EqualError(t, err, "unknown checker 'nope'")
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+)
+
+func TestCheckFilters(t *testing.T) {
+	only := tuners.OnlyCheckers([]tuners.CheckerID{tuners.SwapChecker, tuners.NtpChecker})
+	skip := tuners.SkipCheckers([]tuners.CheckerID{tuners.NtpChecker})
+	all := tuners.AllCheckFilters(only, nil, skip)
+
+	require.True(t, only(tuners.SwapChecker))
+	require.False(t, only(tuners.FstrimChecker))
+	require.False(t, skip(tuners.NtpChecker))
+	require.True(t, skip(tuners.FstrimChecker))
+
+	require.True(t, all(tuners.SwapChecker))
+	require.False(t, all(tuners.NtpChecker))
+	require.False(t, all(tuners.FstrimChecker))
+	require.True(t, tuners.AllCheckFilters()(tuners.FstrimChecker))
+}
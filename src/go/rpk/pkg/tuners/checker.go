@@ -34,6 +34,9 @@ type CheckResult struct {
 	Desc		string
 	Severity	Severity
 	Required	string
+	Category	Category
+	// Additional information about what was checked, e.g. each swap area.
+	Details	map[string]string
 }
 
 type Checker interface {
@@ -43,3 +46,32 @@ type Checker interface {
 	GetRequiredAsString() string
 	GetSeverity() Severity
 }
+
+type Category byte
+
+const (
+	ConfigCategory	Category	= iota
+	SystemCategory
+	DiskCategory
+	NetworkCategory
+	ClusterCategory
+	SecurityCategory
+)
+
+func (c Category) String() string {
+	switch c {
+	case ConfigCategory:
+		return "config"
+	case SystemCategory:
+		return "system"
+	case DiskCategory:
+		return "disk"
+	case NetworkCategory:
+		return "network"
+	case ClusterCategory:
+		return "cluster"
+	case SecurityCategory:
+		return "security"
+	}
+	panic("Wrong checker category")
+}
@@ -18,7 +18,12 @@ import (
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
 )
 
-const prefferedClkSource = "tsc"
+const (
+	prefferedClkSource	= "tsc"
+
+	CurrentClockSourceFile		= "/sys/devices/system/clocksource/clocksource0/current_clocksource"
+	AvailableClockSourcesFile	= "/sys/devices/system/clocksource/clocksource0/available_clocksource"
+)
 
 func NewClockSourceChecker(fs afero.Fs) Checker {
 	return NewEqualityChecker(
@@ -27,8 +32,7 @@ func NewClockSourceChecker(fs afero.Fs) Checker {
 		Warning,
 		prefferedClkSource,
 		func() (interface{}, error) {
-			content, err := afero.ReadFile(fs,
-				"/sys/devices/system/clocksource/clocksource0/current_clocksource")
+			content, err := afero.ReadFile(fs, CurrentClockSourceFile)
 			if err != nil {
 				return "", err
 			}
@@ -42,7 +46,7 @@ func NewClockSourceTuner(fs afero.Fs, executor executors.Executor) Tunable {
 		NewClockSourceChecker(fs),
 		func() TuneResult {
 			err := executor.Execute(commands.NewWriteFileCmd(fs,
-				"/sys/devices/system/clocksource/clocksource0/current_clocksource",
+				CurrentClockSourceFile,
 				prefferedClkSource))
 			if err != nil {
 				return NewTuneError(err)
@@ -50,8 +54,7 @@ func NewClockSourceTuner(fs afero.Fs, executor executors.Executor) Tunable {
 			return NewTuneResult(false)
 		},
 		func() (bool, string) {
-			content, err := afero.ReadFile(fs,
-				"/sys/devices/system/clocksource/clocksource0/available_clocksource")
+			content, err := afero.ReadFile(fs, AvailableClockSourcesFile)
 			if err != nil {
 				return false, err.Error()
 			}
@@ -63,7 +66,11 @@ func NewClockSourceTuner(fs afero.Fs, executor executors.Executor) Tunable {
 				}
 			}
 			return false, fmt.Sprintf(
-				"Preffered clocksource '%s' not avaialable", prefferedClkSource)
+				"Preferred clocksource '%s' isn't available. Available"+
+					" clocksources: %s",
+				prefferedClkSource,
+				strings.Join(availableSrcs, ", "),
+			)
 		},
 		executor.IsLazy(),
 	)
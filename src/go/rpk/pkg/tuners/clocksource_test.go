@@ -0,0 +1,70 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+)
+
+func TestClockSourceTuner(t *testing.T) {
+	tests := []struct {
+		name			string
+		current			string
+		available		string
+		expectedSupported	bool
+		expectedReason		string
+		expectedCurrent		string
+	}{
+		{
+			name:			"it should set the clocksource to tsc",
+			current:		"xen\n",
+			available:		"xen tsc hpet acpi_pm\n",
+			expectedSupported:	true,
+			expectedCurrent:	"tsc",
+		},
+		{
+			name:			"it shouldn't be supported if tsc isn't available",
+			current:		"xen\n",
+			available:		"xen hpet acpi_pm\n",
+			expectedReason:		"Preferred clocksource 'tsc' isn't available. Available clocksources: xen, hpet, acpi_pm",
+			expectedCurrent:	"xen\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			err := afero.WriteFile(fs, tuners.CurrentClockSourceFile, []byte(tt.current), 0644)
+			require.NoError(st, err)
+			err = afero.WriteFile(fs, tuners.AvailableClockSourcesFile, []byte(tt.available), 0644)
+			require.NoError(st, err)
+
+			tuner := tuners.NewClockSourceTuner(fs, executors.NewDirectExecutor())
+			supported, reason := tuner.CheckIfSupported()
+			require.Equal(st, tt.expectedSupported, supported)
+			require.Equal(st, tt.expectedReason, reason)
+			if supported {
+				res := tuner.Tune()
+				require.NoError(st, res.Error())
+			}
+			current, err := afero.ReadFile(fs, tuners.CurrentClockSourceFile)
+			require.NoError(st, err)
+			require.Equal(st, tt.expectedCurrent, string(current))
+
+			res := tuners.NewClockSourceChecker(fs).Check()
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectedSupported, res.IsOk)
+		})
+	}
+}
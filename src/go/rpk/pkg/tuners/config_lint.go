@@ -0,0 +1,192 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/go-units"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/redpanda"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
+)
+
+// The minimum amount of memory that should be left to the OS when --memory
+// is set.
+const minOSMemoryMB = 1024
+
+var productionPathPrefixes = []string{"/etc/redpanda", "/var/lib/redpanda"}
+
+// Flags which have a dedicated field in the rpk config, and which therefore
+// shouldn't be set through rpk.additional_start_flags.
+var deprecatedStartFlags = map[string]string{
+	"smp":			"rpk.smp",
+	"overprovisioned":	"rpk.overprovisioned",
+	"lock-memory":		"rpk.enable_memory_locking",
+}
+
+type LintResult struct {
+	Rule		string
+	Severity	Severity
+	Category	Category
+	Message		string
+}
+
+type lintRule struct {
+	name		string
+	severity	Severity
+	category	Category
+	// Returns the list of issues found by the rule, if any.
+	lint	func(*config.Config) []string
+}
+
+// Applies the set of lint rules to the given configuration, returning a result
+// for each issue found.
+func LintConfig(fs afero.Fs, conf *config.Config) []LintResult {
+	return lintConfig(conf, lintRules(func() (int, error) {
+		return system.GetMemTotalMB(fs)
+	}))
+}
+
+func lintConfig(conf *config.Config, rules []lintRule) []LintResult {
+	results := []LintResult{}
+	for _, r := range rules {
+		for _, msg := range r.lint(conf) {
+			results = append(results, LintResult{
+				Rule:		r.name,
+				Severity:	r.severity,
+				Category:	r.category,
+				Message:	msg,
+			})
+		}
+	}
+	return results
+}
+
+func lintRules(getMemTotalMB func() (int, error)) []lintRule {
+	return []lintRule{
+		{
+			name:		"developer_mode_in_production",
+			severity:	Warning,
+			category:	ConfigCategory,
+			lint:		lintDeveloperMode,
+		},
+		{
+			name:		"missing_seed_servers",
+			severity:	Warning,
+			category:	ClusterCategory,
+			lint:		lintSeedServers,
+		},
+		{
+			name:		"deprecated_start_flags",
+			severity:	Warning,
+			category:	ConfigCategory,
+			lint:		lintDeprecatedStartFlags,
+		},
+		{
+			name:		"aggressive_memory",
+			severity:	Fatal,
+			category:	SystemCategory,
+			lint: func(conf *config.Config) []string {
+				return lintMemory(conf, getMemTotalMB)
+			},
+		},
+	}
+}
+
+func lintDeveloperMode(conf *config.Config) []string {
+	if !conf.Redpanda.DeveloperMode {
+		return nil
+	}
+	paths := [][2]string{
+		{"config file", conf.ConfigFile},
+		{"data directory", conf.Redpanda.Directory},
+	}
+	issues := []string{}
+	for _, p := range paths {
+		for _, prefix := range productionPathPrefixes {
+			if strings.HasPrefix(p[1], prefix) {
+				issues = append(issues, fmt.Sprintf(
+					"redpanda.developer_mode is enabled, but the"+
+						" %s '%s' is in a production path",
+					p[0],
+					p[1],
+				))
+				break
+			}
+		}
+	}
+	return issues
+}
+
+func lintSeedServers(conf *config.Config) []string {
+	if conf.Redpanda.Id == 0 || len(conf.Redpanda.SeedServers) != 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf(
+		"redpanda.node_id is %d but redpanda.seed_servers is empty."+
+			" The node won't be able to join an existing cluster",
+		conf.Redpanda.Id,
+	)}
+}
+
+func lintDeprecatedStartFlags(conf *config.Config) []string {
+	issues := []string{}
+	flags := redpanda.ParseFlags(conf.Rpk.AdditionalStartFlags)
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if key, ok := deprecatedStartFlags[name]; ok {
+			issues = append(issues, fmt.Sprintf(
+				"'--%s' is set in rpk.additional_start_flags."+
+					" Use '%s' instead",
+				name,
+				key,
+			))
+		}
+	}
+	return issues
+}
+
+func lintMemory(
+	conf *config.Config, getMemTotalMB func() (int, error),
+) []string {
+	memory := redpanda.ParseFlags(conf.Rpk.AdditionalStartFlags)["memory"]
+	if memory == "" {
+		return nil
+	}
+	memBytes, err := units.RAMInBytes(memory)
+	if err != nil {
+		return []string{fmt.Sprintf("'--memory=%s' isn't a valid size", memory)}
+	}
+	totalMB, err := getMemTotalMB()
+	if err != nil {
+		log.Debugf("Couldn't read the total system memory: %v", err)
+		return nil
+	}
+	requestedMB := int(memBytes / units.MiB)
+	if requestedMB > totalMB-minOSMemoryMB {
+		return []string{fmt.Sprintf(
+			"'--memory=%s' leaves less than %dMB of the system's"+
+				" %dMB to the OS",
+			memory,
+			minOSMemoryMB,
+			totalMB,
+		)}
+	}
+	return nil
+}
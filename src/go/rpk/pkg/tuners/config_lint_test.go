@@ -0,0 +1,129 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+func TestLintConfig(t *testing.T) {
+	tests := []struct {
+		name		string
+		conf		func() *config.Config
+		memTotalMB	int
+		expected	[]string
+	}{
+		{
+			name:	"it should warn if developer_mode is enabled in production paths",
+			conf: func() *config.Config {
+				conf := config.Default()
+				conf.Redpanda.DeveloperMode = true
+				return conf
+			},
+			expected:	[]string{"developer_mode_in_production", "developer_mode_in_production"},
+		},
+		{
+			name:	"it shouldn't report anything if developer_mode is enabled elsewhere",
+			conf: func() *config.Config {
+				conf := config.Default()
+				conf.ConfigFile = "/home/user/redpanda.yaml"
+				conf.Redpanda.Directory = "/home/user/data"
+				return conf
+			},
+			expected:	[]string{},
+		},
+		{
+			name:	"it should warn if node_id isn't 0 and there are no seeds",
+			conf: func() *config.Config {
+				conf := config.Default()
+				conf.Redpanda.DeveloperMode = false
+				conf.Redpanda.Id = 2
+				return conf
+			},
+			expected:	[]string{"missing_seed_servers"},
+		},
+		{
+			name:	"it should warn about flags with a dedicated config field",
+			conf: func() *config.Config {
+				conf := config.Default()
+				conf.Redpanda.DeveloperMode = false
+				conf.Rpk.AdditionalStartFlags = []string{
+					"--smp=2",
+					"--overprovisioned",
+					"--default-log-level=info",
+				}
+				return conf
+			},
+			expected:	[]string{"deprecated_start_flags", "deprecated_start_flags"},
+		},
+		{
+			name:	"it should warn about flags whose value is separated by spaces",
+			conf: func() *config.Config {
+				conf := config.Default()
+				conf.Redpanda.DeveloperMode = false
+				conf.Rpk.AdditionalStartFlags = []string{
+					"--smp 2",
+					"--lock-memory", "true",
+				}
+				return conf
+			},
+			expected:	[]string{"deprecated_start_flags", "deprecated_start_flags"},
+		},
+		{
+			name:	"it should fail if --memory leaves too little for the OS",
+			conf: func() *config.Config {
+				conf := config.Default()
+				conf.Redpanda.DeveloperMode = false
+				conf.Rpk.AdditionalStartFlags = []string{"--memory=4G"}
+				return conf
+			},
+			memTotalMB:	4096,
+			expected:	[]string{"aggressive_memory"},
+		},
+		{
+			name:	"it should fail if --memory, separated by a space, leaves too little for the OS",
+			conf: func() *config.Config {
+				conf := config.Default()
+				conf.Redpanda.DeveloperMode = false
+				conf.Rpk.AdditionalStartFlags = []string{"--memory 4G"}
+				return conf
+			},
+			memTotalMB:	4096,
+			expected:	[]string{"aggressive_memory"},
+		},
+		{
+			name:	"it shouldn't report a --memory value that leaves enough for the OS",
+			conf: func() *config.Config {
+				conf := config.Default()
+				conf.Redpanda.DeveloperMode = false
+				conf.Rpk.AdditionalStartFlags = []string{"--memory=2G"}
+				return conf
+			},
+			memTotalMB:	8192,
+			expected:	[]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			rules := lintRules(func() (int, error) {
+				return tt.memTotalMB, nil
+			})
+			results := lintConfig(tt.conf(), rules)
+			names := []string{}
+			for _, r := range results {
+				names = append(names, r.Rule)
+			}
+			require.Exactly(st, tt.expected, names)
+		})
+	}
+}
@@ -11,13 +11,18 @@ package coredump
 
 import (
 	"bytes"
+	"fmt"
+	"os"
+	"strings"
 	"text/template"
 
+	"github.com/docker/go-units"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
-	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+	"golang.org/x/sys/unix"
 )
 
 const (
@@ -39,20 +44,44 @@ cat - > "${COREDUMP_PATH}"
 `
 )
 
+// Makes the kernel pipe core dumps to a script which saves them to the
+// configured directory, through /proc/sys/kernel/core_pattern, and raises
+// RLIMIT_CORE for rpk's process, and therefore redpanda's, so that they're
+// actually dumped. 'rpk tune coredump --revert' restores the previous
+// core_pattern.
 type tuner struct {
 	fs		afero.Fs
-	conf		config.Config
+	coredumpDir	string
+	getLimit	func() (*unix.Rlimit, error)
+	setLimit	func(*unix.Rlimit) error
 	executor	executors.Executor
+	tuned		bool
+	previous	string
+	coreLimit	string
 }
 
 func NewCoredumpTuner(
-	fs afero.Fs, conf config.Config, executor executors.Executor,
+	fs afero.Fs,
+	coredumpDir string,
+	getLimit func() (*unix.Rlimit, error),
+	setLimit func(*unix.Rlimit) error,
+	executor executors.Executor,
 ) tuners.Tunable {
-	return &tuner{fs, conf, executor}
+	return &tuner{
+		fs:		fs,
+		coredumpDir:	coredumpDir,
+		getLimit:	getLimit,
+		setLimit:	setLimit,
+		executor:	executor,
+	}
 }
 
 func (t *tuner) Tune() tuners.TuneResult {
-	script, err := renderTemplate(coredumpScriptTmpl, t.conf.Rpk)
+	script, err := renderTemplate(coredumpScriptTmpl, t.coredumpDir)
+	if err != nil {
+		return tuners.NewTuneError(err)
+	}
+	previous, err := readPattern(t.fs)
 	if err != nil {
 		return tuners.NewTuneError(err)
 	}
@@ -64,20 +93,125 @@ func (t *tuner) Tune() tuners.TuneResult {
 	if err != nil {
 		return tuners.NewTuneError(err)
 	}
+	t.tuned, t.previous = true, previous
+	// The limit only applies to rpk's process and its children, so there's
+	// nothing to raise when the commands are just being written to a script.
+	if t.executor.IsLazy() {
+		return tuners.NewTuneResult(false)
+	}
+	limit, err := t.raiseCoreLimit()
+	if err != nil {
+		return tuners.NewTuneError(err)
+	}
+	t.coreLimit = limit
 	return tuners.NewTuneResult(false)
 }
 
-func (*tuner) CheckIfSupported() (supported bool, reason string) {
+func (t *tuner) CheckIfSupported() (supported bool, reason string) {
+	f, err := t.fs.OpenFile(corePatternFilePath, os.O_WRONLY, 0)
+	if err != nil {
+		return false, fmt.Sprintf(
+			"'%s' isn't writable: %v",
+			corePatternFilePath,
+			err,
+		)
+	}
+	f.Close()
 	return true, ""
 }
 
-func renderTemplate(templateStr string, conf config.RpkConfig) (string, error) {
+// Returns the new core_pattern and the directory the cores are saved to.
+func (t *tuner) Details() map[string]string {
+	if !t.tuned {
+		return nil
+	}
+	details := map[string]string{
+		"core_pattern":	coredumpPattern,
+		"coredump_dir":	t.coredumpDir,
+	}
+	if t.coreLimit != "" {
+		details["core_limit"] = t.coreLimit
+	}
+	return details
+}
+
+func (t *tuner) PreviousValues() map[string]string {
+	if !t.tuned || t.previous == "" {
+		return nil
+	}
+	return map[string]string{corePatternFilePath: t.previous}
+}
+
+func (t *tuner) Revert(previous map[string]string) tuners.TuneResult {
+	for file, value := range previous {
+		err := t.executor.Execute(commands.NewWriteFileCmd(t.fs, file, value))
+		if err != nil {
+			return tuners.NewTuneError(err)
+		}
+	}
+	return tuners.NewTuneResult(false)
+}
+
+// Raises RLIMIT_CORE to unlimited, or to the hard limit if it can't be raised
+// (which requires CAP_SYS_RESOURCE), and returns the new soft limit.
+func (t *tuner) raiseCoreLimit() (string, error) {
+	limit, err := t.getLimit()
+	if err != nil {
+		return "", err
+	}
+	if limit.Cur == unix.RLIM_INFINITY {
+		return formatCoreLimit(limit.Cur), nil
+	}
+	raised := &unix.Rlimit{Cur: unix.RLIM_INFINITY, Max: unix.RLIM_INFINITY}
+	if err = t.setLimit(raised); err != nil {
+		log.Debugf("Couldn't set the max core size to unlimited: %v", err)
+		raised = &unix.Rlimit{Cur: limit.Max, Max: limit.Max}
+		if err = t.setLimit(raised); err != nil {
+			return "", fmt.Errorf(
+				"couldn't raise the max core size from %s: %v."+
+					" Raise it with 'ulimit -c' or systemd's"+
+					" LimitCORE",
+				formatCoreLimit(limit.Cur),
+				err,
+			)
+		}
+	}
+	log.Infof(
+		"Raised the max core size from %s to %s",
+		formatCoreLimit(limit.Cur),
+		formatCoreLimit(raised.Cur),
+	)
+	return formatCoreLimit(raised.Cur), nil
+}
+
+func formatCoreLimit(limit uint64) string {
+	if limit == unix.RLIM_INFINITY {
+		return "unlimited"
+	}
+	return units.BytesSize(float64(limit))
+}
+
+// Returns the current core_pattern, or "" if it doesn't exist.
+func readPattern(fs afero.Fs) (string, error) {
+	exists, err := afero.Exists(fs, corePatternFilePath)
+	if err != nil || !exists {
+		return "", err
+	}
+	content, err := afero.ReadFile(fs, corePatternFilePath)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func renderTemplate(templateStr string, coredumpDir string) (string, error) {
 	tmpl, err := template.New("template").Parse(templateStr)
 	if err != nil {
 		return "", err
 	}
 	var buf bytes.Buffer
-	if err = tmpl.Execute(&buf, conf); err != nil {
+	err = tmpl.Execute(&buf, struct{ CoredumpDir string }{coredumpDir})
+	if err != nil {
 		return "", err
 	}
 	return buf.String(), nil
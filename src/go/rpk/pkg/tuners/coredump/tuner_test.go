@@ -10,13 +10,16 @@
 package coredump
 
 import (
+	"errors"
 	"os"
 	"testing"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"golang.org/x/sys/unix"
 )
 
 func validConfig() *config.Config {
@@ -53,7 +56,13 @@ func TestTune(t *testing.T) {
 				err := tt.pre(fs)
 				require.NoError(t, err)
 			}
-			tuner := NewCoredumpTuner(fs, *conf, executors.NewDirectExecutor())
+			tuner := NewCoredumpTuner(
+				fs,
+				conf.Rpk.CoredumpDir,
+				getLimit(unix.Rlimit{Cur: 0, Max: 0}),
+				setLimit(&unix.Rlimit{}, nil),
+				executors.NewDirectExecutor(),
+			)
 			res := tuner.Tune()
 			require.NoError(t, res.Error())
 			pattern, err := fs.Open(corePatternFilePath)
@@ -65,7 +74,7 @@ func TestTune(t *testing.T) {
 			// Check that the script is world-readable, writable and executable
 			expectedMode := os.FileMode(int(0777))
 			require.Equal(t, expectedMode, info.Mode())
-			expectedScript, err := renderTemplate(coredumpScriptTmpl, conf.Rpk)
+			expectedScript, err := renderTemplate(coredumpScriptTmpl, conf.Rpk.CoredumpDir)
 			require.NoError(t, err)
 			buf := make([]byte, len(expectedScript))
 			_, err = script.Read(buf)
@@ -80,3 +89,131 @@ func TestTune(t *testing.T) {
 		})
 	}
 }
+
+func getLimit(limit unix.Rlimit) func() (*unix.Rlimit, error) {
+	return func() (*unix.Rlimit, error) {
+		return &limit, nil
+	}
+}
+
+// Records the limit it's called with into set, failing with err if the limit
+// is unlimited.
+func setLimit(set *unix.Rlimit, err error) func(*unix.Rlimit) error {
+	return func(limit *unix.Rlimit) error {
+		if err != nil && limit.Cur == unix.RLIM_INFINITY {
+			return err
+		}
+		*set = *limit
+		return nil
+	}
+}
+
+func TestCheckIfSupported(t *testing.T) {
+	tests := []struct {
+		name			string
+		fs			func() afero.Fs
+		expectedSupported	bool
+		expectedReason		string
+	}{
+		{
+			name:	"it should be supported if core_pattern is writable",
+			fs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				_ = afero.WriteFile(fs, corePatternFilePath, []byte("core\n"), 0644)
+				return fs
+			},
+			expectedSupported:	true,
+		},
+		{
+			name:	"it shouldn't be supported if core_pattern is read-only",
+			fs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				_ = afero.WriteFile(fs, corePatternFilePath, []byte("core\n"), 0644)
+				return afero.NewReadOnlyFs(fs)
+			},
+			expectedReason:	"'/proc/sys/kernel/core_pattern' isn't writable: operation not permitted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			tuner := NewCoredumpTuner(
+				tt.fs(),
+				"/var/lib/redpanda/coredump",
+				getLimit(unix.Rlimit{}),
+				setLimit(&unix.Rlimit{}, nil),
+				executors.NewDirectExecutor(),
+			)
+			supported, reason := tuner.CheckIfSupported()
+			require.Equal(st, tt.expectedSupported, supported)
+			require.Equal(st, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestTuneCoreLimit(t *testing.T) {
+	tests := []struct {
+		name		string
+		limit		unix.Rlimit
+		setErr		error
+		expected	unix.Rlimit
+		expectedLimit	string
+	}{
+		{
+			name:		"it should raise the max core size to unlimited",
+			limit:		unix.Rlimit{Cur: 0, Max: 1024},
+			expected:	unix.Rlimit{Cur: unix.RLIM_INFINITY, Max: unix.RLIM_INFINITY},
+			expectedLimit:	"unlimited",
+		},
+		{
+			name:		"it should raise it to the hard limit if it can't be unlimited",
+			limit:		unix.Rlimit{Cur: 0, Max: 1024},
+			setErr:		errors.New("operation not permitted"),
+			expected:	unix.Rlimit{Cur: 1024, Max: 1024},
+			expectedLimit:	"1KiB",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			set := unix.Rlimit{}
+			tuner := NewCoredumpTuner(
+				fs,
+				"/var/lib/redpanda/coredump",
+				getLimit(tt.limit),
+				setLimit(&set, tt.setErr),
+				executors.NewDirectExecutor(),
+			)
+			res := tuner.Tune()
+			require.NoError(st, res.Error())
+			require.Equal(st, tt.expected, set)
+			details := tuners.TuneDetails(tuner)
+			require.Equal(st, tt.expectedLimit, details["core_limit"])
+			require.Equal(st, "/var/lib/redpanda/coredump", details["coredump_dir"])
+		})
+	}
+}
+
+func TestRevert(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, corePatternFilePath, []byte("core\n"), 0644)
+	require.NoError(t, err)
+	tuner := NewCoredumpTuner(
+		fs,
+		"/var/lib/redpanda/coredump",
+		getLimit(unix.Rlimit{Cur: unix.RLIM_INFINITY, Max: unix.RLIM_INFINITY}),
+		setLimit(&unix.Rlimit{}, nil),
+		executors.NewDirectExecutor(),
+	)
+	res := tuner.Tune()
+	require.NoError(t, res.Error())
+	rt, ok := tuner.(tuners.RevertibleTunable)
+	require.True(t, ok)
+	previous := rt.PreviousValues()
+	require.Equal(t, map[string]string{corePatternFilePath: "core"}, previous)
+
+	res = rt.Revert(previous)
+	require.NoError(t, res.Error())
+	pattern, err := afero.ReadFile(fs, corePatternFilePath)
+	require.NoError(t, err)
+	require.Equal(t, "core", string(pattern))
+}
@@ -0,0 +1,248 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/hwloc"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/irq"
+)
+
+const cpuSysDir = "/sys/devices/system/cpu"
+
+// Disables the CPU idle states (C-states) whose exit latency is higher than
+// rpk.cstate_max_latency_us, on the CPUs redpanda runs on, through
+// /sys/devices/system/cpu/cpu<n>/cpuidle/state<m>/disable. Waking up from deep
+// C-states adds latency to requests arriving on otherwise idle CPUs.
+type cStateTuner struct {
+	fs		afero.Fs
+	cpuMasks	irq.CpuMasks
+	cpuMask		string
+	maxLatency	int
+	executor	executors.Executor
+	// The disable files' previous values.
+	previous	map[string]string
+	// The states which were disabled, with the number of CPUs and their
+	// exit latency, keyed by name (e.g. 'C6').
+	disabled	map[string]string
+}
+
+type cState struct {
+	name		string
+	latency		int
+	disableFile	string
+}
+
+func NewCStateTuner(
+	fs afero.Fs,
+	cpuMasks irq.CpuMasks,
+	cpuMask string,
+	maxLatency int,
+	executor executors.Executor,
+) Tunable {
+	return &cStateTuner{
+		fs:		fs,
+		cpuMasks:	cpuMasks,
+		cpuMask:	cpuMask,
+		maxLatency:	maxLatency,
+		executor:	executor,
+	}
+}
+
+func (t *cStateTuner) CheckIfSupported() (supported bool, reason string) {
+	if t.maxLatency < 0 {
+		return false, fmt.Sprintf(
+			"rpk.cstate_max_latency_us can't be negative, but it's %d",
+			t.maxLatency,
+		)
+	}
+	cpus, err := t.cpus()
+	if err != nil {
+		return false, err.Error()
+	}
+	for _, cpu := range cpus {
+		states, err := t.states(cpu)
+		if err != nil {
+			return false, err.Error()
+		}
+		if len(states) == 0 {
+			return false, fmt.Sprintf(
+				"CPU %d has no idle states in '%s'. cpuidle"+
+					" might be disabled",
+				cpu,
+				cpuIdleDir(cpu),
+			)
+		}
+	}
+	return true, ""
+}
+
+func (t *cStateTuner) Tune() TuneResult {
+	cpus, err := t.cpus()
+	if err != nil {
+		return NewTuneError(err)
+	}
+	previous := map[string]string{}
+	disabledCPUs := map[string]int{}
+	latencies := map[string]int{}
+	for _, cpu := range cpus {
+		states, err := t.states(cpu)
+		if err != nil {
+			return NewTuneError(err)
+		}
+		for _, state := range states {
+			if state.latency <= t.maxLatency {
+				continue
+			}
+			current, err := readTrimmed(t.fs, state.disableFile)
+			if err != nil {
+				return NewTuneError(err)
+			}
+			previous[state.disableFile] = current
+			disabledCPUs[state.name]++
+			latencies[state.name] = state.latency
+			if current == "1" {
+				continue
+			}
+			log.Debugf(
+				"Disabling idle state %s (%dus) on CPU %d",
+				state.name,
+				state.latency,
+				cpu,
+			)
+			err = t.executor.Execute(
+				commands.NewWriteFileCmd(t.fs, state.disableFile, "1"),
+			)
+			if err != nil {
+				return NewTuneError(err)
+			}
+		}
+	}
+	disabled := map[string]string{}
+	for name, count := range disabledCPUs {
+		disabled[name] = fmt.Sprintf(
+			"disabled on %d CPUs (exit latency %dus)",
+			count,
+			latencies[name],
+		)
+	}
+	t.previous, t.disabled = previous, disabled
+	return NewTuneResult(false)
+}
+
+// Returns the disabled idle states, e.g. C6 => "disabled on 16 CPUs (exit
+// latency 133us)".
+func (t *cStateTuner) Details() map[string]string {
+	return t.disabled
+}
+
+func (t *cStateTuner) PreviousValues() map[string]string {
+	return t.previous
+}
+
+func (t *cStateTuner) Revert(previous map[string]string) TuneResult {
+	files := make([]string, 0, len(previous))
+	for file := range previous {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		err := t.executor.Execute(
+			commands.NewWriteFileCmd(t.fs, file, previous[file]),
+		)
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	return NewTuneResult(false)
+}
+
+// Returns the CPUs in the tuner's CPU mask. For 'all', they're listed from
+// sysfs, so hwloc isn't needed.
+func (t *cStateTuner) cpus() ([]uint, error) {
+	if t.cpuMask != "all" {
+		mask, err := t.cpuMasks.BaseCpuMask(t.cpuMask)
+		if err != nil {
+			return nil, err
+		}
+		return hwloc.CpusInMask(mask)
+	}
+	dirs, err := afero.Glob(t.fs, filepath.Join(cpuSysDir, "cpu[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	cpus := []uint{}
+	for _, dir := range dirs {
+		cpu, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "cpu"))
+		if err != nil {
+			continue
+		}
+		cpus = append(cpus, uint(cpu))
+	}
+	if len(cpus) == 0 {
+		return nil, fmt.Errorf("no CPUs were found in '%s'", cpuSysDir)
+	}
+	sort.Slice(cpus, func(i, j int) bool { return cpus[i] < cpus[j] })
+	return cpus, nil
+}
+
+// Returns the CPU's idle states which can be disabled.
+func (t *cStateTuner) states(cpu uint) ([]cState, error) {
+	dirs, err := afero.Glob(t.fs, filepath.Join(cpuIdleDir(cpu), "state*"))
+	if err != nil {
+		return nil, err
+	}
+	var states []cState
+	for _, dir := range dirs {
+		disableFile := filepath.Join(dir, "disable")
+		if exists, _ := afero.Exists(t.fs, disableFile); !exists {
+			continue
+		}
+		name, err := readTrimmed(t.fs, filepath.Join(dir, "name"))
+		if err != nil {
+			return nil, err
+		}
+		rawLatency, err := readTrimmed(t.fs, filepath.Join(dir, "latency"))
+		if err != nil {
+			return nil, err
+		}
+		latency, err := strconv.Atoi(rawLatency)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"couldn't parse the latency of '%s': %v",
+				dir,
+				err,
+			)
+		}
+		states = append(states, cState{name, latency, disableFile})
+	}
+	return states, nil
+}
+
+func cpuIdleDir(cpu uint) string {
+	return filepath.Join(cpuSysDir, fmt.Sprintf("cpu%d", cpu), "cpuidle")
+}
+
+func readTrimmed(fs afero.Fs, file string) (string, error) {
+	content, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+)
+
+// Writes the idle states of the given CPUs: POLL (0us), C1 (2us) and C6
+// (133us), all of them enabled.
+func writeCStates(fs afero.Fs, cpus ...int) error {
+	states := []struct {
+		name	string
+		latency	int
+	}{{"POLL", 0}, {"C1", 2}, {"C6", 133}}
+	for _, cpu := range cpus {
+		for i, s := range states {
+			dir := fmt.Sprintf("%s/state%d", cpuIdleDir(uint(cpu)), i)
+			files := map[string]string{
+				"name":		s.name + "\n",
+				"latency":	fmt.Sprintf("%d\n", s.latency),
+				"disable":	"0\n",
+			}
+			for name, content := range files {
+				err := afero.WriteFile(fs, dir+"/"+name, []byte(content), 0644)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestCStateTuner(t *testing.T) {
+	tests := []struct {
+		name			string
+		cpuMask			string
+		maxLatency		int
+		before			func(afero.Fs) error
+		expectedSupported	bool
+		expectedReason		string
+		expectedDisabled	map[string]bool
+		expectedDetails		map[string]string
+	}{
+		{
+			name:		"it should disable the deep states on all CPUs",
+			cpuMask:	"all",
+			maxLatency:	10,
+			before: func(fs afero.Fs) error {
+				return writeCStates(fs, 0, 1)
+			},
+			expectedSupported:	true,
+			expectedDisabled: map[string]bool{
+				"/sys/devices/system/cpu/cpu0/cpuidle/state2/disable":	true,
+				"/sys/devices/system/cpu/cpu1/cpuidle/state2/disable":	true,
+			},
+			expectedDetails: map[string]string{
+				"C6": "disabled on 2 CPUs (exit latency 133us)",
+			},
+		},
+		{
+			name:		"it should only tune the CPUs in the mask",
+			cpuMask:	"0x00000002",
+			maxLatency:	0,
+			before: func(fs afero.Fs) error {
+				return writeCStates(fs, 0, 1)
+			},
+			expectedSupported:	true,
+			expectedDisabled: map[string]bool{
+				"/sys/devices/system/cpu/cpu1/cpuidle/state1/disable":	true,
+				"/sys/devices/system/cpu/cpu1/cpuidle/state2/disable":	true,
+			},
+			expectedDetails: map[string]string{
+				"C1":	"disabled on 1 CPUs (exit latency 2us)",
+				"C6":	"disabled on 1 CPUs (exit latency 133us)",
+			},
+		},
+		{
+			name:		"it shouldn't be supported if cpuidle isn't available",
+			cpuMask:	"all",
+			maxLatency:	10,
+			before: func(fs afero.Fs) error {
+				return fs.MkdirAll("/sys/devices/system/cpu/cpu0", 0755)
+			},
+			expectedReason:	"CPU 0 has no idle states in '/sys/devices/system/cpu/cpu0/cpuidle'. cpuidle might be disabled",
+		},
+		{
+			name:			"it shouldn't be supported if the latency is negative",
+			cpuMask:		"all",
+			maxLatency:		-1,
+			expectedReason:		"rpk.cstate_max_latency_us can't be negative, but it's -1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.before != nil {
+				require.NoError(st, tt.before(fs))
+			}
+			cpuMasks := &cpuMasksMock{
+				baseCpuMask: func(mask string) (string, error) {
+					return mask, nil
+				},
+			}
+			tuner := NewCStateTuner(
+				fs,
+				cpuMasks,
+				tt.cpuMask,
+				tt.maxLatency,
+				executors.NewDirectExecutor(),
+			)
+			supported, reason := tuner.CheckIfSupported()
+			require.Equal(st, tt.expectedSupported, supported)
+			require.Equal(st, tt.expectedReason, reason)
+			if !supported {
+				return
+			}
+			res := tuner.Tune()
+			require.False(st, res.IsFailed())
+			files, err := afero.Glob(fs, "/sys/devices/system/cpu/cpu*/cpuidle/state*/disable")
+			require.NoError(st, err)
+			for _, file := range files {
+				value, err := afero.ReadFile(fs, file)
+				require.NoError(st, err)
+				expected := "0"
+				if tt.expectedDisabled[file] {
+					expected = "1"
+				}
+				require.Equal(st, expected, strings.TrimSpace(string(value)), file)
+			}
+			require.Equal(st, tt.expectedDetails, TuneDetails(tuner))
+
+			rt := tuner.(RevertibleTunable)
+			res = rt.Revert(rt.PreviousValues())
+			require.False(st, res.IsFailed())
+			for _, file := range files {
+				value, err := afero.ReadFile(fs, file)
+				require.NoError(st, err)
+				require.Equal(st, "0", strings.TrimSpace(string(value)), file)
+			}
+		})
+	}
+}
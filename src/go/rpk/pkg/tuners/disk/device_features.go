@@ -33,6 +33,8 @@ type DeviceFeatures interface {
 	GetSchedulerFeatureFile(device string) (string, error)
 	GetWriteCache(device string) (string, error)
 	GetWriteCacheFeatureFile(device string) (string, error)
+	GetRqAffinityFeatureFile(device string) (string, error)
+	IsRotational(device string) (bool, error)
 }
 
 func NewDeviceFeatures(fs afero.Fs, blockDevices BlockDevices) DeviceFeatures {
@@ -108,6 +110,30 @@ func (d *deviceFeatures) GetWriteCacheFeatureFile(
 	return d.getQueueFeatureFile(deviceNode(device), "write_cache")
 }
 
+func (d *deviceFeatures) GetRqAffinityFeatureFile(
+	device string,
+) (string, error) {
+	return d.getQueueFeatureFile(deviceNode(device), "rq_affinity")
+}
+
+func (d *deviceFeatures) IsRotational(device string) (bool, error) {
+	featureFile, err := d.getQueueFeatureFile(deviceNode(device), "rotational")
+	if err != nil {
+		return false, err
+	}
+	if featureFile == "" {
+		return false, fmt.Errorf(
+			"couldn't find whether device '%s' is rotational",
+			device,
+		)
+	}
+	bytes, err := afero.ReadFile(d.fs, featureFile)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(bytes)) == "1", nil
+}
+
 func (d *deviceFeatures) getSchedulerOptions(
 	device string,
 ) (*system.RuntimeOptions, error) {
@@ -0,0 +1,34 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package disk
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const sysBlockDir = "/sys/block"
+
+// Returns the file backing the given loop device (e.g. 'loop0'), or an empty
+// string if the device isn't a loop device or isn't attached to a file.
+func GetLoopBackingFile(fs afero.Fs, device string) (string, error) {
+	path := filepath.Join(sysBlockDir, device, "loop", "backing_file")
+	exists, err := afero.Exists(fs, path)
+	if err != nil || !exists {
+		return "", err
+	}
+	content, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
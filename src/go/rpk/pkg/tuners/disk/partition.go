@@ -0,0 +1,32 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package disk
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// Returns the disk the given device (e.g. 'sda1') is a partition of, or the
+// device itself if it's not a partition. Partitions show up in sysfs as
+// /sys/block/<disk>/<partition>.
+func GetParentDevice(fs afero.Fs, device string) (string, error) {
+	matches, err := afero.Glob(fs, filepath.Join(sysBlockDir, "*", device))
+	if err != nil {
+		return "", err
+	}
+	for _, match := range matches {
+		if exists, _ := afero.Exists(fs, filepath.Join(match, "partition")); exists {
+			return filepath.Base(filepath.Dir(match)), nil
+		}
+	}
+	return device, nil
+}
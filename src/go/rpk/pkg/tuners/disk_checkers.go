@@ -11,6 +11,7 @@ package tuners
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/afero"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/disk"
@@ -258,6 +259,8 @@ func NewDisksIRQAffinityChecker(
 	mode irq.Mode,
 	blockDevices disk.BlockDevices,
 	cpuMasks irq.CpuMasks,
+	irqProcFile irq.ProcFile,
+	nvmeTuned bool,
 ) Checker {
 	return NewEqualityChecker(
 		DiskIRQsAffinityChecker,
@@ -271,6 +274,8 @@ func NewDisksIRQAffinityChecker(
 				mode,
 				blockDevices,
 				cpuMasks,
+				irqProcFile,
+				nvmeTuned,
 			)
 		},
 	)
@@ -283,6 +288,8 @@ func NewDirectoryIRQAffinityChecker(
 	mode irq.Mode,
 	blockDevices disk.BlockDevices,
 	cpuMasks irq.CpuMasks,
+	irqProcFile irq.ProcFile,
+	nvmeTuned bool,
 ) Checker {
 	return NewEqualityChecker(
 		DiskIRQsAffinityChecker,
@@ -300,27 +307,57 @@ func NewDirectoryIRQAffinityChecker(
 				mode,
 				blockDevices,
 				cpuMasks,
+				irqProcFile,
+				nvmeTuned,
 			)
 		},
 	)
 }
 
+// Checks the devices' IRQs are distributed as the disk IRQs tuner does it, or,
+// if nvmeTuned is set, with the NVMe IRQs distributed as the NVMe IRQs tuner
+// does it instead.
 func areDevicesIRQsDistributed(
 	devices []string,
 	cpuMask string,
 	mode irq.Mode,
 	blockDevices disk.BlockDevices,
 	cpuMasks irq.CpuMasks,
+	irqProcFile irq.ProcFile,
+	nvmeTuned bool,
 ) (bool, error) {
-	expectedDistribution, err := GetExpectedIRQsDistribution(
+	expectedDistribution, err := getDiskIRQsDistribution(
 		devices,
 		blockDevices,
 		mode,
 		cpuMask,
-		cpuMasks)
+		cpuMasks,
+		irqProcFile,
+		nvmeTuned,
+	)
 	if err != nil {
 		return false, err
 	}
+	if nvmeTuned {
+		nvmeIRQs, err := GetNvmeIRQs(devices, irqProcFile)
+		if err != nil {
+			return false, err
+		}
+		if len(nvmeIRQs) > 0 {
+			nvmeDistribution, err := GetExpectedNvmeIRQsDistribution(
+				nvmeIRQs,
+				mode,
+				cpuMask,
+				cpuMasks,
+			)
+			if err != nil {
+				return false, err
+			}
+			for IRQ, mask := range nvmeDistribution {
+				expectedDistribution[IRQ] = mask
+			}
+		}
+	}
 
 	for IRQ, mask := range expectedDistribution {
 		readMask, err := cpuMasks.ReadIRQMask(IRQ)
@@ -337,3 +374,163 @@ func areDevicesIRQsDistributed(
 	}
 	return true, nil
 }
+
+// Warns if the data directory is on a loop device (e.g. one backed by a
+// sparse file), which performs poorly and makes the measured io-properties
+// meaningless.
+type loopDeviceChecker struct {
+	fs		afero.Fs
+	dir		string
+	blockDevices	disk.BlockDevices
+}
+
+func NewDirectoryLoopDeviceChecker(
+	fs afero.Fs, dir string, blockDevices disk.BlockDevices,
+) Checker {
+	return &loopDeviceChecker{fs: fs, dir: dir, blockDevices: blockDevices}
+}
+
+func (*loopDeviceChecker) Id() CheckerID {
+	return LoopDeviceChecker
+}
+
+func (c *loopDeviceChecker) GetDesc() string {
+	return fmt.Sprintf("Dir '%s' on a loop device", c.dir)
+}
+
+func (*loopDeviceChecker) GetSeverity() Severity {
+	return Warning
+}
+
+func (*loopDeviceChecker) GetRequiredAsString() string {
+	return "false"
+}
+
+func (c *loopDeviceChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+	}
+	devices, err := c.blockDevices.GetDirectoryDevices(c.dir)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	var loops []string
+	for _, device := range devices {
+		backingFile, err := disk.GetLoopBackingFile(c.fs, device)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		if backingFile != "" {
+			loops = append(loops, fmt.Sprintf("%s: %s", device, backingFile))
+		}
+	}
+	if len(loops) == 0 {
+		res.Current = "false"
+		res.IsOk = true
+		return res
+	}
+	res.Current = fmt.Sprintf(
+		"true (%s). Performance will be poor, and the measured"+
+			" io-properties won't be representative",
+		strings.Join(loops, ", "),
+	)
+	return res
+}
+
+// Warns if the data directory is on the same physical disk as the root
+// filesystem, where the OS's IO competes with redpanda's.
+type dataDiskSharedChecker struct {
+	fs		afero.Fs
+	dir		string
+	blockDevices	disk.BlockDevices
+}
+
+func NewDataDiskSharedChecker(
+	fs afero.Fs, dir string, blockDevices disk.BlockDevices,
+) Checker {
+	return &dataDiskSharedChecker{fs: fs, dir: dir, blockDevices: blockDevices}
+}
+
+func (*dataDiskSharedChecker) Id() CheckerID {
+	return DataDiskSharedChecker
+}
+
+func (c *dataDiskSharedChecker) GetDesc() string {
+	return fmt.Sprintf("Dir '%s' on the OS disk", c.dir)
+}
+
+func (*dataDiskSharedChecker) GetSeverity() Severity {
+	return Warning
+}
+
+func (*dataDiskSharedChecker) GetRequiredAsString() string {
+	return "false"
+}
+
+func (c *dataDiskSharedChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+	}
+	dataDisks, err := c.disks(c.dir)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	rootDisks, err := c.disks("/")
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	shared := false
+	for _, d := range dataDisks {
+		for _, r := range rootDisks {
+			shared = shared || d == r
+		}
+	}
+	if !shared {
+		res.Current = "false"
+		res.IsOk = true
+		return res
+	}
+	res.Current = fmt.Sprintf(
+		"true (%s on %s, / on %s)",
+		c.dir,
+		devPaths(dataDisks),
+		devPaths(rootDisks),
+	)
+	return res
+}
+
+// Returns the physical disks the given directory is on, resolving partitions
+// to the disk they belong to.
+func (c *dataDiskSharedChecker) disks(dir string) ([]string, error) {
+	devices, err := c.blockDevices.GetDirectoryDevices(dir)
+	if err != nil {
+		return nil, err
+	}
+	var disks []string
+	for _, device := range devices {
+		parent, err := disk.GetParentDevice(c.fs, device)
+		if err != nil {
+			return nil, err
+		}
+		disks = append(disks, parent)
+	}
+	return disks, nil
+}
+
+func devPaths(devices []string) string {
+	paths := make([]string, 0, len(devices))
+	for _, device := range devices {
+		paths = append(paths, "/dev/"+device)
+	}
+	return strings.Join(paths, ", ")
+}
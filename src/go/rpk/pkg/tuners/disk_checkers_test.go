@@ -0,0 +1,146 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoopDeviceChecker(t *testing.T) {
+	tests := []struct {
+		name		string
+		devices		[]string
+		before		func(afero.Fs) error
+		expectedOk	bool
+		expectedCurrent	string
+	}{
+		{
+			name:			"it should pass if the dir isn't on a loop device",
+			devices:		[]string{"nvme0n1"},
+			expectedOk:		true,
+			expectedCurrent:	"false",
+		},
+		{
+			name:		"it should fail if the dir is on a loop device",
+			devices:	[]string{"loop0"},
+			before: func(fs afero.Fs) error {
+				return afero.WriteFile(
+					fs,
+					"/sys/block/loop0/loop/backing_file",
+					[]byte("/images/redpanda.img\n"),
+					0644,
+				)
+			},
+			expectedCurrent:	"true (loop0: /images/redpanda.img). Performance will be poor, and the measured io-properties won't be representative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.before != nil {
+				require.NoError(st, tt.before(fs))
+			}
+			blockDevices := &blockDevicesMock{
+				getDirectoryDevices: func(string) ([]string, error) {
+					return tt.devices, nil
+				},
+			}
+			checker := NewDirectoryLoopDeviceChecker(
+				fs,
+				"/var/lib/redpanda/data",
+				blockDevices,
+			)
+			res := checker.Check()
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectedOk, res.IsOk)
+			require.Equal(st, tt.expectedCurrent, res.Current)
+			require.EqualValues(st, Warning, res.Severity)
+		})
+	}
+}
+
+func TestDataDiskSharedChecker(t *testing.T) {
+	tests := []struct {
+		name		string
+		devices		map[string][]string
+		before		func(afero.Fs) error
+		expectedOk	bool
+		expectedCurrent	string
+	}{
+		{
+			name:	"it should pass if the data dir is on another disk",
+			devices: map[string][]string{
+				"/var/lib/redpanda/data":	{"nvme0n1"},
+				"/":				{"sda1"},
+			},
+			before: func(fs afero.Fs) error {
+				return fs.MkdirAll("/sys/block/sda/sda1/partition", 0755)
+			},
+			expectedOk:		true,
+			expectedCurrent:	"false",
+		},
+		{
+			name:	"it should fail if the data dir is on another partition of the OS disk",
+			devices: map[string][]string{
+				"/var/lib/redpanda/data":	{"sda2"},
+				"/":				{"sda1"},
+			},
+			before: func(fs afero.Fs) error {
+				for _, p := range []string{"sda1", "sda2"} {
+					err := afero.WriteFile(
+						fs,
+						"/sys/block/sda/"+p+"/partition",
+						[]byte("1\n"),
+						0644,
+					)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			expectedCurrent:	"true (/var/lib/redpanda/data on /dev/sda, / on /dev/sda)",
+		},
+		{
+			name:	"it should fail if the data dir is on the root filesystem",
+			devices: map[string][]string{
+				"/var/lib/redpanda/data":	{"nvme0n1"},
+				"/":				{"nvme0n1"},
+			},
+			expectedCurrent:	"true (/var/lib/redpanda/data on /dev/nvme0n1, / on /dev/nvme0n1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.before != nil {
+				require.NoError(st, tt.before(fs))
+			}
+			blockDevices := &blockDevicesMock{
+				getDirectoryDevices: func(dir string) ([]string, error) {
+					return tt.devices[dir], nil
+				},
+			}
+			checker := NewDataDiskSharedChecker(
+				fs,
+				"/var/lib/redpanda/data",
+				blockDevices,
+			)
+			res := checker.Check()
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectedOk, res.IsOk)
+			require.Equal(st, tt.expectedCurrent, res.Current)
+			require.EqualValues(st, Warning, res.Severity)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+)
+
+const (
+	MountsFile	= "/proc/mounts"
+	// The XFS project ID the data directory is assigned to.
+	DiskQuotaProjectID	= 4242
+)
+
+// A filesystem mounted on the machine, as listed in /proc/mounts.
+type mount struct {
+	path	string
+	fsType	string
+	options	[]string
+}
+
+// Limits the space the data directory can take up with an XFS project quota,
+// so that redpanda can't fill the whole volume and starve the OS.
+type diskQuotaTuner struct {
+	fs		afero.Fs
+	dataDir		string
+	size		string
+	proc		os.Proc
+	timeout		time.Duration
+	executor	executors.Executor
+	mountPoint	string
+	limit		int64
+}
+
+func NewDiskQuotaTuner(
+	fs afero.Fs,
+	dataDir string,
+	size string,
+	proc os.Proc,
+	timeout time.Duration,
+	executor executors.Executor,
+) Tunable {
+	return &diskQuotaTuner{
+		fs:		fs,
+		dataDir:	dataDir,
+		size:		size,
+		proc:		proc,
+		timeout:	timeout,
+		executor:	executor,
+	}
+}
+
+func (t *diskQuotaTuner) CheckIfSupported() (supported bool, reason string) {
+	if t.size == "" {
+		return false, "rpk.disk_quota_size isn't set"
+	}
+	m, err := mountOf(t.fs, t.dataDir)
+	if err != nil {
+		return false, err.Error()
+	}
+	if m.fsType != "xfs" {
+		return false, fmt.Sprintf(
+			"'%s' is on a %s filesystem, but project quotas are only"+
+				" supported on XFS",
+			t.dataDir,
+			m.fsType,
+		)
+	}
+	if !hasProjectQuotas(m.options) {
+		return false, fmt.Sprintf(
+			"'%s' isn't mounted with project quotas enabled (prjquota)",
+			m.path,
+		)
+	}
+	t.mountPoint = m.path
+	return true, ""
+}
+
+func (t *diskQuotaTuner) Tune() TuneResult {
+	limit, err := units.RAMInBytes(t.size)
+	if err != nil || limit <= 0 {
+		return NewTuneError(fmt.Errorf("invalid rpk.disk_quota_size '%s'", t.size))
+	}
+	err = t.executor.Execute(commands.NewLaunchCmd(
+		t.proc,
+		t.timeout,
+		"xfs_quota",
+		"-x",
+		"-c", fmt.Sprintf("project -s -p %s %d", t.dataDir, DiskQuotaProjectID),
+		"-c", fmt.Sprintf("limit -p bhard=%d %d", limit, DiskQuotaProjectID),
+		t.mountPoint,
+	))
+	if err != nil {
+		return NewTuneError(err)
+	}
+	t.limit = limit
+	log.Infof(
+		"Limited '%s' to %s with XFS project %d",
+		t.dataDir,
+		units.BytesSize(float64(limit)),
+		DiskQuotaProjectID,
+	)
+	return NewTuneResult(false)
+}
+
+func (t *diskQuotaTuner) Details() map[string]string {
+	if t.limit == 0 {
+		return nil
+	}
+	return map[string]string{
+		"mountpoint":	t.mountPoint,
+		"project":	fmt.Sprint(DiskQuotaProjectID),
+		"limit":	units.BytesSize(float64(t.limit)),
+	}
+}
+
+// Returns the filesystem the given path is on, i.e. the one with the longest
+// mount path containing it.
+func mountOf(fs afero.Fs, path string) (*mount, error) {
+	f, err := fs.Open(MountsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	path = filepath.Clean(path)
+	var found *mount
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// e.g. '/dev/nvme0n1 /var/lib/redpanda xfs rw,prjquota 0 0'
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		mountPath := filepath.Clean(fields[1])
+		rel, err := filepath.Rel(mountPath, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if found != nil && len(found.path) >= len(mountPath) {
+			continue
+		}
+		found = &mount{
+			path:		mountPath,
+			fsType:		fields[2],
+			options:	strings.Split(fields[3], ","),
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("couldn't find the filesystem '%s' is on", path)
+	}
+	return found, nil
+}
+
+func hasProjectQuotas(options []string) bool {
+	for _, o := range options {
+		switch o {
+		case "prjquota", "pquota", "pqnoenforce":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+)
+
+// Records the commands run.
+type recordingProcMock struct {
+	commands []string
+}
+
+func (m *recordingProcMock) RunWithSystemLdPath(
+	_ time.Duration, command string, args ...string,
+) ([]string, error) {
+	m.commands = append(
+		m.commands,
+		strings.Join(append([]string{command}, args...), " "),
+	)
+	return nil, nil
+}
+
+func (*recordingProcMock) IsRunning(time.Duration, string) bool {
+	return false
+}
+
+func TestDiskQuotaTuner(t *testing.T) {
+	tests := []struct {
+		name			string
+		mounts			string
+		size			string
+		expectedSupported	bool
+		expectedReason		string
+		expectedErrMsg		string
+		expectedCommands	[]string
+		expectedDetails		map[string]string
+	}{
+		{
+			name:	"it should set a project quota on the data directory",
+			mounts: "/dev/sda1 / ext4 rw,relatime 0 0\n" +
+				"/dev/nvme0n1 /var/lib/redpanda xfs rw,noatime,prjquota 0 0\n",
+			size:			"500GiB",
+			expectedSupported:	true,
+			expectedCommands: []string{
+				"xfs_quota -x" +
+					" -c project -s -p /var/lib/redpanda/data 4242" +
+					" -c limit -p bhard=536870912000 4242" +
+					" /var/lib/redpanda",
+			},
+			expectedDetails: map[string]string{
+				"mountpoint":	"/var/lib/redpanda",
+				"project":	"4242",
+				"limit":	"500GiB",
+			},
+		},
+		{
+			name:	"it shouldn't be supported if the data dir isn't on XFS",
+			mounts: "/dev/sda1 / ext4 rw,relatime 0 0\n" +
+				"/dev/nvme0n1 /var/lib/redpanda/data2 xfs rw,prjquota 0 0\n",
+			size:	"500GiB",
+			expectedReason: "'/var/lib/redpanda/data' is on a ext4" +
+				" filesystem, but project quotas are only supported on XFS",
+		},
+		{
+			name:		"it shouldn't be supported without project quotas",
+			mounts:		"/dev/nvme0n1 /var/lib/redpanda xfs rw,noatime 0 0\n",
+			size:		"500GiB",
+			expectedReason:	"'/var/lib/redpanda' isn't mounted with project quotas enabled (prjquota)",
+		},
+		{
+			name:		"it shouldn't be supported if the size isn't set",
+			mounts:		"/dev/nvme0n1 /var/lib/redpanda xfs rw,prjquota 0 0\n",
+			expectedReason:	"rpk.disk_quota_size isn't set",
+		},
+		{
+			name:			"it should fail if the size is invalid",
+			mounts:			"/dev/nvme0n1 /var/lib/redpanda xfs rw,prjquota 0 0\n",
+			size:			"lots",
+			expectedSupported:	true,
+			expectedErrMsg:		"invalid rpk.disk_quota_size 'lots'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			err := afero.WriteFile(fs, MountsFile, []byte(tt.mounts), 0644)
+			require.NoError(st, err)
+			proc := &recordingProcMock{}
+			tuner := NewDiskQuotaTuner(
+				fs,
+				"/var/lib/redpanda/data",
+				tt.size,
+				proc,
+				time.Second,
+				executors.NewDirectExecutor(),
+			)
+			supported, reason := tuner.CheckIfSupported()
+			require.Equal(st, tt.expectedSupported, supported)
+			require.Equal(st, tt.expectedReason, reason)
+			if !supported {
+				return
+			}
+			res := tuner.Tune()
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, res.Error(), tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, res.Error())
+			require.Equal(st, tt.expectedCommands, proc.commands)
+			require.Equal(st, tt.expectedDetails, TuneDetails(tuner))
+		})
+	}
+}
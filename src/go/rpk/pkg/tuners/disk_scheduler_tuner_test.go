@@ -27,6 +27,8 @@ type deviceFeaturesMock struct {
 	getScheduler			func(string) (string, error)
 	getWriteCacheFeatureFile	func(string) (string, error)
 	getWriteCache			func(string) (string, error)
+	getRqAffinityFeatureFile	func(string) (string, error)
+	isRotational			func(string) (bool, error)
 }
 
 func (m *deviceFeaturesMock) GetScheduler(device string) (string, error) {
@@ -65,6 +67,16 @@ func (m *deviceFeaturesMock) GetWriteCache(device string) (string, error) {
 	return m.getWriteCache(device)
 }
 
+func (m *deviceFeaturesMock) GetRqAffinityFeatureFile(
+	device string,
+) (string, error) {
+	return m.getRqAffinityFeatureFile(device)
+}
+
+func (m *deviceFeaturesMock) IsRotational(device string) (bool, error) {
+	return m.isRotational(device)
+}
+
 func TestDeviceSchedulerTuner_Tune(t *testing.T) {
 	// given
 	deviceFeatures := &deviceFeaturesMock{
@@ -10,6 +10,8 @@
 package tuners
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/disk"
@@ -41,11 +43,20 @@ type diskTuner struct {
 }
 
 func (tuner *diskTuner) Tune() TuneResult {
+	return tuner.TuneWithContext(context.Background(), NewProgress())
+}
+
+func (tuner *diskTuner) TuneWithContext(
+	ctx context.Context, progress *Progress,
+) TuneResult {
 	tunables, err := tuner.createDeviceTuners()
 	if err != nil {
 		return NewTuneError(err)
 	}
-	return NewAggregatedTunable(tunables).Tune()
+	return newAggregatedTunable(tunables, "disks").TuneWithContext(
+		ctx,
+		progress,
+	)
 }
 
 func (tuner *diskTuner) CheckIfSupported() (supported bool, reason string) {
@@ -10,6 +10,8 @@
 package tuners
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/disk"
@@ -30,6 +32,9 @@ type disksIRQsTuner struct {
 	devices			[]string
 	numberOfCpus		int
 	executor		executors.Executor
+	// Whether the NVMe IRQs tuner runs too, in which case the NVMe IRQs
+	// are left to it.
+	nvmeTuned	bool
 }
 
 func NewDiskIRQTuner(
@@ -45,6 +50,7 @@ func NewDiskIRQTuner(
 	blockDevices disk.BlockDevices,
 	numberOfCpus int,
 	executor executors.Executor,
+	nvmeTuned bool,
 ) Tunable {
 	log.Debugf("Creating disk IRQs tuner with mode '%s', cpu mask '%s', directories '%s' and devices '%s'",
 		mode, cpuMask, dirs, devices)
@@ -62,6 +68,7 @@ func NewDiskIRQTuner(
 		devices:		devices,
 		numberOfCpus:		numberOfCpus,
 		executor:		executor,
+		nvmeTuned:		nvmeTuned,
 	}
 }
 
@@ -80,6 +87,12 @@ func (tuner *disksIRQsTuner) CheckIfSupported() (
 }
 
 func (tuner *disksIRQsTuner) Tune() TuneResult {
+	return tuner.TuneWithContext(context.Background(), NewProgress())
+}
+
+func (tuner *disksIRQsTuner) TuneWithContext(
+	ctx context.Context, progress *Progress,
+) TuneResult {
 	directoryDevices, err := tuner.blockDevices.GetDirectoriesDevices(
 		tuner.directories)
 	if err != nil {
@@ -101,14 +114,18 @@ func (tuner *disksIRQsTuner) Tune() TuneResult {
 	if result := balanceServiceTuner.Tune(); result.IsFailed() {
 		return result
 	}
-	affinityTuner := NewDiskIRQsAffinityTuner(
+	affinityTuner := newDiskIRQsAffinityTuner(
+		ctx,
+		progress,
 		tuner.fs,
 		allDevices,
 		tuner.baseCPUMask,
 		tuner.mode,
 		tuner.blockDevices,
 		tuner.cpuMasks,
+		tuner.irqProcFile,
 		tuner.executor,
+		tuner.nvmeTuned,
 	)
 	return affinityTuner.Tune()
 }
@@ -151,21 +168,71 @@ func NewDiskIRQsAffinityTuner(
 	mode irq.Mode,
 	blockDevices disk.BlockDevices,
 	cpuMasks irq.CpuMasks,
+	irqProcFile irq.ProcFile,
+	executor executors.Executor,
+	nvmeTuned bool,
+) Tunable {
+	return newDiskIRQsAffinityTuner(
+		context.Background(),
+		NewProgress(),
+		fs,
+		devices,
+		cpuMask,
+		mode,
+		blockDevices,
+		cpuMasks,
+		irqProcFile,
+		executor,
+		nvmeTuned,
+	)
+}
+
+func newDiskIRQsAffinityTuner(
+	ctx context.Context,
+	progress *Progress,
+	fs afero.Fs,
+	devices []string,
+	cpuMask string,
+	mode irq.Mode,
+	blockDevices disk.BlockDevices,
+	cpuMasks irq.CpuMasks,
+	irqProcFile irq.ProcFile,
 	executor executors.Executor,
+	nvmeTuned bool,
 ) Tunable {
 	return NewCheckedTunable(
-		NewDisksIRQAffinityChecker(fs, devices, cpuMask, mode, blockDevices, cpuMasks),
+		NewDisksIRQAffinityChecker(
+			fs,
+			devices,
+			cpuMask,
+			mode,
+			blockDevices,
+			cpuMasks,
+			irqProcFile,
+			nvmeTuned,
+		),
 		func() TuneResult {
-			distribution, err := GetExpectedIRQsDistribution(
+			distribution, err := getDiskIRQsDistribution(
 				devices,
 				blockDevices,
 				mode,
 				cpuMask,
-				cpuMasks)
+				cpuMasks,
+				irqProcFile,
+				nvmeTuned,
+			)
 			if err != nil {
 				return NewTuneError(err)
 			}
-			cpuMasks.DistributeIRQs(distribution)
+			progress.Start(len(distribution), "IRQs")
+			err = cpuMasks.DistributeIRQsWithContext(
+				ctx,
+				distribution,
+				func(int) { progress.Step() },
+			)
+			if err != nil {
+				return NewTuneError(interruptedError(ctx, progress))
+			}
 			return NewTuneResult(false)
 		},
 		func() (bool, string) {
@@ -240,6 +307,38 @@ func GetExpectedIRQsDistribution(
 	return devicesIRQsDistribution, nil
 }
 
+// Returns the IRQs distribution the disk IRQs tuner sets. If nvmeTuned is
+// set, the NVMe IRQs are left out, since the NVMe IRQs tuner distributes them
+// differently, and they'd otherwise be moved back and forth on every run.
+// They're found in /proc/interrupts, as the NVMe IRQs tuner does.
+func getDiskIRQsDistribution(
+	devices []string,
+	blockDevices disk.BlockDevices,
+	mode irq.Mode,
+	cpuMask string,
+	cpuMasks irq.CpuMasks,
+	irqProcFile irq.ProcFile,
+	nvmeTuned bool,
+) (map[int]string, error) {
+	distribution, err := GetExpectedIRQsDistribution(
+		devices,
+		blockDevices,
+		mode,
+		cpuMask,
+		cpuMasks)
+	if err != nil || !nvmeTuned {
+		return distribution, err
+	}
+	nvmeIRQs, err := GetNvmeIRQs(devices, irqProcFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, IRQ := range nvmeIRQs {
+		delete(distribution, IRQ)
+	}
+	return distribution, nil
+}
+
 func GetDefaultMode(
 	cpuMask string,
 	diskInfoByType map[disk.DiskType]disk.DevicesIRQs,
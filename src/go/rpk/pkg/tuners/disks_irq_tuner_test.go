@@ -12,6 +12,7 @@ package tuners
 import (
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/disk"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/irq"
@@ -21,7 +22,13 @@ type cpuMasksMock struct {
 	irq.CpuMasks
 	baseCpuMask			func(string) (string, error)
 	cpuMaskForIRQs			func(irq.Mode, string) (string, error)
+	cpuMaskForComputations		func(irq.Mode, string) (string, error)
+	complementCpuMask		func(string) (string, error)
+	readIRQMask			func(int) (string, error)
 	getIRQsDistributionMasks	func([]int, string) (map[int]string, error)
+	getNumberOfCores		func(string) (uint, error)
+	getNumberOfPUs			func(string) (uint, error)
+	setMask				func(string, string) error
 }
 
 type blockDevicesMock struct {
@@ -42,6 +49,36 @@ func (m *cpuMasksMock) CpuMaskForIRQs(
 	return m.cpuMaskForIRQs(mode, cpuMask)
 }
 
+func (m *cpuMasksMock) CpuMaskForComputations(
+	mode irq.Mode, cpuMask string,
+) (string, error) {
+	return m.cpuMaskForComputations(mode, cpuMask)
+}
+
+func (m *cpuMasksMock) ComplementCpuMask(cpuMask string) (string, error) {
+	return m.complementCpuMask(cpuMask)
+}
+
+func (m *cpuMasksMock) ReadIRQMask(IRQ int) (string, error) {
+	return m.readIRQMask(IRQ)
+}
+
+func (m *cpuMasksMock) GetNumberOfCores(mask string) (uint, error) {
+	return m.getNumberOfCores(mask)
+}
+
+func (m *cpuMasksMock) GetNumberOfPUs(mask string) (uint, error) {
+	return m.getNumberOfPUs(mask)
+}
+
+func (m *cpuMasksMock) SetMask(path string, mask string) error {
+	return m.setMask(path, mask)
+}
+
+func (*cpuMasksMock) IsSupported() bool {
+	return true
+}
+
 func (m *cpuMasksMock) GetIRQsDistributionMasks(
 	IRQs []int, cpuMask string,
 ) (map[int]string, error) {
@@ -153,3 +190,128 @@ func TestGetExpectedIRQsDistribution(t *testing.T) {
 		})
 	}
 }
+
+func TestDisksIRQAffinityCheckerNvmeTuned(t *testing.T) {
+	blockDevices := &blockDevicesMock{
+		getDiskInfoByType: func([]string) (map[disk.DiskType]disk.DevicesIRQs, error) {
+			return map[disk.DiskType]disk.DevicesIRQs{
+				disk.NonNvme: {
+					Devices:	[]string{"sda"},
+					Irqs:		[]int{10},
+				},
+				disk.Nvme: {
+					Devices:	[]string{"nvme0n1"},
+					Irqs:		[]int{12, 15},
+				},
+			}, nil
+		},
+	}
+	// IRQ 16 is only listed in /proc/interrupts, where the NVMe IRQs tuner
+	// finds the IRQs it distributes.
+	procFile := &procFileMock{lines: map[int]string{
+		10:	"  10:  0  0  IR-IO-APIC  10-edge  ahci[0000:00:17.0]",
+		12:	"  12:  0  0  IR-PCI-MSI 1048576-edge  nvme0q0",
+		15:	"  15:  0  0  IR-PCI-MSI 1048577-edge  nvme0q1",
+		16:	"  16:  0  0  IR-PCI-MSI 1048578-edge  nvme0q2",
+	}}
+	diskIRQLayout := map[int]string{10: "0x01", 12: "0x01", 15: "0x02"}
+	nvmeIRQLayout := map[int]string{10: "0x01", 12: "0x02", 15: "0x04", 16: "0x08"}
+	newCpuMasks := func(current map[int]string) *cpuMasksMock {
+		return &cpuMasksMock{
+			baseCpuMask: func(string) (string, error) {
+				return "0xff", nil
+			},
+			cpuMaskForIRQs: func(irq.Mode, string) (string, error) {
+				return "0x01", nil
+			},
+			cpuMaskForComputations: func(irq.Mode, string) (string, error) {
+				return "0xfe", nil
+			},
+			getNumberOfCores: func(string) (uint, error) {
+				return 8, nil
+			},
+			getNumberOfPUs: func(string) (uint, error) {
+				return 16, nil
+			},
+			getIRQsDistributionMasks: func(IRQs []int, mask string) (map[int]string, error) {
+				switch mask {
+				case "0x01":
+					return map[int]string{10: "0x01"}, nil
+				case "0xfe":
+					// The NVMe IRQs tuner's computations CPUs.
+					masks := map[int]string{}
+					for _, IRQ := range IRQs {
+						masks[IRQ] = nvmeIRQLayout[IRQ]
+					}
+					return masks, nil
+				}
+				return map[int]string{12: "0x01", 15: "0x02"}, nil
+			},
+			readIRQMask: func(IRQ int) (string, error) {
+				return current[IRQ], nil
+			},
+		}
+	}
+	tests := []struct {
+		name		string
+		current		map[int]string
+		nvmeTuned	bool
+		expectedOk	bool
+	}{
+		{
+			name:		"it should expect the disk IRQs tuner's layout",
+			current:	diskIRQLayout,
+			expectedOk:	true,
+		},
+		{
+			name:		"it should expect the NVMe IRQs tuner's layout for the NVMe IRQs if it runs",
+			current:	nvmeIRQLayout,
+			nvmeTuned:	true,
+			expectedOk:	true,
+		},
+		{
+			name:		"it should fail if the NVMe IRQs tuner runs but they have the disk IRQs tuner's layout",
+			current:	diskIRQLayout,
+			nvmeTuned:	true,
+		},
+		{
+			name:	"it should check the NVMe IRQs found in /proc/interrupts",
+			current: map[int]string{
+				10:	"0x01",
+				12:	"0x02",
+				15:	"0x04",
+				16:	"0x01",
+			},
+			nvmeTuned:	true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			res := NewDisksIRQAffinityChecker(
+				afero.NewMemMapFs(),
+				[]string{"sda", "nvme0n1"},
+				"all",
+				irq.Default,
+				blockDevices,
+				newCpuMasks(tt.current),
+				procFile,
+				tt.nvmeTuned,
+			).Check()
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectedOk, res.IsOk)
+		})
+	}
+
+	// The disk IRQs tuner leaves the NVMe IRQs to the NVMe IRQs tuner.
+	distribution, err := getDiskIRQsDistribution(
+		[]string{"sda", "nvme0n1"},
+		blockDevices,
+		irq.Default,
+		"all",
+		newCpuMasks(nil),
+		procFile,
+		true,
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[int]string{10: "0x01"}, distribution)
+}
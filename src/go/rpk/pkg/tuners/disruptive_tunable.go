@@ -0,0 +1,51 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import "context"
+
+// Implemented by tunables whose changes may disrupt other workloads running on
+// the machine, such as restarting irqbalance. Tunables which don't implement
+// it aren't considered disruptive.
+type DisruptiveTunable interface {
+	Tunable
+	IsDisruptive() bool
+}
+
+func IsDisruptive(tunable Tunable) bool {
+	dt, ok := tunable.(DisruptiveTunable)
+	return ok && dt.IsDisruptive()
+}
+
+// Marks the given tunable as disruptive.
+func NewDisruptiveTunable(tunable Tunable) Tunable {
+	return &disruptiveTunable{tunable}
+}
+
+type disruptiveTunable struct {
+	Tunable
+}
+
+func (*disruptiveTunable) IsDisruptive() bool {
+	return true
+}
+
+func (t *disruptiveTunable) TuneWithContext(
+	ctx context.Context, progress *Progress,
+) TuneResult {
+	if pt, ok := t.Tunable.(ProgressTunable); ok {
+		return pt.TuneWithContext(ctx, progress)
+	}
+	return t.Tunable.Tune()
+}
+
+func (t *disruptiveTunable) Details() map[string]string {
+	return TuneDetails(t.Tunable)
+}
@@ -0,0 +1,28 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDisruptive(t *testing.T) {
+	tunable := &mockedTunable{
+		tune: func() TuneResult {
+			return NewTuneResult(true)
+		},
+	}
+	require.False(t, IsDisruptive(tunable))
+	disruptive := NewDisruptiveTunable(tunable)
+	require.True(t, IsDisruptive(disruptive))
+	require.Exactly(t, NewTuneResult(true), disruptive.Tune())
+}
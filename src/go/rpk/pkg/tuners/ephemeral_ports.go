@@ -0,0 +1,106 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/network"
+)
+
+// Warns if any of redpanda's listener ports is in the ephemeral port range
+// and isn't reserved, in which case the kernel might assign it to an outgoing
+// connection before redpanda binds to it.
+type ephemeralPortsChecker struct {
+	fs	afero.Fs
+	conf	*config.Config
+}
+
+func NewEphemeralPortsChecker(fs afero.Fs, conf *config.Config) Checker {
+	return &ephemeralPortsChecker{fs: fs, conf: conf}
+}
+
+func (*ephemeralPortsChecker) Id() CheckerID {
+	return EphemeralPortsChecker
+}
+
+func (*ephemeralPortsChecker) GetDesc() string {
+	return "Listener ports outside the ephemeral range"
+}
+
+func (*ephemeralPortsChecker) GetSeverity() Severity {
+	return Warning
+}
+
+func (*ephemeralPortsChecker) GetRequiredAsString() string {
+	return "true"
+}
+
+func (c *ephemeralPortsChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+	}
+	ephemeral, err := network.GetLocalPortRange(c.fs)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	reserved, err := network.GetReservedPorts(c.fs)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	listeners := []struct {
+		name	string
+		port	int
+	}{
+		{"kafka_api", c.conf.Redpanda.KafkaApi.Port},
+		{"rpc_server", c.conf.Redpanda.RPCServer.Port},
+		{"admin", c.conf.Redpanda.AdminApi.Port},
+	}
+	var inRange []string
+	for _, l := range listeners {
+		if l.port == 0 || !ephemeral.Contains(l.port) {
+			continue
+		}
+		if isReservedPort(reserved, l.port) {
+			continue
+		}
+		inRange = append(inRange, fmt.Sprintf("%s: %d", l.name, l.port))
+	}
+	if len(inRange) == 0 {
+		res.Current = fmt.Sprintf("true (ephemeral range %s)", ephemeral)
+		res.IsOk = true
+		return res
+	}
+	res.Current = fmt.Sprintf(
+		"false (%s in the ephemeral range %s). Use ports below %d, or"+
+			" reserve them in net.ipv4.ip_local_reserved_ports",
+		strings.Join(inRange, ", "),
+		ephemeral,
+		ephemeral.Low,
+	)
+	return res
+}
+
+func isReservedPort(reserved []network.PortRange, port int) bool {
+	for _, r := range reserved {
+		if r.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/network"
+)
+
+func TestEphemeralPortsChecker(t *testing.T) {
+	tests := []struct {
+		name		string
+		kafkaPort	int
+		rpcPort		int
+		reserved	string
+		expectedOk	bool
+		expectedCurrent	string
+	}{
+		{
+			name:			"it should pass if the ports are below the range",
+			kafkaPort:		9092,
+			rpcPort:		33145,
+			reserved:		"33145\n",
+			expectedOk:		true,
+			expectedCurrent:	"true (ephemeral range 32768-60999)",
+		},
+		{
+			name:			"it should fail if a port is in the range",
+			kafkaPort:		40000,
+			rpcPort:		33145,
+			reserved:		"8000-8010,33140-33150\n",
+			expectedCurrent:	"false (kafka_api: 40000 in the ephemeral range 32768-60999). Use ports below 32768, or reserve them in net.ipv4.ip_local_reserved_ports",
+		},
+		{
+			name:			"it should fail if the ports aren't reserved",
+			kafkaPort:		9092,
+			rpcPort:		33145,
+			expectedCurrent:	"false (rpc_server: 33145 in the ephemeral range 32768-60999). Use ports below 32768, or reserve them in net.ipv4.ip_local_reserved_ports",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			err := afero.WriteFile(
+				fs,
+				network.LocalPortRangeFile,
+				[]byte("32768\t60999\n"),
+				0644,
+			)
+			require.NoError(st, err)
+			if tt.reserved != "" {
+				err = afero.WriteFile(
+					fs,
+					network.ReservedPortsFile,
+					[]byte(tt.reserved),
+					0644,
+				)
+				require.NoError(st, err)
+			}
+			conf := config.Default()
+			conf.Redpanda.KafkaApi.Port = tt.kafkaPort
+			conf.Redpanda.RPCServer.Port = tt.rpcPort
+			res := NewEphemeralPortsChecker(fs, conf).Check()
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectedOk, res.IsOk)
+			require.Equal(st, tt.expectedCurrent, res.Current)
+			require.EqualValues(st, Warning, res.Severity)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system/filesystem"
+)
+
+type fallocateCommand struct {
+	fs	afero.Fs
+	path	string
+	size	int64
+}
+
+// Reserves size bytes of disk space for the file at path, creating it if
+// needed. Where fallocate isn't supported, the file is only extended, which
+// doesn't reserve any blocks.
+func NewFallocateCmd(fs afero.Fs, path string, size int64) Command {
+	return &fallocateCommand{fs, path, size}
+}
+
+func (c *fallocateCommand) Execute() error {
+	log.Debugf("Preallocating %d bytes for '%s'", c.size, c.path)
+	file, err := c.fs.OpenFile(c.path, os.O_CREATE|os.O_RDWR, defaultMode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = filesystem.Fallocate(file, c.size)
+	if err != filesystem.ErrFallocateUnsupported {
+		return err
+	}
+	log.Warnf("fallocate isn't supported for '%s', the space won't be"+
+		" reserved", c.path)
+	return file.Truncate(c.size)
+}
+
+func (c *fallocateCommand) RenderScript(w *bufio.Writer) error {
+	fmt.Fprintf(w, "fallocate -l %d %s\n", c.size, c.path)
+	return w.Flush()
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system/systemd"
+)
+
+// Installs a drop-in for a systemd unit, i.e. a file under
+// /etc/systemd/system/<unit>.d/ whose settings override the unit's, and
+// reloads systemd. The unit must be restarted for them to apply.
+type installSystemdDropInCommand struct {
+	client	systemd.Client
+	fs	afero.Fs
+	unit	string
+	name	string
+	body	string
+}
+
+func NewInstallSystemdDropInCmd(
+	client systemd.Client, fs afero.Fs, unit, name, body string,
+) Command {
+	return &installSystemdDropInCommand{
+		client:	client,
+		fs:	fs,
+		unit:	unit,
+		name:	name,
+		body:	body,
+	}
+}
+
+func (cmd *installSystemdDropInCommand) Execute() error {
+	path := systemd.DropInPath(cmd.unit, cmd.name)
+	err := cmd.fs.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return cmd.client.LoadUnit(
+		cmd.fs,
+		cmd.body,
+		systemd.DropInName(cmd.unit, cmd.name),
+	)
+}
+
+func (cmd *installSystemdDropInCommand) RenderScript(w *bufio.Writer) error {
+	path := systemd.DropInPath(cmd.unit, cmd.name)
+	_, err := fmt.Fprintf(
+		w,
+		"mkdir -p %s\ncat << EOF > %s\n%s\nEOF\nsudo systemctl daemon-reload\n",
+		filepath.Dir(path),
+		path,
+		cmd.body,
+	)
+	return err
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package commands_test
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system/systemd"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+)
+
+const dropInBody = `[Service]
+LimitNOFILE=1048576
+`
+
+func TestInstallSystemdDropInCmdRender(t *testing.T) {
+	cmd := commands.NewInstallSystemdDropInCmd(
+		systemd.NewMockClient(nil, nil, nil, nil),
+		afero.NewMemMapFs(),
+		"foo.service",
+		"limits.conf",
+		dropInBody,
+	)
+
+	expected := `mkdir -p /etc/systemd/system/foo.service.d
+cat << EOF > /etc/systemd/system/foo.service.d/limits.conf
+` + dropInBody + `
+EOF
+sudo systemctl daemon-reload
+`
+	var buf bytes.Buffer
+
+	w := bufio.NewWriter(&buf)
+	require.NoError(t, cmd.RenderScript(w))
+	require.NoError(t, w.Flush())
+
+	require.Equal(t, expected, buf.String())
+}
+
+func TestInstallSystemdDropInCmdExecute(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	var loaded string
+	loadUnit := func(_ afero.Fs, _, name string) error {
+		loaded = name
+		return nil
+	}
+	cmd := commands.NewInstallSystemdDropInCmd(
+		systemd.NewMockClient(nil, nil, nil, loadUnit),
+		fs,
+		"foo.service",
+		"limits.conf",
+		dropInBody,
+	)
+	require.NoError(t, cmd.Execute())
+	require.Equal(t, "foo.service.d/limits.conf", loaded)
+
+	isDir, err := afero.IsDir(fs, "/etc/systemd/system/foo.service.d")
+	require.NoError(t, err)
+	require.True(t, isDir)
+}
@@ -10,7 +10,12 @@
 package factory
 
 import (
+	"fmt"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -20,6 +25,8 @@ import (
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/net"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system/filesystem"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system/systemd"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/coredump"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/cpu"
@@ -28,11 +35,13 @@ import (
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/hwloc"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/irq"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/network"
 )
 
 var (
 	allTuners = map[string]func(*tunersFactory, *TunerParams) tuners.Tunable{
 		"disk_irq":			(*tunersFactory).newDiskIRQTuner,
+		"nvme_irq":			(*tunersFactory).newNvmeIRQTuner,
 		"disk_scheduler":		(*tunersFactory).newDiskSchedulerTuner,
 		"disk_nomerges":		(*tunersFactory).newDiskNomergesTuner,
 		"disk_write_cache":		(*tunersFactory).newGcpWriteCacheTuner,
@@ -42,8 +51,20 @@ var (
 		"aio_events":			(*tunersFactory).newMaxAIOEventsTuner,
 		"clocksource":			(*tunersFactory).newClockSourceTuner,
 		"swappiness":			(*tunersFactory).newSwappinessTuner,
+		"overcommit":			(*tunersFactory).newOvercommitTuner,
 		"transparent_hugepages":	(*tunersFactory).newTHPTuner,
 		"coredump":			(*tunersFactory).newCoredumpTuner,
+		"prealloc":			(*tunersFactory).newPreallocTuner,
+		"smt":				(*tunersFactory).newSMTTuner,
+		"rq_affinity":			(*tunersFactory).newRqAffinityTuner,
+		"cstate":			(*tunersFactory).newCStateTuner,
+		"ring_buffer":			(*tunersFactory).newRingBufferTuner,
+		"numa_hugepages":		(*tunersFactory).newNUMAHugepagesTuner,
+		"disk_quota":			(*tunersFactory).newDiskQuotaTuner,
+		"nic_irq_affinity":		(*tunersFactory).newNICIRQAffinityTuner,
+		"ballast_file":			(*tunersFactory).newBallastFileTuner,
+		"io_scheduler":			(*tunersFactory).newIOSchedulerTuner,
+		"file_descriptors":		(*tunersFactory).newFdLimitTuner,
 	}
 )
 
@@ -54,6 +75,18 @@ type TunerParams struct {
 	Disks		[]string
 	Directories	[]string
 	Nics		[]string
+	Profile		tuners.TuneProfile
+	// The size of the XFS project quota set on the data directory.
+	DiskQuota	string
+	// The directory the coredump tuner makes the kernel save cores to.
+	CoredumpDir	string
+	// The file the ballast_file tuner allocates, which can be deleted to
+	// free up space when the disk fills up, and its size.
+	BallastFilePath	string
+	BallastFileSize	string
+	// The vm.swappiness the swappiness tuner sets. Defaults to
+	// tuners.ExpectedSwappiness.
+	Swappiness	*int
 }
 
 type TunersFactory interface {
@@ -71,6 +104,7 @@ type tunersFactory struct {
 	proc			os.Proc
 	grub			system.Grub
 	executor		executors.Executor
+	timeout			time.Duration
 }
 
 func NewDirectExecutorTunersFactory(
@@ -113,6 +147,7 @@ func newTunersFactory(
 		grub:			system.NewGrub(os.NewCommands(proc), proc, fs, executor, timeout),
 		proc:			proc,
 		executor:		executor,
+		timeout:		timeout,
 	}
 }
 
@@ -121,6 +156,8 @@ func AvailableTuners() []string {
 	for key := range allTuners {
 		keys = append(keys, key)
 	}
+	// Sorted, so that tuners run in a stable order.
+	sort.Strings(keys)
 	return keys
 }
 
@@ -132,6 +169,8 @@ func IsTunerEnabled(tuner string, rpkConfig config.RpkConfig) bool {
 	switch tuner {
 	case "disk_irq":
 		return rpkConfig.TuneDiskIrq
+	case "nvme_irq":
+		return rpkConfig.TuneNvmeIrq
 	case "disk_scheduler":
 		return rpkConfig.TuneDiskScheduler
 	case "disk_nomerges":
@@ -150,10 +189,36 @@ func IsTunerEnabled(tuner string, rpkConfig config.RpkConfig) bool {
 		return rpkConfig.TuneClocksource
 	case "swappiness":
 		return rpkConfig.TuneSwappiness
+	case "overcommit":
+		return rpkConfig.TuneOvercommit
 	case "transparent_hugepages":
 		return rpkConfig.TuneTransparentHugePages
 	case "coredump":
 		return rpkConfig.TuneCoredump
+	case "prealloc":
+		// Opt-in, since it takes up disk space.
+		return rpkConfig.PreallocSize != ""
+	case "smt":
+		return rpkConfig.TuneSMT
+	case "rq_affinity":
+		return rpkConfig.RqAffinity != nil
+	case "cstate":
+		return rpkConfig.CStateMaxLatency != nil
+	case "ring_buffer":
+		return rpkConfig.TuneRingBuffers
+	case "numa_hugepages":
+		// Opt-in, since the pages are taken from the node's memory.
+		return rpkConfig.NUMAHugepages != ""
+	case "disk_quota":
+		return rpkConfig.TuneDiskQuota
+	case "nic_irq_affinity":
+		return rpkConfig.TuneNicIrqAffinity
+	case "ballast_file":
+		return rpkConfig.TuneBallastFile
+	case "io_scheduler":
+		return rpkConfig.TuneIOScheduler
+	case "file_descriptors":
+		return rpkConfig.TuneFdLimit
 	}
 	return false
 }
@@ -161,14 +226,55 @@ func IsTunerEnabled(tuner string, rpkConfig config.RpkConfig) bool {
 func (factory *tunersFactory) CreateTuner(
 	tunerName string, tunerParams *TunerParams,
 ) tuners.Tunable {
-	return allTuners[tunerName](factory, tunerParams)
+	timeout, err := TunerTimeout(tunerName, factory.conf.Rpk, factory.timeout)
+	if err != nil {
+		log.Warnf("%v. Using the default one (%s)", err, factory.timeout)
+		timeout = factory.timeout
+	}
+	if timeout == factory.timeout {
+		return allTuners[tunerName](factory, tunerParams)
+	}
+	log.Debugf("Giving tuner '%s' a timeout of %s", tunerName, timeout)
+	// The helpers which run commands (e.g. hwloc) get the timeout too.
+	withTimeout := newTunersFactory(
+		factory.fs,
+		factory.conf,
+		factory.irqProcFile,
+		factory.proc,
+		factory.irqDeviceInfo,
+		factory.executor,
+		timeout,
+	).(*tunersFactory)
+	return allTuners[tunerName](withTimeout, tunerParams)
+}
+
+// Returns the timeout the given tuner should be given: its override in
+// rpk.tuner_timeouts if there's one, or timeout otherwise.
+func TunerTimeout(
+	tunerName string, conf config.RpkConfig, timeout time.Duration,
+) (time.Duration, error) {
+	override, ok := conf.TunerTimeouts[tunerName]
+	if !ok {
+		return timeout, nil
+	}
+	d, err := time.ParseDuration(override)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf(
+			"invalid timeout '%s' for tuner '%s' in"+
+				" rpk.tuner_timeouts. It must be a positive"+
+				" duration, e.g. '5m'",
+			override,
+			tunerName,
+		)
+	}
+	return d, nil
 }
 
 func (factory *tunersFactory) newDiskIRQTuner(
 	params *TunerParams,
 ) tuners.Tunable {
-
-	return tuners.NewDiskIRQTuner(
+	// The IRQs are banned from irqbalance, which is then restarted.
+	return tuners.NewDisruptiveTunable(tuners.NewDiskIRQTuner(
 		factory.fs,
 		irq.ModeFromString(params.Mode),
 		params.CpuMask,
@@ -181,7 +287,26 @@ func (factory *tunersFactory) newDiskIRQTuner(
 		factory.blockDevices,
 		runtime.NumCPU(),
 		factory.executor,
-	)
+		IsTunerEnabled("nvme_irq", factory.conf.Rpk),
+	))
+}
+
+func (factory *tunersFactory) newNvmeIRQTuner(
+	params *TunerParams,
+) tuners.Tunable {
+	// The NVMe IRQs are banned from irqbalance, which is then restarted.
+	return tuners.NewDisruptiveTunable(tuners.NewNvmeIRQTuner(
+		factory.fs,
+		irq.ModeFromString(params.Mode),
+		params.CpuMask,
+		params.Directories,
+		params.Disks,
+		factory.cpuMasks,
+		factory.irqBalanceService,
+		factory.irqProcFile,
+		factory.blockDevices,
+		factory.executor,
+	))
 }
 
 func (factory *tunersFactory) newDiskSchedulerTuner(
@@ -232,7 +357,9 @@ func (factory *tunersFactory) newNetworkTuner(
 	if err != nil {
 		panic(err)
 	}
-	return tuners.NewNetTuner(
+	// Like the disk IRQs tuner, it restarts irqbalance, and it also
+	// changes the NICs' settings.
+	return tuners.NewDisruptiveTunable(tuners.NewNetTuner(
 		irq.ModeFromString(params.Mode),
 		params.CpuMask,
 		params.Nics,
@@ -243,7 +370,8 @@ func (factory *tunersFactory) newNetworkTuner(
 		factory.irqProcFile,
 		ethtool,
 		factory.executor,
-	)
+		factory.conf.Rpk.TuneLoopbackRps,
+	))
 }
 
 func (factory *tunersFactory) newCpuTuner(params *TunerParams) tuners.Tunable {
@@ -259,7 +387,11 @@ func (factory *tunersFactory) newCpuTuner(params *TunerParams) tuners.Tunable {
 func (factory *tunersFactory) newMaxAIOEventsTuner(
 	params *TunerParams,
 ) tuners.Tunable {
-	return tuners.NewMaxAIOEventsTuner(factory.fs, factory.executor)
+	return tuners.NewMaxAIOEventsTuner(
+		factory.fs,
+		params.Profile,
+		factory.executor,
+	)
 }
 
 func (factory *tunersFactory) newClockSourceTuner(
@@ -271,17 +403,237 @@ func (factory *tunersFactory) newClockSourceTuner(
 func (factory *tunersFactory) newSwappinessTuner(
 	params *TunerParams,
 ) tuners.Tunable {
-	return tuners.NewSwappinessTuner(factory.fs, factory.executor)
+	value := tuners.ExpectedSwappiness
+	if params.Swappiness != nil {
+		value = *params.Swappiness
+	}
+	return tuners.NewSwappinessTuner(factory.fs, value, factory.executor)
+}
+
+func (factory *tunersFactory) newOvercommitTuner(
+	_ *TunerParams,
+) tuners.Tunable {
+	return tuners.NewOvercommitTuner(factory.fs, factory.executor)
 }
 
 func (factory *tunersFactory) newTHPTuner(_ *TunerParams) tuners.Tunable {
-	return tuners.NewEnableTHPTuner(factory.fs, factory.executor)
+	return tuners.NewTHPTuner(
+		factory.fs,
+		factory.conf.Rpk.TransparentHugePagesMode,
+		factory.executor,
+	)
 }
 
 func (factory *tunersFactory) newCoredumpTuner(
 	params *TunerParams,
 ) tuners.Tunable {
-	return coredump.NewCoredumpTuner(factory.fs, factory.conf, factory.executor)
+	return coredump.NewCoredumpTuner(
+		factory.fs,
+		params.CoredumpDir,
+		system.GetCoreLimit,
+		system.SetCoreLimit,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newFdLimitTuner(_ *TunerParams) tuners.Tunable {
+	return tuners.NewFdLimitTuner(
+		factory.fs,
+		system.GetFdLimit,
+		system.SetFdLimit,
+		tuners.RequiredFdLimit(factory.conf.Rpk),
+		systemd.NewDbusClient,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newPreallocTuner(
+	_ *TunerParams,
+) tuners.Tunable {
+	return tuners.NewPreallocTuner(
+		factory.fs,
+		factory.conf.Redpanda.Directory,
+		factory.conf.Rpk.PreallocSize,
+		tuners.RequiredMinFreeDisk(factory.conf.Rpk),
+		filesystem.GetFreeDiskSpaceGB,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newSMTTuner(_ *TunerParams) tuners.Tunable {
+	return tuners.NewSMTTuner(
+		factory.fs,
+		factory.conf.Rpk.RecommendSMT,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newRqAffinityTuner(
+	params *TunerParams,
+) tuners.Tunable {
+	value := 0
+	if factory.conf.Rpk.RqAffinity != nil {
+		value = *factory.conf.Rpk.RqAffinity
+	}
+	return tuners.NewRqAffinityTuner(
+		factory.fs,
+		params.Directories,
+		params.Disks,
+		factory.blockDevices,
+		disk.NewDeviceFeatures(factory.fs, factory.blockDevices),
+		value,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newIOSchedulerTuner(
+	params *TunerParams,
+) tuners.Tunable {
+	return tuners.NewIOSchedulerTuner(
+		factory.fs,
+		params.Directories,
+		params.Disks,
+		factory.blockDevices,
+		disk.NewDeviceFeatures(factory.fs, factory.blockDevices),
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newCStateTuner(
+	params *TunerParams,
+) tuners.Tunable {
+	maxLatency := 0
+	if factory.conf.Rpk.CStateMaxLatency != nil {
+		maxLatency = *factory.conf.Rpk.CStateMaxLatency
+	}
+	return tuners.NewCStateTuner(
+		factory.fs,
+		factory.cpuMasks,
+		params.CpuMask,
+		maxLatency,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newRingBufferTuner(
+	params *TunerParams,
+) tuners.Tunable {
+	return tuners.NewRingBufferTuner(
+		params.Nics,
+		factory.conf.Rpk.RingBufferSize,
+		params.Profile,
+		factory.proc,
+		factory.timeout,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newNUMAHugepagesTuner(
+	_ *TunerParams,
+) tuners.Tunable {
+	return tuners.NewNUMAHugepagesTuner(
+		factory.fs,
+		factory.conf.Rpk.NUMAHugepagesNode,
+		factory.conf.Rpk.NUMAHugepages,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newDiskQuotaTuner(
+	params *TunerParams,
+) tuners.Tunable {
+	return tuners.NewDiskQuotaTuner(
+		factory.fs,
+		factory.conf.Redpanda.Directory,
+		params.DiskQuota,
+		factory.proc,
+		factory.timeout,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newBallastFileTuner(
+	params *TunerParams,
+) tuners.Tunable {
+	return tuners.NewBallastFileTuner(
+		factory.fs,
+		params.BallastFilePath,
+		params.BallastFileSize,
+		tuners.RequiredMinFreeDisk(factory.conf.Rpk),
+		func(dir string) (bool, error) {
+			return filesystem.FallocateSupported(factory.fs, dir)
+		},
+		filesystem.GetFreeDiskSpaceGB,
+		factory.executor,
+	)
+}
+
+func (factory *tunersFactory) newNICIRQAffinityTuner(
+	params *TunerParams,
+) tuners.Tunable {
+	ethtool, err := ethtool.NewEthtoolWrapper()
+	if err != nil {
+		panic(err)
+	}
+	nics := []network.Nic{}
+	for _, name := range kafkaNics(&factory.conf, params.Nics) {
+		nics = append(nics, network.NewNic(
+			factory.fs,
+			factory.irqProcFile,
+			factory.irqDeviceInfo,
+			ethtool,
+			name,
+		))
+	}
+	return tuners.NewNICIRQAffinityTuner(
+		nics,
+		redpandaCpuMask(&factory.conf, params.CpuMask),
+		factory.cpuMasks,
+		factory.irqBalanceService,
+	)
+}
+
+// Returns the NIC with the advertised Kafka API address (or the Kafka API's
+// address, if it isn't set), or nics if there's none, e.g. because redpanda
+// listens on 0.0.0.0.
+func kafkaNics(conf *config.Config, nics []string) []string {
+	address := conf.Redpanda.KafkaApi.Address
+	if conf.Redpanda.AdvertisedKafkaApi != nil {
+		address = conf.Redpanda.AdvertisedKafkaApi.Address
+	}
+	nic, err := net.GetInterfaceByAddress(address)
+	if err != nil || nic == "" {
+		log.Debugf(
+			"Couldn't find the NIC with address '%s' (%v). Using '%v'",
+			address,
+			err,
+			nics,
+		)
+		return nics
+	}
+	return []string{nic}
+}
+
+// Returns the CPUs redpanda runs on: cpuMask, if it's restricted, or the
+// --cpuset in rpk.additional_start_flags.
+func redpandaCpuMask(conf *config.Config, cpuMask string) string {
+	if cpuMask != "" && cpuMask != "all" {
+		return cpuMask
+	}
+	pattern := regexp.MustCompile(`[\s=]+`)
+	for _, flag := range conf.Rpk.AdditionalStartFlags {
+		parts := pattern.Split(strings.TrimSpace(flag), 2)
+		if len(parts) != 2 || strings.TrimLeft(parts[0], "-") != "cpuset" {
+			continue
+		}
+		mask, err := hwloc.TranslateToHwLocCpuSet(parts[1])
+		if err != nil {
+			log.Warn(err)
+			break
+		}
+		return mask
+	}
+	return "all"
 }
 
 func MergeTunerParamsConfig(
@@ -300,9 +652,44 @@ func MergeTunerParamsConfig(
 	if len(params.Directories) == 0 {
 		params.Directories = []string{conf.Redpanda.Directory}
 	}
+	if params.DiskQuota == "" {
+		params.DiskQuota = conf.Rpk.DiskQuotaSize
+	}
+	if params.CoredumpDir == "" {
+		params.CoredumpDir = coredumpDir(conf)
+	}
+	if params.Swappiness == nil {
+		params.Swappiness = conf.Rpk.Swappiness
+	}
+	fillBallastFileParams(params, conf)
 	return params, nil
 }
 
+// Sets the ballast file's path in the data directory and its size, unless
+// they were passed already.
+func fillBallastFileParams(params *TunerParams, conf *config.Config) {
+	if params.BallastFilePath == "" {
+		params.BallastFilePath = tuners.BallastFilePath(
+			conf.Redpanda.Directory,
+		)
+	}
+	if params.BallastFileSize == "" {
+		params.BallastFileSize = conf.Rpk.BallastFileSize
+	}
+	if params.BallastFileSize == "" {
+		params.BallastFileSize = tuners.DefaultBallastFileSize
+	}
+}
+
+// Returns rpk.coredump_dir, or a directory next to the data directory if it
+// isn't set.
+func coredumpDir(conf *config.Config) string {
+	if conf.Rpk.CoredumpDir != "" {
+		return conf.Rpk.CoredumpDir
+	}
+	return filepath.Join(filepath.Dir(conf.Redpanda.Directory), "coredump")
+}
+
 func FillTunerParamsWithValuesFromConfig(
 	params *TunerParams, conf *config.Config,
 ) error {
@@ -315,5 +702,12 @@ func FillTunerParamsWithValuesFromConfig(
 	log.Infof("Redpanda uses '%v' NICs", params.Nics)
 	log.Infof("Redpanda data directory '%s'", conf.Redpanda.Directory)
 	params.Directories = []string{conf.Redpanda.Directory}
+	if params.DiskQuota == "" {
+		params.DiskQuota = conf.Rpk.DiskQuotaSize
+	}
+	if params.CoredumpDir == "" {
+		params.CoredumpDir = coredumpDir(conf)
+	}
+	fillBallastFileParams(params, conf)
 	return nil
 }
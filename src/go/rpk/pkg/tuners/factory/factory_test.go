@@ -11,6 +11,7 @@ package factory_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
@@ -19,12 +20,15 @@ import (
 
 func getValidTunerParams() *factory.TunerParams {
 	return &factory.TunerParams{
-		Mode:		"",
-		CpuMask:	"00000000000000000000000000000001",
-		RebootAllowed:	true,
-		Disks:		[]string{"dev1"},
-		Directories:	[]string{"/var/lib/redpanda"},
-		Nics:		[]string{"eth0"},
+		Mode:			"",
+		CpuMask:		"00000000000000000000000000000001",
+		RebootAllowed:		true,
+		Disks:			[]string{"dev1"},
+		Directories:		[]string{"/var/lib/redpanda"},
+		Nics:			[]string{"eth0"},
+		CoredumpDir:		"/var/lib/redpanda/cores",
+		BallastFilePath:	"/var/lib/redpanda/.rpk_ballast",
+		BallastFileSize:	"2GiB",
 	}
 }
 
@@ -44,6 +48,9 @@ func TestMergeTunerParamsConfig(t *testing.T) {
 			tunerParams: func() *factory.TunerParams {
 				params := getValidTunerParams()
 				params.Directories = []string{}
+				params.CoredumpDir = ""
+				params.BallastFilePath = ""
+				params.BallastFileSize = ""
 				return params
 			},
 			expected: func() *factory.TunerParams {
@@ -51,6 +58,9 @@ func TestMergeTunerParamsConfig(t *testing.T) {
 				params.Directories = []string{
 					config.Default().Redpanda.Directory,
 				}
+				params.CoredumpDir = config.Default().Rpk.CoredumpDir
+				params.BallastFilePath = "/var/lib/redpanda/data/.rpk_ballast"
+				params.BallastFileSize = "1GiB"
 				return params
 			},
 		},
@@ -66,3 +76,54 @@ func TestMergeTunerParamsConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestTunerTimeout(t *testing.T) {
+	tests := []struct {
+		name		string
+		timeouts	map[string]string
+		expected	time.Duration
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should default to the global timeout",
+			expected:	10 * time.Second,
+		},
+		{
+			name:		"it should ignore the other tuners' overrides",
+			timeouts:	map[string]string{"cpu": "1m"},
+			expected:	10 * time.Second,
+		},
+		{
+			name:		"it should return the tuner's override",
+			timeouts:	map[string]string{"disk_irq": "5m"},
+			expected:	5 * time.Minute,
+		},
+		{
+			name:		"it should fail if the override isn't a duration",
+			timeouts:	map[string]string{"disk_irq": "5"},
+			expectedErrMsg:	"invalid timeout '5' for tuner 'disk_irq' in rpk.tuner_timeouts. It must be a positive duration, e.g. '5m'",
+		},
+		{
+			name:		"it should fail if the override isn't positive",
+			timeouts:	map[string]string{"disk_irq": "0s"},
+			expectedErrMsg:	"invalid timeout '0s' for tuner 'disk_irq' in rpk.tuner_timeouts. It must be a positive duration, e.g. '5m'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			conf := config.Default()
+			conf.Rpk.TunerTimeouts = tt.timeouts
+			timeout, err := factory.TunerTimeout(
+				"disk_irq",
+				conf.Rpk,
+				10*time.Second,
+			)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, timeout)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system/systemd"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// The minimum RLIMIT_NOFILE (ulimit -n) the file_descriptors tuner
+	// raises the limit to, and the checker requires, unless
+	// rpk.min_fd_limit is set.
+	DefaultMinFdLimit	uint64	= 1048576
+
+	redpandaUnit		= "redpanda.service"
+	fdLimitDropInName	= "rpk-fd-limit.conf"
+)
+
+// Returns rpk.min_fd_limit, or DefaultMinFdLimit if it isn't set.
+func RequiredFdLimit(conf config.RpkConfig) uint64 {
+	if conf.MinFdLimit != 0 {
+		return conf.MinFdLimit
+	}
+	return DefaultMinFdLimit
+}
+
+// Checks that RLIMIT_NOFILE (ulimit -n) is at least the required value.
+// Otherwise, redpanda may fail to open segments or accept connections under
+// load.
+type fdLimitChecker struct {
+	getLimit	func() (*unix.Rlimit, error)
+	required	uint64
+}
+
+func NewFdLimitChecker(
+	getLimit func() (*unix.Rlimit, error), required uint64,
+) Checker {
+	return &fdLimitChecker{getLimit: getLimit, required: required}
+}
+
+func (*fdLimitChecker) Id() CheckerID {
+	return FdLimitChecker
+}
+
+func (*fdLimitChecker) GetDesc() string {
+	return "Max open files (ulimit -n)"
+}
+
+func (*fdLimitChecker) GetSeverity() Severity {
+	return Warning
+}
+
+func (c *fdLimitChecker) GetRequiredAsString() string {
+	return ">= " + formatFdLimit(c.required)
+}
+
+func (c *fdLimitChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+	}
+	limit, err := c.getLimit()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Current = formatFdLimit(limit.Cur)
+	res.IsOk = limit.Cur >= c.required
+	return res
+}
+
+// Raises RLIMIT_NOFILE for rpk's process, and therefore redpanda's, and, if
+// redpanda runs as a systemd service, installs a drop-in setting its
+// LimitNOFILE, since the limit is per-process and the service doesn't inherit
+// it from rpk. The drop-in applies once the service is restarted. Without a
+// newClient, only the process' limit is raised.
+type fdLimitTuner struct {
+	fs		afero.Fs
+	getLimit	func() (*unix.Rlimit, error)
+	setLimit	func(*unix.Rlimit) error
+	required	uint64
+	newClient	func() (systemd.Client, error)
+	executor	executors.Executor
+	previous	uint64
+	raised		uint64
+	dropIn		string
+}
+
+func NewFdLimitTuner(
+	fs afero.Fs,
+	getLimit func() (*unix.Rlimit, error),
+	setLimit func(*unix.Rlimit) error,
+	required uint64,
+	newClient func() (systemd.Client, error),
+	executor executors.Executor,
+) Tunable {
+	return &fdLimitTuner{
+		fs:		fs,
+		getLimit:	getLimit,
+		setLimit:	setLimit,
+		required:	required,
+		newClient:	newClient,
+		executor:	executor,
+	}
+}
+
+func (*fdLimitTuner) CheckIfSupported() (supported bool, reason string) {
+	return true, ""
+}
+
+func (t *fdLimitTuner) Tune() TuneResult {
+	// The limit only applies to rpk's process and its children, so there's
+	// nothing to raise when the commands are just being written to a script.
+	if !t.executor.IsLazy() {
+		err := t.raiseLimit()
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	if t.newClient == nil {
+		return NewTuneResult(false)
+	}
+	c, err := t.newClient()
+	if err != nil {
+		log.Debugf("Not installing a systemd drop-in: %v", err)
+		return NewTuneResult(false)
+	}
+	defer c.Shutdown()
+	loadState, _, err := c.UnitState(redpandaUnit)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	if !systemd.IsLoaded(loadState) {
+		log.Debugf(
+			"Not installing a systemd drop-in, since '%s' isn't"+
+				" installed",
+			redpandaUnit,
+		)
+		return NewTuneResult(false)
+	}
+	body := fmt.Sprintf("[Service]\nLimitNOFILE=%d\n", t.required)
+	err = t.executor.Execute(commands.NewInstallSystemdDropInCmd(
+		c,
+		t.fs,
+		redpandaUnit,
+		fdLimitDropInName,
+		body,
+	))
+	if err != nil {
+		return NewTuneError(err)
+	}
+	t.dropIn = systemd.DropInPath(redpandaUnit, fdLimitDropInName)
+	log.Infof(
+		"Installed '%s'. Restart %s for its limit to apply",
+		t.dropIn,
+		redpandaUnit,
+	)
+	return NewTuneResult(false)
+}
+
+// Returns how the process' limit changed, and the systemd drop-in installed.
+func (t *fdLimitTuner) Details() map[string]string {
+	details := map[string]string{}
+	if t.raised != 0 {
+		details["nofile"] = fmt.Sprintf(
+			"%s -> %s",
+			formatFdLimit(t.previous),
+			formatFdLimit(t.raised),
+		)
+	}
+	if t.dropIn != "" {
+		details["systemd_drop_in"] = t.dropIn
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// Raises the soft limit to the required value, and the hard one too if it's
+// lower, which requires CAP_SYS_RESOURCE. Unlike other limits, the soft one
+// can't be unlimited, so it's not raised up to the hard one.
+func (t *fdLimitTuner) raiseLimit() error {
+	limit, err := t.getLimit()
+	if err != nil {
+		return err
+	}
+	if limit.Cur >= t.required {
+		return nil
+	}
+	raised := &unix.Rlimit{Cur: t.required, Max: limit.Max}
+	if limit.Max < t.required {
+		raised = &unix.Rlimit{Cur: t.required, Max: t.required}
+	}
+	err = t.setLimit(raised)
+	if err != nil {
+		return fmt.Errorf(
+			"couldn't raise the max open files from %s to %s: %v."+
+				" Raise it with 'ulimit -n' or systemd's LimitNOFILE",
+			formatFdLimit(limit.Cur),
+			formatFdLimit(raised.Cur),
+			err,
+		)
+	}
+	log.Infof(
+		"Raised the max open files from %s to %s",
+		formatFdLimit(limit.Cur),
+		formatFdLimit(raised.Cur),
+	)
+	t.previous, t.raised = limit.Cur, raised.Cur
+	return nil
+}
+
+func formatFdLimit(limit uint64) string {
+	if limit == unix.RLIM_INFINITY {
+		return "unlimited"
+	}
+	return fmt.Sprint(limit)
+}
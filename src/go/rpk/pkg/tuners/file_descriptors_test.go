@@ -0,0 +1,206 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system/systemd"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+	"golang.org/x/sys/unix"
+)
+
+func TestRequiredFdLimit(t *testing.T) {
+	require.Equal(t, DefaultMinFdLimit, RequiredFdLimit(config.RpkConfig{}))
+	require.EqualValues(
+		t,
+		65536,
+		RequiredFdLimit(config.RpkConfig{MinFdLimit: 65536}),
+	)
+}
+
+func TestFdLimitChecker(t *testing.T) {
+	tests := []struct {
+		name		string
+		limit		uint64
+		expectedOk	bool
+		expectedCurrent	string
+	}{
+		{
+			name:			"it should pass if the limit is enough",
+			limit:			2097152,
+			expectedOk:		true,
+			expectedCurrent:	"2097152",
+		},
+		{
+			name:			"it should pass if there's no limit",
+			limit:			unix.RLIM_INFINITY,
+			expectedOk:		true,
+			expectedCurrent:	"unlimited",
+		},
+		{
+			name:			"it should fail if the limit is too low",
+			limit:			1024,
+			expectedCurrent:	"1024",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			getLimit := func() (*unix.Rlimit, error) {
+				return &unix.Rlimit{Cur: tt.limit, Max: tt.limit}, nil
+			}
+			res := NewFdLimitChecker(getLimit, 1048576).Check()
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectedOk, res.IsOk)
+			require.Equal(st, tt.expectedCurrent, res.Current)
+			require.Equal(st, ">= 1048576", res.Required)
+			require.EqualValues(st, Warning, res.Severity)
+		})
+	}
+}
+
+func TestFdLimitTuner(t *testing.T) {
+	dropIn := "/etc/systemd/system/redpanda.service.d/rpk-fd-limit.conf"
+	tests := []struct {
+		name		string
+		limit		unix.Rlimit
+		setErr		error
+		loadState	systemd.LoadState
+		noClient	bool
+		expected	unix.Rlimit
+		expectedDetails	map[string]string
+		expectedDropIn	bool
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should raise the soft limit if the hard one is enough",
+			limit:		unix.Rlimit{Cur: 1024, Max: unix.RLIM_INFINITY},
+			loadState:	systemd.LoadStateNotFound,
+			expected:	unix.Rlimit{Cur: 1048576, Max: unix.RLIM_INFINITY},
+			expectedDetails: map[string]string{
+				"nofile": "1024 -> 1048576",
+			},
+		},
+		{
+			name:		"it should raise the hard limit if it's too low",
+			limit:		unix.Rlimit{Cur: 1024, Max: 4096},
+			loadState:	systemd.LoadStateNotFound,
+			expected:	unix.Rlimit{Cur: 1048576, Max: 1048576},
+			expectedDetails: map[string]string{
+				"nofile": "1024 -> 1048576",
+			},
+		},
+		{
+			name:		"it should install a drop-in if redpanda is a systemd service",
+			limit:		unix.Rlimit{Cur: 1048576, Max: 1048576},
+			loadState:	systemd.LoadStateLoaded,
+			expected:	unix.Rlimit{Cur: 1048576, Max: 1048576},
+			expectedDetails: map[string]string{
+				"systemd_drop_in": dropIn,
+			},
+			expectedDropIn:	true,
+		},
+		{
+			name:		"it should only raise the limit if there's no systemd client",
+			limit:		unix.Rlimit{Cur: 1024, Max: 1048576},
+			noClient:	true,
+			expected:	unix.Rlimit{Cur: 1048576, Max: 1048576},
+			expectedDetails: map[string]string{
+				"nofile": "1024 -> 1048576",
+			},
+		},
+		{
+			name:		"it shouldn't change anything if the limit is enough",
+			limit:		unix.Rlimit{Cur: 2097152, Max: 2097152},
+			loadState:	systemd.LoadStateNotFound,
+			expected:	unix.Rlimit{Cur: 2097152, Max: 2097152},
+		},
+		{
+			name:		"it should fail if the limit can't be raised",
+			limit:		unix.Rlimit{Cur: 1024, Max: 4096},
+			setErr:		errors.New("operation not permitted"),
+			expected:	unix.Rlimit{Cur: 1024, Max: 4096},
+			expectedErrMsg:	"couldn't raise the max open files from 1024 to 1048576: operation not permitted. Raise it with 'ulimit -n' or systemd's LimitNOFILE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			limit := tt.limit
+			getLimit := func() (*unix.Rlimit, error) {
+				l := limit
+				return &l, nil
+			}
+			setLimit := func(l *unix.Rlimit) error {
+				if tt.setErr != nil {
+					return tt.setErr
+				}
+				limit = *l
+				return nil
+			}
+			newClient := func() (systemd.Client, error) {
+				return systemd.NewMockClient(
+					func() error { return nil },
+					nil,
+					func(string) (systemd.LoadState, systemd.ActiveState, error) {
+						return tt.loadState, systemd.ActiveStateActive, nil
+					},
+					func(fs afero.Fs, body, name string) error {
+						_, err := utils.WriteBytes(
+							fs,
+							[]byte(body),
+							systemd.UnitPath(name),
+						)
+						return err
+					},
+				), nil
+			}
+			if tt.noClient {
+				newClient = nil
+			}
+			tuner := NewFdLimitTuner(
+				fs,
+				getLimit,
+				setLimit,
+				1048576,
+				newClient,
+				executors.NewDirectExecutor(),
+			)
+			res := tuner.Tune()
+			if tt.expectedErrMsg != "" {
+				require.True(st, res.IsFailed())
+				require.EqualError(st, res.Error(), tt.expectedErrMsg)
+				require.Equal(st, tt.expected, limit)
+				return
+			}
+			require.False(st, res.IsFailed())
+			require.Equal(st, tt.expected, limit)
+			require.Equal(st, tt.expectedDetails, TuneDetails(tuner))
+
+			exists, err := afero.Exists(fs, dropIn)
+			require.NoError(st, err)
+			require.Equal(st, tt.expectedDropIn, exists)
+			if tt.expectedDropIn {
+				body, err := afero.ReadFile(fs, dropIn)
+				require.NoError(st, err)
+				require.Equal(
+					st,
+					"[Service]\nLimitNOFILE=1048576\n",
+					string(body),
+				)
+			}
+		})
+	}
+}
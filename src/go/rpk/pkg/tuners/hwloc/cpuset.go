@@ -10,19 +10,22 @@
 package hwloc
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
+// Translates cpuset, in cpuset(7)'s list format (e.g. '0-3,8,10-11'), to
+// hwloc's PU locations, e.g. 'PU:0-3 PU:8 PU:10-11'. "all" is kept as is.
 func TranslateToHwLocCpuSet(cpuset string) (string, error) {
-
-	cpuSetPattern := regexp.MustCompile("^(\\d+-)?(\\d+)(,(\\d+-)?(\\d+))*$")
 	if cpuset == "all" {
 		return cpuset, nil
 	}
-	if !cpuSetPattern.MatchString(cpuset) {
-		return "", fmt.Errorf("configured cpuset '%s' is invalid", cpuset)
+	_, err := CpusInList(cpuset)
+	if err != nil {
+		return "", err
 	}
 	var logicalCores []string
 	for _, part := range strings.Split(cpuset, ",") {
@@ -30,3 +33,139 @@ func TranslateToHwLocCpuSet(cpuset string) (string, error) {
 	}
 	return strings.Join(logicalCores, " "), nil
 }
+
+// Checks that mask is an hwloc bitmask, i.e. comma-separated 32-bit hex words
+// such as '0x0000ffff' or '0x000000ff,,0xffffffff' (an empty word is zero),
+// with at least one CPU set.
+func ValidateCpuMask(mask string) error {
+	cpuMaskPattern := regexp.MustCompile(
+		"^0x[[:xdigit:]]{1,8}(,+0x[[:xdigit:]]{1,8})*$",
+	)
+	if !cpuMaskPattern.MatchString(mask) {
+		return fmt.Errorf(
+			"CPU mask '%s' is invalid. It must be in hwloc's format,"+
+				" e.g. '0x0000ffff' or '0x000000ff,0xffffffff'",
+			mask,
+		)
+	}
+	if strings.Trim(mask, "0x,") == "" {
+		return fmt.Errorf("CPU mask '%s' doesn't include any CPUs", mask)
+	}
+	return nil
+}
+
+// Returns the indexes of the CPUs in mask, an hwloc bitmask whose words are
+// ordered from the most to the least significant one.
+func CpusInMask(mask string) ([]uint, error) {
+	err := ValidateCpuMask(mask)
+	if err != nil {
+		return nil, err
+	}
+	words := strings.Split(mask, ",")
+	cpus := []uint{}
+	for i := len(words) - 1; i >= 0; i-- {
+		if words[i] == "" {
+			continue
+		}
+		word, err := strconv.ParseUint(words[i], 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		offset := uint(len(words)-1-i) * 32
+		for bit := uint(0); bit < 32; bit++ {
+			if word&(1<<bit) != 0 {
+				cpus = append(cpus, offset+bit)
+			}
+		}
+	}
+	return cpus, nil
+}
+
+// Returns the CPUs in cpuset, in cpuset(7)'s list format (e.g. '0-3,8'),
+// which aren't in mask, an hwloc bitmask such as the one All returns.
+func CpusMissingFromMask(cpuset, mask string) ([]uint, error) {
+	available, err := CpusInMask(mask)
+	if err != nil {
+		return nil, err
+	}
+	requested, err := CpusInList(cpuset)
+	if err != nil {
+		return nil, err
+	}
+	present := map[uint]bool{}
+	for _, cpu := range available {
+		present[cpu] = true
+	}
+	missing := []uint{}
+	for _, cpu := range requested {
+		if !present[cpu] {
+			missing = append(missing, cpu)
+		}
+	}
+	return missing, nil
+}
+
+// Parses a list of CPUs in cpuset(7)'s list format, e.g. '0-3,8'.
+func CpusInList(cpuset string) ([]uint, error) {
+	cpus := []uint{}
+	for _, part := range strings.Split(cpuset, ",") {
+		first, last, err := parseCpuRange(part)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"configured cpuset '%s' is invalid: %v",
+				cpuset,
+				err,
+			)
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, uint(cpu))
+		}
+	}
+	return cpus, nil
+}
+
+// Parses an element of a cpuset list, either a CPU (e.g. '8') or an
+// inclusive range of them (e.g. '0-3'), into its first and last CPU.
+func parseCpuRange(part string) (first, last uint64, err error) {
+	if part == "" {
+		return 0, 0, errors.New(
+			"it has an empty element. The elements must be" +
+				" separated by a single comma, e.g. '0-3,8'",
+		)
+	}
+	bounds := strings.SplitN(part, "-", 2)
+	if len(bounds) == 2 && (bounds[0] == "" || bounds[1] == "") {
+		return 0, 0, fmt.Errorf(
+			"range '%s' is open-ended. Both of its ends must be"+
+				" set, e.g. '0-3'",
+			part,
+		)
+	}
+	first, err = strconv.ParseUint(bounds[0], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf(
+			"'%s' isn't a CPU number or a range like '0-3'",
+			part,
+		)
+	}
+	if len(bounds) == 1 {
+		return first, first, nil
+	}
+	last, err = strconv.ParseUint(bounds[1], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf(
+			"'%s' isn't a CPU number or a range like '0-3'",
+			part,
+		)
+	}
+	if last < first {
+		return 0, 0, fmt.Errorf(
+			"range '%s' is reversed. Its first CPU must be lower"+
+				" than its last one, e.g. '%d-%d'",
+			part,
+			last,
+			first,
+		)
+	}
+	return first, last, nil
+}
@@ -20,30 +20,185 @@ func TestTranslateToHwLocCpuSet(t *testing.T) {
 		name	string
 		cpuset	string
 		want	string
-		wantErr	bool
+		wantErr	string
 	}{
 		{
-			name:		"shall return all in not changed form",
-			cpuset:		"all",
-			want:		"all",
-			wantErr:	false,
+			name:	"shall return all in not changed form",
+			cpuset:	"all",
+			want:	"all",
+		},
+		{
+			name:	"shall translate a single core",
+			cpuset:	"8",
+			want:	"PU:8",
 		},
 		{
-			name:		"shall translate cpuset(7) list type to hwloc PU's",
-			cpuset:		"0-1,4,10-12,3",
-			want:		"PU:0-1 PU:4 PU:10-12 PU:3",
-			wantErr:	false,
+			name:	"shall translate a range",
+			cpuset:	"0-3",
+			want:	"PU:0-3",
+		},
+		{
+			name:	"shall translate cpuset(7) list type to hwloc PU's",
+			cpuset:	"0-1,4,10-12,3",
+			want:	"PU:0-1 PU:4 PU:10-12 PU:3",
 		},
 		{
 			name:		"shall return error on invalid CPU set",
 			cpuset:		"0 to 1",
-			want:		"",
-			wantErr:	true,
+			wantErr:	"configured cpuset '0 to 1' is invalid: '0 to 1' isn't a CPU number or a range like '0-3'",
+		},
+		{
+			name:		"shall return error on a reversed range",
+			cpuset:		"0,3-1",
+			wantErr:	"configured cpuset '0,3-1' is invalid: range '3-1' is reversed. Its first CPU must be lower than its last one, e.g. '1-3'",
+		},
+		{
+			name:		"shall return error on an open-ended range",
+			cpuset:		"0-",
+			wantErr:	"configured cpuset '0-' is invalid: range '0-' is open-ended. Both of its ends must be set, e.g. '0-3'",
+		},
+		{
+			name:		"shall return error on a range without a start",
+			cpuset:		"-3",
+			wantErr:	"configured cpuset '-3' is invalid: range '-3' is open-ended. Both of its ends must be set, e.g. '0-3'",
+		},
+		{
+			name:		"shall return error on an empty element",
+			cpuset:		"0-3,,8",
+			wantErr:	"configured cpuset '0-3,,8' is invalid: it has an empty element. The elements must be separated by a single comma, e.g. '0-3,8'",
+		},
+		{
+			name:		"shall return error on an empty CPU set",
+			cpuset:		"",
+			wantErr:	"configured cpuset '' is invalid: it has an empty element. The elements must be separated by a single comma, e.g. '0-3,8'",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := TranslateToHwLocCpuSet(tt.cpuset)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateCpuMask(t *testing.T) {
+	tests := []struct {
+		name	string
+		mask	string
+		wantErr	string
+	}{
+		{
+			name:	"shall accept a single word",
+			mask:	"0x0000ffff",
+		},
+		{
+			name:	"shall accept several words, including empty ones",
+			mask:	"0x000000ff,,0xFFFFFFFF",
+		},
+		{
+			name:		"shall fail if a word is longer than 32 bits",
+			mask:		"0x1ffffffff",
+			wantErr:	"CPU mask '0x1ffffffff' is invalid. It must be in hwloc's format, e.g. '0x0000ffff' or '0x000000ff,0xffffffff'",
+		},
+		{
+			name:		"shall fail if the mask is a cpuset",
+			mask:		"0-3",
+			wantErr:	"CPU mask '0-3' is invalid. It must be in hwloc's format, e.g. '0x0000ffff' or '0x000000ff,0xffffffff'",
+		},
+		{
+			name:		"shall fail if the mask has a trailing comma",
+			mask:		"0x000000ff,",
+			wantErr:	"CPU mask '0x000000ff,' is invalid. It must be in hwloc's format, e.g. '0x0000ffff' or '0x000000ff,0xffffffff'",
+		},
+		{
+			name:		"shall fail if no CPU is set",
+			mask:		"0x00000000,0x0",
+			wantErr:	"CPU mask '0x00000000,0x0' doesn't include any CPUs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCpuMask(tt.mask)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCpusInMask(t *testing.T) {
+	tests := []struct {
+		name	string
+		mask	string
+		want	[]uint
+		wantErr	bool
+	}{
+		{
+			name:	"shall return the CPUs in a single word",
+			mask:	"0x00000013",
+			want:	[]uint{0, 1, 4},
+		},
+		{
+			name:	"shall order the words from the most significant one",
+			mask:	"0x00000001,,0x80000000",
+			want:	[]uint{31, 64},
+		},
+		{
+			name:		"shall return error on invalid mask",
+			mask:		"0-3",
+			wantErr:	true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CpusInMask(tt.mask)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCpusMissingFromMask(t *testing.T) {
+	tests := []struct {
+		name	string
+		cpuset	string
+		mask	string
+		want	[]uint
+		wantErr	bool
+	}{
+		{
+			name:	"shall return nothing if all the CPUs are present",
+			cpuset:	"0-3,7",
+			mask:	"0x000000ff",
+			want:	[]uint{},
+		},
+		{
+			name:	"shall return the CPUs which aren't present",
+			cpuset:	"2-5,9",
+			mask:	"0x0000000f",
+			want:	[]uint{4, 5, 9},
+		},
+		{
+			name:		"shall return error on invalid cpuset",
+			cpuset:		"3-1",
+			mask:		"0x0000000f",
+			wantErr:	true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CpusMissingFromMask(tt.cpuset, tt.mask)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
@@ -68,6 +68,10 @@ func (hwLocCmd *hwLocCmd) GetNumberOfPUs(mask string) (uint, error) {
 	return hwLocCmd.getNumberOf(mask, "PU")
 }
 
+func (hwLocCmd *hwLocCmd) GetNumberOfNUMANodes(mask string) (uint, error) {
+	return hwLocCmd.getNumberOf(mask, "NUMANode")
+}
+
 func (hwLocCmd *hwLocCmd) GetPhysIntersection(
 	firstMask string, secondMask string,
 ) ([]uint, error) {
@@ -121,8 +125,18 @@ func (*hwLocCmd) IsSupported() bool {
 	return true
 }
 
+// Runs the given hwloc command, loading the topology from the file set with
+// UseTopologyFile, if any.
+func (hwLocCmd *hwLocCmd) run(bin string, args ...string) ([]string, error) {
+	env := topologyEnv()
+	if envProc, ok := hwLocCmd.proc.(os.EnvProc); ok && len(env) > 0 {
+		return envProc.RunWithSystemLdPathAndEnv(hwLocCmd.timeout, env, bin, args...)
+	}
+	return hwLocCmd.proc.RunWithSystemLdPath(hwLocCmd.timeout, bin, args...)
+}
+
 func (hwLocCmd *hwLocCmd) runCalc(args ...string) (string, error) {
-	outputLines, err := hwLocCmd.proc.RunWithSystemLdPath(hwLocCmd.timeout, CalcBin, args...)
+	outputLines, err := hwLocCmd.run(CalcBin, args...)
 	if err != nil {
 		return "", err
 	}
@@ -131,7 +145,7 @@ func (hwLocCmd *hwLocCmd) runCalc(args ...string) (string, error) {
 
 func (hwLocCmd *hwLocCmd) runDistrib(args ...string) ([]string, error) {
 	var result []string
-	outputLines, err := hwLocCmd.proc.RunWithSystemLdPath(hwLocCmd.timeout, DistribBin, args...)
+	outputLines, err := hwLocCmd.run(DistribBin, args...)
 	if err != nil {
 		return nil, err
 	}
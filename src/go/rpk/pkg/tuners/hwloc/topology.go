@@ -0,0 +1,147 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package hwloc
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+const (
+	// The env var hwloc reads the XML topology to use from, instead of
+	// discovering it through /sys.
+	TopologyFileEnv	= "HWLOC_XMLFILE"
+	// Tells hwloc that the topology loaded from XML is the current
+	// system's.
+	thisSystemEnv	= "HWLOC_THISSYSTEM"
+)
+
+// The topology file set with UseTopologyFile.
+var topologyFile string
+
+// Makes the hwloc commands run by rpk load the topology from the given XML
+// file (e.g. one exported with `lstopo topo.xml`), which is useful where /sys
+// is masked, such as in some containers.
+func UseTopologyFile(fs afero.Fs, path string) error {
+	info, err := fs.Stat(path)
+	if err != nil {
+		return fmt.Errorf("couldn't read topology file '%s': %v", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("topology file '%s' is a directory", path)
+	}
+	f, err := fs.Open(path)
+	if err != nil {
+		return fmt.Errorf("couldn't read topology file '%s': %v", path, err)
+	}
+	f.Close()
+	log.Debugf("Using the hwloc topology in '%s'", path)
+	// It's passed to the hwloc commands only, rather than set in rpk's
+	// environment, which redpanda would inherit.
+	topologyFile = path
+	return nil
+}
+
+// Returns the env vars the hwloc commands need to load the topology file set
+// with UseTopologyFile, if any.
+func topologyEnv() []string {
+	if topologyFile == "" {
+		return nil
+	}
+	return []string{
+		TopologyFileEnv + "=" + topologyFile,
+		thisSystemEnv + "=1",
+	}
+}
+
+// Returns the topology file the hwloc commands load, either the one set with
+// UseTopologyFile or the one in HWLOC_XMLFILE, if any.
+func TopologyFile() string {
+	if topologyFile != "" {
+		return topologyFile
+	}
+	return os.Getenv(TopologyFileEnv)
+}
+
+// The topology the hwloc commands run by rpk see, which may be constrained
+// (e.g. in a container) or loaded from a topology file.
+type TopologySummary struct {
+	// The topology file in use, or empty if hwloc discovers the topology.
+	TopologyFile	string		`json:"topology_file,omitempty"`
+	AllMask		string		`json:"all_mask"`
+	CpuSetMask	string		`json:"cpu_set_mask"`
+	Cores		uint		`json:"cores"`
+	PUs		uint		`json:"pus"`
+	NUMANodes	[]NUMANode	`json:"numa_nodes"`
+}
+
+type NUMANode struct {
+	Index	uint	`json:"index"`
+	Mask	string	`json:"mask"`
+	Cores	uint	`json:"cores"`
+	PUs	uint	`json:"pus"`
+}
+
+// Describes the topology hwloc reports, along with the mask the given cpuset
+// (as returned by TranslateToHwLocCpuSet) resolves to.
+func Summarize(hw HwLoc, cpuSet string) (*TopologySummary, error) {
+	all, err := hw.All()
+	if err != nil {
+		return nil, err
+	}
+	cpuSetMask, err := hw.CalcSingle(cpuSet)
+	if err != nil {
+		return nil, err
+	}
+	cores, err := hw.GetNumberOfCores(all)
+	if err != nil {
+		return nil, err
+	}
+	pus, err := hw.GetNumberOfPUs(all)
+	if err != nil {
+		return nil, err
+	}
+	numaNodes, err := hw.GetNumberOfNUMANodes(all)
+	if err != nil {
+		return nil, err
+	}
+	summary := &TopologySummary{
+		TopologyFile:	TopologyFile(),
+		AllMask:	all,
+		CpuSetMask:	cpuSetMask,
+		Cores:		cores,
+		PUs:		pus,
+		NUMANodes:	[]NUMANode{},
+	}
+	for i := uint(0); i < numaNodes; i++ {
+		mask, err := hw.CalcSingle(fmt.Sprintf("numa:%d", i))
+		if err != nil {
+			return nil, err
+		}
+		cores, err := hw.GetNumberOfCores(mask)
+		if err != nil {
+			return nil, err
+		}
+		pus, err := hw.GetNumberOfPUs(mask)
+		if err != nil {
+			return nil, err
+		}
+		summary.NUMANodes = append(summary.NUMANodes, NUMANode{
+			Index:	i,
+			Mask:	mask,
+			Cores:	cores,
+			PUs:	pus,
+		})
+	}
+	return summary, nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package hwloc
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	vos "github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+)
+
+func TestUseTopologyFile(t *testing.T) {
+	tests := []struct {
+		name		string
+		before		func(afero.Fs) error
+		path		string
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should use the file if it exists",
+			before: func(fs afero.Fs) error {
+				return afero.WriteFile(fs, "/tmp/topo.xml", []byte("<topology/>"), 0644)
+			},
+			path:	"/tmp/topo.xml",
+		},
+		{
+			name:		"it should fail if the file doesn't exist",
+			path:		"/tmp/missing.xml",
+			expectedErrMsg:	"couldn't read topology file '/tmp/missing.xml': open /tmp/missing.xml: file does not exist",
+		},
+		{
+			name:	"it should fail if the path is a directory",
+			before: func(fs afero.Fs) error {
+				return fs.MkdirAll("/tmp/topo", 0755)
+			},
+			path:		"/tmp/topo",
+			expectedErrMsg:	"topology file '/tmp/topo' is a directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			defer func() { topologyFile = "" }()
+			fs := afero.NewMemMapFs()
+			if tt.before != nil {
+				require.NoError(st, tt.before(fs))
+			}
+			err := UseTopologyFile(fs, tt.path)
+			// rpk's env, which redpanda inherits, is left untouched.
+			require.Empty(st, os.Getenv(TopologyFileEnv))
+			require.Empty(st, os.Getenv(thisSystemEnv))
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				require.Empty(st, topologyEnv())
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.path, TopologyFile())
+			require.Equal(
+				st,
+				[]string{
+					"HWLOC_XMLFILE=" + tt.path,
+					"HWLOC_THISSYSTEM=1",
+				},
+				topologyEnv(),
+			)
+		})
+	}
+}
+
+// Records the env the hwloc commands are run with.
+type envProcMock struct {
+	vos.Proc
+	env	[]string
+}
+
+func (m *envProcMock) RunWithSystemLdPath(
+	time.Duration, string, ...string,
+) ([]string, error) {
+	m.env = nil
+	return []string{"0x00000001"}, nil
+}
+
+func (m *envProcMock) RunWithSystemLdPathAndEnv(
+	_ time.Duration, env []string, _ string, _ ...string,
+) ([]string, error) {
+	m.env = env
+	return []string{"0x00000001"}, nil
+}
+
+func TestHwLocCmdTopologyEnv(t *testing.T) {
+	defer func() { topologyFile = "" }()
+	proc := &envProcMock{}
+	hw := NewHwLocCmd(proc, time.Second)
+	_, err := hw.All()
+	require.NoError(t, err)
+	require.Empty(t, proc.env)
+
+	fs := afero.NewMemMapFs()
+	err = afero.WriteFile(fs, "/tmp/topo.xml", []byte("<topology/>"), 0644)
+	require.NoError(t, err)
+	require.NoError(t, UseTopologyFile(fs, "/tmp/topo.xml"))
+	_, err = hw.All()
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]string{"HWLOC_XMLFILE=/tmp/topo.xml", "HWLOC_THISSYSTEM=1"},
+		proc.env,
+	)
+	_, err = hw.Distribute(1)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]string{"HWLOC_XMLFILE=/tmp/topo.xml", "HWLOC_THISSYSTEM=1"},
+		proc.env,
+	)
+}
+
+// Answers the hwloc-calc invocations from a map of their args.
+type calcProcMock struct {
+	vos.Proc
+	outputs	map[string]string
+}
+
+func (m *calcProcMock) RunWithSystemLdPath(
+	_ time.Duration, _ string, args ...string,
+) ([]string, error) {
+	return []string{m.outputs[strings.Join(args, " ")]}, nil
+}
+
+func TestSummarize(t *testing.T) {
+	const (
+		all	= "0x000000ff"
+		node0	= "0x0000000f"
+		node1	= "0x000000f0"
+	)
+	proc := &calcProcMock{outputs: map[string]string{
+		"all":							all,
+		"PU:0 PU:1":						"0x00000003",
+		"--number-of core machine:0 --restrict " + all:		"4",
+		"--number-of PU machine:0 --restrict " + all:		"8",
+		"--number-of NUMANode machine:0 --restrict " + all:	"2",
+		"numa:0":						node0,
+		"numa:1":						node1,
+		"--number-of core machine:0 --restrict " + node0:	"2",
+		"--number-of PU machine:0 --restrict " + node0:		"4",
+		"--number-of core machine:0 --restrict " + node1:	"2",
+		"--number-of PU machine:0 --restrict " + node1:		"4",
+	}}
+	summary, err := Summarize(NewHwLocCmd(proc, time.Second), "PU:0 PU:1")
+	require.NoError(t, err)
+	expected := &TopologySummary{
+		AllMask:	all,
+		CpuSetMask:	"0x00000003",
+		Cores:		4,
+		PUs:		8,
+		NUMANodes: []NUMANode{
+			{Index: 0, Mask: node0, Cores: 2, PUs: 4},
+			{Index: 1, Mask: node1, Cores: 2, PUs: 4},
+		},
+	}
+	require.Equal(t, expected, summary)
+}
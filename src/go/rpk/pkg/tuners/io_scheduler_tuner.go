@@ -0,0 +1,224 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/disk"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+)
+
+// Sets the I/O scheduler of the data devices according to their type: 'none'
+// for NVMe devices, whose many hardware queues don't benefit from reordering
+// requests, and 'mq-deadline' for the others (e.g. SATA SSDs), which do.
+type ioSchedulerTuner struct {
+	fs		afero.Fs
+	directories	[]string
+	devices		[]string
+	blockDevices	disk.BlockDevices
+	deviceFeatures	disk.DeviceFeatures
+	executor	executors.Executor
+	// The schedulers found and set, keyed by the scheduler file.
+	previous	map[string]string
+	applied		map[string]string
+}
+
+// The scheduler chosen for a device, and the file it's set through.
+type deviceScheduler struct {
+	file		string
+	scheduler	string
+}
+
+func NewIOSchedulerTuner(
+	fs afero.Fs,
+	directories []string,
+	devices []string,
+	blockDevices disk.BlockDevices,
+	deviceFeatures disk.DeviceFeatures,
+	executor executors.Executor,
+) Tunable {
+	return &ioSchedulerTuner{
+		fs:		fs,
+		directories:	directories,
+		devices:	devices,
+		blockDevices:	blockDevices,
+		deviceFeatures:	deviceFeatures,
+		executor:	executor,
+	}
+}
+
+func (t *ioSchedulerTuner) CheckIfSupported() (supported bool, reason string) {
+	if len(t.directories) == 0 && len(t.devices) == 0 {
+		return false,
+			"Either directories or devices must be provided for the io_scheduler tuner"
+	}
+	_, err := t.schedulers()
+	if err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
+
+func (t *ioSchedulerTuner) Tune() TuneResult {
+	schedulers, err := t.schedulers()
+	if err != nil {
+		return NewTuneError(err)
+	}
+	previous := map[string]string{}
+	applied := map[string]string{}
+	for device, s := range schedulers {
+		current, err := t.deviceFeatures.GetScheduler(device)
+		if err != nil {
+			return NewTuneError(err)
+		}
+		previous[s.file] = current
+		applied[s.file] = s.scheduler
+		if current == s.scheduler {
+			log.Debugf(
+				"The I/O scheduler for '%s' is already '%s'",
+				device,
+				s.scheduler,
+			)
+			continue
+		}
+		err = t.executor.Execute(
+			commands.NewWriteFileCmd(t.fs, s.file, s.scheduler),
+		)
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	t.previous, t.applied = previous, applied
+	return NewTuneResult(false)
+}
+
+// Returns each scheduler file's previous and applied scheduler, e.g.
+// /sys/block/nvme0n1/queue/scheduler => "mq-deadline -> none".
+func (t *ioSchedulerTuner) Details() map[string]string {
+	details := map[string]string{}
+	for file, applied := range t.applied {
+		details[file] = fmt.Sprintf("%s -> %s", t.previous[file], applied)
+	}
+	return details
+}
+
+func (t *ioSchedulerTuner) PreviousValues() map[string]string {
+	return t.previous
+}
+
+func (t *ioSchedulerTuner) Revert(previous map[string]string) TuneResult {
+	files := make([]string, 0, len(previous))
+	for file := range previous {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		err := t.executor.Execute(
+			commands.NewWriteFileCmd(t.fs, file, previous[file]),
+		)
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	return NewTuneResult(false)
+}
+
+// Returns the scheduler to set for each of the data devices. The directories
+// are resolved to the physical devices backing them, through LVM and md.
+func (t *ioSchedulerTuner) schedulers() (map[string]deviceScheduler, error) {
+	devices := map[string]bool{}
+	for _, dir := range t.directories {
+		directoryDevices, err := t.blockDevices.GetDirectoriesDevices(
+			[]string{dir})
+		if err != nil {
+			return nil, fmt.Errorf(
+				"couldn't find the block device backing '%s' (it"+
+					" may be on tmpfs or overlay): %v",
+				dir,
+				err,
+			)
+		}
+		if len(directoryDevices[dir]) == 0 {
+			return nil, fmt.Errorf(
+				"no block device backs '%s' (it may be on tmpfs"+
+					" or overlay)",
+				dir,
+			)
+		}
+		for _, device := range directoryDevices[dir] {
+			devices[device] = true
+		}
+	}
+	for _, device := range t.devices {
+		devices[device] = true
+	}
+	schedulers := map[string]deviceScheduler{}
+	for _, device := range utils.GetKeys(devices) {
+		scheduler, err := t.preferredScheduler(device)
+		if err != nil {
+			return nil, err
+		}
+		file, err := t.deviceFeatures.GetSchedulerFeatureFile(device)
+		if err != nil {
+			return nil, err
+		}
+		if file == "" {
+			return nil, fmt.Errorf(
+				"the I/O scheduler isn't available for device '%s'",
+				device,
+			)
+		}
+		schedulers[device] = deviceScheduler{file: file, scheduler: scheduler}
+	}
+	return schedulers, nil
+}
+
+// Returns the scheduler for the device: 'none' for NVMe devices and
+// 'mq-deadline' for the others, or their single-queue equivalents ('noop' and
+// 'deadline') on kernels without blk-mq.
+func (t *ioSchedulerTuner) preferredScheduler(device string) (string, error) {
+	candidates := []string{"none", "noop"}
+	if !strings.HasPrefix(device, "nvme") {
+		// Make sure it's an actual disk, whose type the kernel knows.
+		_, err := t.deviceFeatures.IsRotational(device)
+		if err != nil {
+			return "", fmt.Errorf(
+				"couldn't determine the type of device '%s': %v",
+				device,
+				err,
+			)
+		}
+		candidates = []string{"mq-deadline", "deadline"}
+	}
+	supported, err := t.deviceFeatures.GetSupportedSchedulers(device)
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range candidates {
+		for _, s := range supported {
+			if s == candidate {
+				return candidate, nil
+			}
+		}
+	}
+	return "", fmt.Errorf(
+		"device '%s' supports none of the schedulers %s, only %s",
+		device,
+		strings.Join(candidates, ", "),
+		strings.Join(supported, ", "),
+	)
+}
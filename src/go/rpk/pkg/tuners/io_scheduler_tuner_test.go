@@ -0,0 +1,169 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+)
+
+const schedulerFile = "/sys/devices/pci0000:00/0000:00:1d.0/fake/queue/scheduler"
+
+func TestIOSchedulerTuner(t *testing.T) {
+	tests := []struct {
+		name			string
+		device			string
+		supportedSchedulers	[]string
+		current			string
+		rotationalErr		error
+		expectedSupported	bool
+		expectedReason		string
+		expectedScheduler	string
+		expectedDetails		map[string]string
+	}{
+		{
+			name:			"it should set 'none' for NVMe devices",
+			device:			"nvme0n1",
+			supportedSchedulers:	[]string{"mq-deadline", "none"},
+			current:		"mq-deadline",
+			expectedSupported:	true,
+			expectedScheduler:	"none",
+			expectedDetails:	map[string]string{schedulerFile: "mq-deadline -> none"},
+		},
+		{
+			name:			"it should set 'mq-deadline' for other devices",
+			device:			"sda",
+			supportedSchedulers:	[]string{"mq-deadline", "none", "bfq"},
+			current:		"bfq",
+			expectedSupported:	true,
+			expectedScheduler:	"mq-deadline",
+			expectedDetails:	map[string]string{schedulerFile: "bfq -> mq-deadline"},
+		},
+		{
+			name:			"it should fall back to single-queue schedulers",
+			device:			"sda",
+			supportedSchedulers:	[]string{"noop", "deadline", "cfq"},
+			current:		"cfq",
+			expectedSupported:	true,
+			expectedScheduler:	"deadline",
+			expectedDetails:	map[string]string{schedulerFile: "cfq -> deadline"},
+		},
+		{
+			name:			"it should succeed if the scheduler is already set",
+			device:			"nvme0n1",
+			supportedSchedulers:	[]string{"mq-deadline", "none"},
+			current:		"none",
+			expectedSupported:	true,
+			expectedScheduler:	"none",
+			expectedDetails:	map[string]string{schedulerFile: "none -> none"},
+		},
+		{
+			name:			"it shouldn't be supported if the device type is unknown",
+			device:			"sda",
+			supportedSchedulers:	[]string{"mq-deadline", "none"},
+			rotationalErr:		errors.New("no such file"),
+			expectedReason:		"couldn't determine the type of device 'sda': no such file",
+		},
+		{
+			name:			"it shouldn't be supported if no preferred scheduler is available",
+			device:			"sda",
+			supportedSchedulers:	[]string{"bfq", "kyber"},
+			expectedReason:		"device 'sda' supports none of the schedulers mq-deadline, deadline, only bfq, kyber",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			err := afero.WriteFile(fs, schedulerFile, []byte(tt.current), 0644)
+			require.NoError(st, err)
+			deviceFeatures := &deviceFeaturesMock{
+				getSupportedSchedulers: func(string) ([]string, error) {
+					return tt.supportedSchedulers, nil
+				},
+				getSchedulerFeatureFile: func(string) (string, error) {
+					return schedulerFile, nil
+				},
+				getScheduler: func(string) (string, error) {
+					bs, err := afero.ReadFile(fs, schedulerFile)
+					return strings.TrimSpace(string(bs)), err
+				},
+				isRotational: func(string) (bool, error) {
+					return false, tt.rotationalErr
+				},
+			}
+			blockDevices := &blockDevicesMock{
+				getDirectoriesDevices: func(
+					[]string,
+				) (map[string][]string, error) {
+					return map[string][]string{
+						"/var/lib/redpanda": {tt.device},
+					}, nil
+				},
+			}
+			tuner := NewIOSchedulerTuner(
+				fs,
+				[]string{"/var/lib/redpanda"},
+				nil,
+				blockDevices,
+				deviceFeatures,
+				executors.NewDirectExecutor(),
+			)
+			supported, reason := tuner.CheckIfSupported()
+			require.Equal(st, tt.expectedSupported, supported)
+			require.Equal(st, tt.expectedReason, reason)
+			if !supported {
+				return
+			}
+			res := tuner.Tune()
+			require.False(st, res.IsFailed())
+			value, err := afero.ReadFile(fs, schedulerFile)
+			require.NoError(st, err)
+			require.Equal(st, tt.expectedScheduler, strings.TrimSpace(string(value)))
+			require.Equal(st, tt.expectedDetails, TuneDetails(tuner))
+
+			rt := tuner.(RevertibleTunable)
+			res = rt.Revert(rt.PreviousValues())
+			require.False(st, res.IsFailed())
+			value, err = afero.ReadFile(fs, schedulerFile)
+			require.NoError(st, err)
+			require.Equal(st, tt.current, strings.TrimSpace(string(value)))
+		})
+	}
+}
+
+func TestIOSchedulerTunerNoBlockDevice(t *testing.T) {
+	blockDevices := &blockDevicesMock{
+		getDirectoriesDevices: func(
+			[]string,
+		) (map[string][]string, error) {
+			return map[string][]string{"/var/lib/redpanda": {}}, nil
+		},
+	}
+	tuner := NewIOSchedulerTuner(
+		afero.NewMemMapFs(),
+		[]string{"/var/lib/redpanda"},
+		nil,
+		blockDevices,
+		&deviceFeaturesMock{},
+		executors.NewDirectExecutor(),
+	)
+	supported, reason := tuner.CheckIfSupported()
+	require.False(t, supported)
+	require.Equal(
+		t,
+		"no block device backs '/var/lib/redpanda' (it may be on tmpfs or overlay)",
+		reason,
+	)
+}
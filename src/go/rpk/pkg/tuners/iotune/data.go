@@ -11,10 +11,10 @@ package iotune
 
 import (
 	"fmt"
+	"sort"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/vendor"
-	"gopkg.in/yaml.v2"
 )
 
 type IoProperties struct {
@@ -56,15 +56,112 @@ func DataForVendor(
 	return DataFor(mountpoint, v.Name(), vmType, "default")
 }
 
-func ToYaml(props IoProperties) (string, error) {
-	type ioPropertiesWrapper struct {
-		Disks []IoProperties `yaml:"disks"`
+// A well-known IO profile, i.e. the IO properties measured for a storage type
+// of a vendor's VM type.
+type Candidate struct {
+	Vendor	string
+	VM	string
+	Storage	string
+	Props	IoProperties
+}
+
+// Returns the profile in the --well-known-io format,
+// <vendor>:<vm type>:<storage type>.
+func (c Candidate) String() string {
+	return fmt.Sprintf("%s:%s:%s", c.Vendor, c.VM, c.Storage)
+}
+
+// Returns the profiles for each of the storage types known for the given VM
+// type, sorted by storage type.
+func CandidatesFor(mountPoint, v, vm string) ([]Candidate, error) {
+	data := precompiledData()
+	vms, ok := data[v]
+	if !ok {
+		return nil, fmt.Errorf("no iotune data found for vendor '%s'", v)
+	}
+	storages, ok := vms[vm]
+	if !ok {
+		return nil, fmt.Errorf("no iotune data found for VM '%s', of vendor '%s'", vm, v)
+	}
+	var candidates []Candidate
+	for storage, settings := range storages {
+		settings.MountPoint = mountPoint
+		candidates = append(candidates, Candidate{v, vm, storage, settings})
 	}
-	yaml, err := yaml.Marshal(ioPropertiesWrapper{[]io{props}})
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Storage < candidates[j].Storage
+	})
+	return candidates, nil
+}
+
+func CandidatesForVendor(
+	mountPoint string, v vendor.InitializedVendor,
+) ([]Candidate, error) {
+	vmType, err := v.VmType()
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("Couldn't get the current VM type for vendor '%s'", v.Name())
+	}
+	log.Infof("Detected vendor '%s' and VM type '%s'", v.Name(), vmType)
+	return CandidatesFor(mountPoint, v.Name(), vmType)
+}
+
+// Returns every well-known profile, or only the given vendor's if v isn't
+// empty, sorted by vendor, VM type and storage type.
+func WellKnown(v string) ([]Candidate, error) {
+	data := precompiledData()
+	if _, ok := data[v]; v != "" && !ok {
+		return nil, fmt.Errorf("no iotune data found for vendor '%s'", v)
 	}
-	return string(yaml), nil
+	var candidates []Candidate
+	for vendor, vms := range data {
+		if v != "" && vendor != v {
+			continue
+		}
+		for vm, storages := range vms {
+			for storage, settings := range storages {
+				candidates = append(
+					candidates,
+					Candidate{vendor, vm, storage, settings},
+				)
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Vendor != b.Vendor {
+			return a.Vendor < b.Vendor
+		}
+		if a.VM != b.VM {
+			return a.VM < b.VM
+		}
+		return a.Storage < b.Storage
+	})
+	return candidates, nil
+}
+
+// Picks the profile to use among the given ones, and returns its index and
+// why it was picked. The 'default' storage type is preferred, since it's the
+// one the VM type is usually provisioned with. Otherwise, the one with the
+// highest write IOPS is picked, since it's likely the instance's local NVMe
+// storage, where redpanda's data directory should be.
+func BestCandidate(candidates []Candidate) (int, string) {
+	if len(candidates) == 1 {
+		return 0, "it's the only profile for the VM type"
+	}
+	best := 0
+	for i, c := range candidates {
+		if c.Storage == "default" {
+			return i, "it's the profile for the VM type's default storage"
+		}
+		if c.Props.WriteIops > candidates[best].Props.WriteIops {
+			best = i
+		}
+	}
+	return best, "it has the highest write IOPS, which usually means it's the VM's local storage"
+}
+
+func ToYaml(props IoProperties) (string, error) {
+	return DisksToYaml([]IoProperties{props})
 }
 
 func precompiledData() map[string]map[string]map[string]io {
@@ -72,3 +72,83 @@ func TestDataFor(t *testing.T) {
 		})
 	}
 }
+
+func TestCandidatesFor(t *testing.T) {
+	candidates, err := iotune.CandidatesFor("/mount/point", "aws", "i3.large")
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	require.Equal(t, "aws:i3.large:default", candidates[0].String())
+	require.Equal(t, "/mount/point", candidates[0].Props.MountPoint)
+
+	_, err = iotune.CandidatesFor("/mount/point", "aws", "unsupported")
+	require.EqualError(t, err, "no iotune data found for VM 'unsupported', of vendor 'aws'")
+}
+
+func TestBestCandidate(t *testing.T) {
+	candidate := func(storage string, writeIops int64) iotune.Candidate {
+		return iotune.Candidate{
+			Vendor:		"aws",
+			VM:		"m5d.large",
+			Storage:	storage,
+			Props:		iotune.IoProperties{WriteIops: writeIops},
+		}
+	}
+	tests := []struct {
+		name		string
+		candidates	[]iotune.Candidate
+		expected	int
+		expectedReason	string
+	}{
+		{
+			name:		"it should pick the only candidate",
+			candidates:	[]iotune.Candidate{candidate("ebs", 100)},
+			expected:	0,
+			expectedReason:	"it's the only profile for the VM type",
+		},
+		{
+			name: "it should prefer the default storage",
+			candidates: []iotune.Candidate{
+				candidate("ebs", 100),
+				candidate("default", 10),
+				candidate("nvme", 1000),
+			},
+			expected:	1,
+			expectedReason:	"it's the profile for the VM type's default storage",
+		},
+		{
+			name: "it should pick the one with the highest write IOPS",
+			candidates: []iotune.Candidate{
+				candidate("ebs", 100),
+				candidate("nvme", 1000),
+			},
+			expected:	1,
+			expectedReason:	"it has the highest write IOPS, which usually means it's the VM's local storage",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			best, reason := iotune.BestCandidate(tt.candidates)
+			require.Equal(st, tt.expected, best)
+			require.Equal(st, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestWellKnown(t *testing.T) {
+	all, err := iotune.WellKnown("")
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+	// Every profile should be resolvable by DataFor, which 'rpk start' uses.
+	for _, c := range all {
+		_, err := iotune.DataFor("/mount/point", c.Vendor, c.VM, c.Storage)
+		require.NoError(t, err)
+	}
+
+	aws, err := iotune.WellKnown("aws")
+	require.NoError(t, err)
+	require.Equal(t, all, aws)
+	require.Equal(t, "aws:i3.16xlarge:default", aws[0].String())
+
+	_, err = iotune.WellKnown("unsupported")
+	require.EqualError(t, err, "no iotune data found for vendor 'unsupported'")
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package iotune
+
+import (
+	"fmt"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// The format of the IO properties files iotune writes and seastar reads.
+type ioPropertiesFile struct {
+	Disks []IoProperties `yaml:"disks"`
+}
+
+// Reads the disks' IO properties from the given IO properties file.
+func ReadFile(fs afero.Fs, path string) ([]IoProperties, error) {
+	bs, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	f := ioPropertiesFile{}
+	err = yaml.UnmarshalStrict(bs, &f)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse '%s': %v", path, err)
+	}
+	return f.Disks, nil
+}
+
+// Reads the given IO properties files and unions their disks, in order. A
+// mountpoint may be described by more than one file, as long as it has the
+// same properties in all of them.
+func MergeFiles(fs afero.Fs, paths []string) ([]IoProperties, error) {
+	var merged []IoProperties
+	// The index of each mountpoint in merged, and the file it came from.
+	seen := map[string]int{}
+	sources := map[string]string{}
+	for _, path := range paths {
+		disks, err := ReadFile(fs, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range disks {
+			i, ok := seen[d.MountPoint]
+			if !ok {
+				seen[d.MountPoint] = len(merged)
+				sources[d.MountPoint] = path
+				merged = append(merged, d)
+				continue
+			}
+			if merged[i] != d {
+				return nil, fmt.Errorf(
+					"'%s' and '%s' have conflicting IO"+
+						" properties for mountpoint '%s'",
+					sources[d.MountPoint],
+					path,
+					d.MountPoint,
+				)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// Returns the YAML document for the given disks' IO properties, in the format
+// seastar's --io-properties and --io-properties-file take.
+func DisksToYaml(disks []IoProperties) (string, error) {
+	bs, err := yaml.Marshal(ioPropertiesFile{disks})
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
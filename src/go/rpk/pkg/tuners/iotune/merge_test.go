@@ -0,0 +1,105 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package iotune_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/iotune"
+)
+
+func TestMergeFiles(t *testing.T) {
+	nvme := `disks:
+- mountpoint: /mnt/nvme
+  read_iops: 200000
+  read_bandwidth: 1000000000
+  write_iops: 100000
+  write_bandwidth: 500000000
+`
+	ebs := `disks:
+- mountpoint: /mnt/ebs
+  read_iops: 16000
+  read_bandwidth: 250000000
+  write_iops: 16000
+  write_bandwidth: 250000000
+`
+	conflicting := `disks:
+- mountpoint: /mnt/nvme
+  read_iops: 1
+  read_bandwidth: 1
+  write_iops: 1
+  write_bandwidth: 1
+`
+	tests := []struct {
+		name		string
+		files		map[string]string
+		paths		[]string
+		expected	[]iotune.IoProperties
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should union the disks",
+			files: map[string]string{
+				"/nvme.yaml":	nvme,
+				"/ebs.yaml":	ebs,
+			},
+			paths:	[]string{"/nvme.yaml", "/ebs.yaml"},
+			expected: []iotune.IoProperties{
+				{"/mnt/nvme", 200000, 1000000000, 100000, 500000000},
+				{"/mnt/ebs", 16000, 250000000, 16000, 250000000},
+			},
+		},
+		{
+			name:	"it should accept the same disk in more than one file",
+			files: map[string]string{
+				"/nvme.yaml":	nvme,
+				"/all.yaml":	nvme + ebs[len("disks:\n"):],
+			},
+			paths:	[]string{"/nvme.yaml", "/all.yaml"},
+			expected: []iotune.IoProperties{
+				{"/mnt/nvme", 200000, 1000000000, 100000, 500000000},
+				{"/mnt/ebs", 16000, 250000000, 16000, 250000000},
+			},
+		},
+		{
+			name:	"it should fail if a disk's properties conflict",
+			files: map[string]string{
+				"/nvme.yaml":		nvme,
+				"/conflicting.yaml":	conflicting,
+			},
+			paths:		[]string{"/nvme.yaml", "/conflicting.yaml"},
+			expectedErrMsg:	"'/nvme.yaml' and '/conflicting.yaml' have conflicting IO properties for mountpoint '/mnt/nvme'",
+		},
+		{
+			name:		"it should fail if a file isn't valid",
+			files:		map[string]string{"/bad.yaml": "disks: 1"},
+			paths:		[]string{"/bad.yaml"},
+			expectedErrMsg:	"couldn't parse '/bad.yaml': yaml: unmarshal errors:\n  line 1: cannot unmarshal !!int `1` into []iotune.IoProperties",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				err := afero.WriteFile(fs, path, []byte(content), 0644)
+				require.NoError(st, err)
+			}
+			merged, err := iotune.MergeFiles(fs, tt.paths)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, merged)
+		})
+	}
+}
@@ -10,7 +10,9 @@
 package irq
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -25,10 +27,16 @@ type CpuMasks interface {
 	BaseCpuMask(cpuMask string) (string, error)
 	CpuMaskForComputations(mode Mode, cpuMask string) (string, error)
 	CpuMaskForIRQs(mode Mode, cpuMask string) (string, error)
+	ComplementCpuMask(cpuMask string) (string, error)
 	SetMask(path string, mask string) error
 	ReadMask(path string) (string, error)
 	ReadIRQMask(IRQ int) (string, error)
 	DistributeIRQs(irqsDistribution map[int]string)
+	DistributeIRQsWithContext(
+		ctx context.Context,
+		irqsDistribution map[int]string,
+		applied func(IRQ int),
+	) error
 	GetDistributionMasks(count uint) ([]string, error)
 	GetIRQsDistributionMasks(IRQs []int, cpuMask string) (map[int]string, error)
 	GetNumberOfCores(mask string) (uint, error)
@@ -120,6 +128,28 @@ func (masks *cpuMasks) CpuMaskForIRQs(
 	return maskForIRQs, err
 }
 
+// Returns the mask of the CPUs which aren't in cpuMask, failing if there are
+// none.
+func (masks *cpuMasks) ComplementCpuMask(cpuMask string) (string, error) {
+	baseMask, err := masks.BaseCpuMask(cpuMask)
+	if err != nil {
+		return "", err
+	}
+	complement, err := masks.hwloc.Calc("all", "~"+baseMask)
+	if err != nil {
+		return "", err
+	}
+	if masks.hwloc.CheckIfMaskIsEmpty(complement) {
+		return "", fmt.Errorf(
+			"cpu-mask value '%s' includes all the CPUs, so there are"+
+				" none outside of it",
+			cpuMask,
+		)
+	}
+	log.Debugf("Complement of CPU mask '%s': '%s'", cpuMask, complement)
+	return complement, nil
+}
+
 func (masks *cpuMasks) SetMask(path string, mask string) error {
 	if _, err := masks.fs.Stat(path); err != nil {
 		return fmt.Errorf("SMP affinity file '%s' not exist", path)
@@ -157,12 +187,35 @@ func (masks *cpuMasks) GetIRQsDistributionMasks(
 }
 
 func (masks *cpuMasks) DistributeIRQs(irqsDistribution map[int]string) {
+	// context.Background() is never done, so no error can be returned.
+	_ = masks.DistributeIRQsWithContext(
+		context.Background(),
+		irqsDistribution,
+		func(int) {},
+	)
+}
+
+// Sets the IRQs' affinity in ascending IRQ order, calling applied after each
+// one. It stops and returns ctx's error once ctx is done.
+func (masks *cpuMasks) DistributeIRQsWithContext(
+	ctx context.Context,
+	irqsDistribution map[int]string,
+	applied func(IRQ int),
+) error {
 	log.Debugf("Distributing IRQs '%v' ", irqsDistribution)
 	errMsg := "An IRQ's affinity couldn't be set. This might be because the" +
 		" IRQ isn't IO-APIC compatible, or because the IRQ is managed" +
 		" by the kernel, and can be safely ignored."
-	for IRQ, mask := range irqsDistribution {
-		err := masks.SetMask(irqAffinityPath(IRQ), mask)
+	IRQs := make([]int, 0, len(irqsDistribution))
+	for IRQ := range irqsDistribution {
+		IRQs = append(IRQs, IRQ)
+	}
+	sort.Ints(IRQs)
+	for _, IRQ := range IRQs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := masks.SetMask(IRQAffinityPath(IRQ), irqsDistribution[IRQ])
 		// IRQ SMP affinity is tuned on a best-effort basis. Most
 		// IO-APIC compatible IRQs allow their affinity to be set, but
 		// there are exceptions (such as IRQ 0, which is the timer IRQ).
@@ -173,10 +226,13 @@ func (masks *cpuMasks) DistributeIRQs(irqsDistribution map[int]string) {
 			log.Debug(err)
 			log.Debug(errMsg)
 		}
+		applied(IRQ)
 	}
+	return nil
 }
 
-func irqAffinityPath(IRQ int) string {
+// Returns the file an IRQ's SMP affinity is set through.
+func IRQAffinityPath(IRQ int) string {
 	return fmt.Sprintf("/proc/irq/%d/smp_affinity", IRQ)
 }
 
@@ -201,7 +257,7 @@ func (masks *cpuMasks) ReadMask(path string) (string, error) {
 }
 
 func (masks *cpuMasks) ReadIRQMask(IRQ int) (string, error) {
-	return masks.ReadMask(irqAffinityPath(IRQ))
+	return masks.ReadMask(IRQAffinityPath(IRQ))
 }
 
 func (masks *cpuMasks) GetNumberOfCores(mask string) (uint, error) {
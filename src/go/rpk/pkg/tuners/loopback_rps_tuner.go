@@ -0,0 +1,87 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/irq"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/network"
+)
+
+// Sets up RPS for the loopback interface, steering the packets received in
+// each of its queues across the CPUs redpanda uses. It's useful for hosts
+// running several brokers which talk to each other over lo.
+type loopbackRpsTuner struct {
+	nic		network.Nic
+	mode		irq.Mode
+	cpuMask		string
+	cpuMasks	irq.CpuMasks
+	masks		map[string]string
+}
+
+func NewLoopbackRpsTuner(
+	nic network.Nic, mode irq.Mode, cpuMask string, cpuMasks irq.CpuMasks,
+) Tunable {
+	return &loopbackRpsTuner{
+		nic:		nic,
+		mode:		mode,
+		cpuMask:	cpuMask,
+		cpuMasks:	cpuMasks,
+	}
+}
+
+func (t *loopbackRpsTuner) CheckIfSupported() (supported bool, reason string) {
+	if !t.cpuMasks.IsSupported() {
+		return false, "Tuner is not supported as 'hwloc' is not installed"
+	}
+	rpsCPUs, err := t.nic.GetRpsCPUFiles()
+	if err != nil {
+		return false, err.Error()
+	}
+	if len(rpsCPUs) == 0 {
+		return false, fmt.Sprintf("No RPS queues found for '%s'", t.nic.Name())
+	}
+	return true, ""
+}
+
+func (t *loopbackRpsTuner) Tune() TuneResult {
+	log.Debugf("Tuning '%s' RPS", t.nic.Name())
+	rpsCPUs, err := t.nic.GetRpsCPUFiles()
+	if err != nil {
+		return NewTuneError(err)
+	}
+	rpsMask, err := network.GetLoopbackRpsCPUMask(t.mode, t.cpuMask, t.cpuMasks)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	masks := map[string]string{}
+	for _, rpsCPUFile := range rpsCPUs {
+		err := t.cpuMasks.SetMask(rpsCPUFile, rpsMask)
+		if err != nil {
+			return NewTuneError(err)
+		}
+		// e.g. /sys/class/net/lo/queues/rx-0/rps_cpus => lo/rx-0
+		queue := filepath.Base(filepath.Dir(rpsCPUFile))
+		masks[t.nic.Name()+"/"+queue] = rpsMask
+	}
+	t.masks = masks
+	return NewTuneResult(false)
+}
+
+// Returns the RPS mask set for each of lo's queues, keyed by "lo/<queue>".
+func (t *loopbackRpsTuner) Details() map[string]string {
+	if len(t.masks) == 0 {
+		return nil
+	}
+	return t.masks
+}
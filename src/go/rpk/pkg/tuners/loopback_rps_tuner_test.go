@@ -0,0 +1,100 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/irq"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/network"
+)
+
+func TestLoopbackRpsTuner(t *testing.T) {
+	tests := []struct {
+		name		string
+		mode		irq.Mode
+		expectedMask	string
+	}{
+		{
+			name:		"it should steer lo's queues to all CPUs by default",
+			mode:		irq.Default,
+			expectedMask:	"0xff",
+		},
+		{
+			name:		"it should steer lo's queues to the computation CPUs for the given mode",
+			mode:		irq.Sq,
+			expectedMask:	"0xfe",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for _, q := range []string{"rx-0", "rx-1"} {
+				err := afero.WriteFile(
+					fs,
+					"/sys/class/net/lo/queues/"+q+"/rps_cpus",
+					[]byte("0"),
+					0644,
+				)
+				require.NoError(t, err)
+			}
+			set := map[string]string{}
+			cpuMasks := &cpuMasksMock{
+				baseCpuMask: func(string) (string, error) {
+					return "0xff", nil
+				},
+				cpuMaskForComputations: func(mode irq.Mode, mask string) (string, error) {
+					require.Equal(t, irq.Sq, mode)
+					return "0xfe", nil
+				},
+				setMask: func(path, mask string) error {
+					set[path] = mask
+					return nil
+				},
+			}
+			nic := network.NewNic(fs, nil, nil, nil, network.LoopbackInterface)
+			tuner := NewLoopbackRpsTuner(nic, tt.mode, "all", cpuMasks)
+			supported, reason := tuner.CheckIfSupported()
+			require.True(t, supported, reason)
+			require.Nil(t, TuneDetails(tuner))
+
+			res := tuner.Tune()
+			require.NoError(t, res.Error())
+			require.Equal(t, map[string]string{
+				"/sys/class/net/lo/queues/rx-0/rps_cpus":	tt.expectedMask,
+				"/sys/class/net/lo/queues/rx-1/rps_cpus":	tt.expectedMask,
+			}, set)
+			expectedDetails := map[string]string{
+				"lo/rx-0":	tt.expectedMask,
+				"lo/rx-1":	tt.expectedMask,
+			}
+			require.Equal(t, expectedDetails, TuneDetails(tuner))
+			// The details should be reported through the network
+			// tuner too.
+			require.Equal(
+				t,
+				expectedDetails,
+				TuneDetails(NewDisruptiveTunable(NewAggregatedTunable(
+					[]Tunable{NewAggregatedTunable(nil), tuner},
+				))),
+			)
+		})
+	}
+}
+
+func TestLoopbackRpsTunerUnsupported(t *testing.T) {
+	nic := network.NewNic(afero.NewMemMapFs(), nil, nil, nil, network.LoopbackInterface)
+	tuner := NewLoopbackRpsTuner(nic, irq.Default, "all", &cpuMasksMock{})
+	supported, reason := tuner.CheckIfSupported()
+	require.False(t, supported)
+	require.Equal(t, "No RPS queues found for 'lo'", reason)
+}
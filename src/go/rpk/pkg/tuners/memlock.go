@@ -0,0 +1,128 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+
+	"github.com/docker/go-units"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// Checks that RLIMIT_MEMLOCK (ulimit -l) is enough to lock the memory
+// redpanda will use when --lock-memory is set. Otherwise, redpanda fails to
+// start when it calls mlockall.
+type memlockLimitChecker struct {
+	getLimit	func() (*unix.Rlimit, error)
+	required	uint64
+}
+
+func NewMemlockLimitChecker(
+	getLimit func() (*unix.Rlimit, error), required uint64,
+) Checker {
+	return &memlockLimitChecker{getLimit: getLimit, required: required}
+}
+
+func (*memlockLimitChecker) Id() CheckerID {
+	return MemlockLimitChecker
+}
+
+func (*memlockLimitChecker) GetDesc() string {
+	return "Max locked memory (ulimit -l)"
+}
+
+func (*memlockLimitChecker) GetSeverity() Severity {
+	return Fatal
+}
+
+func (c *memlockLimitChecker) GetRequiredAsString() string {
+	return ">= " + formatMemlockLimit(c.required)
+}
+
+func (c *memlockLimitChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+	}
+	limit, err := c.getLimit()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Current = formatMemlockLimit(limit.Cur)
+	res.IsOk = limit.Cur >= c.required
+	return res
+}
+
+// Raises RLIMIT_MEMLOCK for rpk's process, and therefore redpanda's, so that
+// the memory redpanda will use can be locked. The soft limit can always be
+// raised up to the hard one, but raising the hard limit requires
+// CAP_SYS_RESOURCE.
+type memlockLimitTuner struct {
+	getLimit	func() (*unix.Rlimit, error)
+	setLimit	func(*unix.Rlimit) error
+	required	uint64
+}
+
+func NewMemlockLimitTuner(
+	getLimit func() (*unix.Rlimit, error),
+	setLimit func(*unix.Rlimit) error,
+	required uint64,
+) Tunable {
+	return &memlockLimitTuner{
+		getLimit:	getLimit,
+		setLimit:	setLimit,
+		required:	required,
+	}
+}
+
+func (*memlockLimitTuner) CheckIfSupported() (supported bool, reason string) {
+	return true, ""
+}
+
+func (t *memlockLimitTuner) Tune() TuneResult {
+	limit, err := t.getLimit()
+	if err != nil {
+		return NewTuneError(err)
+	}
+	if limit.Cur >= t.required {
+		return NewTuneResult(false)
+	}
+	raised := &unix.Rlimit{Cur: limit.Max, Max: limit.Max}
+	if limit.Max < t.required {
+		raised = &unix.Rlimit{Cur: t.required, Max: t.required}
+	}
+	err = t.setLimit(raised)
+	if err != nil {
+		return NewTuneError(fmt.Errorf(
+			"couldn't raise the max locked memory from %s to %s: %v."+
+				" Raise it with 'ulimit -l' or systemd's LimitMEMLOCK",
+			formatMemlockLimit(limit.Cur),
+			formatMemlockLimit(raised.Cur),
+			err,
+		))
+	}
+	log.Infof(
+		"Raised the max locked memory from %s to %s",
+		formatMemlockLimit(limit.Cur),
+		formatMemlockLimit(raised.Cur),
+	)
+	return NewTuneResult(false)
+}
+
+func formatMemlockLimit(limit uint64) string {
+	if limit == unix.RLIM_INFINITY {
+		return "unlimited"
+	}
+	return units.BytesSize(float64(limit))
+}
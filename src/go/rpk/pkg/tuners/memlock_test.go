@@ -0,0 +1,117 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/go-units"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestMemlockLimitChecker(t *testing.T) {
+	tests := []struct {
+		name		string
+		limit		uint64
+		expectedOk	bool
+		expectedCurrent	string
+	}{
+		{
+			name:			"it should pass if the limit is enough",
+			limit:			8 * units.GiB,
+			expectedOk:		true,
+			expectedCurrent:	"8GiB",
+		},
+		{
+			name:			"it should pass if there's no limit",
+			limit:			unix.RLIM_INFINITY,
+			expectedOk:		true,
+			expectedCurrent:	"unlimited",
+		},
+		{
+			name:			"it should fail if the limit is too low",
+			limit:			64 * units.KiB,
+			expectedCurrent:	"64KiB",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			getLimit := func() (*unix.Rlimit, error) {
+				return &unix.Rlimit{Cur: tt.limit, Max: tt.limit}, nil
+			}
+			res := NewMemlockLimitChecker(getLimit, 4*units.GiB).Check()
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectedOk, res.IsOk)
+			require.Equal(st, tt.expectedCurrent, res.Current)
+			require.Equal(st, ">= 4GiB", res.Required)
+			require.EqualValues(st, Fatal, res.Severity)
+		})
+	}
+}
+
+func TestMemlockLimitTuner(t *testing.T) {
+	tests := []struct {
+		name		string
+		limit		unix.Rlimit
+		setErr		error
+		expected	unix.Rlimit
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should raise the soft limit up to the hard one",
+			limit:		unix.Rlimit{Cur: 64 * units.KiB, Max: unix.RLIM_INFINITY},
+			expected:	unix.Rlimit{Cur: unix.RLIM_INFINITY, Max: unix.RLIM_INFINITY},
+		},
+		{
+			name:		"it should raise the hard limit if it's too low",
+			limit:		unix.Rlimit{Cur: 64 * units.KiB, Max: 64 * units.KiB},
+			expected:	unix.Rlimit{Cur: 4 * units.GiB, Max: 4 * units.GiB},
+		},
+		{
+			name:		"it shouldn't change the limit if it's enough",
+			limit:		unix.Rlimit{Cur: 8 * units.GiB, Max: 8 * units.GiB},
+			expected:	unix.Rlimit{Cur: 8 * units.GiB, Max: 8 * units.GiB},
+		},
+		{
+			name:		"it should fail if the limit can't be raised",
+			limit:		unix.Rlimit{Cur: 64 * units.KiB, Max: 64 * units.KiB},
+			setErr:		errors.New("operation not permitted"),
+			expected:	unix.Rlimit{Cur: 64 * units.KiB, Max: 64 * units.KiB},
+			expectedErrMsg:	"couldn't raise the max locked memory from 64KiB to 4GiB: operation not permitted. Raise it with 'ulimit -l' or systemd's LimitMEMLOCK",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			limit := tt.limit
+			getLimit := func() (*unix.Rlimit, error) {
+				l := limit
+				return &l, nil
+			}
+			setLimit := func(l *unix.Rlimit) error {
+				if tt.setErr != nil {
+					return tt.setErr
+				}
+				limit = *l
+				return nil
+			}
+			tuner := NewMemlockLimitTuner(getLimit, setLimit, 4*units.GiB)
+			res := tuner.Tune()
+			if tt.expectedErrMsg != "" {
+				require.True(st, res.IsFailed())
+				require.EqualError(st, res.Error(), tt.expectedErrMsg)
+			} else {
+				require.False(st, res.IsFailed())
+			}
+			require.Equal(st, tt.expected, limit)
+		})
+	}
+}
@@ -32,21 +32,30 @@ func NewNetTuner(
 	irqProcFile irq.ProcFile,
 	ethtool ethtool.EthtoolWrapper,
 	executor executors.Executor,
+	tuneLoopback bool,
 ) Tunable {
 	factory := NewNetTunersFactory(
 		fs, irqProcFile, irqDeviceInfo, ethtool, irqBalanceService, cpuMasks, executor)
-	return NewAggregatedTunable(
-		[]Tunable{
-			factory.NewNICsBalanceServiceTuner(interfaces),
-			factory.NewNICsIRQsAffinityTuner(interfaces, mode, cpuMask),
-			factory.NewNICsRpsTuner(interfaces, mode, cpuMask),
-			factory.NewNICsRfsTuner(interfaces),
-			factory.NewNICsNTupleTuner(interfaces),
-			factory.NewNICsXpsTuner(interfaces),
-			factory.NewRfsTableSizeTuner(),
-			factory.NewListenBacklogTuner(),
-			factory.NewSynBacklogTuner(),
-		})
+	tunables := []Tunable{
+		factory.NewNICsBalanceServiceTuner(interfaces),
+		factory.NewNICsIRQsAffinityTuner(interfaces, mode, cpuMask),
+		factory.NewNICsRpsTuner(interfaces, mode, cpuMask),
+		factory.NewNICsRfsTuner(interfaces),
+		factory.NewNICsNTupleTuner(interfaces),
+		factory.NewNICsXpsTuner(interfaces),
+		factory.NewRfsTableSizeTuner(),
+		factory.NewListenBacklogTuner(),
+		factory.NewSynBacklogTuner(),
+	}
+	if tuneLoopback {
+		// lo may have no RPS queues (e.g. in some containers), which
+		// shouldn't keep the NICs from being tuned.
+		tunables = append(tunables, newOptionalTunable(
+			factory.NewLoopbackRpsTuner(mode, cpuMask),
+			"the loopback (lo) RPS setup",
+		))
+	}
+	return NewAggregatedTunable(tunables)
 }
 
 type NetTunersFactory interface {
@@ -56,6 +65,7 @@ type NetTunersFactory interface {
 	NewNICsRfsTuner(interfaces []string) Tunable
 	NewNICsNTupleTuner(interfaces []string) Tunable
 	NewNICsXpsTuner(interfaces []string) Tunable
+	NewLoopbackRpsTuner(mode irq.Mode, cpuMask string) Tunable
 	NewRfsTableSizeTuner() Tunable
 	NewListenBacklogTuner() Tunable
 	NewSynBacklogTuner() Tunable
@@ -263,6 +273,23 @@ func (f *netTunersFactory) NewNICsXpsTuner(interfaces []string) Tunable {
 	)
 }
 
+func (f *netTunersFactory) NewLoopbackRpsTuner(
+	mode irq.Mode, cpuMask string,
+) Tunable {
+	return NewLoopbackRpsTuner(
+		network.NewNic(
+			f.fs,
+			f.irqProcFile,
+			f.irqDeviceInfo,
+			f.ethtool,
+			network.LoopbackInterface,
+		),
+		mode,
+		cpuMask,
+		f.cpuMasks,
+	)
+}
+
 func (f *netTunersFactory) NewRfsTableSizeTuner() Tunable {
 	return NewCheckedTunable(
 		f.checkersFactory.NewRfsTableSizeChecker(),
@@ -10,9 +10,12 @@
 package network
 
 const (
+	LoopbackInterface	= "lo"
 	RfsTableSizeProperty	= "net.core.rps_sock_flow_entries"
 	ListenBacklogFile	= "/proc/sys/net/core/somaxconn"
 	SynBacklogFile		= "/proc/sys/net/ipv4/tcp_max_syn_backlog"
+	LocalPortRangeFile	= "/proc/sys/net/ipv4/ip_local_port_range"
+	ReservedPortsFile	= "/proc/sys/net/ipv4/ip_local_reserved_ports"
 	RfsTableSize		= 32768
 	SynBacklogSize		= 4096
 	ListenBacklogSize	= 4096
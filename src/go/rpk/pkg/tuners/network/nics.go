@@ -89,6 +89,22 @@ func GetRpsCPUMask(
 	return computationsCPUMask, nil
 }
 
+// Returns the mask of the CPUs to steer the loopback interface's packets to.
+// lo has no IRQs, so there's no default mode to deduce from its queues and all
+// the CPUs in the mask are used, unless a mode is given.
+func GetLoopbackRpsCPUMask(
+	mode irq.Mode, cpuMask string, cpuMasks irq.CpuMasks,
+) (string, error) {
+	effectiveCPUMask, err := cpuMasks.BaseCpuMask(cpuMask)
+	if err != nil {
+		return "", err
+	}
+	if mode == irq.Default {
+		return effectiveCPUMask, nil
+	}
+	return cpuMasks.CpuMaskForComputations(mode, effectiveCPUMask)
+}
+
 func GetHwInterfaceIRQsDistribution(
 	nic Nic, mode irq.Mode, cpuMask string, cpuMasks irq.CpuMasks,
 ) (map[int]string, error) {
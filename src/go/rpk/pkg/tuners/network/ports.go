@@ -0,0 +1,104 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package network
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// An inclusive range of ports.
+type PortRange struct {
+	Low	int
+	High	int
+}
+
+func (r PortRange) Contains(port int) bool {
+	return port >= r.Low && port <= r.High
+}
+
+func (r PortRange) String() string {
+	if r.Low == r.High {
+		return strconv.Itoa(r.Low)
+	}
+	return fmt.Sprintf("%d-%d", r.Low, r.High)
+}
+
+// Returns the range the kernel picks ephemeral ports from, read from
+// ip_local_port_range, which looks like '32768	60999'.
+func GetLocalPortRange(fs afero.Fs) (PortRange, error) {
+	content, err := afero.ReadFile(fs, LocalPortRangeFile)
+	if err != nil {
+		return PortRange{}, err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) != 2 {
+		return PortRange{}, fmt.Errorf(
+			"couldn't parse '%s' in %s",
+			strings.TrimSpace(string(content)),
+			LocalPortRangeFile,
+		)
+	}
+	low, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return PortRange{}, err
+	}
+	high, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return PortRange{}, err
+	}
+	return PortRange{low, high}, nil
+}
+
+// Returns the ports excluded from the ephemeral range, read from
+// ip_local_reserved_ports, which looks like '8000-8010,9092'. It returns no
+// ranges if the file doesn't exist.
+func GetReservedPorts(fs afero.Fs) ([]PortRange, error) {
+	if exists, _ := afero.Exists(fs, ReservedPortsFile); !exists {
+		return nil, nil
+	}
+	content, err := afero.ReadFile(fs, ReservedPortsFile)
+	if err != nil {
+		return nil, err
+	}
+	var ranges []PortRange
+	for _, part := range strings.Split(strings.TrimSpace(string(content)), ",") {
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		low, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"couldn't parse '%s' in %s: %v",
+				part,
+				ReservedPortsFile,
+				err,
+			)
+		}
+		high := low
+		if len(bounds) == 2 {
+			high, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, fmt.Errorf(
+					"couldn't parse '%s' in %s: %v",
+					part,
+					ReservedPortsFile,
+					err,
+				)
+			}
+		}
+		ranges = append(ranges, PortRange{low, high})
+	}
+	return ranges, nil
+}
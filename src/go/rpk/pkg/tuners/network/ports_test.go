@@ -0,0 +1,35 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package network
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReservedPorts(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ranges, err := GetReservedPorts(fs)
+	require.NoError(t, err)
+	require.Empty(t, ranges)
+
+	err = afero.WriteFile(fs, ReservedPortsFile, []byte("8000-8010,9092\n"), 0644)
+	require.NoError(t, err)
+	ranges, err = GetReservedPorts(fs)
+	require.NoError(t, err)
+	require.Equal(t, []PortRange{{8000, 8010}, {9092, 9092}}, ranges)
+
+	err = afero.WriteFile(fs, ReservedPortsFile, []byte("80a0\n"), 0644)
+	require.NoError(t, err)
+	_, err = GetReservedPorts(fs)
+	require.EqualError(t, err, `couldn't parse '80a0' in /proc/sys/net/ipv4/ip_local_reserved_ports: strconv.Atoi: parsing "80a0": invalid syntax`)
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+)
+
+// A NIC's RX and TX ring buffer sizes, as reported by 'ethtool -g'.
+type RingParams struct {
+	RxMax	int
+	TxMax	int
+	Rx	int
+	Tx	int
+}
+
+// Returns the interface's ring buffer sizes, running 'ethtool -g'.
+func GetRingParams(
+	proc os.Proc, timeout time.Duration, intf string,
+) (*RingParams, error) {
+	lines, err := proc.RunWithSystemLdPath(timeout, "ethtool", "-g", intf)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't get the ring parameters of '%s': %v",
+			intf,
+			err,
+		)
+	}
+	return ParseRingParams(lines)
+}
+
+// Parses the output of 'ethtool -g', which looks like
+//
+//	Ring parameters for eth0:
+//	Pre-set maximums:
+//	RX:		4096
+//	RX Mini:	0
+//	RX Jumbo:	0
+//	TX:		4096
+//	Current hardware settings:
+//	RX:		512
+//	...
+func ParseRingParams(lines []string) (*RingParams, error) {
+	params := &RingParams{}
+	var rx, tx *int
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Pre-set maximums"):
+			rx, tx = &params.RxMax, &params.TxMax
+			continue
+		case strings.HasPrefix(line, "Current hardware settings"):
+			rx, tx = &params.Rx, &params.Tx
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if rx == nil || len(parts) != 2 {
+			continue
+		}
+		var field *int
+		switch parts[0] {
+		case "RX":
+			field = rx
+		case "TX":
+			field = tx
+		default:
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if value == "n/a" {
+			continue
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse '%s': %v", line, err)
+		}
+		*field = v
+	}
+	if params.RxMax == 0 && params.TxMax == 0 {
+		return nil, errors.New(
+			"couldn't find the ring buffer maximums in ethtool's output",
+		)
+	}
+	return params, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRingParams(t *testing.T) {
+	tests := []struct {
+		name		string
+		lines		[]string
+		expected	*RingParams
+		expectedErrMsg	string
+	}{
+		{
+			name:	"it should parse the maximums and current sizes",
+			lines: []string{
+				"Ring parameters for eth0:",
+				"Pre-set maximums:",
+				"RX:\t\t4096",
+				"RX Mini:\t0",
+				"RX Jumbo:\t0",
+				"TX:\t\t4096",
+				"Current hardware settings:",
+				"RX:\t\t512",
+				"RX Mini:\t0",
+				"RX Jumbo:\t0",
+				"TX:\t\t1024",
+				"",
+			},
+			expected:	&RingParams{RxMax: 4096, TxMax: 4096, Rx: 512, Tx: 1024},
+		},
+		{
+			name:	"it should skip the values which aren't available",
+			lines: []string{
+				"Ring parameters for ens5:",
+				"Pre-set maximums:",
+				"RX:\t\t16384",
+				"RX Mini:\tn/a",
+				"RX Jumbo:\tn/a",
+				"TX:\t\t1024",
+				"Current hardware settings:",
+				"RX:\t\t1024",
+				"RX Mini:\tn/a",
+				"RX Jumbo:\tn/a",
+				"TX:\t\t1024",
+			},
+			expected:	&RingParams{RxMax: 16384, TxMax: 1024, Rx: 1024, Tx: 1024},
+		},
+		{
+			name:		"it should fail if there are no maximums",
+			lines:		[]string{"Cannot get device ring settings: Operation not supported"},
+			expectedErrMsg:	"couldn't find the ring buffer maximums in ethtool's output",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			params, err := ParseRingParams(tt.lines)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, params)
+		})
+	}
+}
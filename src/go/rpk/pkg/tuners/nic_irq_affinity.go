@@ -0,0 +1,145 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/irq"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/network"
+)
+
+// Steers the IRQs of the NICs serving the Kafka API to the CPUs redpanda
+// doesn't run on, through /proc/irq/<n>/smp_affinity, so that handling them
+// doesn't take time away from seastar's reactors.
+type nicIRQAffinityTuner struct {
+	nics			[]network.Nic
+	cpuMask			string
+	cpuMasks		irq.CpuMasks
+	irqBalanceService	irq.BalanceService
+	// The masks found and set, keyed by the smp_affinity file.
+	previous	map[string]string
+	applied		map[string]string
+}
+
+func NewNICIRQAffinityTuner(
+	nics []network.Nic,
+	cpuMask string,
+	cpuMasks irq.CpuMasks,
+	irqBalanceService irq.BalanceService,
+) Tunable {
+	return &nicIRQAffinityTuner{
+		nics:			nics,
+		cpuMask:		cpuMask,
+		cpuMasks:		cpuMasks,
+		irqBalanceService:	irqBalanceService,
+	}
+}
+
+func (t *nicIRQAffinityTuner) CheckIfSupported() (supported bool, reason string) {
+	if !t.cpuMasks.IsSupported() {
+		return false, "Tuner is not supported as 'hwloc' is not installed"
+	}
+	if t.irqBalanceService.IsRunning() {
+		return false, "'irqbalance' is running, and it would override the" +
+			" NIC IRQs' affinity. Stop it, or use the net tuner instead"
+	}
+	if len(t.nics) == 0 {
+		return false, "No NICs were found for redpanda's Kafka API address"
+	}
+	_, err := t.cpuMasks.ComplementCpuMask(t.cpuMask)
+	if err != nil {
+		return false, fmt.Sprintf(
+			"There are no CPUs left to steer the NIC IRQs to: %v."+
+				" Restrict redpanda's CPUs with --cpuset",
+			err,
+		)
+	}
+	return true, ""
+}
+
+func (t *nicIRQAffinityTuner) Tune() TuneResult {
+	mask, err := t.cpuMasks.ComplementCpuMask(t.cpuMask)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	IRQs, err := t.collectIRQs()
+	if err != nil {
+		return NewTuneError(err)
+	}
+	previous := map[string]string{}
+	applied := map[string]string{}
+	for _, IRQ := range IRQs {
+		file := irq.IRQAffinityPath(IRQ)
+		current, err := t.cpuMasks.ReadIRQMask(IRQ)
+		if err != nil {
+			return NewTuneError(err)
+		}
+		previous[file] = current
+		applied[file] = mask
+		equal, err := irq.MasksEqual(current, mask)
+		if err == nil && equal {
+			log.Debugf("IRQ %d's affinity is already '%s'", IRQ, mask)
+			continue
+		}
+		err = t.cpuMasks.SetMask(file, mask)
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	t.previous, t.applied = previous, applied
+	return NewTuneResult(false)
+}
+
+// Returns each smp_affinity file's previous and applied mask, e.g.
+// /proc/irq/42/smp_affinity => "0x000000ff -> 0x000000f0".
+func (t *nicIRQAffinityTuner) Details() map[string]string {
+	details := map[string]string{}
+	for file, applied := range t.applied {
+		details[file] = fmt.Sprintf("%s -> %s", t.previous[file], applied)
+	}
+	return details
+}
+
+func (t *nicIRQAffinityTuner) PreviousValues() map[string]string {
+	return t.previous
+}
+
+func (t *nicIRQAffinityTuner) Revert(previous map[string]string) TuneResult {
+	files := make([]string, 0, len(previous))
+	for file := range previous {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		err := t.cpuMasks.SetMask(file, previous[file])
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	return NewTuneResult(false)
+}
+
+// Returns the IRQs of the NICs, including their slaves' if they're bonds, in
+// ascending order.
+func (t *nicIRQAffinityTuner) collectIRQs() ([]int, error) {
+	IRQs := []int{}
+	for _, nic := range t.nics {
+		nicIRQs, err := network.CollectIRQs(nic)
+		if err != nil {
+			return nil, err
+		}
+		IRQs = append(IRQs, nicIRQs...)
+	}
+	sort.Ints(IRQs)
+	return IRQs, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/irq"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/network"
+)
+
+type nicMock struct {
+	network.Nic
+	irqs	[]int
+}
+
+func (*nicMock) IsHwInterface() bool {
+	return true
+}
+
+func (*nicMock) IsBondIface() bool {
+	return false
+}
+
+func (m *nicMock) GetIRQs() ([]int, error) {
+	return m.irqs, nil
+}
+
+type irqBalanceMock struct {
+	irq.BalanceService
+	running	bool
+}
+
+func (m *irqBalanceMock) IsRunning() bool {
+	return m.running
+}
+
+func TestNICIRQAffinityTuner(t *testing.T) {
+	tests := []struct {
+		name			string
+		nics			[]network.Nic
+		irqBalanceRunning	bool
+		complement		string
+		complementErr		error
+		current			map[string]string
+		expectedReason		string
+		expectedMasks		map[string]string
+		expectedDetails		map[string]string
+	}{
+		{
+			name:		"it should steer the NIC IRQs to the other CPUs",
+			nics:		[]network.Nic{&nicMock{irqs: []int{43, 42}}},
+			complement:	"0x000000f0",
+			current: map[string]string{
+				"/proc/irq/42/smp_affinity":	"0x000000ff",
+				"/proc/irq/43/smp_affinity":	"0x000000f0",
+			},
+			expectedMasks: map[string]string{
+				"/proc/irq/42/smp_affinity":	"0x000000f0",
+				"/proc/irq/43/smp_affinity":	"0x000000f0",
+			},
+			expectedDetails: map[string]string{
+				"/proc/irq/42/smp_affinity":	"0x000000ff -> 0x000000f0",
+				"/proc/irq/43/smp_affinity":	"0x000000f0 -> 0x000000f0",
+			},
+		},
+		{
+			name:			"it shouldn't be supported if irqbalance is running",
+			nics:			[]network.Nic{&nicMock{irqs: []int{42}}},
+			irqBalanceRunning:	true,
+			complement:		"0x000000f0",
+			expectedReason:		"'irqbalance' is running, and it would override the NIC IRQs' affinity. Stop it, or use the net tuner instead",
+		},
+		{
+			name:		"it shouldn't be supported if there are no NICs",
+			complement:	"0x000000f0",
+			expectedReason:	"No NICs were found for redpanda's Kafka API address",
+		},
+		{
+			name:		"it shouldn't be supported if redpanda runs on all the CPUs",
+			nics:		[]network.Nic{&nicMock{irqs: []int{42}}},
+			complementErr:	errors.New("cpu-mask value 'all' includes all the CPUs, so there are none outside of it"),
+			expectedReason:	"There are no CPUs left to steer the NIC IRQs to: cpu-mask value 'all' includes all the CPUs, so there are none outside of it. Restrict redpanda's CPUs with --cpuset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			masks := map[string]string{}
+			for file, mask := range tt.current {
+				masks[file] = mask
+			}
+			cpuMasks := &cpuMasksMock{
+				complementCpuMask: func(string) (string, error) {
+					return tt.complement, tt.complementErr
+				},
+				readIRQMask: func(IRQ int) (string, error) {
+					return masks[irq.IRQAffinityPath(IRQ)], nil
+				},
+				setMask: func(path, mask string) error {
+					masks[path] = mask
+					return nil
+				},
+			}
+			tuner := NewNICIRQAffinityTuner(
+				tt.nics,
+				"PU:0-3",
+				cpuMasks,
+				&irqBalanceMock{running: tt.irqBalanceRunning},
+			)
+			supported, reason := tuner.CheckIfSupported()
+			require.Equal(st, tt.expectedReason, reason)
+			require.Equal(st, tt.expectedReason == "", supported)
+			if !supported {
+				return
+			}
+			res := tuner.Tune()
+			require.False(st, res.IsFailed())
+			require.Equal(st, tt.expectedMasks, masks)
+			require.Equal(st, tt.expectedDetails, tuner.(DetailedTunable).Details())
+
+			revertible := tuner.(RevertibleTunable)
+			res = revertible.Revert(revertible.PreviousValues())
+			require.False(st, res.IsFailed())
+			require.Equal(st, tt.current, masks)
+		})
+	}
+}
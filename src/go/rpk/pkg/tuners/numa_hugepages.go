@@ -0,0 +1,157 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/go-units"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+)
+
+// The size of the hugepages the NUMA hugepages tuner allocates.
+const hugepageSize = 2 * units.MiB
+
+// Allocates rpk.numa_hugepages worth of 2MiB hugepages on the NUMA node
+// redpanda is pinned to (rpk.numa_hugepages_node, see 'rpk start
+// --numa-node'), through the node's nr_hugepages, so that they're local to
+// redpanda's CPUs. Global allocations spread the pages across all the nodes.
+type numaHugepagesTuner struct {
+	fs		afero.Fs
+	node		int
+	size		string
+	executor	executors.Executor
+	previous	string
+	allocated	string
+}
+
+func NewNUMAHugepagesTuner(
+	fs afero.Fs, node int, size string, executor executors.Executor,
+) Tunable {
+	return &numaHugepagesTuner{
+		fs:		fs,
+		node:		node,
+		size:		size,
+		executor:	executor,
+	}
+}
+
+func NUMANodeHugepagesFile(node int) string {
+	return filepath.Join(
+		system.NUMANodeDir(node),
+		"hugepages",
+		fmt.Sprintf("hugepages-%dkB", hugepageSize/units.KiB),
+		"nr_hugepages",
+	)
+}
+
+func (t *numaHugepagesTuner) CheckIfSupported() (supported bool, reason string) {
+	if t.size == "" {
+		return false, "rpk.numa_hugepages isn't set"
+	}
+	if exists, _ := afero.DirExists(t.fs, system.NUMANodeDir(t.node)); !exists {
+		return false, fmt.Sprintf("NUMA node %d doesn't exist", t.node)
+	}
+	file := NUMANodeHugepagesFile(t.node)
+	if exists, _ := afero.Exists(t.fs, file); !exists {
+		return false, fmt.Sprintf(
+			"NUMA node %d has no per-node hugepages control ('%s'"+
+				" doesn't exist)",
+			t.node,
+			file,
+		)
+	}
+	return true, ""
+}
+
+func (t *numaHugepagesTuner) Tune() TuneResult {
+	size, err := units.RAMInBytes(t.size)
+	if err != nil || size <= 0 {
+		return NewTuneError(fmt.Errorf("invalid rpk.numa_hugepages '%s'", t.size))
+	}
+	pages := (size + hugepageSize - 1) / hugepageSize
+	file := NUMANodeHugepagesFile(t.node)
+	previous, err := readTrimmed(t.fs, file)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	log.Debugf("Allocating %d hugepages on NUMA node %d", pages, t.node)
+	err = t.executor.Execute(
+		commands.NewWriteFileCmd(t.fs, file, strconv.FormatInt(pages, 10)),
+	)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	t.previous, t.allocated = previous, strconv.FormatInt(pages, 10)
+	if t.executor.IsLazy() {
+		return NewTuneResult(false)
+	}
+	// The kernel allocates as many pages as it can, which might be fewer
+	// if the node's memory is fragmented.
+	current, err := readTrimmed(t.fs, file)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	t.allocated = current
+	allocated, err := strconv.ParseInt(current, 10, 64)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	if allocated < pages {
+		return NewTuneError(fmt.Errorf(
+			"only %d of %d hugepages could be allocated on NUMA"+
+				" node %d. Its memory might be fragmented or in use",
+			allocated,
+			pages,
+			t.node,
+		))
+	}
+	return NewTuneResult(false)
+}
+
+// Returns the hugepages allocated on the node, e.g. node0 => "0 -> 2048
+// pages (4GiB)".
+func (t *numaHugepagesTuner) Details() map[string]string {
+	if t.allocated == "" {
+		return nil
+	}
+	pages, _ := strconv.ParseInt(t.allocated, 10, 64)
+	return map[string]string{
+		fmt.Sprintf("node%d", t.node): fmt.Sprintf(
+			"%s -> %s pages (%s)",
+			t.previous,
+			t.allocated,
+			units.BytesSize(float64(pages*hugepageSize)),
+		),
+	}
+}
+
+func (t *numaHugepagesTuner) PreviousValues() map[string]string {
+	if t.allocated == "" {
+		return nil
+	}
+	return map[string]string{NUMANodeHugepagesFile(t.node): t.previous}
+}
+
+func (t *numaHugepagesTuner) Revert(previous map[string]string) TuneResult {
+	for file, value := range previous {
+		err := t.executor.Execute(commands.NewWriteFileCmd(t.fs, file, value))
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	return NewTuneResult(false)
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+)
+
+func TestNUMAHugepagesTuner(t *testing.T) {
+	tests := []struct {
+		name			string
+		node			int
+		size			string
+		before			func(afero.Fs) error
+		expectedSupported	bool
+		expectedReason		string
+		expectedErrMsg		string
+		expectedPages		string
+		expectedDetails		map[string]string
+	}{
+		{
+			name:	"it should allocate the hugepages on the node",
+			node:	1,
+			size:	"4GiB",
+			before: func(fs afero.Fs) error {
+				return afero.WriteFile(fs, NUMANodeHugepagesFile(1), []byte("0\n"), 0644)
+			},
+			expectedSupported:	true,
+			expectedPages:		"2048",
+			expectedDetails: map[string]string{
+				"node1": "0 -> 2048 pages (4GiB)",
+			},
+		},
+		{
+			name:	"it should round the number of pages up",
+			size:	"3MiB",
+			before: func(fs afero.Fs) error {
+				return afero.WriteFile(fs, NUMANodeHugepagesFile(0), []byte("1\n"), 0644)
+			},
+			expectedSupported:	true,
+			expectedPages:		"2",
+			expectedDetails: map[string]string{
+				"node0": "1 -> 2 pages (4MiB)",
+			},
+		},
+		{
+			name:	"it should fail if the size is invalid",
+			size:	"lots",
+			before: func(fs afero.Fs) error {
+				return afero.WriteFile(fs, NUMANodeHugepagesFile(0), []byte("0\n"), 0644)
+			},
+			expectedSupported:	true,
+			expectedErrMsg:		"invalid rpk.numa_hugepages 'lots'",
+		},
+		{
+			name:		"it shouldn't be supported if the size isn't set",
+			expectedReason:	"rpk.numa_hugepages isn't set",
+		},
+		{
+			name:		"it shouldn't be supported if the node doesn't exist",
+			node:		3,
+			size:		"4GiB",
+			expectedReason:	"NUMA node 3 doesn't exist",
+		},
+		{
+			name:	"it shouldn't be supported without per-node hugepages control",
+			size:	"4GiB",
+			before: func(fs afero.Fs) error {
+				return fs.MkdirAll("/sys/devices/system/node/node0", 0755)
+			},
+			expectedReason:	"NUMA node 0 has no per-node hugepages control ('/sys/devices/system/node/node0/hugepages/hugepages-2048kB/nr_hugepages' doesn't exist)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.before != nil {
+				require.NoError(st, tt.before(fs))
+			}
+			tuner := NewNUMAHugepagesTuner(
+				fs,
+				tt.node,
+				tt.size,
+				executors.NewDirectExecutor(),
+			)
+			supported, reason := tuner.CheckIfSupported()
+			require.Equal(st, tt.expectedSupported, supported)
+			require.Equal(st, tt.expectedReason, reason)
+			if !supported {
+				return
+			}
+			res := tuner.Tune()
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, res.Error(), tt.expectedErrMsg)
+				return
+			}
+			require.False(st, res.IsFailed())
+			file := NUMANodeHugepagesFile(tt.node)
+			pages, err := readTrimmed(fs, file)
+			require.NoError(st, err)
+			require.Equal(st, tt.expectedPages, pages)
+			require.Equal(st, tt.expectedDetails, TuneDetails(tuner))
+
+			rt := tuner.(RevertibleTunable)
+			previous := rt.PreviousValues()
+			res = rt.Revert(previous)
+			require.False(st, res.IsFailed())
+			pages, err = readTrimmed(fs, file)
+			require.NoError(st, err)
+			require.Equal(st, previous[file], pages)
+		})
+	}
+}
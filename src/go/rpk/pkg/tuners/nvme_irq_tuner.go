@@ -0,0 +1,226 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/disk"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/irq"
+)
+
+var (
+	nvmeControllerPattern	= regexp.MustCompile(`^(nvme\d+)`)
+	nvmeQueuePattern	= regexp.MustCompile(`(?:^|[\s,])(nvme\d+)q\d+(?:[\s,]|$)`)
+)
+
+// Distributes the IRQs of the NVMe queues backing the given directories and
+// devices across the CPUs redpanda uses for computations, so that completions
+// are handled by the CPUs which issued the IO. The CPUs the NIC IRQs tuner
+// steers its IRQs to (for the same mode) are left out, so both tuners don't
+// compete for them.
+type nvmeIRQsTuner struct {
+	fs			afero.Fs
+	cpuMasks		irq.CpuMasks
+	irqBalanceService	irq.BalanceService
+	irqProcFile		irq.ProcFile
+	blockDevices		disk.BlockDevices
+	mode			irq.Mode
+	baseCPUMask		string
+	directories		[]string
+	devices			[]string
+	executor		executors.Executor
+	distribution		map[int]string
+}
+
+func NewNvmeIRQTuner(
+	fs afero.Fs,
+	mode irq.Mode,
+	cpuMask string,
+	dirs []string,
+	devices []string,
+	cpuMasks irq.CpuMasks,
+	irqBalanceService irq.BalanceService,
+	irqProcFile irq.ProcFile,
+	blockDevices disk.BlockDevices,
+	executor executors.Executor,
+) Tunable {
+	log.Debugf("Creating NVMe IRQs tuner with mode '%s', cpu mask '%s', directories '%s' and devices '%s'",
+		mode, cpuMask, dirs, devices)
+
+	return &nvmeIRQsTuner{
+		fs:			fs,
+		cpuMasks:		cpuMasks,
+		irqBalanceService:	irqBalanceService,
+		irqProcFile:		irqProcFile,
+		blockDevices:		blockDevices,
+		mode:			mode,
+		baseCPUMask:		cpuMask,
+		directories:		dirs,
+		devices:		devices,
+		executor:		executor,
+	}
+}
+
+func (tuner *nvmeIRQsTuner) CheckIfSupported() (
+	supported bool,
+	reason string,
+) {
+	if len(tuner.directories) == 0 && len(tuner.devices) == 0 {
+		return false, "Directories or devices are required for NVMe IRQs Tuner"
+	}
+	if !tuner.cpuMasks.IsSupported() {
+		return false, "Unable to calculate CPU masks required for IRQs " +
+			"tuner. Please install 'hwloc'"
+	}
+	return true, ""
+}
+
+func (tuner *nvmeIRQsTuner) Tune() TuneResult {
+	return tuner.TuneWithContext(context.Background(), NewProgress())
+}
+
+func (tuner *nvmeIRQsTuner) TuneWithContext(
+	ctx context.Context, progress *Progress,
+) TuneResult {
+	directoryDevices, err := tuner.blockDevices.GetDirectoriesDevices(
+		tuner.directories)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	allDevices := append([]string{}, tuner.devices...)
+	for _, devices := range directoryDevices {
+		allDevices = append(allDevices, devices...)
+	}
+	IRQs, err := GetNvmeIRQs(allDevices, tuner.irqProcFile)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	if len(IRQs) == 0 {
+		log.Infof("No NVMe IRQs found for devices %v", allDevices)
+		return NewTuneResult(false)
+	}
+	distribution, err := GetExpectedNvmeIRQsDistribution(
+		IRQs,
+		tuner.mode,
+		tuner.baseCPUMask,
+		tuner.cpuMasks,
+	)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	if err := tuner.irqBalanceService.BanIRQsAndRestart(IRQs); err != nil {
+		return NewTuneError(err)
+	}
+	progress.Start(len(distribution), "IRQs")
+	err = tuner.cpuMasks.DistributeIRQsWithContext(
+		ctx,
+		distribution,
+		func(int) { progress.Step() },
+	)
+	if err != nil {
+		return NewTuneError(interruptedError(ctx, progress))
+	}
+	tuner.distribution = distribution
+	return NewTuneResult(false)
+}
+
+// Returns the CPU mask set for each NVMe IRQ, keyed by "irq<number>".
+func (tuner *nvmeIRQsTuner) Details() map[string]string {
+	if len(tuner.distribution) == 0 {
+		return nil
+	}
+	details := make(map[string]string, len(tuner.distribution))
+	for IRQ, mask := range tuner.distribution {
+		details[fmt.Sprintf("irq%d", IRQ)] = mask
+	}
+	return details
+}
+
+// Returns the sorted IRQs of the queues of the NVMe controllers the given
+// devices (e.g. nvme0n1) belong to, as listed in /proc/interrupts.
+func GetNvmeIRQs(devices []string, procFile irq.ProcFile) ([]int, error) {
+	controllers := map[string]bool{}
+	for _, device := range devices {
+		if m := nvmeControllerPattern.FindStringSubmatch(device); m != nil {
+			controllers[m[1]] = true
+		}
+	}
+	if len(controllers) == 0 {
+		return nil, nil
+	}
+	lines, err := procFile.GetIRQProcFileLinesMap()
+	if err != nil {
+		return nil, err
+	}
+	var IRQs []int
+	for IRQ, line := range lines {
+		for _, m := range nvmeQueuePattern.FindAllStringSubmatch(line, -1) {
+			if controllers[m[1]] {
+				IRQs = append(IRQs, IRQ)
+				break
+			}
+		}
+	}
+	sort.Ints(IRQs)
+	log.Debugf("NVMe IRQs for devices %v: %v", devices, IRQs)
+	return IRQs, nil
+}
+
+// Distributes the given IRQs across the CPUs in cpuMask which are used for
+// computations in the given mode, i.e. the complement of the CPUs the NIC
+// IRQs are assigned to.
+func GetExpectedNvmeIRQsDistribution(
+	IRQs []int, mode irq.Mode, cpuMask string, cpuMasks irq.CpuMasks,
+) (map[int]string, error) {
+	finalCpuMask, err := cpuMasks.BaseCpuMask(cpuMask)
+	if err != nil {
+		return nil, err
+	}
+	effectiveMode := mode
+	if mode == irq.Default {
+		effectiveMode, err = getNvmeDefaultMode(finalCpuMask, cpuMasks)
+		if err != nil {
+			return nil, err
+		}
+	}
+	computationsMask, err := cpuMasks.CpuMaskForComputations(
+		effectiveMode, finalCpuMask)
+	if err != nil {
+		return nil, err
+	}
+	return cpuMasks.GetIRQsDistributionMasks(IRQs, computationsMask)
+}
+
+// Mirrors the default mode the NIC IRQs tuner picks for a hardware interface,
+// so that in the default mode the NVMe IRQs stay off the CPUs reserved for
+// the NIC IRQs.
+func getNvmeDefaultMode(cpuMask string, cpuMasks irq.CpuMasks) (irq.Mode, error) {
+	numOfCores, err := cpuMasks.GetNumberOfCores(cpuMask)
+	if err != nil {
+		return "", err
+	}
+	numOfPUs, err := cpuMasks.GetNumberOfPUs(cpuMask)
+	if err != nil {
+		return "", err
+	}
+	if numOfPUs <= 4 {
+		return irq.Mq, nil
+	} else if numOfCores <= 4 {
+		return irq.Sq, nil
+	}
+	return irq.SqSplit, nil
+}
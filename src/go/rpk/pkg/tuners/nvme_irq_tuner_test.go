@@ -0,0 +1,141 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/irq"
+)
+
+type procFileMock struct {
+	lines map[int]string
+}
+
+func (m *procFileMock) GetIRQProcFileLinesMap() (map[int]string, error) {
+	return m.lines, nil
+}
+
+func TestGetNvmeIRQs(t *testing.T) {
+	procFile := &procFileMock{lines: map[int]string{
+		30:	"  30:  0  0  IR-PCI-MSI 1048576-edge  nvme0q0",
+		31:	"  31:  0  0  IR-PCI-MSI 1048577-edge  nvme0q1",
+		32:	"  32:  0  0  IR-PCI-MSI 1048578-edge  nvme0q2",
+		40:	"  40:  0  0  IR-PCI-MSI 2097153-edge  nvme1q1",
+		41:	"  41:  0  0  IR-PCI-MSI 2097154-edge  nvme10q1",
+		50:	"  50:  0  0  IR-PCI-MSI 524288-edge   eth0-TxRx-0",
+	}}
+	tests := []struct {
+		name		string
+		devices		[]string
+		expected	[]int
+	}{
+		{
+			name:		"it should match the queues of the devices' controllers",
+			devices:	[]string{"nvme0n1", "nvme1n1p1"},
+			expected:	[]int{30, 31, 32, 40},
+		},
+		{
+			name:		"it shouldn't match controllers sharing a prefix",
+			devices:	[]string{"nvme1n1"},
+			expected:	[]int{40},
+		},
+		{
+			name:		"it should ignore non-NVMe devices",
+			devices:	[]string{"sda", "xvdb"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			IRQs, err := GetNvmeIRQs(tt.devices, procFile)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, IRQs)
+		})
+	}
+}
+
+func TestGetExpectedNvmeIRQsDistribution(t *testing.T) {
+	tests := []struct {
+		name		string
+		mode		irq.Mode
+		cores		uint
+		PUs		uint
+		expectedMode	irq.Mode
+	}{
+		{
+			name:		"it should use the given mode",
+			mode:		irq.Mq,
+			expectedMode:	irq.Mq,
+		},
+		{
+			name:		"it should use 'mq' by default with up to 4 PUs",
+			mode:		irq.Default,
+			cores:		2,
+			PUs:		4,
+			expectedMode:	irq.Mq,
+		},
+		{
+			name:		"it should use 'sq' by default with up to 4 cores",
+			mode:		irq.Default,
+			cores:		4,
+			PUs:		8,
+			expectedMode:	irq.Sq,
+		},
+		{
+			name:		"it should use 'sq_split' by default with more than 4 cores",
+			mode:		irq.Default,
+			cores:		8,
+			PUs:		16,
+			expectedMode:	irq.SqSplit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var computationsMode irq.Mode
+			cpuMasks := &cpuMasksMock{
+				baseCpuMask: func(string) (string, error) {
+					return "0xff", nil
+				},
+				getNumberOfCores: func(string) (uint, error) {
+					return tt.cores, nil
+				},
+				getNumberOfPUs: func(string) (uint, error) {
+					return tt.PUs, nil
+				},
+				cpuMaskForComputations: func(mode irq.Mode, mask string) (string, error) {
+					computationsMode = mode
+					require.Equal(t, "0xff", mask)
+					return "0xfe", nil
+				},
+				getIRQsDistributionMasks: func(IRQs []int, mask string) (map[int]string, error) {
+					require.Equal(t, "0xfe", mask)
+					return map[int]string{31: "0x02", 32: "0x04"}, nil
+				},
+			}
+			distribution, err := GetExpectedNvmeIRQsDistribution(
+				[]int{31, 32}, tt.mode, "all", cpuMasks)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedMode, computationsMode)
+			require.Equal(t, map[int]string{31: "0x02", 32: "0x04"}, distribution)
+		})
+	}
+}
+
+func TestNvmeIRQTunerDetails(t *testing.T) {
+	tuner := &nvmeIRQsTuner{}
+	require.Nil(t, TuneDetails(tuner))
+	tuner.distribution = map[int]string{31: "0x02", 32: "0x04"}
+	require.Equal(
+		t,
+		map[string]string{"irq31": "0x02", "irq32": "0x04"},
+		TuneDetails(NewDisruptiveTunable(tuner)),
+	)
+}
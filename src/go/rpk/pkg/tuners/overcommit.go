@@ -0,0 +1,107 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+)
+
+const (
+	OvercommitMemoryFile	string	= "/proc/sys/vm/overcommit_memory"
+	OvercommitRatioFile	string	= "/proc/sys/vm/overcommit_ratio"
+	// Heuristic overcommit, the kernel's default. 'Always overcommit' (1)
+	// lets allocations succeed which may later get the broker OOM-killed,
+	// while 'never overcommit' (2) makes the commit limit depend on
+	// vm.overcommit_ratio, which may be too low for redpanda's allocation.
+	ExpectedOvercommitMemory	int	= 0
+)
+
+type overcommitChecker struct {
+	fs afero.Fs
+}
+
+func NewOvercommitChecker(fs afero.Fs) Checker {
+	return &overcommitChecker{fs: fs}
+}
+
+func (c *overcommitChecker) Id() CheckerID {
+	return OvercommitChecker
+}
+
+func (c *overcommitChecker) GetDesc() string {
+	return "Memory overcommit mode"
+}
+
+func (c *overcommitChecker) GetSeverity() Severity {
+	return Warning
+}
+
+func (c *overcommitChecker) GetRequiredAsString() string {
+	return fmt.Sprintf("vm.overcommit_memory=%d", ExpectedOvercommitMemory)
+}
+
+func (c *overcommitChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+	}
+	mode, err := utils.ReadIntFromFile(c.fs, OvercommitMemoryFile)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	ratio, err := utils.ReadIntFromFile(c.fs, OvercommitRatioFile)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.IsOk = mode == ExpectedOvercommitMemory
+	res.Current = fmt.Sprintf(
+		"vm.overcommit_memory=%d, vm.overcommit_ratio=%d",
+		mode,
+		ratio,
+	)
+	return res
+}
+
+func NewOvercommitTuner(fs afero.Fs, executor executors.Executor) Tunable {
+	return NewCheckedTunable(
+		NewOvercommitChecker(fs),
+		func() TuneResult {
+			log.Debugf(
+				"Setting vm.overcommit_memory to %d",
+				ExpectedOvercommitMemory,
+			)
+			err := executor.Execute(
+				commands.NewWriteFileCmd(
+					fs,
+					OvercommitMemoryFile,
+					fmt.Sprint(ExpectedOvercommitMemory),
+				),
+			)
+			if err != nil {
+				return NewTuneError(err)
+			}
+			return NewTuneResult(false)
+		},
+		func() (bool, string) {
+			return true, ""
+		},
+		executor.IsLazy(),
+	)
+}
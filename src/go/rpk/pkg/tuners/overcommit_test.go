@@ -0,0 +1,95 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+)
+
+func writeOvercommit(fs afero.Fs, mode, ratio string) error {
+	_, err := utils.WriteBytes(fs, []byte(mode), tuners.OvercommitMemoryFile)
+	if err != nil {
+		return err
+	}
+	_, err = utils.WriteBytes(fs, []byte(ratio), tuners.OvercommitRatioFile)
+	return err
+}
+
+func TestOvercommitChecker(t *testing.T) {
+	tests := []struct {
+		name		string
+		before		func(fs afero.Fs) error
+		expectOk	bool
+		expectCurrent	string
+		expectErr	bool
+	}{
+		{
+			name:	"It should return true if heuristic overcommit is set",
+			before: func(fs afero.Fs) error {
+				return writeOvercommit(fs, "0", "50")
+			},
+			expectOk:	true,
+			expectCurrent:	"vm.overcommit_memory=0, vm.overcommit_ratio=50",
+		},
+		{
+			name:	"It should return false if overcommit is always allowed",
+			before: func(fs afero.Fs) error {
+				return writeOvercommit(fs, "1", "50")
+			},
+			expectOk:	false,
+			expectCurrent:	"vm.overcommit_memory=1, vm.overcommit_ratio=50",
+		},
+		{
+			name:	"It should return false if overcommit is never allowed",
+			before: func(fs afero.Fs) error {
+				return writeOvercommit(fs, "2", "80")
+			},
+			expectOk:	false,
+			expectCurrent:	"vm.overcommit_memory=2, vm.overcommit_ratio=80",
+		},
+		{
+			name:		"It should fail if the files don't exist",
+			expectErr:	true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.before != nil {
+				require.NoError(t, tt.before(fs))
+			}
+			res := tuners.NewOvercommitChecker(fs).Check()
+			if tt.expectErr {
+				require.Error(t, res.Err)
+				return
+			}
+			require.NoError(t, res.Err)
+			require.Equal(t, tt.expectOk, res.IsOk)
+			require.Equal(t, tt.expectCurrent, res.Current)
+		})
+	}
+}
+
+func TestOvercommitTuner(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, writeOvercommit(fs, "1", "50"))
+	tuner := tuners.NewOvercommitTuner(fs, executors.NewDirectExecutor())
+	res := tuner.Tune()
+	require.NoError(t, res.Error())
+	mode, err := utils.ReadIntFromFile(fs, tuners.OvercommitMemoryFile)
+	require.NoError(t, err)
+	require.Equal(t, tuners.ExpectedOvercommitMemory, mode)
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/docker/go-units"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+)
+
+// The file in the data directory the space is preallocated to.
+const PreallocFile = ".rpk_prealloc"
+
+// Reserves the configured amount of space in the data directory upfront, so
+// that filesystem metadata doesn't have to be allocated on startup and a lack
+// of space is detected before redpanda starts. The data partition must keep
+// at least minFree (rpk.min_free_disk) free after preallocating.
+type preallocTuner struct {
+	fs		afero.Fs
+	dataDir		string
+	size		string
+	minFree		string
+	freeSpaceGB	func(path string) (float64, error)
+	executor	executors.Executor
+	preallocated	int64
+}
+
+func NewPreallocTuner(
+	fs afero.Fs,
+	dataDir string,
+	size string,
+	minFree string,
+	freeSpaceGB func(path string) (float64, error),
+	executor executors.Executor,
+) Tunable {
+	return &preallocTuner{
+		fs:		fs,
+		dataDir:	dataDir,
+		size:		size,
+		minFree:	minFree,
+		freeSpaceGB:	freeSpaceGB,
+		executor:	executor,
+	}
+}
+
+func PreallocPath(dataDir string) string {
+	return filepath.Join(dataDir, PreallocFile)
+}
+
+func (t *preallocTuner) CheckIfSupported() (supported bool, reason string) {
+	if t.size == "" {
+		return false, "rpk.prealloc_size isn't set"
+	}
+	if exists, _ := afero.DirExists(t.fs, t.dataDir); !exists {
+		return false, fmt.Sprintf("Data directory '%s' doesn't exist", t.dataDir)
+	}
+	return true, ""
+}
+
+func (t *preallocTuner) Tune() TuneResult {
+	size, err := units.RAMInBytes(t.size)
+	if err != nil || size <= 0 {
+		return NewTuneError(fmt.Errorf("invalid rpk.prealloc_size '%s'", t.size))
+	}
+	path := PreallocPath(t.dataDir)
+	var current int64
+	if info, err := t.fs.Stat(path); err == nil {
+		current = info.Size()
+	}
+	if current >= size {
+		log.Debugf("'%s' already has %d bytes preallocated", path, current)
+		t.preallocated = current
+		return NewTuneResult(false)
+	}
+	minFreeGB, err := parseMinFreeDiskGB(t.minFree)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	freeGB, err := t.freeSpaceGB(t.dataDir)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	neededGB := float64(size-current) / units.GiB
+	if freeGB-neededGB < minFreeGB {
+		return NewTuneError(fmt.Errorf(
+			"not enough free space in '%s' to preallocate %s: %.2f GiB"+
+				" available, and at least %s must remain free",
+			t.dataDir,
+			units.BytesSize(float64(size)),
+			freeGB,
+			t.minFree,
+		))
+	}
+	err = t.executor.Execute(commands.NewFallocateCmd(t.fs, path, size))
+	if err != nil {
+		return NewTuneError(err)
+	}
+	t.preallocated = size
+	log.Infof("Preallocated %s in '%s'", units.BytesSize(float64(size)), path)
+	return NewTuneResult(false)
+}
+
+func (t *preallocTuner) Details() map[string]string {
+	if t.preallocated == 0 {
+		return nil
+	}
+	return map[string]string{
+		"path":		PreallocPath(t.dataDir),
+		"preallocated":	units.BytesSize(float64(t.preallocated)),
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+)
+
+func TestPreallocTuner(t *testing.T) {
+	const dataDir = "/var/lib/redpanda/data"
+	tests := []struct {
+		name		string
+		size		string
+		minFree		string
+		freeGB		float64
+		existing	int64
+		expectedSize	int64
+		expectedDetails	map[string]string
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should preallocate the configured size",
+			size:		"2MiB",
+			freeGB:		100,
+			expectedSize:	2 * 1024 * 1024,
+			expectedDetails: map[string]string{
+				"path":		dataDir + "/.rpk_prealloc",
+				"preallocated":	"2MiB",
+			},
+		},
+		{
+			name:		"it should do nothing if enough space was already preallocated",
+			size:		"1MiB",
+			freeGB:		0,
+			existing:	2 * 1024 * 1024,
+			expectedSize:	2 * 1024 * 1024,
+			expectedDetails: map[string]string{
+				"path":		dataDir + "/.rpk_prealloc",
+				"preallocated":	"2MiB",
+			},
+		},
+		{
+			name:		"it should fail if the minimum free space wouldn't remain",
+			size:		"5GiB",
+			freeGB:		12,
+			expectedErrMsg:	"not enough free space in '/var/lib/redpanda/data' to preallocate 5GiB: 12.00 GiB available, and at least 10GiB must remain free",
+		},
+		{
+			name:		"it should keep the configured minimum free space",
+			size:		"2MiB",
+			minFree:	"1GiB",
+			freeGB:		1.5,
+			expectedSize:	2 * 1024 * 1024,
+			expectedDetails: map[string]string{
+				"path":		dataDir + "/.rpk_prealloc",
+				"preallocated":	"2MiB",
+			},
+		},
+		{
+			name:		"it should fail if the configured minimum free space wouldn't remain",
+			size:		"1GiB",
+			minFree:	"20GiB",
+			freeGB:		12,
+			expectedErrMsg:	"not enough free space in '/var/lib/redpanda/data' to preallocate 1GiB: 12.00 GiB available, and at least 20GiB must remain free",
+		},
+		{
+			name:		"it should fail if the minimum free space is invalid",
+			size:		"2MiB",
+			minFree:	"lots",
+			freeGB:		100,
+			expectedErrMsg:	"invalid rpk.min_free_disk 'lots': invalid size: 'lots'",
+		},
+		{
+			name:		"it should fail if the size is invalid",
+			size:		"lots",
+			freeGB:		100,
+			expectedErrMsg:	"invalid rpk.prealloc_size 'lots'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, fs.MkdirAll(dataDir, 0755))
+			path := tuners.PreallocPath(dataDir)
+			if tt.existing > 0 {
+				f, err := fs.Create(path)
+				require.NoError(t, err)
+				require.NoError(t, f.Truncate(tt.existing))
+				f.Close()
+			}
+			minFree := tt.minFree
+			if minFree == "" {
+				minFree = tuners.DefaultMinFreeDisk
+			}
+			tuner := tuners.NewPreallocTuner(
+				fs,
+				dataDir,
+				tt.size,
+				minFree,
+				func(string) (float64, error) { return tt.freeGB, nil },
+				executors.NewDirectExecutor(),
+			)
+			supported, _ := tuner.CheckIfSupported()
+			require.True(t, supported)
+			res := tuner.Tune()
+			if tt.expectedErrMsg != "" {
+				require.True(t, res.IsFailed())
+				require.EqualError(t, res.Error(), tt.expectedErrMsg)
+				require.Nil(t, tuners.TuneDetails(tuner))
+				return
+			}
+			require.False(t, res.IsFailed())
+			info, err := fs.Stat(path)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedSize, info.Size())
+			require.Equal(t, tt.expectedDetails, tuners.TuneDetails(tuner))
+		})
+	}
+}
+
+func TestPreallocTunerNotSupported(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tuner := tuners.NewPreallocTuner(
+		fs,
+		"/var/lib/redpanda/data",
+		"1GiB",
+		tuners.DefaultMinFreeDisk,
+		func(string) (float64, error) { return 100, nil },
+		executors.NewDirectExecutor(),
+	)
+	supported, reason := tuner.CheckIfSupported()
+	require.False(t, supported)
+	require.Equal(t, "Data directory '/var/lib/redpanda/data' doesn't exist", reason)
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How far the tuners push the values they set.
+type TuneProfile string
+
+const (
+	// Halves the tuners' targets.
+	ConservativeProfile	TuneProfile	= "conservative"
+	// The tuners' default targets.
+	BalancedProfile		TuneProfile	= "balanced"
+	// Quadruples the tuners' targets, up to the hardware maximums.
+	AggressiveProfile	TuneProfile	= "aggressive"
+)
+
+func TuneProfiles() []string {
+	return []string{
+		string(ConservativeProfile),
+		string(BalancedProfile),
+		string(AggressiveProfile),
+	}
+}
+
+// Parses the given profile. An empty one is the balanced profile.
+func ParseTuneProfile(profile string) (TuneProfile, error) {
+	switch p := TuneProfile(strings.ToLower(profile)); p {
+	case "":
+		return BalancedProfile, nil
+	case ConservativeProfile, BalancedProfile, AggressiveProfile:
+		return p, nil
+	}
+	return "", fmt.Errorf(
+		"'%s' is not a valid tune profile. Available profiles: %s",
+		profile,
+		strings.Join(TuneProfiles(), ", "),
+	)
+}
+
+// Scales the given balanced target to the profile. Tuners capped by the
+// hardware must cap the result themselves.
+func (p TuneProfile) Scale(target int) int {
+	switch p {
+	case ConservativeProfile:
+		return target / 2
+	case AggressiveProfile:
+		return target * 4
+	}
+	return target
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTuneProfile(t *testing.T) {
+	tests := []struct {
+		input		string
+		expected	TuneProfile
+		expectedErrMsg	string
+	}{
+		{input: "", expected: BalancedProfile},
+		{input: "conservative", expected: ConservativeProfile},
+		{input: "Aggressive", expected: AggressiveProfile},
+		{
+			input:		"reckless",
+			expectedErrMsg:	"'reckless' is not a valid tune profile. Available profiles: conservative, balanced, aggressive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(st *testing.T) {
+			profile, err := ParseTuneProfile(tt.input)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, err)
+			require.Equal(st, tt.expected, profile)
+		})
+	}
+}
+
+func TestTuneProfileScale(t *testing.T) {
+	require.Equal(t, 512, ConservativeProfile.Scale(1024))
+	require.Equal(t, 1024, BalancedProfile.Scale(1024))
+	require.Equal(t, 4096, AggressiveProfile.Scale(1024))
+	require.Equal(t, 1024, TuneProfile("").Scale(1024))
+}
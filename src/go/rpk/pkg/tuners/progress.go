@@ -0,0 +1,97 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Keeps track of how many of a tuner's sub-operations (e.g. IRQs, disks)
+// have been applied, so that a tuner which is interrupted can report how far
+// it got.
+type Progress struct {
+	mu	sync.Mutex
+	unit	string
+	done	int
+	total	int
+}
+
+func NewProgress() *Progress {
+	return &Progress{}
+}
+
+// Sets the number of sub-operations the tuner is going to apply, and the
+// unit they're counted in.
+func (p *Progress) Start(total int, unit string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+	p.done = 0
+	p.unit = unit
+}
+
+// Records that a sub-operation was applied.
+func (p *Progress) Step() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+}
+
+func (p *Progress) Done() (done, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done, p.total
+}
+
+func (p *Progress) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("applied %d of %d %s", p.done, p.total, p.unit)
+}
+
+// A Tunable which applies several sub-operations, reporting its progress
+// and stopping between them once ctx is done.
+type ProgressTunable interface {
+	Tunable
+	TuneWithContext(ctx context.Context, progress *Progress) TuneResult
+}
+
+// Runs the given tunable. If it's a ProgressTunable, it's given at most
+// timeout to finish, and the returned Progress holds the number of
+// sub-operations it applied. Otherwise the returned Progress is empty.
+func TuneWithTimeout(
+	tunable Tunable, timeout time.Duration,
+) (TuneResult, *Progress) {
+	progress := NewProgress()
+	pt, ok := tunable.(ProgressTunable)
+	if !ok {
+		return tunable.Tune(), progress
+	}
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return pt.TuneWithContext(ctx, progress), progress
+}
+
+func interruptedError(ctx context.Context, progress *Progress) error {
+	if msg := progress.String(); msg != "" {
+		return fmt.Errorf("tuning interrupted (%s): %v", msg, ctx.Err())
+	}
+	return fmt.Errorf("tuning interrupted: %v", ctx.Err())
+}
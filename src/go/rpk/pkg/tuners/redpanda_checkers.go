@@ -10,8 +10,11 @@
 package tuners
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
+	"github.com/docker/go-units"
 	"github.com/spf13/afero"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/cloud/gcp"
@@ -58,8 +61,128 @@ const (
 	Swappiness
 	KernelVersion
 	WriteCachePolicyChecker
+	OvercommitChecker
+	RPCTLSFilesChecker
+	RPCTLSCertExpiryChecker
+	HyperthreadingChecker
+	LoopDeviceChecker
+	SwapDevicesChecker
+	MemlockLimitChecker
+	NicRingBufferChecker
+	DataDiskSharedChecker
+	EphemeralPortsChecker
+	FdLimitChecker
 )
 
+var checkerCategories = map[CheckerID]Category{
+	ConfigFileChecker:		ConfigCategory,
+	DataDirAccessChecker:		DiskCategory,
+	DiskSpaceChecker:		DiskCategory,
+	FsTypeChecker:			DiskCategory,
+	IoConfigFileChecker:		DiskCategory,
+	SchedulerChecker:		DiskCategory,
+	NomergesChecker:		DiskCategory,
+	DiskIRQsAffinityStaticChecker:	DiskCategory,
+	DiskIRQsAffinityChecker:	DiskCategory,
+	FstrimChecker:			DiskCategory,
+	WriteCachePolicyChecker:	DiskCategory,
+	LoopDeviceChecker:		DiskCategory,
+	DataDiskSharedChecker:		DiskCategory,
+	NicIRQsAffinitChecker:		NetworkCategory,
+	NicIRQsAffinitStaticChecker:	NetworkCategory,
+	NicRfsChecker:			NetworkCategory,
+	NicXpsChecker:			NetworkCategory,
+	NicRpsChecker:			NetworkCategory,
+	NicNTupleChecker:		NetworkCategory,
+	RfsTableEntriesChecker:		NetworkCategory,
+	ListenBacklogChecker:		NetworkCategory,
+	SynBacklogChecker:		NetworkCategory,
+	NicRingBufferChecker:		NetworkCategory,
+	EphemeralPortsChecker:		NetworkCategory,
+	RPCTLSFilesChecker:		SecurityCategory,
+	RPCTLSCertExpiryChecker:	SecurityCategory,
+}
+
+// Stable names for the checkers, used to refer to them from the command line
+// and in persisted check results.
+var checkerNames = map[CheckerID]string{
+	ConfigFileChecker:		"config_file",
+	DataDirAccessChecker:		"data_dir_access",
+	DiskSpaceChecker:		"disk_space",
+	FreeMemChecker:			"free_memory",
+	SwapChecker:			"swap",
+	FsTypeChecker:			"fs_type",
+	IoConfigFileChecker:		"io_config_file",
+	TransparentHugePagesChecker:	"transparent_hugepages",
+	NtpChecker:			"ntp",
+	SchedulerChecker:		"disk_scheduler",
+	NomergesChecker:		"disk_nomerges",
+	DiskIRQsAffinityStaticChecker:	"disk_irq_static",
+	DiskIRQsAffinityChecker:	"disk_irq_affinity",
+	FstrimChecker:			"fstrim",
+	NicIRQsAffinitChecker:		"nic_irq_affinity",
+	NicIRQsAffinitStaticChecker:	"nic_irq_static",
+	NicRfsChecker:			"nic_rfs",
+	NicXpsChecker:			"nic_xps",
+	NicRpsChecker:			"nic_rps",
+	NicNTupleChecker:		"nic_ntuple",
+	RfsTableEntriesChecker:		"rfs_table_entries",
+	ListenBacklogChecker:		"listen_backlog",
+	SynBacklogChecker:		"syn_backlog",
+	MaxAIOEvents:			"aio_events",
+	ClockSource:			"clocksource",
+	Swappiness:			"swappiness",
+	KernelVersion:			"kernel_version",
+	WriteCachePolicyChecker:	"disk_write_cache",
+	OvercommitChecker:		"overcommit",
+	RPCTLSFilesChecker:		"rpc_tls_files",
+	RPCTLSCertExpiryChecker:	"rpc_tls_cert_expiry",
+	HyperthreadingChecker:		"smt",
+	LoopDeviceChecker:		"loop_device",
+	SwapDevicesChecker:		"swap_devices",
+	MemlockLimitChecker:		"memlock_limit",
+	NicRingBufferChecker:		"nic_ring_buffer",
+	DataDiskSharedChecker:		"data_disk_shared",
+	EphemeralPortsChecker:		"ephemeral_ports",
+	FdLimitChecker:			"file_descriptors",
+}
+
+func (id CheckerID) String() string {
+	if name, ok := checkerNames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("checker_%d", int(id))
+}
+
+// Returns the ID of the checker with the given name.
+func CheckerIDFromName(name string) (CheckerID, error) {
+	for id, n := range checkerNames {
+		if n == name {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown checker '%s'", name)
+}
+
+// Returns the names of all the checkers, sorted.
+func CheckerNames() []string {
+	names := make([]string, 0, len(checkerNames))
+	for _, name := range checkerNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the category the checker belongs to. Checkers which don't belong to
+// any specific category are considered system checkers.
+func (id CheckerID) Category() Category {
+	if c, ok := checkerCategories[id]; ok {
+		return c
+	}
+	return SystemCategory
+}
+
 func NewConfigChecker(conf *config.Config) Checker {
 	return NewEqualityChecker(
 		ConfigFileChecker,
@@ -83,20 +206,90 @@ func NewDataDirWritableChecker(fs afero.Fs, path string) Checker {
 		})
 }
 
-func NewFreeDiskSpaceChecker(path string) Checker {
-	return NewFloatChecker(
-		DiskSpaceChecker,
-		"Data partition free space [GB]",
-		Warning,
-		func(current float64) bool {
-			return current >= 10.0
-		},
-		func() string {
-			return ">= 10"
-		},
-		func() (float64, error) {
-			return filesystem.GetFreeDiskSpaceGB(path)
-		})
+// The default minimum free space the data partition should have, if
+// rpk.min_free_disk isn't set.
+const DefaultMinFreeDisk = "10GiB"
+
+// Returns rpk.min_free_disk, or DefaultMinFreeDisk if it isn't set.
+func RequiredMinFreeDisk(conf config.RpkConfig) string {
+	if conf.MinFreeDisk != "" {
+		return conf.MinFreeDisk
+	}
+	return DefaultMinFreeDisk
+}
+
+// Parses minFree (a size such as '10GiB') into GiB.
+func parseMinFreeDiskGB(minFree string) (float64, error) {
+	min, err := units.RAMInBytes(minFree)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"invalid rpk.min_free_disk '%s': %v",
+			minFree,
+			err,
+		)
+	}
+	return float64(min) / units.GiB, nil
+}
+
+type freeDiskSpaceChecker struct {
+	path		string
+	minFree		string
+	severity	Severity
+}
+
+// Checks that the filesystem path is in has at least minFree (a size such as
+// '10GiB') free. Its severity is configurable, since running out of space
+// may be fatal for some deployments.
+func NewFreeDiskSpaceChecker(
+	path, minFree string, severity Severity,
+) Checker {
+	return &freeDiskSpaceChecker{
+		path:		path,
+		minFree:	minFree,
+		severity:	severity,
+	}
+}
+
+func (c *freeDiskSpaceChecker) Id() CheckerID {
+	return DiskSpaceChecker
+}
+
+func (c *freeDiskSpaceChecker) GetDesc() string {
+	return "Data partition free space [GB]"
+}
+
+func (c *freeDiskSpaceChecker) GetSeverity() Severity {
+	return c.severity
+}
+
+func (c *freeDiskSpaceChecker) GetRequiredAsString() string {
+	minGB, err := parseMinFreeDiskGB(c.minFree)
+	if err != nil {
+		return fmt.Sprintf(">= %s", c.minFree)
+	}
+	return fmt.Sprintf(">= %v", minGB)
+}
+
+func (c *freeDiskSpaceChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+	}
+	minGB, err := parseMinFreeDiskGB(c.minFree)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	freeGB, err := filesystem.GetFreeDiskSpaceGB(c.path)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.IsOk = freeGB >= minGB
+	res.Current = fmt.Sprintf("%.2f", freeGB)
+	return res
 }
 
 func NewMemoryChecker(fs afero.Fs) Checker {
@@ -200,7 +393,15 @@ func RedpandaCheckers(
 	balanceService := irq.NewBalanceService(fs, proc, executor, timeout)
 	cpuMasks := irq.NewCpuMasks(fs, hwloc.NewHwLocCmd(proc, timeout), executor)
 	dirIRQAffinityChecker := NewDirectoryIRQAffinityChecker(
-		fs, config.Redpanda.Directory, "all", irq.Default, blockDevices, cpuMasks)
+		fs,
+		config.Redpanda.Directory,
+		"all",
+		irq.Default,
+		blockDevices,
+		cpuMasks,
+		irqProcFile,
+		config.Rpk.TuneNvmeIrq,
+	)
 	dirIRQAffinityStaticChecker := NewDirectoryIRQsAffinityStaticChecker(
 		fs,
 		config.Redpanda.Directory,
@@ -213,13 +414,22 @@ func RedpandaCheckers(
 	}
 	netCheckersFactory := NewNetCheckersFactory(
 		fs, irqProcFile, irqDeviceInfo, ethtool, balanceService, cpuMasks)
+	minFreeDisk := RequiredMinFreeDisk(config.Rpk)
+	var minFreeDiskSeverity Severity = Warning
+	if config.Rpk.MinFreeDiskFatal {
+		minFreeDiskSeverity = Fatal
+	}
+	swappiness := ExpectedSwappiness
+	if config.Rpk.Swappiness != nil {
+		swappiness = *config.Rpk.Swappiness
+	}
 	checkers := map[CheckerID][]Checker{
 		ConfigFileChecker:		{NewConfigChecker(config)},
 		IoConfigFileChecker:		{NewIOConfigFileExistanceChecker(fs, ioConfigFile)},
 		FreeMemChecker:			{NewMemoryChecker(fs)},
 		SwapChecker:			{NewSwapChecker(fs)},
 		DataDirAccessChecker:		{NewDataDirWritableChecker(fs, config.Redpanda.Directory)},
-		DiskSpaceChecker:		{NewFreeDiskSpaceChecker(config.Redpanda.Directory)},
+		DiskSpaceChecker:		{NewFreeDiskSpaceChecker(config.Redpanda.Directory, minFreeDisk, minFreeDiskSeverity)},
 		FsTypeChecker:			{NewFilesystemTypeChecker(config.Redpanda.Directory)},
 		TransparentHugePagesChecker:	{NewTransparentHugePagesChecker(fs)},
 		NtpChecker:			{NewNTPSyncChecker(timeout, fs)},
@@ -238,8 +448,26 @@ func RedpandaCheckers(
 		NicXpsChecker:			netCheckersFactory.NewNicXpsCheckers(interfaces),
 		MaxAIOEvents:			{NewMaxAIOEventsChecker(fs)},
 		ClockSource:			{NewClockSourceChecker(fs)},
-		Swappiness:			{NewSwappinessChecker(fs)},
+		Swappiness:			{NewSwappinessChecker(fs, swappiness)},
+		OvercommitChecker:		{NewOvercommitChecker(fs)},
 		KernelVersion:			{NewKernelVersionChecker(GetKernelVersion)},
+		HyperthreadingChecker:		{NewHyperthreadingChecker(fs, config.Rpk.RecommendSMT)},
+		LoopDeviceChecker:		{NewDirectoryLoopDeviceChecker(fs, config.Redpanda.Directory, blockDevices)},
+		SwapDevicesChecker:		{NewSwapDevicesChecker(fs, blockDevices, deviceFeatures)},
+		DataDiskSharedChecker:		{NewDataDiskSharedChecker(fs, config.Redpanda.Directory, blockDevices)},
+		NicRingBufferChecker:		{NewRingBufferChecker(interfaces, proc, timeout)},
+		EphemeralPortsChecker:		{NewEphemeralPortsChecker(fs, config)},
+		FdLimitChecker:			{NewFdLimitChecker(system.GetFdLimit, RequiredFdLimit(config.Rpk))},
+	}
+
+	if config.Redpanda.RPCServerTLS.Enabled {
+		tls := config.Redpanda.RPCServerTLS
+		checkers[RPCTLSFilesChecker] = []Checker{
+			NewRPCTLSFilesChecker(fs, tls, time.Now),
+		}
+		checkers[RPCTLSCertExpiryChecker] = []Checker{
+			NewRPCTLSCertExpiryChecker(fs, tls, time.Now),
+		}
 	}
 
 	v, err := cloud.AvailableVendor()
@@ -13,6 +13,7 @@ package tuners_test
 
 import (
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -213,3 +214,53 @@ func TestFreeMemoryChecker(t *testing.T) {
 		})
 	}
 }
+
+func TestFreeDiskSpaceChecker(t *testing.T) {
+	tests := []struct {
+		name		string
+		minFree		string
+		severity	tuners.Severity
+		expectOk	bool
+		expectedErrMsg	string
+	}{
+		{
+			name:		"it should pass if there's more free space than the minimum",
+			minFree:	"1B",
+			severity:	tuners.Warning,
+			expectOk:	true,
+		},
+		{
+			name:		"it should fail if there's less free space than the minimum",
+			minFree:	"1000PiB",
+			severity:	tuners.Fatal,
+		},
+		{
+			name:		"it should fail if the minimum is invalid",
+			minFree:	"lots",
+			severity:	tuners.Warning,
+			expectedErrMsg:	"invalid rpk.min_free_disk 'lots': invalid size: 'lots'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			checker := tuners.NewFreeDiskSpaceChecker(
+				st.TempDir(),
+				tt.minFree,
+				tt.severity,
+			)
+			res := checker.Check()
+			require.EqualValues(st, tuners.DiskSpaceChecker, res.CheckerId)
+			require.Equal(st, "Data partition free space [GB]", res.Desc)
+			require.Equal(st, tt.severity, res.Severity)
+			if tt.expectedErrMsg != "" {
+				require.EqualError(st, res.Err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectOk, res.IsOk)
+			free, err := strconv.ParseFloat(res.Current, 64)
+			require.NoError(st, err)
+			require.NotZero(st, free)
+		})
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/os"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/network"
+)
+
+// Sets the RX and TX ring buffer sizes of the given NICs with 'ethtool -G',
+// to the hardware maximums (scaled to the tune profile) or to
+// rpk.ring_buffer_size, if it's set. Small rings drop packets under bursty
+// load.
+type ringBufferTuner struct {
+	interfaces	[]string
+	size		int
+	profile		TuneProfile
+	proc		os.Proc
+	timeout		time.Duration
+	executor	executors.Executor
+	// The sizes found and set, keyed by '<interface> <rx|tx>'.
+	previous	map[string]string
+	applied		map[string]string
+}
+
+func NewRingBufferTuner(
+	interfaces []string,
+	size int,
+	profile TuneProfile,
+	proc os.Proc,
+	timeout time.Duration,
+	executor executors.Executor,
+) Tunable {
+	return &ringBufferTuner{
+		interfaces:	interfaces,
+		size:		size,
+		profile:	profile,
+		proc:		proc,
+		timeout:	timeout,
+		executor:	executor,
+	}
+}
+
+func (t *ringBufferTuner) CheckIfSupported() (supported bool, reason string) {
+	if t.size < 0 {
+		return false, fmt.Sprintf(
+			"rpk.ring_buffer_size can't be negative, but it's %d",
+			t.size,
+		)
+	}
+	if len(t.interfaces) == 0 {
+		return false, "No NICs were given to tune the ring buffers of"
+	}
+	for _, intf := range t.interfaces {
+		_, err := network.GetRingParams(t.proc, t.timeout, intf)
+		if err != nil {
+			return false, err.Error()
+		}
+	}
+	return true, ""
+}
+
+func (t *ringBufferTuner) Tune() TuneResult {
+	previous := map[string]string{}
+	applied := map[string]string{}
+	for _, intf := range t.interfaces {
+		params, err := network.GetRingParams(t.proc, t.timeout, intf)
+		if err != nil {
+			return NewTuneError(err)
+		}
+		rx := t.target(intf, "RX", params.RxMax)
+		tx := t.target(intf, "TX", params.TxMax)
+		previous[intf+" rx"] = strconv.Itoa(params.Rx)
+		previous[intf+" tx"] = strconv.Itoa(params.Tx)
+		applied[intf+" rx"] = strconv.Itoa(rx)
+		applied[intf+" tx"] = strconv.Itoa(tx)
+		if rx == params.Rx && tx == params.Tx {
+			log.Debugf("The ring buffers of '%s' are already set", intf)
+			continue
+		}
+		err = t.setRingParams(intf, rx, tx)
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	t.previous, t.applied = previous, applied
+	return NewTuneResult(false)
+}
+
+// Returns each ring buffer's previous and applied size, e.g.
+// eth0 rx => "512 -> 4096".
+func (t *ringBufferTuner) Details() map[string]string {
+	details := map[string]string{}
+	for ring, applied := range t.applied {
+		details[ring] = fmt.Sprintf("%s -> %s", t.previous[ring], applied)
+	}
+	return details
+}
+
+func (t *ringBufferTuner) PreviousValues() map[string]string {
+	return t.previous
+}
+
+func (t *ringBufferTuner) Revert(previous map[string]string) TuneResult {
+	sizes := map[string]map[string]int{}
+	for ring, value := range previous {
+		parts := strings.Fields(ring)
+		if len(parts) != 2 {
+			return NewTuneError(fmt.Errorf("invalid ring buffer '%s'", ring))
+		}
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return NewTuneError(fmt.Errorf(
+				"invalid size '%s' for ring buffer '%s'",
+				value,
+				ring,
+			))
+		}
+		if sizes[parts[0]] == nil {
+			sizes[parts[0]] = map[string]int{}
+		}
+		sizes[parts[0]][parts[1]] = size
+	}
+	interfaces := make([]string, 0, len(sizes))
+	for intf := range sizes {
+		interfaces = append(interfaces, intf)
+	}
+	sort.Strings(interfaces)
+	for _, intf := range interfaces {
+		err := t.setRingParams(intf, sizes[intf]["rx"], sizes[intf]["tx"])
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	return NewTuneResult(false)
+}
+
+// Returns the size to set a ring to: the configured one, capped at the
+// hardware maximum, or the maximum scaled to the profile if there's none.
+func (t *ringBufferTuner) target(intf, ring string, max int) int {
+	if t.size == 0 {
+		if size := t.profile.Scale(max); size < max {
+			return size
+		}
+		return max
+	}
+	if t.size > max {
+		log.Warnf(
+			"rpk.ring_buffer_size (%d) is over the %s ring maximum for"+
+				" '%s'. Setting it to %d",
+			t.size,
+			ring,
+			intf,
+			max,
+		)
+		return max
+	}
+	return t.size
+}
+
+func (t *ringBufferTuner) setRingParams(intf string, rx, tx int) error {
+	args := []string{"-G", intf}
+	if rx != 0 {
+		args = append(args, "rx", strconv.Itoa(rx))
+	}
+	if tx != 0 {
+		args = append(args, "tx", strconv.Itoa(tx))
+	}
+	return t.executor.Execute(
+		commands.NewLaunchCmd(t.proc, t.timeout, "ethtool", args...),
+	)
+}
+
+// Warns if any of the given NICs' RX or TX ring buffers is under half of its
+// hardware maximum. The NICs whose ring parameters can't be read are reported
+// as unsupported.
+type ringBufferChecker struct {
+	interfaces	[]string
+	proc		os.Proc
+	timeout		time.Duration
+}
+
+func NewRingBufferChecker(
+	interfaces []string, proc os.Proc, timeout time.Duration,
+) Checker {
+	return &ringBufferChecker{
+		interfaces:	interfaces,
+		proc:		proc,
+		timeout:	timeout,
+	}
+}
+
+func (*ringBufferChecker) Id() CheckerID {
+	return NicRingBufferChecker
+}
+
+func (*ringBufferChecker) GetDesc() string {
+	return "NIC ring buffer sizes"
+}
+
+func (*ringBufferChecker) GetSeverity() Severity {
+	return Warning
+}
+
+func (*ringBufferChecker) GetRequiredAsString() string {
+	return ">= 50% of the maximum"
+}
+
+func (c *ringBufferChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+		IsOk:		true,
+	}
+	var rings, small []string
+	for _, intf := range c.interfaces {
+		params, err := network.GetRingParams(c.proc, c.timeout, intf)
+		if err != nil {
+			// Virtual NICs (e.g. veth) have no ring buffers, and
+			// ethtool may not be installed at all, neither of
+			// which should fail the check for the other NICs.
+			log.Debugf("Skipping the ring buffers of '%s': %v", intf, err)
+			rings = append(rings, intf+" unsupported")
+			continue
+		}
+		for _, r := range []struct {
+			name		string
+			size, max	int
+		}{
+			{"rx", params.Rx, params.RxMax},
+			{"tx", params.Tx, params.TxMax},
+		} {
+			ring := fmt.Sprintf("%s %s %d/%d", intf, r.name, r.size, r.max)
+			rings = append(rings, ring)
+			if r.size*2 < r.max {
+				small = append(small, ring)
+			}
+		}
+	}
+	res.Current = strings.Join(rings, ", ")
+	if len(small) > 0 {
+		res.IsOk = false
+		res.Current = fmt.Sprintf(
+			"%s (under half the maximum: %s)",
+			res.Current,
+			strings.Join(small, ", "),
+		)
+	}
+	return res
+}
@@ -0,0 +1,246 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+)
+
+// Fakes ethtool's ring parameters (-g) and records the ones set (-G).
+type ethtoolRingProcMock struct {
+	max, current	map[string][2]int
+}
+
+func (m *ethtoolRingProcMock) RunWithSystemLdPath(
+	_ time.Duration, command string, args ...string,
+) ([]string, error) {
+	if command != "ethtool" || len(args) < 2 {
+		return nil, fmt.Errorf("unexpected command '%s %v'", command, args)
+	}
+	intf := args[1]
+	switch args[0] {
+	case "-g":
+		max, ok := m.max[intf]
+		if !ok {
+			return nil, fmt.Errorf("no such device '%s'", intf)
+		}
+		cur := m.current[intf]
+		return strings.Split(fmt.Sprintf(
+			"Ring parameters for %s:\nPre-set maximums:\nRX:\t%d\nTX:\t%d\n"+
+				"Current hardware settings:\nRX:\t%d\nTX:\t%d",
+			intf, max[0], max[1], cur[0], cur[1],
+		), "\n"), nil
+	case "-G":
+		cur := m.current[intf]
+		for i := 2; i+1 < len(args); i += 2 {
+			v, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if args[i] == "rx" {
+				cur[0] = v
+			} else {
+				cur[1] = v
+			}
+		}
+		m.current[intf] = cur
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected command '%s %v'", command, args)
+}
+
+func (*ethtoolRingProcMock) IsRunning(time.Duration, string) bool {
+	return false
+}
+
+func TestRingBufferTuner(t *testing.T) {
+	tests := []struct {
+		name			string
+		size			int
+		profile			TuneProfile
+		interfaces		[]string
+		expectedSupported	bool
+		expectedReason		string
+		expected		map[string][2]int
+		expectedDetails		map[string]string
+	}{
+		{
+			name:			"it should set the rings to the maximums",
+			interfaces:		[]string{"eth0", "eth1"},
+			expectedSupported:	true,
+			expected: map[string][2]int{
+				"eth0":	{4096, 2048},
+				"eth1":	{1024, 1024},
+			},
+			expectedDetails: map[string]string{
+				"eth0 rx":	"512 -> 4096",
+				"eth0 tx":	"512 -> 2048",
+				"eth1 rx":	"1024 -> 1024",
+				"eth1 tx":	"1024 -> 1024",
+			},
+		},
+		{
+			name:			"it should set the rings to the configured size, capped at the maximums",
+			size:			3072,
+			interfaces:		[]string{"eth0"},
+			expectedSupported:	true,
+			expected: map[string][2]int{
+				"eth0":	{3072, 2048},
+				"eth1":	{1024, 1024},
+			},
+			expectedDetails: map[string]string{
+				"eth0 rx":	"512 -> 3072",
+				"eth0 tx":	"512 -> 2048",
+			},
+		},
+		{
+			name:			"it should set the rings to half the maximums with the conservative profile",
+			profile:		ConservativeProfile,
+			interfaces:		[]string{"eth0"},
+			expectedSupported:	true,
+			expected: map[string][2]int{
+				"eth0":	{2048, 1024},
+				"eth1":	{1024, 1024},
+			},
+			expectedDetails: map[string]string{
+				"eth0 rx":	"512 -> 2048",
+				"eth0 tx":	"512 -> 1024",
+			},
+		},
+		{
+			name:			"the aggressive profile shouldn't go over the maximums",
+			profile:		AggressiveProfile,
+			interfaces:		[]string{"eth0"},
+			expectedSupported:	true,
+			expected: map[string][2]int{
+				"eth0":	{4096, 2048},
+				"eth1":	{1024, 1024},
+			},
+			expectedDetails: map[string]string{
+				"eth0 rx":	"512 -> 4096",
+				"eth0 tx":	"512 -> 2048",
+			},
+		},
+		{
+			name:			"it shouldn't be supported if ethtool fails",
+			interfaces:		[]string{"eth2"},
+			expectedReason:		"couldn't get the ring parameters of 'eth2': no such device 'eth2'",
+		},
+		{
+			name:			"it shouldn't be supported if there are no NICs",
+			expectedReason:		"No NICs were given to tune the ring buffers of",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			proc := &ethtoolRingProcMock{
+				max: map[string][2]int{
+					"eth0":	{4096, 2048},
+					"eth1":	{1024, 1024},
+				},
+				current: map[string][2]int{
+					"eth0":	{512, 512},
+					"eth1":	{1024, 1024},
+				},
+			}
+			tuner := NewRingBufferTuner(
+				tt.interfaces,
+				tt.size,
+				tt.profile,
+				proc,
+				time.Second,
+				executors.NewDirectExecutor(),
+			)
+			supported, reason := tuner.CheckIfSupported()
+			require.Equal(st, tt.expectedSupported, supported)
+			require.Equal(st, tt.expectedReason, reason)
+			if !supported {
+				return
+			}
+			res := tuner.Tune()
+			require.False(st, res.IsFailed())
+			require.Equal(st, tt.expected, proc.current)
+			require.Equal(st, tt.expectedDetails, TuneDetails(tuner))
+
+			rt := tuner.(RevertibleTunable)
+			res = rt.Revert(rt.PreviousValues())
+			require.False(st, res.IsFailed())
+			require.Equal(
+				st,
+				map[string][2]int{
+					"eth0":	{512, 512},
+					"eth1":	{1024, 1024},
+				},
+				proc.current,
+			)
+		})
+	}
+}
+
+func TestRingBufferChecker(t *testing.T) {
+	proc := &ethtoolRingProcMock{
+		max: map[string][2]int{
+			"eth0":	{4096, 2048},
+			"eth1":	{1024, 1024},
+		},
+		current: map[string][2]int{
+			"eth0":	{512, 2048},
+			"eth1":	{1024, 512},
+		},
+	}
+	res := NewRingBufferChecker(
+		[]string{"eth0", "eth1"},
+		proc,
+		time.Second,
+	).Check()
+	require.NoError(t, res.Err)
+	require.False(t, res.IsOk)
+	require.Equal(
+		t,
+		"eth0 rx 512/4096, eth0 tx 2048/2048, eth1 rx 1024/1024, eth1 tx 512/1024 (under half the maximum: eth0 rx 512/4096)",
+		res.Current,
+	)
+
+	proc.current["eth0"] = [2]int{2048, 2048}
+	res = NewRingBufferChecker([]string{"eth0"}, proc, time.Second).Check()
+	require.NoError(t, res.Err)
+	require.True(t, res.IsOk)
+}
+
+func TestRingBufferCheckerUnsupported(t *testing.T) {
+	proc := &ethtoolRingProcMock{
+		max: map[string][2]int{
+			"eth0":	{4096, 2048},
+		},
+		current: map[string][2]int{
+			"eth0":	{4096, 2048},
+		},
+	}
+	// ethtool -g fails for veth0, as it does for virtual NICs.
+	res := NewRingBufferChecker(
+		[]string{"veth0", "eth0"},
+		proc,
+		time.Second,
+	).Check()
+	require.NoError(t, res.Err)
+	require.True(t, res.IsOk)
+	require.Equal(
+		t,
+		"veth0 unsupported, eth0 rx 4096/4096, eth0 tx 2048/2048",
+		res.Current,
+	)
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/disk"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+)
+
+// Sets the block layer's rq_affinity for the data devices, which controls the
+// CPU I/O completions are processed on: 1 completes them on a CPU in the
+// same group as the one which submitted the request, and 2 forces them onto
+// the submitting CPU itself.
+type rqAffinityTuner struct {
+	fs		afero.Fs
+	directories	[]string
+	devices		[]string
+	blockDevices	disk.BlockDevices
+	deviceFeatures	disk.DeviceFeatures
+	value		int
+	executor	executors.Executor
+	// The values found and set, keyed by the rq_affinity file.
+	previous	map[string]string
+	applied		map[string]string
+}
+
+func NewRqAffinityTuner(
+	fs afero.Fs,
+	directories []string,
+	devices []string,
+	blockDevices disk.BlockDevices,
+	deviceFeatures disk.DeviceFeatures,
+	value int,
+	executor executors.Executor,
+) Tunable {
+	return &rqAffinityTuner{
+		fs:		fs,
+		directories:	directories,
+		devices:	devices,
+		blockDevices:	blockDevices,
+		deviceFeatures:	deviceFeatures,
+		value:		value,
+		executor:	executor,
+	}
+}
+
+func (t *rqAffinityTuner) CheckIfSupported() (supported bool, reason string) {
+	if t.value < 0 || t.value > 2 {
+		return false, fmt.Sprintf(
+			"rpk.rq_affinity must be 0, 1 or 2, but it's %d",
+			t.value,
+		)
+	}
+	if len(t.directories) == 0 && len(t.devices) == 0 {
+		return false,
+			"Either directories or devices must be provided for the rq_affinity tuner"
+	}
+	files, err := t.featureFiles()
+	if err != nil {
+		return false, err.Error()
+	}
+	for device, file := range files {
+		if file == "" {
+			return false, fmt.Sprintf(
+				"rq_affinity isn't available for device '%s'",
+				device,
+			)
+		}
+	}
+	return true, ""
+}
+
+func (t *rqAffinityTuner) Tune() TuneResult {
+	files, err := t.featureFiles()
+	if err != nil {
+		return NewTuneError(err)
+	}
+	previous := map[string]string{}
+	applied := map[string]string{}
+	value := strconv.Itoa(t.value)
+	for device, file := range files {
+		current, err := readTrimmed(t.fs, file)
+		if err != nil {
+			return NewTuneError(err)
+		}
+		previous[file] = current
+		applied[file] = value
+		if current == value {
+			log.Debugf("rq_affinity for '%s' is already %s", device, value)
+			continue
+		}
+		err = t.executor.Execute(commands.NewWriteFileCmd(t.fs, file, value))
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	t.previous, t.applied = previous, applied
+	return NewTuneResult(false)
+}
+
+// Returns each rq_affinity file's previous and applied value, e.g.
+// /sys/block/nvme0n1/queue/rq_affinity => "1 -> 2".
+func (t *rqAffinityTuner) Details() map[string]string {
+	details := map[string]string{}
+	for file, applied := range t.applied {
+		details[file] = fmt.Sprintf("%s -> %s", t.previous[file], applied)
+	}
+	return details
+}
+
+func (t *rqAffinityTuner) PreviousValues() map[string]string {
+	return t.previous
+}
+
+func (t *rqAffinityTuner) Revert(previous map[string]string) TuneResult {
+	files := make([]string, 0, len(previous))
+	for file := range previous {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		err := t.executor.Execute(
+			commands.NewWriteFileCmd(t.fs, file, previous[file]),
+		)
+		if err != nil {
+			return NewTuneError(err)
+		}
+	}
+	return NewTuneResult(false)
+}
+
+// Returns the rq_affinity file of each of the data devices, or an empty path
+// for the devices which don't have one.
+func (t *rqAffinityTuner) featureFiles() (map[string]string, error) {
+	directoryDevices, err := t.blockDevices.GetDirectoriesDevices(
+		t.directories)
+	if err != nil {
+		return nil, err
+	}
+	devices := map[string]bool{}
+	for _, devs := range directoryDevices {
+		for _, device := range devs {
+			devices[device] = true
+		}
+	}
+	for _, device := range t.devices {
+		devices[device] = true
+	}
+	files := map[string]string{}
+	for _, device := range utils.GetKeys(devices) {
+		file, err := t.deviceFeatures.GetRqAffinityFeatureFile(device)
+		if err != nil {
+			return nil, err
+		}
+		files[device] = file
+	}
+	return files, nil
+}
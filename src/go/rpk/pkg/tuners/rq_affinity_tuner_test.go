@@ -0,0 +1,113 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+)
+
+const rqAffinityFile = "/sys/devices/pci0000:00/0000:00:1d.0/0000:71:00.0/nvme/fake/queue/rq_affinity"
+
+func TestRqAffinityTuner(t *testing.T) {
+	tests := []struct {
+		name			string
+		value			int
+		featureFile		string
+		current			string
+		expectedSupported	bool
+		expectedReason		string
+		expectedDetails		map[string]string
+	}{
+		{
+			name:			"it should set rq_affinity",
+			value:			2,
+			featureFile:		rqAffinityFile,
+			current:		"1\n",
+			expectedSupported:	true,
+			expectedDetails:	map[string]string{rqAffinityFile: "1 -> 2"},
+		},
+		{
+			name:			"it should succeed if rq_affinity is already set",
+			value:			2,
+			featureFile:		rqAffinityFile,
+			current:		"2\n",
+			expectedSupported:	true,
+			expectedDetails:	map[string]string{rqAffinityFile: "2 -> 2"},
+		},
+		{
+			name:			"it shouldn't be supported if the device has no rq_affinity",
+			value:			2,
+			expectedReason:		"rq_affinity isn't available for device 'fake'",
+		},
+		{
+			name:			"it shouldn't be supported if the value is invalid",
+			value:			3,
+			featureFile:		rqAffinityFile,
+			current:		"1\n",
+			expectedReason:		"rpk.rq_affinity must be 0, 1 or 2, but it's 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.featureFile != "" {
+				err := afero.WriteFile(fs, tt.featureFile, []byte(tt.current), 0644)
+				require.NoError(st, err)
+			}
+			deviceFeatures := &deviceFeaturesMock{
+				getRqAffinityFeatureFile: func(string) (string, error) {
+					return tt.featureFile, nil
+				},
+			}
+			blockDevices := &blockDevicesMock{
+				getDirectoriesDevices: func(
+					[]string,
+				) (map[string][]string, error) {
+					return map[string][]string{
+						"/var/lib/redpanda": {"fake"},
+					}, nil
+				},
+			}
+			tuner := NewRqAffinityTuner(
+				fs,
+				[]string{"/var/lib/redpanda"},
+				nil,
+				blockDevices,
+				deviceFeatures,
+				tt.value,
+				executors.NewDirectExecutor(),
+			)
+			supported, reason := tuner.CheckIfSupported()
+			require.Equal(st, tt.expectedSupported, supported)
+			require.Equal(st, tt.expectedReason, reason)
+			if !supported {
+				return
+			}
+			res := tuner.Tune()
+			require.False(st, res.IsFailed())
+			value, err := afero.ReadFile(fs, tt.featureFile)
+			require.NoError(st, err)
+			require.Equal(st, "2", strings.TrimSpace(string(value)))
+			require.Equal(st, tt.expectedDetails, TuneDetails(tuner))
+
+			rt := tuner.(RevertibleTunable)
+			res = rt.Revert(rt.PreviousValues())
+			require.False(st, res.IsFailed())
+			value, err = afero.ReadFile(fs, tt.featureFile)
+			require.NoError(st, err)
+			require.Equal(st, strings.TrimSpace(tt.current), strings.TrimSpace(string(value)))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+)
+
+// Reports whether SMT (hyperthreading) is active. If a recommendation is
+// given (rpk.recommend_smt), it warns if SMT's state doesn't match it.
+// Otherwise, or if SMT's state is unknown, it always passes.
+type smtChecker struct {
+	fs		afero.Fs
+	recommended	*bool
+}
+
+func NewHyperthreadingChecker(fs afero.Fs, recommended *bool) Checker {
+	return &smtChecker{fs: fs, recommended: recommended}
+}
+
+func (*smtChecker) Id() CheckerID {
+	return HyperthreadingChecker
+}
+
+func (*smtChecker) GetDesc() string {
+	return "Hyperthreading (SMT) active"
+}
+
+func (*smtChecker) GetSeverity() Severity {
+	return Warning
+}
+
+func (c *smtChecker) GetRequiredAsString() string {
+	if c.recommended == nil {
+		return "any"
+	}
+	return fmt.Sprint(*c.recommended)
+}
+
+func (c *smtChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+	}
+	active, err := system.IsSMTActive(c.fs)
+	if os.IsNotExist(err) {
+		// Older kernels and some VMs don't expose SMT's state.
+		res.Current = "unknown"
+		res.IsOk = true
+		return res
+	}
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Current = fmt.Sprint(active)
+	res.IsOk = c.recommended == nil || *c.recommended == active
+	return res
+}
+
+// Turns SMT on or off, as recommended in rpk.recommend_smt.
+type smtTuner struct {
+	fs		afero.Fs
+	recommended	*bool
+	executor	executors.Executor
+}
+
+func NewSMTTuner(
+	fs afero.Fs, recommended *bool, executor executors.Executor,
+) Tunable {
+	return &smtTuner{fs: fs, recommended: recommended, executor: executor}
+}
+
+func (t *smtTuner) CheckIfSupported() (supported bool, reason string) {
+	if t.recommended == nil {
+		return false, "rpk.recommend_smt isn't set"
+	}
+	control, err := system.GetSMTControl(t.fs)
+	if err != nil {
+		return false, err.Error()
+	}
+	if control != "on" && control != "off" {
+		return false, fmt.Sprintf(
+			"SMT can't be changed at runtime (%s is '%s')",
+			system.SMTControlFile,
+			control,
+		)
+	}
+	return true, ""
+}
+
+// Turning SMT off takes the sibling CPUs offline, and turning it on brings them
+// back, which affects every workload on the machine.
+func (*smtTuner) IsDisruptive() bool {
+	return true
+}
+
+func (t *smtTuner) Tune() TuneResult {
+	active, err := system.IsSMTActive(t.fs)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	if active == *t.recommended {
+		log.Debugf("SMT is already active: %t", active)
+		return NewTuneResult(false)
+	}
+	control := "off"
+	if *t.recommended {
+		control = "on"
+	}
+	err = t.executor.Execute(
+		commands.NewWriteFileCmd(t.fs, system.SMTControlFile, control),
+	)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	return NewTuneResult(false)
+}
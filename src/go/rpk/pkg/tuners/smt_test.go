@@ -0,0 +1,153 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestHyperthreadingChecker(t *testing.T) {
+	tests := []struct {
+		name			string
+		active			string
+		recommended		*bool
+		expectedOk		bool
+		expectedCurrent		string
+		expectedRequired	string
+	}{
+		{
+			name:			"it should pass if there's no recommendation",
+			active:			"1",
+			expectedOk:		true,
+			expectedCurrent:	"true",
+			expectedRequired:	"any",
+		},
+		{
+			name:			"it should pass if SMT's state matches the recommendation",
+			active:			"0",
+			recommended:		boolPtr(false),
+			expectedOk:		true,
+			expectedCurrent:	"false",
+			expectedRequired:	"false",
+		},
+		{
+			name:			"it should fail if SMT's state doesn't match the recommendation",
+			active:			"1\n",
+			recommended:		boolPtr(false),
+			expectedCurrent:	"true",
+			expectedRequired:	"false",
+		},
+		{
+			name:			"it should pass if SMT's state is unknown",
+			recommended:		boolPtr(false),
+			expectedOk:		true,
+			expectedCurrent:	"unknown",
+			expectedRequired:	"false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.active != "" {
+				err := afero.WriteFile(fs, system.SMTActiveFile, []byte(tt.active), 0644)
+				require.NoError(st, err)
+			}
+			res := tuners.NewHyperthreadingChecker(fs, tt.recommended).Check()
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectedOk, res.IsOk)
+			require.Equal(st, tt.expectedCurrent, res.Current)
+			require.Equal(st, tt.expectedRequired, res.Required)
+			require.EqualValues(st, tuners.Warning, res.Severity)
+		})
+	}
+}
+
+func TestSMTTuner(t *testing.T) {
+	tests := []struct {
+		name		string
+		active		string
+		control		string
+		recommended	*bool
+		expectedReason	string
+		expectedControl	string
+	}{
+		{
+			name:			"it should turn SMT off",
+			active:			"1",
+			control:		"on",
+			recommended:		boolPtr(false),
+			expectedControl:	"off",
+		},
+		{
+			name:			"it should turn SMT on",
+			active:			"0",
+			control:		"off",
+			recommended:		boolPtr(true),
+			expectedControl:	"on",
+		},
+		{
+			name:			"it should do nothing if SMT's state already matches",
+			active:			"1",
+			control:		"on",
+			recommended:		boolPtr(true),
+			expectedControl:	"on",
+		},
+		{
+			name:		"it shouldn't be supported without a recommendation",
+			active:		"1",
+			control:	"on",
+			expectedReason:	"rpk.recommend_smt isn't set",
+		},
+		{
+			name:		"it shouldn't be supported if SMT can't be changed",
+			active:		"0",
+			control:	"forceoff",
+			recommended:	boolPtr(true),
+			expectedReason:	"SMT can't be changed at runtime (/sys/devices/system/cpu/smt/control is 'forceoff')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			err := afero.WriteFile(fs, system.SMTActiveFile, []byte(tt.active), 0644)
+			require.NoError(st, err)
+			err = afero.WriteFile(fs, system.SMTControlFile, []byte(tt.control), 0644)
+			require.NoError(st, err)
+			tuner := tuners.NewSMTTuner(
+				fs,
+				tt.recommended,
+				executors.NewDirectExecutor(),
+			)
+			require.True(st, tuners.IsDisruptive(tuner))
+			supported, reason := tuner.CheckIfSupported()
+			if tt.expectedReason != "" {
+				require.False(st, supported)
+				require.Equal(st, tt.expectedReason, reason)
+				return
+			}
+			require.True(st, supported, reason)
+			res := tuner.Tune()
+			require.NoError(st, res.Error())
+			control, err := system.GetSMTControl(fs)
+			require.NoError(st, err)
+			require.Equal(st, tt.expectedControl, control)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/disk"
+)
+
+// Reports the swap areas and their priorities, and warns if any of them is on
+// a rotational (slow) device, especially if it would be used before swap on a
+// faster one. It's advisory: where swap is slow, locking redpanda's memory
+// (--lock-memory) matters more.
+type swapDevicesChecker struct {
+	fs		afero.Fs
+	blockDevices	disk.BlockDevices
+	deviceFeatures	disk.DeviceFeatures
+}
+
+func NewSwapDevicesChecker(
+	fs afero.Fs,
+	blockDevices disk.BlockDevices,
+	deviceFeatures disk.DeviceFeatures,
+) Checker {
+	return &swapDevicesChecker{
+		fs:		fs,
+		blockDevices:	blockDevices,
+		deviceFeatures:	deviceFeatures,
+	}
+}
+
+func (*swapDevicesChecker) Id() CheckerID {
+	return SwapDevicesChecker
+}
+
+func (*swapDevicesChecker) GetDesc() string {
+	return "Swap on fast devices"
+}
+
+func (*swapDevicesChecker) GetSeverity() Severity {
+	return Warning
+}
+
+func (*swapDevicesChecker) GetRequiredAsString() string {
+	return "true"
+}
+
+func (c *swapDevicesChecker) Check() *CheckResult {
+	res := &CheckResult{
+		CheckerId:	c.Id(),
+		Desc:		c.GetDesc(),
+		Severity:	c.GetSeverity(),
+		Required:	c.GetRequiredAsString(),
+	}
+	swaps, err := system.GetSwapDevices(c.fs)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if len(swaps) == 0 {
+		res.Current = "true (no swap)"
+		res.IsOk = true
+		return res
+	}
+	res.Details = map[string]string{}
+	var slow, fast []system.SwapDevice
+	for _, swap := range swaps {
+		rotational, err := c.isRotational(swap)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		res.Details[swap.Filename] = fmt.Sprintf(
+			"type: %s, size: %dKiB, used: %dKiB, priority: %d, rotational: %t",
+			swap.Type,
+			swap.Size,
+			swap.Used,
+			swap.Priority,
+			rotational,
+		)
+		if rotational {
+			slow = append(slow, swap)
+		} else {
+			fast = append(fast, swap)
+		}
+	}
+	if len(slow) == 0 {
+		res.Current = "true"
+		res.IsOk = true
+		return res
+	}
+	var names, preferred []string
+	for _, s := range slow {
+		names = append(names, s.Filename)
+		for _, f := range fast {
+			if s.Priority >= f.Priority {
+				preferred = append(preferred, s.Filename)
+				break
+			}
+		}
+	}
+	res.Current = fmt.Sprintf(
+		"false (on rotational devices: %s)",
+		strings.Join(names, ", "),
+	)
+	if len(preferred) > 0 {
+		res.Current = fmt.Sprintf(
+			"false (on rotational devices: %s. Used before faster swap: %s)",
+			strings.Join(names, ", "),
+			strings.Join(preferred, ", "),
+		)
+	}
+	return res
+}
+
+// Returns whether the swap area is on a rotational device. For swap files,
+// that's the device of the filesystem holding them.
+func (c *swapDevicesChecker) isRotational(
+	swap system.SwapDevice,
+) (bool, error) {
+	devices := []string{filepath.Base(swap.Filename)}
+	if swap.Type != "partition" {
+		var err error
+		devices, err = c.blockDevices.GetDirectoryDevices(
+			filepath.Dir(swap.Filename),
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+	for _, device := range devices {
+		rotational, err := c.deviceFeatures.IsRotational(device)
+		if err != nil {
+			return false, err
+		}
+		if rotational {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
+)
+
+func TestSwapDevicesChecker(t *testing.T) {
+	const header = "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n"
+	tests := []struct {
+		name		string
+		swaps		string
+		expectedOk	bool
+		expectedCurrent	string
+		expectedDetails	map[string]string
+	}{
+		{
+			name:			"it should pass if there's no swap",
+			swaps:			header,
+			expectedOk:		true,
+			expectedCurrent:	"true (no swap)",
+		},
+		{
+			name:			"it should pass if swap is on fast devices",
+			swaps:			header + "/dev/nvme0n1p2 partition\t8388604\t0\t-2\n",
+			expectedOk:		true,
+			expectedCurrent:	"true",
+			expectedDetails: map[string]string{
+				"/dev/nvme0n1p2": "type: partition, size: 8388604KiB, used: 0KiB, priority: -2, rotational: false",
+			},
+		},
+		{
+			name:	"it should fail if swap is on a rotational device",
+			swaps: header +
+				"/dev/sda2 partition\t8388604\t1024\t-2\n" +
+				"/swapfile file\t2097148\t0\t-3\n",
+			expectedCurrent:	"false (on rotational devices: /dev/sda2. Used before faster swap: /dev/sda2)",
+			expectedDetails: map[string]string{
+				"/dev/sda2":	"type: partition, size: 8388604KiB, used: 1024KiB, priority: -2, rotational: true",
+				"/swapfile":	"type: file, size: 2097148KiB, used: 0KiB, priority: -3, rotational: false",
+			},
+		},
+		{
+			name:	"it should not report the priority if faster swap is preferred",
+			swaps: header +
+				"/dev/sda2 partition\t8388604\t0\t-3\n" +
+				"/swapfile file\t2097148\t0\t10\n",
+			expectedCurrent:	"false (on rotational devices: /dev/sda2)",
+			expectedDetails: map[string]string{
+				"/dev/sda2":	"type: partition, size: 8388604KiB, used: 0KiB, priority: -3, rotational: true",
+				"/swapfile":	"type: file, size: 2097148KiB, used: 0KiB, priority: 10, rotational: false",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			err := afero.WriteFile(fs, system.SwapsFile, []byte(tt.swaps), 0644)
+			require.NoError(st, err)
+			blockDevices := &blockDevicesMock{
+				getDirectoryDevices: func(string) ([]string, error) {
+					return []string{"nvme0n1"}, nil
+				},
+			}
+			deviceFeatures := &deviceFeaturesMock{
+				isRotational: func(device string) (bool, error) {
+					return device == "sda2", nil
+				},
+			}
+			res := NewSwapDevicesChecker(fs, blockDevices, deviceFeatures).Check()
+			require.NoError(st, res.Err)
+			require.Equal(st, tt.expectedOk, res.IsOk)
+			require.Equal(st, tt.expectedCurrent, res.Current)
+			require.Equal(st, tt.expectedDetails, res.Details)
+		})
+	}
+}
@@ -12,52 +12,121 @@ package tuners
 import (
 	"fmt"
 	"strconv"
-	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors/commands"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
 )
 
 const (
 	File			string	= "/proc/sys/vm/swappiness"
 	ExpectedSwappiness	int	= 1
+	// The highest vm.swappiness the kernel accepts (100 before Linux 5.8).
+	MaxSwappiness	int	= 200
 )
 
-func NewSwappinessChecker(fs afero.Fs) Checker {
-	return NewEqualityChecker(
+// Warns if vm.swappiness is higher than max, since the kernel then swaps out
+// redpanda's memory more eagerly.
+func NewSwappinessChecker(fs afero.Fs, max int) Checker {
+	return NewIntChecker(
 		Swappiness,
 		"Swappiness",
 		Warning,
-		ExpectedSwappiness,
-		func() (interface{}, error) {
-			content, err := afero.ReadFile(fs, File)
-			if err != nil {
-				return -1, err
-			}
-			return strconv.Atoi(strings.TrimSpace(string(content)))
+		func(current int) bool {
+			return current <= max
 		},
-	)
-}
-
-func NewSwappinessTuner(fs afero.Fs, executor executors.Executor) Tunable {
-	return NewCheckedTunable(
-		NewSwappinessChecker(fs),
-		func() TuneResult {
-			log.Debugf("Setting swappiness to %d", ExpectedSwappiness)
-			err := executor.Execute(
-				commands.NewWriteFileCmd(
-					fs, File, fmt.Sprint(ExpectedSwappiness)))
-			if err != nil {
-				log.Errorf("got an error while writing %d to %s: %v", ExpectedSwappiness, File, err)
-				return NewTuneError(err)
-			}
-			return NewTuneResult(false)
+		func() string {
+			return fmt.Sprintf("<= %d", max)
 		},
-		func() (bool, string) {
-			return true, ""
+		func() (int, error) {
+			return utils.ReadIntFromFile(fs, File)
 		},
-		executor.IsLazy(),
 	)
 }
+
+// Sets vm.swappiness, which makes the kernel keep process data in-memory for
+// longer the lower it is.
+type swappinessTuner struct {
+	fs		afero.Fs
+	value		int
+	executor	executors.Executor
+	// The values found and set, empty until the tuner runs.
+	previous	string
+	applied		string
+}
+
+func NewSwappinessTuner(
+	fs afero.Fs, value int, executor executors.Executor,
+) Tunable {
+	return &swappinessTuner{
+		fs:		fs,
+		value:		value,
+		executor:	executor,
+	}
+}
+
+func (t *swappinessTuner) CheckIfSupported() (supported bool, reason string) {
+	if t.value < 0 || t.value > MaxSwappiness {
+		return false, fmt.Sprintf(
+			"rpk.swappiness must be between 0 and %d, but it's %d",
+			MaxSwappiness,
+			t.value,
+		)
+	}
+	return true, ""
+}
+
+func (t *swappinessTuner) Tune() TuneResult {
+	current, err := readTrimmed(t.fs, File)
+	if err != nil {
+		return NewTuneError(err)
+	}
+	value := strconv.Itoa(t.value)
+	t.previous, t.applied = current, value
+	if current == value {
+		log.Debugf("vm.swappiness is already %s", value)
+		return NewTuneResult(false)
+	}
+	log.Debugf("Setting vm.swappiness from %s to %s", current, value)
+	err = t.executor.Execute(commands.NewWriteFileCmd(t.fs, File, value))
+	if err != nil {
+		log.Errorf("got an error while writing %s to %s: %v", value, File, err)
+		return NewTuneError(err)
+	}
+	return NewTuneResult(false)
+}
+
+// Returns the previous and applied value, e.g.
+// /proc/sys/vm/swappiness => "60 -> 1".
+func (t *swappinessTuner) Details() map[string]string {
+	if t.applied == "" {
+		return nil
+	}
+	return map[string]string{
+		File: fmt.Sprintf("%s -> %s", t.previous, t.applied),
+	}
+}
+
+func (t *swappinessTuner) PreviousValues() map[string]string {
+	if t.previous == "" {
+		return nil
+	}
+	return map[string]string{File: t.previous}
+}
+
+func (t *swappinessTuner) Revert(previous map[string]string) TuneResult {
+	value, ok := previous[File]
+	if !ok {
+		return NewTuneError(fmt.Errorf(
+			"the previous value of %s wasn't recorded",
+			File,
+		))
+	}
+	err := t.executor.Execute(commands.NewWriteFileCmd(t.fs, File, value))
+	if err != nil {
+		return NewTuneError(err)
+	}
+	return NewTuneResult(false)
+}
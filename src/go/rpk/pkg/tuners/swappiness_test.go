@@ -39,6 +39,14 @@ func TestChecker(t *testing.T) {
 			},
 			expectOk:	true,
 		},
+		{
+			name:	"It should return true if the value is lower",
+			before: func(fs afero.Fs) error {
+				_, err := utils.WriteBytes(fs, []byte("0"), tuners.File)
+				return err
+			},
+			expectOk:	true,
+		},
 		{
 			name:	"It should return false if the file exists but the value iswrong",
 			before: func(fs afero.Fs) error {
@@ -64,7 +72,7 @@ func TestChecker(t *testing.T) {
 				err := tt.before(fs)
 				require.NoError(t, err)
 			}
-			checker := tuners.NewSwappinessChecker(fs)
+			checker := tuners.NewSwappinessChecker(fs, tuners.ExpectedSwappiness)
 			res := checker.Check()
 			if tt.expectErr {
 				require.Error(t, res.Err)
@@ -116,7 +124,11 @@ func TestTuner(t *testing.T) {
 				err := tt.before(fs)
 				require.NoError(t, err)
 			}
-			tuner := tuners.NewSwappinessTuner(fs, executors.NewDirectExecutor())
+			tuner := tuners.NewSwappinessTuner(
+				fs,
+				tuners.ExpectedSwappiness,
+				executors.NewDirectExecutor(),
+			)
 			res := tuner.Tune()
 			if tt.expectErr {
 				require.Error(t, res.Error())
@@ -130,3 +142,45 @@ func TestTuner(t *testing.T) {
 		})
 	}
 }
+
+func TestSwappinessTunerRevert(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, err := utils.WriteBytes(fs, []byte("60\n"), tuners.File)
+	require.NoError(t, err)
+	tuner := tuners.NewSwappinessTuner(fs, 10, executors.NewDirectExecutor())
+	supported, _ := tuner.CheckIfSupported()
+	require.True(t, supported)
+	require.NoError(t, tuner.Tune().Error())
+
+	value, err := utils.ReadIntFromFile(fs, tuners.File)
+	require.NoError(t, err)
+	require.Equal(t, 10, value)
+	require.Equal(
+		t,
+		map[string]string{tuners.File: "60 -> 10"},
+		tuner.(tuners.DetailedTunable).Details(),
+	)
+	revertible := tuner.(tuners.RevertibleTunable)
+	previous := revertible.PreviousValues()
+	require.Equal(t, map[string]string{tuners.File: "60"}, previous)
+
+	require.NoError(t, revertible.Revert(previous).Error())
+	value, err = utils.ReadIntFromFile(fs, tuners.File)
+	require.NoError(t, err)
+	require.Equal(t, 60, value)
+}
+
+func TestSwappinessTunerInvalidValue(t *testing.T) {
+	tuner := tuners.NewSwappinessTuner(
+		afero.NewMemMapFs(),
+		201,
+		executors.NewDirectExecutor(),
+	)
+	supported, reason := tuner.CheckIfSupported()
+	require.False(t, supported)
+	require.Equal(
+		t,
+		"rpk.swappiness must be between 0 and 200, but it's 201",
+		reason,
+	)
+}
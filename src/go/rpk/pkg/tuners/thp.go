@@ -13,8 +13,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/system"
 	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners/executors"
@@ -23,9 +25,20 @@ import (
 
 const enabledFile = "enabled"
 
+// The THP modes, written to <THP dir>/enabled.
+// https://www.kernel.org/doc/Documentation/vm/transhuge.txt
+const (
+	THPAlways	= "always"
+	THPMadvise	= "madvise"
+	THPNever	= "never"
+)
+
 type thpTuner struct {
 	fs		afero.Fs
+	mode		string
 	executor	executors.Executor
+	previous	string
+	tuned		bool
 }
 
 // Returns the known locations where config files for Transparent Huge Pages
@@ -57,15 +70,61 @@ func getTHPDir(fs afero.Fs) (string, error) {
 / Create a new tuner to enable Transparent Huge Pages
 */
 func NewEnableTHPTuner(fs afero.Fs, executor executors.Executor) Tunable {
-	return &thpTuner{fs: fs, executor: executor}
+	return NewTHPTuner(fs, THPAlways, executor)
+}
+
+// Creates a tuner which sets the THP mode (always, madvise or never). An
+// empty mode defaults to always.
+func NewTHPTuner(fs afero.Fs, mode string, executor executors.Executor) Tunable {
+	if mode == "" {
+		mode = THPAlways
+	}
+	return &thpTuner{fs: fs, mode: mode, executor: executor}
 }
 
 func (t *thpTuner) CheckIfSupported() (bool, string) {
+	switch t.mode {
+	case THPAlways, THPMadvise, THPNever:
+	default:
+		return false, fmt.Sprintf(
+			"invalid rpk.transparent_hugepages_mode '%s', expected"+
+				" '%s', '%s' or '%s'",
+			t.mode,
+			THPAlways,
+			THPMadvise,
+			THPNever,
+		)
+	}
 	dir, err := getTHPDir(t.fs)
 	if err != nil {
 		return false, err.Error()
 	}
-	return dir != "", ""
+	file := filepath.Join(dir, enabledFile)
+	if exists, _ := afero.Exists(t.fs, file); !exists {
+		return false, fmt.Sprintf(
+			"'%s' doesn't exist, so THP can't be configured",
+			file,
+		)
+	}
+	options, err := system.ReadRuntineOptions(t.fs, file)
+	if err != nil {
+		// The available modes can't be known, so try anyway.
+		return true, ""
+	}
+	available := options.GetAvailable()
+	for _, opt := range available {
+		if opt == t.mode {
+			return true, ""
+		}
+	}
+	sort.Strings(available)
+	return false, fmt.Sprintf(
+		"THP mode '%s' isn't supported by the kernel. Current: '%s',"+
+			" available: %s",
+		t.mode,
+		options.GetActive(),
+		strings.Join(available, ", "),
+	)
 }
 
 func (t *thpTuner) Tune() TuneResult {
@@ -73,22 +132,35 @@ func (t *thpTuner) Tune() TuneResult {
 	if err != nil {
 		return NewTuneError(err)
 	}
-	// Write 'always' to the 'enabled' file in the existing THP dir so that
-	// they're always enabled.
-	// https://www.kernel.org/doc/Documentation/vm/transhuge.txt
-
-	cmd := commands.NewWriteFileCmd(
-		t.fs,
-		filepath.Join(dir, enabledFile),
-		"always",
-	)
+	file := filepath.Join(dir, enabledFile)
+	if options, err := system.ReadRuntineOptions(t.fs, file); err == nil {
+		t.previous = options.GetActive()
+	}
+	log.Debugf("Setting the THP mode from '%s' to '%s'", t.previous, t.mode)
+	cmd := commands.NewWriteFileCmd(t.fs, file, t.mode)
 	err = t.executor.Execute(cmd)
 	if err != nil {
 		return NewTuneError(err)
 	}
+	t.tuned = true
 	return NewTuneResult(false)
 }
 
+// Returns the current and desired THP modes, e.g. enabled => "always ->
+// madvise".
+func (t *thpTuner) Details() map[string]string {
+	if !t.tuned {
+		return nil
+	}
+	previous := t.previous
+	if previous == "" {
+		previous = "unknown"
+	}
+	return map[string]string{
+		enabledFile: fmt.Sprintf("%s -> %s", previous, t.mode),
+	}
+}
+
 func NewTransparentHugePagesChecker(fs afero.Fs) Checker {
 	return NewEqualityChecker(
 		TransparentHugePagesChecker,
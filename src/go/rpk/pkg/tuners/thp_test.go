@@ -23,19 +23,52 @@ func TestTHPTunerSupported(t *testing.T) {
 	tests := []struct {
 		name		string
 		thpDir		string
+		enabled		string
+		mode		string
 		expected	bool
 		expectedReason	string
 	}{
 		{
 			name:		"should return true if the default dir exists",
 			thpDir:		"/sys/kernel/mm/transparent_hugepage",
+			enabled:	"[always] madvise never",
 			expected:	true,
 		},
 		{
 			name:		"should return true if the RHEL-specific dir exists",
 			thpDir:		"/sys/kernel/mm/redhat_transparent_hugepage",
+			enabled:	"[always] madvise never",
 			expected:	true,
 		},
+		{
+			name:		"should return false if the enabled file doesn't exist",
+			thpDir:		"/sys/kernel/mm/transparent_hugepage",
+			expected:	false,
+			expectedReason:	"'/sys/kernel/mm/transparent_hugepage/enabled' doesn't exist, so THP can't be configured",
+		},
+		{
+			name:		"should return true if the kernel supports the mode",
+			thpDir:		"/sys/kernel/mm/transparent_hugepage",
+			enabled:	"[always] madvise never",
+			mode:		tuners.THPMadvise,
+			expected:	true,
+		},
+		{
+			name:		"should return false if the kernel doesn't support the mode",
+			thpDir:		"/sys/kernel/mm/transparent_hugepage",
+			enabled:	"[always] never",
+			mode:		tuners.THPMadvise,
+			expected:	false,
+			expectedReason:	"THP mode 'madvise' isn't supported by the kernel. Current: 'always', available: always, never",
+		},
+		{
+			name:		"should return false if the mode is invalid",
+			thpDir:		"/sys/kernel/mm/transparent_hugepage",
+			enabled:	"[always] madvise never",
+			mode:		"sometimes",
+			expected:	false,
+			expectedReason:	"invalid rpk.transparent_hugepages_mode 'sometimes', expected 'always', 'madvise' or 'never'",
+		},
 		{
 			name:		"should return false if no dir exists",
 			expected:	false,
@@ -51,8 +84,17 @@ func TestTHPTunerSupported(t *testing.T) {
 				err := fs.MkdirAll(tt.thpDir, 0755)
 				require.NoError(st, err)
 			}
+			if tt.enabled != "" {
+				err := afero.WriteFile(
+					fs,
+					filepath.Join(tt.thpDir, "enabled"),
+					[]byte(tt.enabled),
+					0644,
+				)
+				require.NoError(st, err)
+			}
 			exec := executors.NewDirectExecutor()
-			tuner := tuners.NewEnableTHPTuner(fs, exec)
+			tuner := tuners.NewTHPTuner(fs, tt.mode, exec)
 			supported, reason := tuner.CheckIfSupported()
 			require.Equal(st, tt.expected, supported)
 			require.Equal(st, tt.expectedReason, reason)
@@ -122,6 +164,27 @@ func TestTHPTunerDirectExecutor(t *testing.T) {
 	require.Equal(t, expected, string(bs))
 }
 
+func TestTHPTunerMode(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	dir := "/sys/kernel/mm/transparent_hugepage"
+	filePath := filepath.Join(dir, "enabled")
+	err := afero.WriteFile(fs, filePath, []byte("[always] madvise never\n"), 0644)
+	require.NoError(t, err)
+
+	tuner := tuners.NewTHPTuner(fs, tuners.THPMadvise, executors.NewDirectExecutor())
+
+	res := tuner.Tune()
+	require.False(t, res.IsFailed())
+	bs, err := afero.ReadFile(fs, filePath)
+	require.NoError(t, err)
+	require.Equal(t, "madvise", string(bs))
+	require.Equal(
+		t,
+		map[string]string{"enabled": "always -> madvise"},
+		tuners.TuneDetails(tuner),
+	)
+}
+
 func TestTHPCheckID(t *testing.T) {
 	c := tuners.NewTransparentHugePagesChecker(afero.NewMemMapFs())
 	require.Equal(t, tuners.CheckerID(tuners.TransparentHugePagesChecker), c.Id())
@@ -0,0 +1,127 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+// The number of days before a certificate's expiration date after which a
+// warning is reported.
+const certExpiryWarningDays = 30
+
+const validTLSFiles = "valid"
+
+// Checks that the configured TLS cert, key and truststore files exist, are
+// readable and non-empty, and that the certificate hasn't expired.
+func NewRPCTLSFilesChecker(
+	fs afero.Fs, tls config.ServerTLS, now func() time.Time,
+) Checker {
+	return NewEqualityChecker(
+		RPCTLSFilesChecker,
+		"RPC TLS files valid",
+		Fatal,
+		validTLSFiles,
+		func() (interface{}, error) {
+			return checkTLSFiles(fs, tls, now()), nil
+		},
+	)
+}
+
+func NewRPCTLSCertExpiryChecker(
+	fs afero.Fs, tls config.ServerTLS, now func() time.Time,
+) Checker {
+	return NewIntChecker(
+		RPCTLSCertExpiryChecker,
+		"RPC TLS certificate days until expiry",
+		Warning,
+		func(current int) bool {
+			return current >= certExpiryWarningDays
+		},
+		func() string {
+			return fmt.Sprintf(">= %d", certExpiryWarningDays)
+		},
+		func() (int, error) {
+			cert, err := readCertificate(fs, tls.CertFile)
+			if err != nil {
+				return 0, err
+			}
+			left := cert.NotAfter.Sub(now())
+			return int(left.Hours() / 24), nil
+		},
+	)
+}
+
+// Returns validTLSFiles if all the files are OK, or a description of the
+// first problem found otherwise.
+func checkTLSFiles(fs afero.Fs, tls config.ServerTLS, now time.Time) string {
+	files := [][2]string{
+		{"cert", tls.CertFile},
+		{"key", tls.KeyFile},
+	}
+	if tls.TruststoreFile != "" {
+		files = append(files, [2]string{"truststore", tls.TruststoreFile})
+	}
+	for _, f := range files {
+		if f[1] == "" {
+			return fmt.Sprintf("%s file not set", f[0])
+		}
+		content, err := afero.ReadFile(fs, f[1])
+		if os.IsNotExist(err) {
+			return fmt.Sprintf("%s file '%s' doesn't exist", f[0], f[1])
+		}
+		if err != nil {
+			return fmt.Sprintf(
+				"couldn't read %s file '%s': %v",
+				f[0],
+				f[1],
+				err,
+			)
+		}
+		if len(content) == 0 {
+			return fmt.Sprintf("%s file '%s' is empty", f[0], f[1])
+		}
+	}
+	cert, err := readCertificate(fs, tls.CertFile)
+	if err != nil {
+		return err.Error()
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Sprintf(
+			"cert '%s' expired on %s",
+			tls.CertFile,
+			cert.NotAfter.Format(time.RFC3339),
+		)
+	}
+	return validTLSFiles
+}
+
+func readCertificate(fs afero.Fs, path string) (*x509.Certificate, error) {
+	content, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("cert file '%s' isn't PEM-encoded", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse cert '%s': %v", path, err)
+	}
+	return cert, nil
+}
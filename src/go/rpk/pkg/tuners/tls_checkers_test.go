@@ -0,0 +1,146 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+)
+
+var tlsTestNow = time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+func writeTestCert(fs afero.Fs, path string, notAfter time.Time) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber:	big.NewInt(1),
+		Subject:	pkix.Name{CommonName: "redpanda"},
+		NotBefore:	tlsTestNow.Add(-24 * time.Hour),
+		NotAfter:	notAfter,
+	}
+	der, err := x509.CreateCertificate(
+		rand.Reader, template, template, &key.PublicKey, key,
+	)
+	if err != nil {
+		return err
+	}
+	bs := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return afero.WriteFile(fs, path, bs, 0644)
+}
+
+func TestRPCTLSCheckers(t *testing.T) {
+	tls := config.ServerTLS{
+		CertFile:	"/etc/redpanda/certs/node.crt",
+		KeyFile:	"/etc/redpanda/certs/node.key",
+		Enabled:	true,
+	}
+	tests := []struct {
+		name		string
+		before		func(afero.Fs) error
+		expectedFiles	string
+		expectedDays	string
+		expectDaysOk	bool
+		expectDaysErr	bool
+	}{
+		{
+			name:	"it should pass if the files are valid",
+			before: func(fs afero.Fs) error {
+				err := writeTestCert(fs, tls.CertFile, tlsTestNow.Add(90*24*time.Hour))
+				if err != nil {
+					return err
+				}
+				return afero.WriteFile(fs, tls.KeyFile, []byte("key"), 0600)
+			},
+			expectedFiles:	"valid",
+			expectedDays:	"90",
+			expectDaysOk:	true,
+		},
+		{
+			name:	"it should warn if the cert is about to expire",
+			before: func(fs afero.Fs) error {
+				err := writeTestCert(fs, tls.CertFile, tlsTestNow.Add(10*24*time.Hour))
+				if err != nil {
+					return err
+				}
+				return afero.WriteFile(fs, tls.KeyFile, []byte("key"), 0600)
+			},
+			expectedFiles:	"valid",
+			expectedDays:	"10",
+		},
+		{
+			name:	"it should fail if the cert expired",
+			before: func(fs afero.Fs) error {
+				err := writeTestCert(fs, tls.CertFile, tlsTestNow.Add(-48*time.Hour))
+				if err != nil {
+					return err
+				}
+				return afero.WriteFile(fs, tls.KeyFile, []byte("key"), 0600)
+			},
+			expectedFiles:	"cert '/etc/redpanda/certs/node.crt' expired on 2021-02-27T00:00:00Z",
+			expectedDays:	"-2",
+		},
+		{
+			name:	"it should fail if the key is missing",
+			before: func(fs afero.Fs) error {
+				return writeTestCert(fs, tls.CertFile, tlsTestNow.Add(90*24*time.Hour))
+			},
+			expectedFiles:	"key file '/etc/redpanda/certs/node.key' doesn't exist",
+			expectedDays:	"90",
+			expectDaysOk:	true,
+		},
+		{
+			name:	"it should fail if the cert is empty",
+			before: func(fs afero.Fs) error {
+				err := afero.WriteFile(fs, tls.CertFile, []byte{}, 0644)
+				if err != nil {
+					return err
+				}
+				return afero.WriteFile(fs, tls.KeyFile, []byte("key"), 0600)
+			},
+			expectedFiles:	"cert file '/etc/redpanda/certs/node.crt' is empty",
+			expectDaysErr:	true,
+		},
+	}
+	now := func() time.Time { return tlsTestNow }
+	for _, tt := range tests {
+		t.Run(tt.name, func(st *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(st, tt.before(fs))
+
+			files := NewRPCTLSFilesChecker(fs, tls, now).Check()
+			require.NoError(st, files.Err)
+			require.Equal(st, tt.expectedFiles, files.Current)
+			require.Equal(st, tt.expectedFiles == "valid", files.IsOk)
+			require.Equal(st, Severity(Fatal), files.Severity)
+
+			days := NewRPCTLSCertExpiryChecker(fs, tls, now).Check()
+			if tt.expectDaysErr {
+				require.Error(st, days.Err)
+				return
+			}
+			require.NoError(st, days.Err)
+			require.Equal(st, tt.expectedDays, days.Current)
+			require.Equal(st, tt.expectDaysOk, days.IsOk)
+		})
+	}
+}
@@ -13,3 +13,27 @@ type Tunable interface {
 	CheckIfSupported() (supported bool, reason string)
 	Tune() TuneResult
 }
+
+// Implemented by tunables which can describe the changes they applied, e.g.
+// the CPU mask each IRQ was assigned. Details is only meaningful after Tune
+// returned successfully.
+type DetailedTunable interface {
+	Tunable
+	Details() map[string]string
+}
+
+// Implemented by tunables which can undo their changes. PreviousValues returns
+// the state found before Tune ran (only meaningful after it returned
+// successfully), which is persisted and later passed to Revert.
+type RevertibleTunable interface {
+	Tunable
+	PreviousValues() map[string]string
+	Revert(previous map[string]string) TuneResult
+}
+
+func TuneDetails(tunable Tunable) map[string]string {
+	if dt, ok := tunable.(DetailedTunable); ok {
+		return dt.Details()
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/config"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/utils"
+)
+
+const tuneStateFile = ".rpk_tune_state.json"
+
+// The values found before each revertible tuner first changed them, keyed by
+// tuner name.
+type tuneState map[string]map[string]string
+
+// Returns the path of the file the values revertible tuners replaced are
+// persisted to, which lives next to the config file.
+func TuneStatePath(conf *config.Config) string {
+	return filepath.Join(filepath.Dir(conf.ConfigFile), tuneStateFile)
+}
+
+// Persists the values a tuner replaced. Values which were already persisted
+// are kept, so that reverting restores the state found before the first run
+// rather than the one left by the previous run.
+func SavePreviousValues(
+	fs afero.Fs, path, tuner string, previous map[string]string,
+) error {
+	state, err := loadTuneState(fs, path)
+	if err != nil {
+		return err
+	}
+	values, ok := state[tuner]
+	if !ok {
+		values = map[string]string{}
+		state[tuner] = values
+	}
+	for k, v := range previous {
+		if _, ok := values[k]; !ok {
+			values[k] = v
+		}
+	}
+	return saveTuneState(fs, path, state)
+}
+
+// Returns the values persisted for a tuner. found is false if there are none.
+func LoadPreviousValues(
+	fs afero.Fs, path, tuner string,
+) (previous map[string]string, found bool, err error) {
+	state, err := loadTuneState(fs, path)
+	if err != nil {
+		return nil, false, err
+	}
+	previous, found = state[tuner]
+	return previous, found, nil
+}
+
+// Forgets the values persisted for a tuner, e.g. once they were restored.
+func ClearPreviousValues(fs afero.Fs, path, tuner string) error {
+	state, err := loadTuneState(fs, path)
+	if err != nil {
+		return err
+	}
+	if _, ok := state[tuner]; !ok {
+		return nil
+	}
+	delete(state, tuner)
+	return saveTuneState(fs, path, state)
+}
+
+func loadTuneState(fs afero.Fs, path string) (tuneState, error) {
+	state := tuneState{}
+	exists, err := afero.Exists(fs, path)
+	if err != nil || !exists {
+		return state, err
+	}
+	bs, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bs, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func saveTuneState(fs afero.Fs, path string, state tuneState) error {
+	bs, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = utils.WriteBytes(fs, bs, path)
+	return err
+}
+
+// Persists the values a tunable replaced if it's revertible, and does nothing
+// otherwise.
+func RecordPreviousValues(
+	fs afero.Fs, path, tuner string, tunable Tunable,
+) error {
+	rt, ok := tunable.(RevertibleTunable)
+	if !ok {
+		return nil
+	}
+	previous := rt.PreviousValues()
+	if len(previous) == 0 {
+		return nil
+	}
+	return SavePreviousValues(fs, path, tuner, previous)
+}
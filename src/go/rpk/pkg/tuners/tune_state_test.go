@@ -0,0 +1,74 @@
+// Copyright 2021 Vectorized, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package tuners_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/vectorizedio/redpanda/src/go/rpk/pkg/tuners"
+)
+
+func TestTuneState(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/etc/redpanda/.rpk_tune_state.json"
+
+	_, found, err := tuners.LoadPreviousValues(fs, path, "rq_affinity")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	err = tuners.SavePreviousValues(
+		fs,
+		path,
+		"rq_affinity",
+		map[string]string{"/sys/block/sda/queue/rq_affinity": "1"},
+	)
+	require.NoError(t, err)
+	// Running the tuner again mustn't overwrite the original values.
+	err = tuners.SavePreviousValues(
+		fs,
+		path,
+		"rq_affinity",
+		map[string]string{
+			"/sys/block/sda/queue/rq_affinity":	"2",
+			"/sys/block/sdb/queue/rq_affinity":	"0",
+		},
+	)
+	require.NoError(t, err)
+	err = tuners.SavePreviousValues(
+		fs,
+		path,
+		"other",
+		map[string]string{"key": "value"},
+	)
+	require.NoError(t, err)
+
+	previous, found, err := tuners.LoadPreviousValues(fs, path, "rq_affinity")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(
+		t,
+		map[string]string{
+			"/sys/block/sda/queue/rq_affinity":	"1",
+			"/sys/block/sdb/queue/rq_affinity":	"0",
+		},
+		previous,
+	)
+
+	require.NoError(t, tuners.ClearPreviousValues(fs, path, "rq_affinity"))
+	_, found, err = tuners.LoadPreviousValues(fs, path, "rq_affinity")
+	require.NoError(t, err)
+	require.False(t, found)
+	previous, found, err = tuners.LoadPreviousValues(fs, path, "other")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, map[string]string{"key": "value"}, previous)
+}